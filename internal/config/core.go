@@ -8,8 +8,11 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
 	"strconv"
+	"strings"
+	"time"
 
 	b64 "encoding/base64"
 
@@ -42,6 +45,17 @@ func loadDefault() (*Config, error) {
 	cfg.Repo.MongoDB.Db = "granica"
 	cfg.Repo.MongoDB.User = "granica"
 	cfg.Repo.MongoDB.Password = "granica"
+	cfg.Repo.Postgres.Host = "localhost"
+	cfg.Repo.Postgres.Port = 5432
+	cfg.Repo.Postgres.Db = "granica"
+	cfg.Repo.Postgres.User = "granica"
+	cfg.Repo.Postgres.Password = "granica"
+	cfg.Repo.Postgres.SSLMode = "disable"
+	cfg.Repo.Bolt.Path = "granica.db"
+	cfg.Repo.Bolt.Backups = 3
+	cfg.Repo.Migration = "m"
+	cfg.Repo.Timeouts.Read = 5 * time.Second
+	cfg.Repo.Timeouts.Write = 5 * time.Second
 	// Broker
 	cfg.Broker.RabbitMQ.Host = "localhost"
 	cfg.Broker.RabbitMQ.Port = 5672
@@ -56,19 +70,86 @@ func loadDefault() (*Config, error) {
 	cfg.Cache.Redis.Port = 8086
 	cfg.Cache.Redis.User = "granica"
 	cfg.Cache.Redis.Password = "granica"
+	// Token
+	cfg.Token.Issuer = "http://localhost:8080"
+	cfg.Token.Audience = "granica"
+	cfg.Token.AccessTTL = 15 * time.Minute
+	cfg.Token.RefreshTTL = 30 * 24 * time.Hour
+	cfg.Token.CodeTTL = time.Minute
+	cfg.Token.Algorithm = "RS256"
+	cfg.Token.KeyReloadInterval = time.Minute
+	cfg.Token.KeyActivationDelay = time.Hour
+	// Mailer
+	cfg.Mailer.Type = "file"
+	cfg.Mailer.From = "no-reply@granica.dev"
+	cfg.Mailer.SMTP.Host = "localhost"
+	cfg.Mailer.SMTP.Port = 587
+	// Auth
+	cfg.Auth.EmailVerificationTTL = 24 * time.Hour
+	cfg.Auth.PasswordResetTTL = time.Hour
+	cfg.Auth.MFAIssuer = "Granica"
+	cfg.Auth.MFAChallengeTTL = 5 * time.Minute
+	cfg.Auth.WebAuthn.RPID = "localhost"
+	cfg.Auth.WebAuthn.RPName = "Granica"
+	cfg.Auth.WebAuthn.Origins = []string{"http://localhost:8080"}
+	cfg.Auth.WebAuthn.Timeout = 5 * time.Minute
+	cfg.Auth.Lockout.AccountThreshold = 5
+	cfg.Auth.Lockout.IPThreshold = 20
+	cfg.Auth.Lockout.Duration = time.Minute
+	cfg.Auth.Lockout.MaxDuration = time.Hour
+	cfg.Auth.Lockout.Window = 24 * time.Hour
+	cfg.Auth.PasswordPolicy.MinLength = 8
+	cfg.Auth.PasswordPolicy.MaxLength = 64
+	cfg.Auth.PasswordPolicy.DisallowUserInfo = true
+	cfg.Auth.PasswordPolicy.DisallowBreached = true
+	cfg.Auth.PasswordPolicy.HistorySize = 5
+	cfg.Auth.Hasher.Algorithm = "argon2id"
+	cfg.Auth.Hasher.BcryptCost = 10
+	cfg.Auth.Hasher.ScryptLogN = 15
+	cfg.Auth.Hasher.ScryptR = 8
+	cfg.Auth.Hasher.ScryptP = 1
+	cfg.Auth.Hasher.Argon2Time = 3
+	cfg.Auth.Hasher.Argon2Memory = 64 * 1024
+	cfg.Auth.Hasher.Argon2Threads = 2
+	cfg.Auth.SystemTenant = "localhost"
+	cfg.Auth.SystemTenantDomains = []string{"localhost"}
+	cfg.Auth.TenantResolution.Strategies = []string{"host"}
+	cfg.Auth.TenantResolution.Header = "X-Tenant-ID"
+	cfg.Auth.TenantResolution.PathPrefix = "/t/"
 	return &cfg, nil
 }
 
 // loadFromEnvvar - Load from envvars.
 // TODO: Default values must be corrected after establishing the appropriate ones.
 func loadFromEnvvar() (*Config, error) {
+	// Durations that do not parse would silently become zero.
+	var invalid []string
+	duration := func(envar, def string) time.Duration {
+		d, err := GetEnvDurationOrDef(envar, def)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+		}
+		return d
+	}
+
 	// Repo
-	repoType := "mongodb"
+	repoType := GetEnvOrDef("REPO_TYPE", "mongodb")
 	mongodbHost := GetEnvOrDef("MONGODB_HOST", "localhost")
 	mongodbPort, _ := strconv.Atoi(GetEnvOrDef("MONGODB_PORT", "27017"))
 	mongodbDb := GetEnvOrDef("MONGODB_DB", "0")
 	mongodbUser := GetEnvOrDef("MONGODB_USER", "granica")
 	mongodbPassword := GetEnvOrDef("MONGODB_PASSWORD", "granica")
+	postgresHost := GetEnvOrDef("POSTGRES_HOST", "localhost")
+	postgresPort, _ := strconv.Atoi(GetEnvOrDef("POSTGRES_PORT", "5432"))
+	postgresDb := GetEnvOrDef("POSTGRES_DB", "granica")
+	postgresUser := GetEnvOrDef("POSTGRES_USER", "granica")
+	postgresPassword := GetEnvOrDef("POSTGRES_PASSWORD", "granica")
+	postgresSSLMode := GetEnvOrDef("POSTGRES_SSL_MODE", "disable")
+	boltPath := GetEnvOrDef("BOLT_PATH", "granica.db")
+	boltBackups, _ := strconv.Atoi(GetEnvOrDef("BOLT_BACKUPS", "3"))
+	repoMigration := GetEnvOrDef("REPO_MIGRATION", "m")
+	repoReadTimeout := duration("REPO_READ_TIMEOUT", "5s")
+	repoWriteTimeout := duration("REPO_WRITE_TIMEOUT", "5s")
 	// Broker
 	brokerType := "rabbitmq"
 	rabbitmqHost := GetEnvOrDef("RABBITMQ_HOST", "localhost")
@@ -78,13 +159,83 @@ func loadFromEnvvar() (*Config, error) {
 	rabbitmqExchange := GetEnvOrDef("RABBITMQ_EXCHANGE", "default")
 	rabbitmqQueue := GetEnvOrDef("RABBITMQ_QUEUE", "main")
 	// Cache
-	cacheType := "redis"
+	cacheType := GetEnvOrDef("CACHE_TYPE", "redis")
 	redisHost := GetEnvOrDef("REDIS_HOST", "localhost")
 	redisPort, _ := strconv.Atoi(GetEnvOrDef("REDIS_PORT", "6379"))
 	redisUser := GetEnvOrDef("REDIS_USER", "granica")
 	redisPassword := GetEnvOrDef("REDIS_PASSWORD", "granica")
 	redisExchange := GetEnvOrDef("REDIS_EXCHANGE", "default")
 	redisQueue := GetEnvOrDef("REDIS_QUEUE", "main")
+	// Token
+	tokenIssuer := GetEnvOrDef("TOKEN_ISSUER", "http://localhost:8080")
+	tokenAudience := GetEnvOrDef("TOKEN_AUDIENCE", "granica")
+	tokenAccessTTL := duration("TOKEN_ACCESS_TTL", "15m")
+	tokenRefreshTTL := duration("TOKEN_REFRESH_TTL", "720h")
+	tokenCodeTTL := duration("TOKEN_CODE_TTL", "1m")
+	tokenRedirectSchemes := GetEnvListOrDef("TOKEN_REDIRECT_SCHEMES", "")
+	tokenAlgorithm := GetEnvOrDef("TOKEN_ALGORITHM", "RS256")
+	tokenPrivateKeyPath := GetEnvOrDef("TOKEN_PRIVATE_KEY_PATH", "")
+	tokenKeysDir := GetEnvOrDef("TOKEN_KEYS_DIR", "")
+	tokenEphemeralKey, _ := strconv.ParseBool(GetEnvOrDef("TOKEN_EPHEMERAL_KEY", "false"))
+	tokenKeyReloadInterval := duration("TOKEN_KEY_RELOAD_INTERVAL", "1m")
+	tokenKeyActivationDelay := duration("TOKEN_KEY_ACTIVATION_DELAY", "1h")
+	// Mailer
+	mailerType := GetEnvOrDef("MAILER_TYPE", "file")
+	mailerFrom := GetEnvOrDef("MAILER_FROM", "no-reply@granica.dev")
+	mailerFilePath := GetEnvOrDef("MAILER_FILE_PATH", "")
+	smtpHost := GetEnvOrDef("SMTP_HOST", "localhost")
+	smtpPort, _ := strconv.Atoi(GetEnvOrDef("SMTP_PORT", "587"))
+	smtpUser := GetEnvOrDef("SMTP_USER", "")
+	smtpPassword := GetEnvOrDef("SMTP_PASSWORD", "")
+	// Auth
+	authRequireVerifiedEmail, _ := strconv.ParseBool(GetEnvOrDef("AUTH_REQUIRE_VERIFIED_EMAIL", "false"))
+	authEmailVerificationTTL := duration("AUTH_EMAIL_VERIFICATION_TTL", "24h")
+	authPasswordResetTTL := duration("AUTH_PASSWORD_RESET_TTL", "1h")
+	authMFAKey := GetEnvOrDef("AUTH_MFA_KEY", "")
+	authMFAIssuer := GetEnvOrDef("AUTH_MFA_ISSUER", "Granica")
+	authMFAChallengeTTL := duration("AUTH_MFA_CHALLENGE_TTL", "5m")
+	authAdmins := GetEnvListOrDef("AUTH_ADMINS", "")
+	authPolicies := GetEnvOrDef("AUTH_POLICIES_FILE", "")
+	authNamespaces := GetEnvOrDef("AUTH_NAMESPACES_FILE", "")
+	authSystemTenant := GetEnvOrDef("AUTH_SYSTEM_TENANT", "localhost")
+	authSystemTenantDomains := GetEnvListOrDef("AUTH_SYSTEM_TENANT_DOMAINS", "localhost")
+	tenantStrategies := GetEnvListOrDef("TENANT_RESOLUTION_STRATEGIES", "host")
+	tenantHeader := GetEnvOrDef("TENANT_HEADER", "X-Tenant-ID")
+	tenantPathPrefix := GetEnvOrDef("TENANT_PATH_PREFIX", "/t/")
+	tenantSubdomainPattern := GetEnvOrDef("TENANT_SUBDOMAIN_PATTERN", "")
+	authTrustedProxies := GetEnvListOrDef("AUTH_TRUSTED_PROXIES", "")
+	webAuthnRPID := GetEnvOrDef("WEBAUTHN_RP_ID", "localhost")
+	webAuthnRPName := GetEnvOrDef("WEBAUTHN_RP_NAME", "Granica")
+	webAuthnOrigins := GetEnvListOrDef("WEBAUTHN_ORIGINS", "http://localhost:8080")
+	webAuthnTimeout := duration("WEBAUTHN_TIMEOUT", "5m")
+	lockoutAccountThreshold, _ := strconv.Atoi(GetEnvOrDef("LOCKOUT_ACCOUNT_THRESHOLD", "5"))
+	lockoutIPThreshold, _ := strconv.Atoi(GetEnvOrDef("LOCKOUT_IP_THRESHOLD", "20"))
+	lockoutDuration := duration("LOCKOUT_DURATION", "1m")
+	lockoutMaxDuration := duration("LOCKOUT_MAX_DURATION", "1h")
+	lockoutWindow := duration("LOCKOUT_WINDOW", "24h")
+	passwordMinLength, _ := strconv.Atoi(GetEnvOrDef("PASSWORD_MIN_LENGTH", "8"))
+	passwordMaxLength, _ := strconv.Atoi(GetEnvOrDef("PASSWORD_MAX_LENGTH", "64"))
+	passwordRequireUpper, _ := strconv.ParseBool(GetEnvOrDef("PASSWORD_REQUIRE_UPPER", "false"))
+	passwordRequireLower, _ := strconv.ParseBool(GetEnvOrDef("PASSWORD_REQUIRE_LOWER", "false"))
+	passwordRequireDigit, _ := strconv.ParseBool(GetEnvOrDef("PASSWORD_REQUIRE_DIGIT", "false"))
+	passwordRequireSymbol, _ := strconv.ParseBool(GetEnvOrDef("PASSWORD_REQUIRE_SYMBOL", "false"))
+	passwordDisallowUserInfo, _ := strconv.ParseBool(GetEnvOrDef("PASSWORD_DISALLOW_USER_INFO", "true"))
+	passwordDisallowBreached, _ := strconv.ParseBool(GetEnvOrDef("PASSWORD_DISALLOW_BREACHED", "true"))
+	passwordHistorySize, _ := strconv.Atoi(GetEnvOrDef("PASSWORD_HISTORY_SIZE", "5"))
+	passwordBreachedFile := GetEnvOrDef("PASSWORD_BREACHED_FILE", "")
+	passwordTenantPoliciesFile := GetEnvOrDef("PASSWORD_TENANT_POLICIES_FILE", "")
+	hasherAlgorithm := GetEnvOrDef("HASHER_ALGORITHM", "argon2id")
+	hasherBcryptCost, _ := strconv.Atoi(GetEnvOrDef("HASHER_BCRYPT_COST", "10"))
+	hasherScryptLogN, _ := strconv.Atoi(GetEnvOrDef("HASHER_SCRYPT_LOG_N", "15"))
+	hasherScryptR, _ := strconv.Atoi(GetEnvOrDef("HASHER_SCRYPT_R", "8"))
+	hasherScryptP, _ := strconv.Atoi(GetEnvOrDef("HASHER_SCRYPT_P", "1"))
+	hasherArgon2Time, _ := strconv.Atoi(GetEnvOrDef("HASHER_ARGON2_TIME", "3"))
+	hasherArgon2Memory, _ := strconv.Atoi(GetEnvOrDef("HASHER_ARGON2_MEMORY", "65536"))
+	hasherArgon2Threads, _ := strconv.Atoi(GetEnvOrDef("HASHER_ARGON2_THREADS", "2"))
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid config: %s", strings.Join(invalid, "; "))
+	}
 
 	mongodb := MongoDBConfig{
 		Host:     mongodbHost,
@@ -94,9 +245,30 @@ func loadFromEnvvar() (*Config, error) {
 		Password: mongodbPassword,
 	}
 
+	postgres := PostgresConfig{
+		Host:     postgresHost,
+		Port:     postgresPort,
+		Db:       postgresDb,
+		User:     postgresUser,
+		Password: postgresPassword,
+		SSLMode:  postgresSSLMode,
+	}
+
+	bolt := BoltConfig{
+		Path:    boltPath,
+		Backups: boltBackups,
+	}
+
 	repo := RepoConfig{
-		Type:    repoType,
-		MongoDB: mongodb,
+		Type:     repoType,
+		MongoDB:  mongodb,
+		Postgres: postgres,
+		Bolt:     bolt,
+		Timeouts: RepoTimeoutsConfig{
+			Read:  repoReadTimeout,
+			Write: repoWriteTimeout,
+		},
+		Migration: repoMigration,
 	}
 
 	redis := RedisConfig{
@@ -127,10 +299,119 @@ func loadFromEnvvar() (*Config, error) {
 		RabbitMQ: rabbitmq,
 	}
 
+	token := TokenConfig{
+		Issuer:             tokenIssuer,
+		Audience:           tokenAudience,
+		AccessTTL:          tokenAccessTTL,
+		RefreshTTL:         tokenRefreshTTL,
+		CodeTTL:            tokenCodeTTL,
+		RedirectSchemes:    tokenRedirectSchemes,
+		Algorithm:          tokenAlgorithm,
+		PrivateKeyPath:     tokenPrivateKeyPath,
+		KeysDir:            tokenKeysDir,
+		EphemeralKey:       tokenEphemeralKey,
+		KeyReloadInterval:  tokenKeyReloadInterval,
+		KeyActivationDelay: tokenKeyActivationDelay,
+	}
+
+	smtp := SMTPConfig{
+		Host:     smtpHost,
+		Port:     smtpPort,
+		User:     smtpUser,
+		Password: smtpPassword,
+	}
+
+	mailer := MailerConfig{
+		Type:     mailerType,
+		From:     mailerFrom,
+		FilePath: mailerFilePath,
+		SMTP:     smtp,
+	}
+
+	webAuthn := WebAuthnConfig{
+		RPID:    webAuthnRPID,
+		RPName:  webAuthnRPName,
+		Origins: webAuthnOrigins,
+		Timeout: webAuthnTimeout,
+	}
+
+	lockout := LockoutConfig{
+		AccountThreshold: lockoutAccountThreshold,
+		IPThreshold:      lockoutIPThreshold,
+		Duration:         lockoutDuration,
+		MaxDuration:      lockoutMaxDuration,
+		Window:           lockoutWindow,
+	}
+
+	passwordPolicy := PasswordPolicyConfig{
+		MinLength:        passwordMinLength,
+		MaxLength:        passwordMaxLength,
+		RequireUpper:     passwordRequireUpper,
+		RequireLower:     passwordRequireLower,
+		RequireDigit:     passwordRequireDigit,
+		RequireSymbol:    passwordRequireSymbol,
+		DisallowUserInfo: passwordDisallowUserInfo,
+		DisallowBreached: passwordDisallowBreached,
+		HistorySize:      passwordHistorySize,
+	}
+
+	// Tenant policies are too structured for envvars.
+	if passwordTenantPoliciesFile != "" {
+		fileBytes, err := ioutil.ReadFile(passwordTenantPoliciesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		err = yaml.Unmarshal(fileBytes, &passwordPolicy.Tenants)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hasher := HasherConfig{
+		Algorithm:     hasherAlgorithm,
+		BcryptCost:    hasherBcryptCost,
+		ScryptLogN:    hasherScryptLogN,
+		ScryptR:       hasherScryptR,
+		ScryptP:       hasherScryptP,
+		Argon2Time:    hasherArgon2Time,
+		Argon2Memory:  hasherArgon2Memory,
+		Argon2Threads: hasherArgon2Threads,
+	}
+
+	auth := AuthConfig{
+		RequireVerifiedEmail: authRequireVerifiedEmail,
+		EmailVerificationTTL: authEmailVerificationTTL,
+		PasswordResetTTL:     authPasswordResetTTL,
+		MFAKey:               authMFAKey,
+		MFAIssuer:            authMFAIssuer,
+		MFAChallengeTTL:      authMFAChallengeTTL,
+		WebAuthn:             webAuthn,
+		Lockout:              lockout,
+		PasswordPolicy:       passwordPolicy,
+		BreachedPasswords:    passwordBreachedFile,
+		Hasher:               hasher,
+		Admins:               authAdmins,
+		Policies:             authPolicies,
+		Namespaces:           authNamespaces,
+		SystemTenant:         authSystemTenant,
+		SystemTenantDomains:  authSystemTenantDomains,
+		TenantResolution: TenantResolutionConfig{
+			Strategies:       tenantStrategies,
+			Header:           tenantHeader,
+			PathPrefix:       tenantPathPrefix,
+			SubdomainPattern: tenantSubdomainPattern,
+		},
+		TrustedProxies: authTrustedProxies,
+	}
+
 	cfg := &Config{
 		Repo:   repo,
 		Broker: broker,
 		Cache:  cache,
+		Token:  token,
+		Mailer: mailer,
+		Auth:   auth,
 	}
 
 	// fmt.Printf("[DEBUG] - Config: %+v", cfg)
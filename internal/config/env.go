@@ -8,7 +8,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"time"
 )
 
 // GetEnvOrDef - Return the value of provided environment variable or default
@@ -25,3 +28,29 @@ func GetEnvOrDef(envar string, def ...string) string {
 	}
 	return ""
 }
+
+// GetEnvListOrDef - Return the comma separated values of provided environment
+// variable or default if this value is empty. Empty values are dropped.
+func GetEnvListOrDef(envar string, def ...string) []string {
+	var list []string
+	for _, v := range strings.Split(GetEnvOrDef(envar, def...), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// GetEnvDurationOrDef - Return the duration of provided environment variable
+// or default if this value is empty. Values that are not valid, non negative
+// durations are reported instead of being read as zero.
+func GetEnvDurationOrDef(envar, def string) (time.Duration, error) {
+	val := GetEnvOrDef(envar, def)
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s: '%s' is not a valid duration", envar, val)
+	}
+
+	return d, nil
+}
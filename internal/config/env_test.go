@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetEnvDurationOrDef(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+		valid bool
+	}{
+		{"default", "", 15 * time.Minute, true},
+		{"set", "1h", time.Hour, true},
+		{"zero", "0s", 0, true},
+		{"typo", "15mn", 0, false},
+		{"no_unit", "15", 0, false},
+		{"negative", "-1m", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("GRANICA_TEST_DURATION", tt.value)
+			defer os.Unsetenv("GRANICA_TEST_DURATION")
+
+			d, err := GetEnvDurationOrDef("GRANICA_TEST_DURATION", "15m")
+			if (err == nil) != tt.valid {
+				t.Fatalf("valid: want %t, got error %v", tt.valid, err)
+			}
+			if d != tt.want {
+				t.Errorf("duration: want %s, got %s", tt.want, d)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidDuration(t *testing.T) {
+	os.Setenv("TOKEN_ACCESS_TTL", "15 minutes")
+	defer os.Unsetenv("TOKEN_ACCESS_TTL")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "TOKEN_ACCESS_TTL") {
+		t.Errorf("want error naming TOKEN_ACCESS_TTL, got %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // Config - Configuration struct.
 type Config struct {
 	// App - App config values
@@ -10,6 +12,12 @@ type Config struct {
 	Broker BrokerConfig `yaml:"broker"`
 	// Cache - App cache config values.
 	Cache CacheConfig `yaml:"cache"`
+	// Token - App token issuance config values.
+	Token TokenConfig `yaml:"token"`
+	// Mailer - App mailer config values.
+	Mailer MailerConfig `yaml:"mailer"`
+	// Auth - App authentication policy config values.
+	Auth AuthConfig `yaml:"auth"`
 }
 
 // AppConfig - App configuration struct.
@@ -19,8 +27,38 @@ type AppConfig struct {
 
 // RepoConfig - Repo configuration struct.
 type RepoConfig struct {
-	Type    string        `yaml:"repo"`
-	MongoDB MongoDBConfig `yaml:"mongoDB"`
+	Type     string             `yaml:"repo"`
+	MongoDB  MongoDBConfig      `yaml:"mongoDB"`
+	Postgres PostgresConfig     `yaml:"postgres"`
+	Bolt     BoltConfig         `yaml:"bolt"`
+	Timeouts RepoTimeoutsConfig `yaml:"timeouts"`
+	// Migration - "m", "r", "n": migrate, rollback the last migration or none.
+	Migration string `yaml:"migration"`
+}
+
+// PostgresConfig - PostgreSQL configuration struct.
+type PostgresConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Db       string `yaml:"db"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	SSLMode  string `yaml:"sslMode"`
+}
+
+// BoltConfig - Embedded file backed repo configuration struct.
+// A copy of the file is made each time it is opened; the latest Backups
+// ones are kept, none if zero.
+type BoltConfig struct {
+	Path    string `yaml:"path"`
+	Backups int    `yaml:"backups"`
+}
+
+// RepoTimeoutsConfig - Repo operation timeouts.
+// Each operation is also cancelled with the request it serves.
+type RepoTimeoutsConfig struct {
+	Read  time.Duration `yaml:"read"`
+	Write time.Duration `yaml:"write"`
 }
 
 // MongoDBConfig - MongoDBConfig configuration struct.
@@ -64,6 +102,137 @@ type RabbitMQConfig struct {
 	Queue    string `yaml:"queue"`
 }
 
+// TokenConfig - Token issuance configuration struct.
+type TokenConfig struct {
+	Issuer             string        `yaml:"issuer"`
+	Audience           string        `yaml:"audience"`
+	AccessTTL          time.Duration `yaml:"accessTTL"`
+	RefreshTTL         time.Duration `yaml:"refreshTTL"`
+	CodeTTL            time.Duration `yaml:"codeTTL"`
+	RedirectSchemes    []string      `yaml:"redirectSchemes"`
+	Algorithm          string        `yaml:"algorithm"`
+	PrivateKeyPath     string        `yaml:"privateKeyPath"`
+	KeysDir            string        `yaml:"keysDir"`
+	EphemeralKey       bool          `yaml:"ephemeralKey"`
+	KeyReloadInterval  time.Duration `yaml:"keyReloadInterval"`
+	KeyActivationDelay time.Duration `yaml:"keyActivationDelay"`
+}
+
+// MailerConfig - Mailer configuration struct.
+// File mailer writes messages to FilePath or to the log if empty.
+type MailerConfig struct {
+	Type     string     `yaml:"mailer"`
+	From     string     `yaml:"from"`
+	FilePath string     `yaml:"filePath"`
+	SMTP     SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig - SMTP configuration struct.
+type SMTPConfig struct {
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+}
+
+// AuthConfig - Authentication policy configuration struct.
+// MFAKey is the base64 encoded 32 bytes key TOTP secrets are encrypted with.
+// BreachedPasswords is the path of a list of SHA-1 digests of breached passwords.
+// Admins are the IDs of the users granted every permission, in any tenant
+// they belong to, so that roles can be set up from scratch.
+// Policies is the path of a YAML or JSON file mapping tenant IDs
+// to the access policies evaluated by the authorization decision API.
+// Namespaces is the path of a YAML or JSON file describing the relations
+// objects of each namespace can have and how they are rewritten.
+// SystemTenant is the slug of the tenant other tenants are managed from,
+// registered on start with SystemTenantDomains if it does not exist.
+type AuthConfig struct {
+	RequireVerifiedEmail bool                   `yaml:"requireVerifiedEmail"`
+	EmailVerificationTTL time.Duration          `yaml:"emailVerificationTTL"`
+	PasswordResetTTL     time.Duration          `yaml:"passwordResetTTL"`
+	MFAKey               string                 `yaml:"mfaKey"`
+	MFAIssuer            string                 `yaml:"mfaIssuer"`
+	MFAChallengeTTL      time.Duration          `yaml:"mfaChallengeTTL"`
+	WebAuthn             WebAuthnConfig         `yaml:"webAuthn"`
+	Lockout              LockoutConfig          `yaml:"lockout"`
+	PasswordPolicy       PasswordPolicyConfig   `yaml:"passwordPolicy"`
+	BreachedPasswords    string                 `yaml:"breachedPasswords"`
+	Hasher               HasherConfig           `yaml:"hasher"`
+	Admins               []string               `yaml:"admins"`
+	Policies             string                 `yaml:"policies"`
+	Namespaces           string                 `yaml:"namespaces"`
+	SystemTenant         string                 `yaml:"systemTenant"`
+	SystemTenantDomains  []string               `yaml:"systemTenantDomains"`
+	TenantResolution     TenantResolutionConfig `yaml:"tenantResolution"`
+	TrustedProxies       []string               `yaml:"trustedProxies"`
+}
+
+// TenantResolutionConfig - Tenant resolution configuration struct.
+// Strategies are tried in order until one of them finds the key of a tenant
+// in the request: host, header, path, subdomain or token.
+// PathPrefix is followed by the tenant slug, as in '/t/{tenant}/sign-in'.
+// SubdomainPattern is a host with a '{tenant}' placeholder for the slug,
+// as in '{tenant}.granica.dev'.
+type TenantResolutionConfig struct {
+	Strategies       []string `yaml:"strategies"`
+	Header           string   `yaml:"header"`
+	PathPrefix       string   `yaml:"pathPrefix"`
+	SubdomainPattern string   `yaml:"subdomainPattern"`
+}
+
+// HasherConfig - Password hashing configuration struct.
+// Algorithm is one of bcrypt, scrypt or argon2id. Stored digests made
+// with another algorithm or weaker parameters are rehashed on sign in.
+// Argon2Memory is expressed in KiB.
+type HasherConfig struct {
+	Algorithm     string `yaml:"algorithm"`
+	BcryptCost    int    `yaml:"bcryptCost"`
+	ScryptLogN    int    `yaml:"scryptLogN"`
+	ScryptR       int    `yaml:"scryptR"`
+	ScryptP       int    `yaml:"scryptP"`
+	Argon2Time    int    `yaml:"argon2Time"`
+	Argon2Memory  int    `yaml:"argon2Memory"`
+	Argon2Threads int    `yaml:"argon2Threads"`
+}
+
+// PasswordPolicyConfig - Password policy configuration struct.
+// Zero values disable a rule, empty passwords are never accepted.
+// Tenants maps tenant IDs to the policy replacing the default one for them.
+type PasswordPolicyConfig struct {
+	MinLength        int                             `yaml:"minLength"`
+	MaxLength        int                             `yaml:"maxLength"`
+	RequireUpper     bool                            `yaml:"requireUpper"`
+	RequireLower     bool                            `yaml:"requireLower"`
+	RequireDigit     bool                            `yaml:"requireDigit"`
+	RequireSymbol    bool                            `yaml:"requireSymbol"`
+	DisallowUserInfo bool                            `yaml:"disallowUserInfo"`
+	DisallowBreached bool                            `yaml:"disallowBreached"`
+	HistorySize      int                             `yaml:"historySize"`
+	Tenants          map[string]PasswordPolicyConfig `yaml:"tenants"`
+}
+
+// LockoutConfig - Failed attempts lockout configuration struct.
+// Accounts and IPs are locked for Duration once their failures in Window
+// reach the threshold, doubling on every further failure up to MaxDuration.
+// A zero threshold disables the lockout.
+type LockoutConfig struct {
+	AccountThreshold int           `yaml:"accountThreshold"`
+	IPThreshold      int           `yaml:"ipThreshold"`
+	Duration         time.Duration `yaml:"duration"`
+	MaxDuration      time.Duration `yaml:"maxDuration"`
+	Window           time.Duration `yaml:"window"`
+}
+
+// WebAuthnConfig - WebAuthn relying party configuration struct.
+// RPID is the domain credentials are scoped to and Origins
+// the origins ceremonies are accepted from.
+type WebAuthnConfig struct {
+	RPID    string        `yaml:"rpID"`
+	RPName  string        `yaml:"rpName"`
+	Origins []string      `yaml:"origins"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
 // LogLevel - App log level.
 type logLevel string
 
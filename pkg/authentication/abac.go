@@ -0,0 +1,106 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/policy"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// AccessRequest asks whether the subject of an access token
+// can perform an action over a resource.
+// Context carries request attributes known to the caller, such as the IP
+// its user connects from.
+type AccessRequest struct {
+	Action   string                 `json:"action"`
+	Resource Resource               `json:"resource"`
+	Context  map[string]interface{} `json:"context"`
+}
+
+// Resource is the object of an access request.
+type Resource struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// name returns the name policies match the resource by, 'type:id' or just 'type'.
+func (r Resource) name() string {
+	if r.ID == "" {
+		return r.Type
+	}
+	return r.Type + ":" + r.ID
+}
+
+// Decide evaluates the policies of the tenant for the access request of the subject
+// of the access token. ErrUnauthorized is returned if the token is not valid.
+func (gs granicaService) Decide(ctx context.Context, accessToken string, ar AccessRequest, tenantID string) (*policy.Decision, error) {
+	if ar.Action == "" || ar.Resource.Type == "" {
+		return nil, errors.New("action and resource type required")
+	}
+
+	user, err := gs.authenticatedUser(ctx, accessToken, tenantID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	roles, permissions, err := gs.userRoles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := accessAttributes(user, roles, permissions, ar, time.Now())
+	decision := policy.Evaluate(gs.policies[tenantID], ar.Action, ar.Resource.name(), attrs)
+	return &decision, nil
+}
+
+// accessAttributes collects the subject, resource, action and context
+// attributes policies are evaluated against.
+// Time is set here so that callers cannot override it.
+func accessAttributes(user *m.User, roles, permissions []string, ar AccessRequest, now time.Time) policy.Attributes {
+	attrs := policy.Attributes{
+		"subject.id":              user.ID.String(),
+		"subject.tenantID":        user.TenantID,
+		"subject.username":        user.Username,
+		"subject.email":           user.Email,
+		"subject.isEmailVerified": user.IsEmailVerified,
+		"subject.isMFAEnabled":    user.IsMFAEnabled,
+		"subject.isActive":        user.IsActive,
+		"subject.givenName":       user.GivenName,
+		"subject.familyName":      user.FamilyName,
+		"subject.contextID":       user.ContextID,
+		"subject.lastKnownIP":     user.LastKnownIP,
+		"subject.geohash":         user.Geohash,
+		"subject.roles":           roles,
+		"subject.permissions":     permissions,
+		"resource.type":           ar.Resource.Type,
+		"resource.id":             ar.Resource.ID,
+		"action":                  ar.Action,
+	}
+
+	if user.LastKnownLocation.Type == m.PointGeoType {
+		attrs["subject.lastKnownLocation"] = user.LastKnownLocation.Point.Coordinates
+	}
+
+	for k, v := range ar.Resource.Attributes {
+		if _, ok := attrs["resource."+k]; !ok {
+			attrs["resource."+k] = v
+		}
+	}
+
+	for k, v := range ar.Context {
+		attrs["context."+k] = v
+	}
+	attrs["context.time"] = now
+
+	return attrs
+}
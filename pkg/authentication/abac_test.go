@@ -0,0 +1,198 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/policy"
+)
+
+const testPolicies = `
+localhost:
+  - id: owners
+    effect: allow
+    actions: ["documents:*"]
+    resources: ["documents:*"]
+    conditions:
+      - attribute: resource.owner
+        operator: eq
+        valueFrom: subject.id
+  - id: office
+    effect: allow
+    actions: ["documents:read"]
+    resources: ["documents:*"]
+    conditions:
+      - attribute: context.ip
+        operator: cidr
+        value: ["10.0.0.0/8"]
+      - attribute: context.time
+        operator: timeBetween
+        value: ["08:00", "18:00"]
+  - id: nearby
+    effect: allow
+    actions: ["printers:use"]
+    resources: ["printers"]
+    conditions:
+      - attribute: subject.lastKnownLocation
+        operator: withinKm
+        value: [21.0122, 52.2297, 10]
+  - id: confidential
+    effect: deny
+    actions: ["*"]
+    resources: ["documents:*"]
+    conditions:
+      - attribute: resource.level
+        operator: gt
+        value: 2
+      - attribute: subject.roles
+        operator: notIn
+        value: ["auditor"]
+`
+
+func TestPolicyEvaluate(t *testing.T) {
+	set, err := policy.Parse([]byte(testPolicies))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	office := time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC)
+	night := time.Date(2019, 10, 1, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		action   string
+		resource string
+		attrs    policy.Attributes
+		allowed  bool
+		reason   string
+	}{
+		{"owner", "documents:edit", "documents:1",
+			policy.Attributes{"subject.id": "u1", "resource.owner": "u1"}, true, "policy 'owners' allows"},
+		{"not owner", "documents:edit", "documents:1",
+			policy.Attributes{"subject.id": "u2", "resource.owner": "u1"}, false, "no policy allows the request"},
+		{"office", "documents:read", "documents:1",
+			policy.Attributes{"context.ip": "10.1.2.3", "context.time": office}, true, "policy 'office' allows"},
+		{"office at night", "documents:read", "documents:1",
+			policy.Attributes{"context.ip": "10.1.2.3", "context.time": night}, false, "no policy allows the request"},
+		{"outside office", "documents:read", "documents:1",
+			policy.Attributes{"context.ip": "192.168.1.1", "context.time": office}, false, "no policy allows the request"},
+		{"nearby", "printers:use", "printers",
+			policy.Attributes{"subject.lastKnownLocation": []float64{21.0, 52.2}}, true, "policy 'nearby' allows"},
+		{"far away", "printers:use", "printers",
+			policy.Attributes{"subject.lastKnownLocation": []float64{2.35, 48.85}}, false, "no policy allows the request"},
+		{"confidential owner", "documents:read", "documents:1",
+			policy.Attributes{"subject.id": "u1", "resource.owner": "u1", "resource.level": 3.0, "subject.roles": []string{}},
+			false, "policy 'confidential' denies"},
+		{"confidential auditor owner", "documents:read", "documents:1",
+			policy.Attributes{"subject.id": "u1", "resource.owner": "u1", "resource.level": 3.0, "subject.roles": []string{"auditor"}},
+			true, "policy 'owners' allows"},
+	}
+
+	for _, tt := range tests {
+		d := policy.Evaluate(set["localhost"], tt.action, tt.resource, tt.attrs)
+		if d.Allowed != tt.allowed {
+			t.Errorf("%s allowed: %t | Expected: %t", tt.name, d.Allowed, tt.allowed)
+		}
+
+		if len(d.Reasons) != 1 || d.Reasons[0] != tt.reason {
+			t.Errorf("%s reasons: %v | Expected: [%s]", tt.name, d.Reasons, tt.reason)
+		}
+	}
+}
+
+func TestPolicyParse(t *testing.T) {
+	invalid := []string{
+		`localhost: [{id: p, effect: permit, actions: ["*"], resources: ["*"]}]`,
+		`localhost: [{id: p, effect: allow, resources: ["*"]}]`,
+		`localhost: [{id: p, effect: allow, actions: ["*"], resources: ["*"], conditions: [{attribute: action, operator: like}]}]`,
+		`localhost: [{id: p, effect: allow, actions: ["*"], resources: ["*"]}, {id: p, effect: deny, actions: ["*"], resources: ["*"]}]`,
+	}
+
+	for _, s := range invalid {
+		if _, err := policy.Parse([]byte(s)); err == nil {
+			t.Errorf("Policies '%s' parsed | Expected: error", s)
+		}
+	}
+
+	json := `{"localhost": [{"id": "p", "effect": "allow", "actions": ["*"], "resources": ["*"]}]}`
+	if _, err := policy.Parse([]byte(json)); err != nil {
+		t.Errorf("JSON policies error: %v | Expected: nil", err)
+	}
+
+	if _, err := policy.Load("../../configs/policies.yaml"); err != nil {
+		t.Errorf("Example policies error: %v | Expected: nil", err)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	set, err := policy.Parse([]byte(testPolicies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.policies = set
+
+	user, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := DecideHandler(svc)
+	decide := func(accessToken, body string) (int, decideResponse) {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost/authorize", strings.NewReader(body))
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var res decideResponse
+		json.NewDecoder(rec.Body).Decode(&res)
+		return rec.Code, res
+	}
+
+	owned := `{"action": "documents:edit", "resource": {"type": "documents", "id": "1", "attributes": {"owner": "` + user.ID.String() + `"}}}`
+	status, res := decide(token.AccessToken, owned)
+	if status != http.StatusOK || res.Decision == nil || !res.Allowed {
+		t.Fatalf("Status: %d, response: %+v | Expected: allowed", status, res)
+	}
+
+	// Subject attributes cannot be forged through the resource ones.
+	forged := `{"action": "documents:edit", "resource": {"type": "documents", "id": "1", "attributes": {"owner": "someone"}}, "context": {"subject.id": "someone"}}`
+	status, res = decide(token.AccessToken, forged)
+	if status != http.StatusOK || res.Decision == nil || res.Allowed {
+		t.Errorf("Status: %d, response: %+v | Expected: denied", status, res)
+	}
+
+	status, _ = decide("", owned)
+	if status != http.StatusUnauthorized {
+		t.Errorf("Status: %d | Expected: %d", status, http.StatusUnauthorized)
+	}
+
+	// Tenants without policies deny everything.
+	svc.policies = nil
+	status, res = decide(token.AccessToken, owned)
+	if status != http.StatusOK || res.Decision == nil || res.Allowed {
+		t.Errorf("Status: %d, response: %+v | Expected: denied", status, res)
+	}
+}
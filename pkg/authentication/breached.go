@@ -0,0 +1,181 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	breachedPrefixLen = 5
+)
+
+// BreachedList looks up breached passwords by the prefix of their SHA-1 digest
+// (k-anonymity), so a list can be served locally or by a range API without
+// ever handing out the digest of the checked password.
+type BreachedList interface {
+	// Range returns the uppercase hex suffixes of the breached digests
+	// starting with the prefix.
+	Range(prefix string) ([]string, error)
+}
+
+// fileBreachedList is a breached list looked up in a local file.
+// The file is never loaded: ranges are found by binary search,
+// so lists of any size take no memory.
+type fileBreachedList struct {
+	f    *os.File
+	size int64
+}
+
+// loadBreachedList opens a list of hex encoded SHA-1 digests, one per line,
+// sorted by digest. Anything past a colon is ignored, so the ordered by hash
+// downloads of Pwned Passwords can be used as they are.
+func loadBreachedList(path string) (*fileBreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	l := &fileBreachedList{f: f, size: fi.Size()}
+
+	// Only the first line is checked, the whole list is too big to be read.
+	first, _, err := l.lineAt(0)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if first != "" && !isSHA1Digest(first) {
+		f.Close()
+		return nil, fmt.Errorf("breached list line 1: not a SHA-1 digest")
+	}
+
+	return l, nil
+}
+
+// Range returns the suffixes of the breached digests starting with the prefix.
+func (l *fileBreachedList) Range(prefix string) ([]string, error) {
+	prefix = strings.ToUpper(prefix)
+
+	// Smallest offset whose next line is not before the prefix.
+	lo, hi := int64(0), l.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		digest, _, err := l.lineAt(mid)
+		if err != nil {
+			return nil, err
+		}
+
+		if digest != "" && digest < prefix {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	var suffixes []string
+	for off := lo; off < l.size; {
+		digest, next, err := l.lineAt(off)
+		if err != nil {
+			return nil, err
+		}
+
+		if digest == "" || !strings.HasPrefix(digest, prefix) {
+			break
+		}
+
+		suffixes = append(suffixes, digest[breachedPrefixLen:])
+		off = next
+	}
+
+	return suffixes, nil
+}
+
+// lineAt returns the uppercase digest of the first line starting at or after
+// the offset and the offset of the line that follows it.
+// The digest is empty past the end of the file.
+func (l *fileBreachedList) lineAt(off int64) (string, int64, error) {
+	r := bufio.NewReader(io.NewSectionReader(l.f, off, l.size-off))
+
+	// The line started before the offset belongs to a smaller one.
+	if off > 0 {
+		b, err := l.byteAt(off - 1)
+		if err != nil {
+			return "", 0, err
+		}
+
+		if b != '\n' {
+			skipped, err := r.ReadString('\n')
+			if err == io.EOF {
+				return "", l.size, nil
+			}
+			if err != nil {
+				return "", 0, err
+			}
+			off += int64(len(skipped))
+		}
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+
+	next := off + int64(len(line))
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+
+	return strings.ToUpper(strings.TrimSpace(line)), next, nil
+}
+
+func (l *fileBreachedList) byteAt(off int64) (byte, error) {
+	b := make([]byte, 1)
+	_, err := l.f.ReadAt(b, off)
+	return b[0], err
+}
+
+// isSHA1Digest - True if the value is a hex encoded SHA-1 digest.
+func isSHA1Digest(value string) bool {
+	if len(value) != sha1.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
+
+// isBreached - True if the password is in the breached list.
+func isBreached(list BreachedList, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := list.Range(digest[:breachedPrefixLen])
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range suffixes {
+		if s == digest[breachedPrefixLen:] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,108 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	clientIPContextKey contextKey = "clientIP"
+)
+
+// clientIPResolver finds the IP of the client requests come from.
+// It is the address of the direct peer unless the peer is one of the
+// trusted proxies. Then X-Forwarded-For is read from the right, skipping
+// the trusted proxies, and the first address left is the client one.
+// Addresses further left were written before reaching a trusted proxy
+// and can be forged by the client.
+type clientIPResolver struct {
+	trusted []*net.IPNet
+}
+
+// newClientIPResolver makes a new client IP resolver.
+// Proxies are IP addresses or CIDR ranges.
+func newClientIPResolver(proxies []string) (*clientIPResolver, error) {
+	cr := &clientIPResolver{}
+
+	for _, p := range proxies {
+		cidr := p
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy '%s'", p)
+		}
+		cr.trusted = append(cr.trusted, n)
+	}
+
+	return cr, nil
+}
+
+// Handler stores the client IP of the requests before passing them to next.
+func (cr *clientIPResolver) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientIPContextKey, cr.resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolve returns the client IP of the request.
+func (cr *clientIPResolver) resolve(r *http.Request) string {
+	peer := peerIP(r)
+
+	ip := net.ParseIP(peer)
+	if ip == nil || !cr.isTrusted(ip) {
+		return peer
+	}
+
+	hops := r.Header["X-Forwarded-For"]
+	addrs := strings.Split(strings.Join(hops, ","), ",")
+	for i := len(addrs) - 1; i >= 0; i-- {
+		next := net.ParseIP(strings.TrimSpace(addrs[i]))
+		if next == nil {
+			// A trusted proxy forwarded a value that is not an address.
+			break
+		}
+
+		ip = next
+		if !cr.isTrusted(ip) {
+			break
+		}
+	}
+
+	return ip.String()
+}
+
+func (cr *clientIPResolver) isTrusted(ip net.IP) bool {
+	for _, n := range cr.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerIP returns the IP of the direct peer of the request.
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
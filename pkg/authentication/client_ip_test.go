@@ -0,0 +1,74 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	cr, err := newClientIPResolver([]string{"10.0.0.0/8", "192.168.1.1", "fd00::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		ip     string
+	}{
+		{"direct client", "203.0.113.7:4242", nil, "203.0.113.7"},
+		{"untrusted peer forging a header", "203.0.113.7:4242", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:4242", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"client forging an entry", "10.0.0.2:4242", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "192.168.1.1:4242", []string{"198.51.100.1, 10.1.2.3"}, "198.51.100.1"},
+		{"repeated headers", "10.0.0.2:4242", []string{"1.2.3.4", "198.51.100.1, 10.1.2.3"}, "198.51.100.1"},
+		{"only trusted hops", "10.0.0.2:4242", []string{"10.1.2.3"}, "10.1.2.3"},
+		{"garbage from a trusted proxy", "10.0.0.2:4242", []string{"198.51.100.1, unknown"}, "10.0.0.2"},
+		{"trusted proxy without header", "10.0.0.2:4242", nil, "10.0.0.2"},
+		{"ipv6 trusted proxy", "[fd00::1]:4242", []string{"2001:db8::7"}, "2001:db8::7"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/sign-in", nil)
+		req.RemoteAddr = tt.remote
+		for _, h := range tt.xff {
+			req.Header.Add("X-Forwarded-For", h)
+		}
+
+		var ip string
+		cr.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip = getRemoteIP(r)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		if ip != tt.ip {
+			t.Errorf("%s: IP: '%s' | Expected: '%s'", tt.name, ip, tt.ip)
+		}
+	}
+}
+
+func TestClientIPResolverInvalidProxy(t *testing.T) {
+	for _, p := range []string{"10.0.0.0/33", "proxy.granica.dev"} {
+		if _, err := newClientIPResolver([]string{p}); err == nil {
+			t.Errorf("proxy '%s' must be rejected", p)
+		}
+	}
+}
+
+func TestGetRemoteIPIgnoresForwardedFor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/sign-in", nil)
+	req.RemoteAddr = "203.0.113.7:4242"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	if ip := getRemoteIP(req); ip != "203.0.113.7" {
+		t.Errorf("IP: '%s' | Expected: '203.0.113.7'", ip)
+	}
+}
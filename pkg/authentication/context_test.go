@@ -0,0 +1,55 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// ctxUserRepo counts the lookups reaching the user repo.
+type ctxUserRepo struct {
+	repo.UserRepo
+	calls int
+}
+
+func (r *ctxUserRepo) GetByUsernameAndTenant(ctx context.Context, username, tenantID string) (*m.User, error) {
+	r.calls++
+	return r.UserRepo.GetByUsernameAndTenant(ctx, username, tenantID)
+}
+
+func TestRepoContextPropagation(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	users := &ctxUserRepo{UserRepo: svc.repo}
+	svc.repo = users
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if _, err := svc.SignIn(cancelled, "username", "password", "127.0.0.1", "localhost"); err == nil {
+		t.Error("expected sign in with a cancelled context to fail")
+	}
+
+	if users.calls != 2 {
+		t.Errorf("Repo calls: %d | Expected: 2", users.calls)
+	}
+}
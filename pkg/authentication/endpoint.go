@@ -16,11 +16,11 @@ import (
 )
 
 func makeSignUpEndpoint(svc GranicaService) endpoint.Endpoint {
-	return func(_ context.Context, request interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(signUpRequest)
 		reqs := fmt.Sprintf("Req: %+v", req)
 		svc.Logger().Log("level", c.LogLevel.Debug, "req", reqs)
-		user, err := svc.SignUp(req.Username, req.Password, req.Email, req.EmailConfirmation, req.TenantID)
+		user, err := svc.SignUp(ctx, req.Username, req.Password, req.Email, req.EmailConfirmation, req.TenantID)
 		if err != nil {
 			return signUpResponse{user, err.Error()}, nil
 		}
@@ -29,11 +29,11 @@ func makeSignUpEndpoint(svc GranicaService) endpoint.Endpoint {
 }
 
 func makeCancelEndpoint(svc GranicaService) endpoint.Endpoint {
-	return func(_ context.Context, request interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(cancelRequest)
 		reqs := fmt.Sprintf("Req: %+v", req)
 		svc.Logger().Log("level", c.LogLevel.Debug, "req", reqs)
-		err := svc.Cancel(req.Username, req.Password, req.TenantID)
+		err := svc.Cancel(ctx, req.Username, req.Password, req.RemoteIP, req.TenantID)
 		if err != nil {
 			return cancelResponse{err.Error()}, nil
 		}
@@ -41,25 +41,155 @@ func makeCancelEndpoint(svc GranicaService) endpoint.Endpoint {
 	}
 }
 
+func makeVerifyEmailEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(verifyEmailRequest)
+		err := svc.VerifyEmail(ctx, req.Token)
+		if err != nil {
+			return verifyEmailResponse{err.Error()}, nil
+		}
+		return verifyEmailResponse{""}, nil
+	}
+}
+
+func makeResendEmailVerificationEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resendEmailVerificationRequest)
+		err := svc.ResendEmailVerification(ctx, req.Username, req.TenantID)
+		if err != nil {
+			return resendEmailVerificationResponse{err.Error()}, nil
+		}
+		return resendEmailVerificationResponse{""}, nil
+	}
+}
+
+func makeForgotPasswordEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(forgotPasswordRequest)
+		err := svc.ForgotPassword(ctx, req.Email, req.TenantID)
+		if err != nil {
+			return forgotPasswordResponse{err.Error()}, nil
+		}
+		return forgotPasswordResponse{""}, nil
+	}
+}
+
+func makeResetPasswordEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resetPasswordRequest)
+		err := svc.ResetPassword(ctx, req.Token, req.Password, req.PasswordConfirmation)
+		if err != nil {
+			return resetPasswordResponse{err.Error()}, nil
+		}
+		return resetPasswordResponse{""}, nil
+	}
+}
+
 func makeSignInEndpoint(svc GranicaService) endpoint.Endpoint {
-	return func(_ context.Context, request interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(signInRequest)
 		reqs := fmt.Sprintf("Req: %+v", req)
 		svc.Logger().Log("level", c.LogLevel.Debug, "req", reqs)
-		user, err := svc.SignIn(req.Username, req.Password, req.TenantID)
+		token, err := svc.SignIn(ctx, req.Username, req.Password, req.RemoteIP, req.TenantID)
+		if err != nil {
+			return signInResponse{token, err.Error()}, nil
+		}
+		return signInResponse{token, ""}, nil
+	}
+}
+
+func makeVerifyMFAEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(verifyMFARequest)
+		token, err := svc.VerifyMFA(ctx, req.MFAToken, req.Code, req.RemoteIP, req.TenantID)
+		if err != nil {
+			return verifyMFAResponse{token, err.Error()}, nil
+		}
+		return verifyMFAResponse{token, ""}, nil
+	}
+}
+
+func makeEnrollMFAEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(enrollMFARequest)
+		enrollment, err := svc.EnrollMFA(ctx, req.Token, req.TenantID)
+		if err != nil {
+			return enrollMFAResponse{enrollment, err.Error()}, nil
+		}
+		return enrollMFAResponse{enrollment, ""}, nil
+	}
+}
+
+func makeConfirmMFAEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(confirmMFARequest)
+		codes, err := svc.ConfirmMFA(ctx, req.Token, req.Code, req.TenantID)
 		if err != nil {
-			return signInResponse{user, err.Error()}, nil
+			return confirmMFAResponse{codes, err.Error()}, nil
 		}
-		return signInResponse{user, ""}, nil
+		return confirmMFAResponse{codes, ""}, nil
+	}
+}
+
+func makeDisableMFAEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(disableMFARequest)
+		err := svc.DisableMFA(ctx, req.AccessToken, req.Code, req.TenantID)
+		if err != nil {
+			return disableMFAResponse{err.Error()}, nil
+		}
+		return disableMFAResponse{""}, nil
+	}
+}
+
+func makeBeginWebAuthnRegistrationEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(beginWebAuthnRegistrationRequest)
+		registration, err := svc.BeginWebAuthnRegistration(ctx, req.AccessToken, req.TenantID)
+		if err != nil {
+			return beginWebAuthnRegistrationResponse{registration, err.Error()}, nil
+		}
+		return beginWebAuthnRegistrationResponse{registration, ""}, nil
+	}
+}
+
+func makeFinishWebAuthnRegistrationEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(finishWebAuthnRegistrationRequest)
+		credential, err := svc.FinishWebAuthnRegistration(ctx, req.AccessToken, req.Token, req.Name, req.Credential, req.TenantID)
+		if err != nil {
+			return finishWebAuthnRegistrationResponse{credential, err.Error()}, nil
+		}
+		return finishWebAuthnRegistrationResponse{credential, ""}, nil
+	}
+}
+
+func makeBeginWebAuthnLoginEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(beginWebAuthnLoginRequest)
+		login, err := svc.BeginWebAuthnLogin(ctx, req.Username, req.TenantID)
+		if err != nil {
+			return beginWebAuthnLoginResponse{login, err.Error()}, nil
+		}
+		return beginWebAuthnLoginResponse{login, ""}, nil
+	}
+}
+
+func makeFinishWebAuthnLoginEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(finishWebAuthnLoginRequest)
+		token, err := svc.FinishWebAuthnLogin(ctx, req.Token, req.Credential, req.TenantID)
+		if err != nil {
+			return finishWebAuthnLoginResponse{token, err.Error()}, nil
+		}
+		return finishWebAuthnLoginResponse{token, ""}, nil
 	}
 }
 
 func makeSignOutEndpoint(svc GranicaService) endpoint.Endpoint {
-	return func(_ context.Context, request interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(signOutRequest)
-		reqs := fmt.Sprintf("Req: %+v", req)
-		svc.Logger().Log("level", c.LogLevel.Debug, "req", reqs)
-		err := svc.SignOut(req.Username, req.TenantID)
+		err := svc.SignOut(ctx, req.AccessToken, req.All, req.TenantID)
 		if err != nil {
 			return signOutResponse{err.Error()}, nil
 		}
@@ -67,12 +197,149 @@ func makeSignOutEndpoint(svc GranicaService) endpoint.Endpoint {
 	}
 }
 
+func makeRefreshEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(refreshRequest)
+		token, err := svc.Refresh(ctx, req.RefreshToken, req.TenantID)
+		if err != nil {
+			return refreshResponse{token, err.Error()}, nil
+		}
+		return refreshResponse{token, ""}, nil
+	}
+}
+
+func makeJWKSEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		set, err := svc.JWKS(ctx)
+		if err != nil {
+			return jwksResponse{Err: err.Error()}, nil
+		}
+		return jwksResponse{Keys: set.Keys}, nil
+	}
+}
+
+func makeIntrospectEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(introspectRequest)
+		i, err := svc.Introspect(ctx, req.Token, req.TokenTypeHint, req.ClientID, req.ClientSecret, req.TenantID)
+		if err != nil {
+			return introspectResponse{nil, toOAuthError(err)}, nil
+		}
+		return introspectResponse{i, nil}, nil
+	}
+}
+
+func makeRevokeTokenEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeTokenRequest)
+		err := svc.RevokeToken(ctx, req.Token, req.TokenTypeHint, req.ClientID, req.ClientSecret, req.TenantID)
+		if err != nil {
+			return revokeTokenResponse{toOAuthError(err)}, nil
+		}
+		return revokeTokenResponse{nil}, nil
+	}
+}
+
+func makeRegisterClientEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(registerClientRequest)
+		client, secret, err := svc.RegisterClient(ctx, req.Name, req.RedirectURIs, req.Scopes, req.GrantTypes, req.Confidential, req.TenantID)
+		if err != nil {
+			return registerClientResponse{client, secret, err.Error()}, nil
+		}
+		return registerClientResponse{client, secret, ""}, nil
+	}
+}
+
+func makeRotateClientSecretEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(rotateClientSecretRequest)
+		secret, err := svc.RotateClientSecret(ctx, req.ClientID, req.TenantID)
+		if err != nil {
+			return rotateClientSecretResponse{secret, err.Error()}, nil
+		}
+		return rotateClientSecretResponse{secret, ""}, nil
+	}
+}
+
+func makeAuthorizeEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(authorizeRequest)
+		ar := req.AuthorizationRequest
+
+		if !req.Submitted {
+			client, redirectURL, err := svc.ValidateAuthorization(ctx, ar)
+			if err != nil {
+				return authorizeResponse{Request: ar, Err: err.Error()}, nil
+			}
+			return authorizeResponse{Client: client, Request: ar, RedirectURL: redirectURL}, nil
+		}
+
+		redirectURL, err := svc.Authorize(ctx, ar, req.Username, req.Password, req.OTP, req.Consent)
+		if err == ErrUnauthorized || err == ErrMFARequired || err == ErrLocked {
+			// Prompt again.
+			client, _, verr := svc.ValidateAuthorization(ctx, ar)
+			if verr != nil {
+				return authorizeResponse{Request: ar, Err: verr.Error()}, nil
+			}
+			msg := "invalid username, password or code"
+			switch err {
+			case ErrMFARequired:
+				msg = "a second factor must be enrolled before signing in"
+			case ErrLocked:
+				msg = err.Error()
+			}
+			return authorizeResponse{Client: client, Request: ar, Err: msg}, nil
+		}
+
+		if err != nil {
+			return authorizeResponse{Request: ar, Err: err.Error()}, nil
+		}
+		return authorizeResponse{Request: ar, RedirectURL: redirectURL}, nil
+	}
+}
+
+func makeTokenEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(tokenRequest)
+		token, err := svc.Token(ctx, req.TokenRequest)
+		if err != nil {
+			return tokenResponse{nil, toOAuthError(err)}, nil
+		}
+		return tokenResponse{token, nil}, nil
+	}
+}
+
+func makeOpenIDConfigurationEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		md, err := svc.OpenIDConfiguration(ctx)
+		if err != nil {
+			return openIDConfigurationResponse{Err: err.Error()}, nil
+		}
+		return openIDConfigurationResponse{md, ""}, nil
+	}
+}
+
+func makeUserInfoEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(userInfoRequest)
+		ui, err := svc.UserInfo(ctx, req.AccessToken, req.TenantID)
+		if err == ErrInsufficientScope {
+			return userInfoResponse{Err: "insufficient_scope"}, nil
+		}
+		if err != nil {
+			return userInfoResponse{Err: "invalid_token"}, nil
+		}
+		return userInfoResponse{ui, ""}, nil
+	}
+}
+
 func makeCreateEndpoint(svc GranicaService) endpoint.Endpoint {
-	return func(_ context.Context, request interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(createRequest)
 		reqs := fmt.Sprintf("Req: %+v", req)
 		svc.Logger().Log("level", c.LogLevel.Debug, "req", reqs)
-		user, err := svc.Create(req.Username, req.Password, req.Email, req.TenantID)
+		user, err := svc.Create(ctx, req.Username, req.Password, req.Email, req.TenantID)
 		if err != nil {
 			return createResponse{user, err.Error()}, nil
 		}
@@ -81,14 +348,14 @@ func makeCreateEndpoint(svc GranicaService) endpoint.Endpoint {
 }
 
 func makeUpdateEndpoint(svc GranicaService) endpoint.Endpoint {
-	return func(_ context.Context, request interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(updateRequest)
 		reqs := fmt.Sprintf("Req: %+v", req)
 		svc.Logger().Log("level", c.LogLevel.Debug, "req", reqs)
-		err := svc.Update(req.Username, req.Password, req.PasswordConfirmation,
+		err := svc.Update(ctx, req.Username, req.Password, req.PasswordConfirmation,
 			req.Email, req.EmailConfirmation, req.Description,
 			req.GivenName, req.MiddleNames, req.FamilyName,
-			req.TenantID)
+			req.RemoteIP, req.TenantID)
 		if err != nil {
 			return updateResponse{err.Error()}, nil
 		}
@@ -97,14 +364,263 @@ func makeUpdateEndpoint(svc GranicaService) endpoint.Endpoint {
 }
 
 func makeRemoveEndpoint(svc GranicaService) endpoint.Endpoint {
-	return func(_ context.Context, request interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(removeRequest)
 		reqs := fmt.Sprintf("Req: %+v", req)
 		svc.Logger().Log("level", c.LogLevel.Debug, "req", reqs)
-		err := svc.Remove(req.Username, req.Email, req.TenantID)
+		err := svc.Remove(ctx, req.Username, req.Email, req.TenantID)
 		if err != nil {
 			return removeResponse{err.Error()}, nil
 		}
 		return removeResponse{""}, nil
 	}
 }
+
+func makeUnlockEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(unlockRequest)
+		err := svc.Unlock(ctx, req.Username, req.RemoteIP, req.TenantID)
+		if err != nil {
+			return unlockResponse{err.Error()}, nil
+		}
+		return unlockResponse{""}, nil
+	}
+}
+
+func makeGetRolesEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getRolesRequest)
+		roles, err := svc.GetRoles(ctx, req.TenantID)
+		if err != nil {
+			return getRolesResponse{nil, err.Error()}, nil
+		}
+		return getRolesResponse{roles, ""}, nil
+	}
+}
+
+func makeCreateRoleEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createRoleRequest)
+		role, err := svc.CreateRole(ctx, req.Name, req.Description, req.Permissions, req.TenantID)
+		if err != nil {
+			return createRoleResponse{nil, err.Error()}, nil
+		}
+		return createRoleResponse{role, ""}, nil
+	}
+}
+
+func makeUpdateRoleEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateRoleRequest)
+		role, err := svc.UpdateRole(ctx, req.ID, req.Name, req.Description, req.Permissions, req.TenantID)
+		if err != nil {
+			return updateRoleResponse{nil, err.Error()}, nil
+		}
+		return updateRoleResponse{role, ""}, nil
+	}
+}
+
+func makeDeleteRoleEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteRoleRequest)
+		err := svc.DeleteRole(ctx, req.ID, req.TenantID)
+		if err != nil {
+			return deleteRoleResponse{err.Error()}, nil
+		}
+		return deleteRoleResponse{""}, nil
+	}
+}
+
+func makeAssignRoleEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(assignRoleRequest)
+		err := svc.AssignRole(ctx, req.Username, req.RoleID, req.TenantID)
+		if err != nil {
+			return assignRoleResponse{err.Error()}, nil
+		}
+		return assignRoleResponse{""}, nil
+	}
+}
+
+func makeUnassignRoleEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(unassignRoleRequest)
+		err := svc.UnassignRole(ctx, req.Username, req.RoleID, req.TenantID)
+		if err != nil {
+			return unassignRoleResponse{err.Error()}, nil
+		}
+		return unassignRoleResponse{""}, nil
+	}
+}
+
+func makeGetUserRolesEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getUserRolesRequest)
+		roles, err := svc.GetUserRoles(ctx, req.Username, req.TenantID)
+		if err != nil {
+			return getUserRolesResponse{nil, err.Error()}, nil
+		}
+		return getUserRolesResponse{roles, ""}, nil
+	}
+}
+
+func makeGetPermissionsEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getPermissionsRequest)
+		permissions, err := svc.GetPermissions(ctx, req.TenantID)
+		if err != nil {
+			return getPermissionsResponse{nil, err.Error()}, nil
+		}
+		return getPermissionsResponse{permissions, ""}, nil
+	}
+}
+
+func makeDecideEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(decideRequest)
+		decision, err := svc.Decide(ctx, req.AccessToken, req.AccessRequest, req.TenantID)
+		if err == ErrUnauthorized {
+			return nil, err
+		}
+		if err != nil {
+			return decideResponse{nil, err.Error()}, nil
+		}
+		return decideResponse{decision, ""}, nil
+	}
+}
+
+func makeCreatePermissionEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createPermissionRequest)
+		permission, err := svc.CreatePermission(ctx, req.Name, req.Description, req.TenantID)
+		if err != nil {
+			return createPermissionResponse{nil, err.Error()}, nil
+		}
+		return createPermissionResponse{permission, ""}, nil
+	}
+}
+
+func makeWriteRelationEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(writeRelationRequest)
+		tuple, err := svc.WriteRelation(ctx, req.Object, req.Relation, req.Subject, req.TenantID)
+		if err != nil {
+			return writeRelationResponse{nil, err.Error()}, nil
+		}
+		return writeRelationResponse{tuple, ""}, nil
+	}
+}
+
+func makeDeleteRelationEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteRelationRequest)
+		err := svc.DeleteRelation(ctx, req.Object, req.Relation, req.Subject, req.TenantID)
+		if err != nil {
+			return deleteRelationResponse{err.Error()}, nil
+		}
+		return deleteRelationResponse{""}, nil
+	}
+}
+
+func makeCheckRelationEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(checkRelationRequest)
+		allowed, err := svc.CheckRelation(ctx, req.Object, req.Relation, req.Subject, req.TenantID)
+		if err != nil {
+			return checkRelationResponse{false, err.Error()}, nil
+		}
+		return checkRelationResponse{allowed, ""}, nil
+	}
+}
+
+func makeExpandRelationEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(expandRelationRequest)
+		tree, err := svc.ExpandRelation(ctx, req.Object, req.Relation, req.TenantID)
+		if err != nil {
+			return expandRelationResponse{nil, err.Error()}, nil
+		}
+		return expandRelationResponse{tree, ""}, nil
+	}
+}
+
+func makeListObjectsEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listObjectsRequest)
+		objects, err := svc.ListObjects(ctx, req.Namespace, req.Relation, req.Subject, req.TenantID)
+		if err != nil {
+			return listObjectsResponse{nil, err.Error()}, nil
+		}
+		return listObjectsResponse{objects, ""}, nil
+	}
+}
+
+func makeCreateTenantEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createTenantRequest)
+		tenant, err := svc.CreateTenant(ctx, req.Slug, req.Name, req.Domains, req.Settings, req.TenantID)
+		if err == ErrForbidden {
+			return nil, err
+		}
+		if err != nil {
+			return createTenantResponse{nil, err.Error()}, nil
+		}
+		return createTenantResponse{tenant, ""}, nil
+	}
+}
+
+func makeGetTenantsEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getTenantsRequest)
+		tenants, err := svc.GetTenants(ctx, req.TenantID)
+		if err == ErrForbidden {
+			return nil, err
+		}
+		if err != nil {
+			return getTenantsResponse{nil, err.Error()}, nil
+		}
+		return getTenantsResponse{tenants, ""}, nil
+	}
+}
+
+func makeGetTenantEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getTenantRequest)
+		tenant, err := svc.GetTenant(ctx, req.Slug, req.TenantID)
+		if err == ErrForbidden {
+			return nil, err
+		}
+		if err != nil {
+			return getTenantResponse{nil, err.Error()}, nil
+		}
+		return getTenantResponse{tenant, ""}, nil
+	}
+}
+
+func makeUpdateTenantEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateTenantRequest)
+		tenant, err := svc.UpdateTenant(ctx, req.Slug, req.Name, req.Domains, req.Status, req.Settings, req.TenantID)
+		if err == ErrForbidden {
+			return nil, err
+		}
+		if err != nil {
+			return updateTenantResponse{nil, err.Error()}, nil
+		}
+		return updateTenantResponse{tenant, ""}, nil
+	}
+}
+
+func makeDeleteTenantEndpoint(svc GranicaService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteTenantRequest)
+		err := svc.DeleteTenant(ctx, req.Slug, req.TenantID)
+		if err == ErrForbidden {
+			return nil, err
+		}
+		if err != nil {
+			return deleteTenantResponse{err.Error()}, nil
+		}
+		return deleteTenantResponse{""}, nil
+	}
+}
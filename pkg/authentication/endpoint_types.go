@@ -8,6 +8,9 @@
 package authentication
 
 import (
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/policy"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/rebac"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/webauthn"
 	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
 )
 
@@ -32,6 +35,7 @@ type cancelRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 	Email    string `json:"email"`
+	RemoteIP string
 	TenantID string
 }
 
@@ -39,28 +43,284 @@ type cancelResponse struct {
 	Err string `json:"error,omitempty"`
 }
 
+// Verify email
+type verifyEmailRequest struct {
+	Token string
+}
+
+type verifyEmailResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+// Resend email verification
+type resendEmailVerificationRequest struct {
+	Username string `json:"username"`
+	TenantID string
+}
+
+type resendEmailVerificationResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+// Forgot password
+type forgotPasswordRequest struct {
+	Email    string `json:"email"`
+	TenantID string
+}
+
+type forgotPasswordResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+// Reset password
+type resetPasswordRequest struct {
+	Token                string `json:"token"`
+	Password             string `json:"password"`
+	PasswordConfirmation string `json:"passwordConfirmation"`
+}
+
+type resetPasswordResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
 // Sign in
 type signInRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	RemoteIP string
 	TenantID string
 }
 
 type signInResponse struct {
-	User *m.User `json:"user,omitempty"`
-	Err  string  `json:"error,omitempty"`
+	Token *AuthToken `json:"token,omitempty"`
+	Err   string     `json:"error,omitempty"`
 }
 
-// Sign out
-type signOutRequest struct {
+// Verify MFA
+type verifyMFARequest struct {
+	MFAToken string `json:"mfaToken"`
+	Code     string `json:"code"`
+	RemoteIP string
+	TenantID string
+}
+
+type verifyMFAResponse struct {
+	Token *AuthToken `json:"token,omitempty"`
+	Err   string     `json:"error,omitempty"`
+}
+
+// Enroll MFA
+type enrollMFARequest struct {
+	Token    string
+	TenantID string
+}
+
+type enrollMFAResponse struct {
+	Enrollment *MFAEnrollment `json:"enrollment,omitempty"`
+	Err        string         `json:"error,omitempty"`
+}
+
+// Confirm MFA
+type confirmMFARequest struct {
+	Code     string `json:"code"`
+	Token    string
+	TenantID string
+}
+
+type confirmMFAResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+	Err           string   `json:"error,omitempty"`
+}
+
+// Disable MFA
+type disableMFARequest struct {
+	Code        string `json:"code"`
+	AccessToken string
+	TenantID    string
+}
+
+type disableMFAResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+// Begin WebAuthn registration
+type beginWebAuthnRegistrationRequest struct {
+	AccessToken string
+	TenantID    string
+}
+
+type beginWebAuthnRegistrationResponse struct {
+	*WebAuthnRegistration
+	Err string `json:"error,omitempty"`
+}
+
+// Finish WebAuthn registration
+type finishWebAuthnRegistrationRequest struct {
+	Token       string                        `json:"token"`
+	Name        string                        `json:"name"`
+	Credential  webauthn.RegistrationResponse `json:"credential"`
+	AccessToken string
+	TenantID    string
+}
+
+type finishWebAuthnRegistrationResponse struct {
+	Credential *m.WebAuthnCredential `json:"credential,omitempty"`
+	Err        string                `json:"error,omitempty"`
+}
+
+// Begin WebAuthn login
+type beginWebAuthnLoginRequest struct {
 	Username string `json:"username"`
 	TenantID string
 }
 
+type beginWebAuthnLoginResponse struct {
+	*WebAuthnLogin
+	Err string `json:"error,omitempty"`
+}
+
+// Finish WebAuthn login
+type finishWebAuthnLoginRequest struct {
+	Token      string                     `json:"token"`
+	Credential webauthn.AssertionResponse `json:"credential"`
+	TenantID   string
+}
+
+type finishWebAuthnLoginResponse struct {
+	Token *AuthToken `json:"token,omitempty"`
+	Err   string     `json:"error,omitempty"`
+}
+
+// Sign out
+type signOutRequest struct {
+	All         bool `json:"all"`
+	AccessToken string
+	TenantID    string
+}
+
 type signOutResponse struct {
 	Err string `json:"error,omitempty"`
 }
 
+// Refresh
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+	TenantID     string
+}
+
+type refreshResponse struct {
+	Token *AuthToken `json:"token,omitempty"`
+	Err   string     `json:"error,omitempty"`
+}
+
+// JWKS
+type jwksRequest struct{}
+
+type jwksResponse struct {
+	Keys []JWK  `json:"keys"`
+	Err  string `json:"error,omitempty"`
+}
+
+// Introspect
+type introspectRequest struct {
+	Token         string
+	TokenTypeHint string
+	ClientID      string
+	ClientSecret  string
+	TenantID      string
+}
+
+type introspectResponse struct {
+	*Introspection
+	*OAuthError
+}
+
+// Revoke token
+type revokeTokenRequest struct {
+	Token         string
+	TokenTypeHint string
+	ClientID      string
+	ClientSecret  string
+	TenantID      string
+}
+
+type revokeTokenResponse struct {
+	*OAuthError
+}
+
+// Register client
+type registerClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectURIs"`
+	Scopes       []string `json:"scopes"`
+	GrantTypes   []string `json:"grantTypes"`
+	Confidential bool     `json:"confidential"`
+	TenantID     string
+}
+
+type registerClientResponse struct {
+	Client *m.Client `json:"client,omitempty"`
+	Secret string    `json:"clientSecret,omitempty"`
+	Err    string    `json:"error,omitempty"`
+}
+
+// Rotate client secret
+type rotateClientSecretRequest struct {
+	ClientID string `json:"clientID"`
+	TenantID string
+}
+
+type rotateClientSecretResponse struct {
+	Secret string `json:"clientSecret,omitempty"`
+	Err    string `json:"error,omitempty"`
+}
+
+// Authorize
+type authorizeRequest struct {
+	AuthorizationRequest
+	Username  string
+	Password  string
+	OTP       string
+	Consent   bool
+	Submitted bool
+}
+
+type authorizeResponse struct {
+	Client      *m.Client
+	Request     AuthorizationRequest
+	RedirectURL string
+	Err         string
+}
+
+// Token
+type tokenRequest struct {
+	TokenRequest
+}
+
+type tokenResponse struct {
+	*AuthToken
+	*OAuthError
+}
+
+// OpenID configuration
+type openIDConfigurationRequest struct{}
+
+type openIDConfigurationResponse struct {
+	*ProviderMetadata
+	Err string `json:"error,omitempty"`
+}
+
+// User info
+type userInfoRequest struct {
+	AccessToken string
+	TenantID    string
+}
+
+type userInfoResponse struct {
+	*UserInfo
+	Err string `json:"error,omitempty"`
+}
+
 // Create
 type createRequest struct {
 	Username string `json:"username"`
@@ -85,6 +345,7 @@ type updateRequest struct {
 	GivenName            string `json:"givenName"`
 	MiddleNames          string `json:"middleNames"`
 	FamilyName           string `json:"familyName"`
+	RemoteIP             string
 	TenantID             string
 }
 
@@ -102,3 +363,237 @@ type removeRequest struct {
 type removeResponse struct {
 	Err string `json:"error,omitempty"`
 }
+
+// Unlock
+type unlockRequest struct {
+	Username string `json:"username"`
+	RemoteIP string `json:"ip"`
+	TenantID string
+}
+
+type unlockResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+// Roles
+type getRolesRequest struct {
+	TenantID string
+}
+
+type getRolesResponse struct {
+	Roles []m.Role `json:"roles"`
+	Err   string   `json:"error,omitempty"`
+}
+
+type createRoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	TenantID    string
+}
+
+type createRoleResponse struct {
+	Role *m.Role `json:"role,omitempty"`
+	Err  string  `json:"error,omitempty"`
+}
+
+type updateRoleRequest struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	TenantID    string
+}
+
+type updateRoleResponse struct {
+	Role *m.Role `json:"role,omitempty"`
+	Err  string  `json:"error,omitempty"`
+}
+
+type deleteRoleRequest struct {
+	ID       string `json:"id"`
+	TenantID string
+}
+
+type deleteRoleResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+// Role assignments
+type assignRoleRequest struct {
+	Username string `json:"username"`
+	RoleID   string `json:"roleID"`
+	TenantID string
+}
+
+type assignRoleResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+type unassignRoleRequest struct {
+	Username string `json:"username"`
+	RoleID   string `json:"roleID"`
+	TenantID string
+}
+
+type unassignRoleResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+type getUserRolesRequest struct {
+	Username string
+	TenantID string
+}
+
+type getUserRolesResponse struct {
+	Roles []m.Role `json:"roles"`
+	Err   string   `json:"error,omitempty"`
+}
+
+// Permissions
+type getPermissionsRequest struct {
+	TenantID string
+}
+
+type getPermissionsResponse struct {
+	Permissions []m.Permission `json:"permissions"`
+	Err         string         `json:"error,omitempty"`
+}
+
+type createPermissionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TenantID    string
+}
+
+type createPermissionResponse struct {
+	Permission *m.Permission `json:"permission,omitempty"`
+	Err        string        `json:"error,omitempty"`
+}
+
+// Access decisions
+type decideRequest struct {
+	AccessToken string
+	AccessRequest
+	TenantID string
+}
+
+type decideResponse struct {
+	*policy.Decision
+	Err string `json:"error,omitempty"`
+}
+
+// Relations
+type writeRelationRequest struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	Subject  string `json:"subject"`
+	TenantID string
+}
+
+type writeRelationResponse struct {
+	Tuple *m.RelationTuple `json:"tuple,omitempty"`
+	Err   string           `json:"error,omitempty"`
+}
+
+type deleteRelationRequest struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	Subject  string `json:"subject"`
+	TenantID string
+}
+
+type deleteRelationResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+type checkRelationRequest struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	Subject  string `json:"subject"`
+	TenantID string
+}
+
+type checkRelationResponse struct {
+	Allowed bool   `json:"allowed"`
+	Err     string `json:"error,omitempty"`
+}
+
+type expandRelationRequest struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	TenantID string
+}
+
+type expandRelationResponse struct {
+	Tree *rebac.Tree `json:"tree,omitempty"`
+	Err  string      `json:"error,omitempty"`
+}
+
+type listObjectsRequest struct {
+	Namespace string `json:"namespace"`
+	Relation  string `json:"relation"`
+	Subject   string `json:"subject"`
+	TenantID  string
+}
+
+type listObjectsResponse struct {
+	Objects []string `json:"objects"`
+	Err     string   `json:"error,omitempty"`
+}
+
+// Tenants
+type createTenantRequest struct {
+	Slug     string           `json:"slug"`
+	Name     string           `json:"name"`
+	Domains  []string         `json:"domains"`
+	Settings m.TenantSettings `json:"settings"`
+	TenantID string
+}
+
+type createTenantResponse struct {
+	Tenant *m.Tenant `json:"tenant,omitempty"`
+	Err    string    `json:"error,omitempty"`
+}
+
+type getTenantsRequest struct {
+	TenantID string
+}
+
+type getTenantsResponse struct {
+	Tenants []m.Tenant `json:"tenants"`
+	Err     string     `json:"error,omitempty"`
+}
+
+type getTenantRequest struct {
+	Slug     string
+	TenantID string
+}
+
+type getTenantResponse struct {
+	Tenant *m.Tenant `json:"tenant,omitempty"`
+	Err    string    `json:"error,omitempty"`
+}
+
+type updateTenantRequest struct {
+	Slug     string           `json:"slug"`
+	Name     string           `json:"name"`
+	Domains  []string         `json:"domains"`
+	Status   string           `json:"status"`
+	Settings m.TenantSettings `json:"settings"`
+	TenantID string
+}
+
+type updateTenantResponse struct {
+	Tenant *m.Tenant `json:"tenant,omitempty"`
+	Err    string    `json:"error,omitempty"`
+}
+
+type deleteTenantRequest struct {
+	Slug     string `json:"slug"`
+	TenantID string
+}
+
+type deleteTenantResponse struct {
+	Err string `json:"error,omitempty"`
+}
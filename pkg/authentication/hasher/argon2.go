@@ -0,0 +1,89 @@
+package hasher
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id hashes passwords with argon2id (RFC 9106).
+// Memory is expressed in KiB.
+type Argon2id struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// NewArgon2id makes a new argon2id hasher.
+func NewArgon2id(time, memory, threads int) Argon2id {
+	if time <= 0 {
+		time = 3
+	}
+	if memory <= 0 {
+		memory = 64 * 1024
+	}
+	if threads <= 0 || threads > 255 {
+		threads = 2
+	}
+	return Argon2id{Time: uint32(time), Memory: uint32(memory), Threads: uint8(threads)}
+}
+
+// Hash returns the argon2id digest of the password.
+func (h Argon2id) Hash(password string) (string, error) {
+	salt, err := genSalt()
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, keyLen)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", Argon2idAlg, argon2.Version, h.Memory, h.Time, h.Threads,
+		b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Verify - True if the password matches the argon2id digest.
+func (h Argon2id) Verify(digest, password string) (bool, error) {
+	params, salt, key, err := parseArgon2id(digest)
+	if err != nil {
+		return false, err
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return keysMatch(computed, key), nil
+}
+
+// NeedsRehash - True if the digest is not an argon2id one or has weaker parameters.
+func (h Argon2id) NeedsRehash(digest string) bool {
+	params, _, _, err := parseArgon2id(digest)
+	if err != nil {
+		return true
+	}
+
+	return params.Time < h.Time || params.Memory < h.Memory || params.Threads < h.Threads
+}
+
+func parseArgon2id(digest string) (Argon2id, []byte, []byte, error) {
+	var params Argon2id
+	p, salt, key, err := phcFields(digest, Argon2idAlg)
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	var version int
+	_, err = fmt.Sscanf(digest, "$"+Argon2idAlg+"$v=%d$", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidDigest
+	}
+
+	_, err = fmt.Sscanf(p, "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads)
+	if err != nil || params.Time == 0 || params.Threads == 0 {
+		return params, nil, nil, ErrInvalidDigest
+	}
+
+	s, k, err := decodeSaltAndKey(salt, key)
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	return params, s, k, nil
+}
@@ -0,0 +1,52 @@
+package hasher
+
+import (
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes passwords with bcrypt.
+// Passwords longer than 72 bytes are truncated by the algorithm.
+type Bcrypt struct {
+	Cost int
+}
+
+// NewBcrypt makes a new bcrypt hasher.
+func NewBcrypt(cost int) Bcrypt {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.DefaultCost
+	}
+	return Bcrypt{Cost: cost}
+}
+
+// Hash returns the bcrypt digest of the password.
+func (h Bcrypt) Hash(password string) (string, error) {
+	digest, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(digest), nil
+}
+
+// Verify - True if the password matches the bcrypt digest.
+func (h Bcrypt) Verify(digest, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(digest), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// NeedsRehash - True if the digest is not a bcrypt one or has a lower cost.
+func (h Bcrypt) NeedsRehash(digest string) bool {
+	if Identify(digest) != BcryptAlg {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(digest))
+	return err != nil || cost < h.Cost
+}
@@ -0,0 +1,146 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+)
+
+// Algorithms
+const (
+	BcryptAlg   = "bcrypt"
+	ScryptAlg   = "scrypt"
+	Argon2idAlg = "argon2id"
+)
+
+const (
+	saltLen = 16
+	keyLen  = 32
+)
+
+var (
+	// ErrUnknownAlgorithm is returned for digests made by an unsupported algorithm.
+	ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+	// ErrInvalidDigest is returned for digests that cannot be decoded.
+	ErrInvalidDigest = errors.New("invalid password digest")
+)
+
+// b64 encodes salts and keys as in the PHC string format.
+var b64 = base64.RawStdEncoding
+
+// PasswordHasher interface
+// Digests are encoded in the PHC string format ($id$params$salt$key)
+// so that they identify the algorithm and the parameters they were made with.
+// bcrypt digests keep their own modular crypt format.
+type PasswordHasher interface {
+	// Hash returns the digest of the password.
+	Hash(password string) (string, error)
+	// Verify - True if the password matches the digest.
+	Verify(digest, password string) (bool, error)
+	// NeedsRehash - True if the digest was not made by this hasher
+	// or with weaker parameters than the current ones.
+	NeedsRehash(digest string) bool
+}
+
+// New makes a new hasher for the configured algorithm.
+func New(cfg *config.Config) (PasswordHasher, error) {
+	hc := cfg.Auth.Hasher
+
+	if hc.Algorithm == Argon2idAlg {
+		return NewArgon2id(hc.Argon2Time, hc.Argon2Memory, hc.Argon2Threads), nil
+
+	} else if hc.Algorithm == ScryptAlg {
+		return NewScrypt(hc.ScryptLogN, hc.ScryptR, hc.ScryptP), nil
+
+	} else if hc.Algorithm == BcryptAlg {
+		return NewBcrypt(hc.BcryptCost), nil
+	}
+
+	return nil, errors.New("not a valid password hashing algorithm")
+}
+
+// Identify returns the algorithm the digest was made with.
+func Identify(digest string) string {
+	switch {
+	case strings.HasPrefix(digest, "$2"):
+		return BcryptAlg
+	case strings.HasPrefix(digest, "$"+ScryptAlg+"$"):
+		return ScryptAlg
+	case strings.HasPrefix(digest, "$"+Argon2idAlg+"$"):
+		return Argon2idAlg
+	}
+	return ""
+}
+
+// Verify - True if the password matches the digest, whatever
+// the supported algorithm and parameters it was made with.
+func Verify(digest, password string) bool {
+	var h PasswordHasher
+	switch Identify(digest) {
+	case BcryptAlg:
+		h = Bcrypt{}
+	case ScryptAlg:
+		h = Scrypt{}
+	case Argon2idAlg:
+		h = Argon2id{}
+	default:
+		return false
+	}
+
+	ok, err := h.Verify(digest, password)
+	return err == nil && ok
+}
+
+// genSalt returns a random salt.
+func genSalt() ([]byte, error) {
+	salt := make([]byte, saltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// phcFields splits a PHC string of the algorithm in its parameters,
+// salt and key fields, skipping the version field if any.
+func phcFields(digest, alg string) (params, salt, key string, err error) {
+	fields := strings.Split(digest, "$")
+	if len(fields) < 5 || fields[0] != "" || fields[1] != alg {
+		return "", "", "", ErrInvalidDigest
+	}
+
+	fields = fields[2:]
+	if strings.HasPrefix(fields[0], "v=") {
+		fields = fields[1:]
+	}
+
+	if len(fields) != 3 {
+		return "", "", "", ErrInvalidDigest
+	}
+
+	return fields[0], fields[1], fields[2], nil
+}
+
+// decodeSaltAndKey decodes the salt and key fields of a PHC string.
+func decodeSaltAndKey(salt, key string) ([]byte, []byte, error) {
+	s, err := b64.DecodeString(salt)
+	if err != nil {
+		return nil, nil, ErrInvalidDigest
+	}
+
+	k, err := b64.DecodeString(key)
+	if err != nil || len(k) == 0 {
+		return nil, nil, ErrInvalidDigest
+	}
+
+	return s, k, nil
+}
+
+// keysMatch compares keys in constant time.
+func keysMatch(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
@@ -0,0 +1,90 @@
+package hasher
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Scrypt hashes passwords with scrypt.
+// LogN is the base 2 logarithm of the CPU/memory cost.
+type Scrypt struct {
+	LogN int
+	R    int
+	P    int
+}
+
+// NewScrypt makes a new scrypt hasher.
+func NewScrypt(logN, r, p int) Scrypt {
+	if logN <= 0 {
+		logN = 15
+	}
+	if r <= 0 {
+		r = 8
+	}
+	if p <= 0 {
+		p = 1
+	}
+	return Scrypt{LogN: logN, R: r, P: p}
+}
+
+// Hash returns the scrypt digest of the password.
+func (h Scrypt) Hash(password string) (string, error) {
+	salt, err := genSalt()
+	if err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<uint(h.LogN), h.R, h.P, keyLen)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("$%s$ln=%d,r=%d,p=%d$%s$%s", ScryptAlg, h.LogN, h.R, h.P,
+		b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Verify - True if the password matches the scrypt digest.
+func (h Scrypt) Verify(digest, password string) (bool, error) {
+	params, salt, key, err := parseScrypt(digest)
+	if err != nil {
+		return false, err
+	}
+
+	computed, err := scrypt.Key([]byte(password), salt, 1<<uint(params.LogN), params.R, params.P, len(key))
+	if err != nil {
+		return false, err
+	}
+
+	return keysMatch(computed, key), nil
+}
+
+// NeedsRehash - True if the digest is not a scrypt one or has weaker parameters.
+func (h Scrypt) NeedsRehash(digest string) bool {
+	params, _, _, err := parseScrypt(digest)
+	if err != nil {
+		return true
+	}
+
+	return params.LogN < h.LogN || params.R < h.R || params.P < h.P
+}
+
+func parseScrypt(digest string) (Scrypt, []byte, []byte, error) {
+	var params Scrypt
+	p, salt, key, err := phcFields(digest, ScryptAlg)
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	_, err = fmt.Sscanf(p, "ln=%d,r=%d,p=%d", &params.LogN, &params.R, &params.P)
+	if err != nil || params.LogN <= 0 || params.LogN > 30 {
+		return params, nil, nil, ErrInvalidDigest
+	}
+
+	s, k, err := decodeSaltAndKey(salt, key)
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	return params, s, k, nil
+}
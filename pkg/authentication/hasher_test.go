@@ -0,0 +1,135 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/hasher"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHashers(t *testing.T) {
+	tests := []struct {
+		h      hasher.PasswordHasher
+		prefix string
+	}{
+		{hasher.NewBcrypt(bcrypt.MinCost), "$2a$04$"},
+		{hasher.NewScrypt(10, 8, 1), "$scrypt$ln=10,r=8,p=1$"},
+		{hasher.NewArgon2id(1, 1024, 1), "$argon2id$v=19$m=1024,t=1,p=1$"},
+	}
+
+	for _, tt := range tests {
+		digest, err := tt.h.Hash("password")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(digest, tt.prefix) {
+			t.Errorf("Digest: '%s' | Expected prefix: '%s'", digest, tt.prefix)
+		}
+
+		if ok, err := tt.h.Verify(digest, "password"); !ok || err != nil {
+			t.Errorf("Verify '%s': %t, %v | Expected: true, nil", tt.prefix, ok, err)
+		}
+
+		if ok, _ := tt.h.Verify(digest, "wrong"); ok {
+			t.Errorf("Verify '%s' with wrong password: true | Expected: false", tt.prefix)
+		}
+
+		if !hasher.Verify(digest, "password") {
+			t.Errorf("Verify by identified algorithm '%s': false | Expected: true", tt.prefix)
+		}
+
+		if tt.h.NeedsRehash(digest) {
+			t.Errorf("NeedsRehash '%s': true | Expected: false", tt.prefix)
+		}
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	weak, err := hasher.NewArgon2id(1, 1024, 1).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, err := hasher.NewBcrypt(bcrypt.MinCost).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := hasher.NewArgon2id(2, 1024, 1)
+	if !h.NeedsRehash(weak) {
+		t.Error("expected digest with weaker parameters to need a rehash")
+	}
+
+	if !h.NeedsRehash(legacy) {
+		t.Error("expected digest of another algorithm to need a rehash")
+	}
+
+	if hasher.NewArgon2id(1, 512, 1).NeedsRehash(weak) {
+		t.Error("expected digest with stronger parameters not to need a rehash")
+	}
+
+	if hasher.Verify("$argon2id$v=19$m=1024,t=1,p=1$bad", "password") {
+		t.Error("expected malformed digest not to verify")
+	}
+}
+
+func TestRehashOnSignIn(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc.hasher = hasher.NewArgon2id(1, 1024, 1)
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := svc.repo.GetByUsernameAndTenant(ctx, "username", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if alg := hasher.Identify(user.PasswordDigest); alg != hasher.Argon2idAlg {
+		t.Fatalf("Digest algorithm: '%s' | Expected: '%s'", alg, hasher.Argon2idAlg)
+	}
+
+	// Rehashed digests keep working.
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestServicePasswordHasher(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.hasher = hasher.NewScrypt(10, 8, 1)
+
+	user, err := svc.Create(ctx, "username", "password", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if alg := hasher.Identify(user.PasswordDigest); alg != hasher.ScryptAlg {
+		t.Errorf("Digest algorithm: '%s' | Expected: '%s'", alg, hasher.ScryptAlg)
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
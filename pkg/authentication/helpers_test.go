@@ -0,0 +1,79 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/hasher"
+	lockoutmem "gitlab.com/mikrowezel/backend/granica/pkg/authentication/lockout/memory"
+	repomem "gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/memory"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/session/memory"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestConfig returns the config of the services under test.
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Token = config.TokenConfig{
+		Issuer:       "granica",
+		Audience:     "granica",
+		AccessTTL:    time.Minute,
+		RefreshTTL:   time.Hour,
+		CodeTTL:      time.Minute,
+		Algorithm:    "ES256",
+		EphemeralKey: true,
+	}
+	cfg.Auth.EmailVerificationTTL = time.Hour
+	cfg.Auth.PasswordResetTTL = time.Hour
+	cfg.Auth.MFAKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	cfg.Auth.MFAIssuer = "Granica"
+	cfg.Auth.MFAChallengeTTL = time.Minute
+	cfg.Auth.WebAuthn = config.WebAuthnConfig{
+		RPID:    "localhost",
+		RPName:  "Granica",
+		Origins: []string{"https://localhost"},
+		Timeout: time.Minute,
+	}
+	cfg.Auth.SystemTenant = "localhost"
+	cfg.Auth.SystemTenantDomains = []string{"localhost"}
+	return cfg
+}
+
+// newTestService returns a service on in-memory repos and stores.
+func newTestService(t *testing.T) *granicaService {
+	ctx := context.Background()
+	cfg := newTestConfig()
+
+	tokens, err := newTokenIssuer(cfg.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := makeService(nil, cfg, log.NewNopLogger())
+	svc.repo, _ = repomem.NewRepo(ctx, cfg, nil)
+	svc.refreshRepo = repomem.NewRefreshTokenRepo()
+	svc.clientRepo = repomem.NewClientRepo()
+	svc.resetRepo = repomem.NewPasswordResetRepo()
+	svc.credentialRepo = repomem.NewWebAuthnCredentialRepo()
+	svc.roleRepo = repomem.NewRoleRepo()
+	svc.permissionRepo = repomem.NewPermissionRepo()
+	svc.assignmentRepo = repomem.NewRoleAssignmentRepo()
+	svc.tupleRepo = repomem.NewRelationTupleRepo()
+	svc.tenantRepo = repomem.NewTenantRepo()
+	svc.sessions, _ = memory.NewStore(nil, cfg, nil)
+	svc.lockouts, _ = lockoutmem.NewStore(nil, cfg, nil)
+	svc.hasher = hasher.NewBcrypt(bcrypt.DefaultCost)
+	svc.tokens = tokens
+	svc.mailer = &fakeMailer{}
+	return svc
+}
@@ -1,12 +1,16 @@
 package authentication
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	// "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/metrics"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/policy"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/rebac"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/webauthn"
 	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
 )
 
@@ -19,83 +23,589 @@ type instrumentationMiddleware struct {
 }
 
 // SignUp is an instrumentation middleware wrapper over another interface implementation of Authenticate.
-func (mw instrumentationMiddleware) SignUp(username, password, email, emailConfirmation, tenantID string) (output *m.User, err error) {
+func (mw instrumentationMiddleware) SignUp(ctx context.Context, username, password, email, emailConfirmation, tenantID string) (output *m.User, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "SignUp", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mw.next.SignUp(username, password, email, emailConfirmation, tenantID)
+	return mw.next.SignUp(ctx, username, password, email, emailConfirmation, tenantID)
 }
 
 // Cancel is an instrumentation middleware wrapper over another interface implementation of Cancel.
-func (mw instrumentationMiddleware) Cancel(username, password, tenantID string) (err error) {
+func (mw instrumentationMiddleware) Cancel(ctx context.Context, username, password, remoteIP, tenantID string) (err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "Cancel", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mw.next.Cancel(username, password, tenantID)
+	return mw.next.Cancel(ctx, username, password, remoteIP, tenantID)
+}
+
+// VerifyEmail is an instrumentation middleware wrapper over another interface implementation of VerifyEmail.
+func (mw instrumentationMiddleware) VerifyEmail(ctx context.Context, token string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "VerifyEmail", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.VerifyEmail(ctx, token)
+}
+
+// ResendEmailVerification is an instrumentation middleware wrapper over another interface implementation of ResendEmailVerification.
+func (mw instrumentationMiddleware) ResendEmailVerification(ctx context.Context, username, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ResendEmailVerification", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.ResendEmailVerification(ctx, username, tenantID)
+}
+
+// ForgotPassword is an instrumentation middleware wrapper over another interface implementation of ForgotPassword.
+func (mw instrumentationMiddleware) ForgotPassword(ctx context.Context, email, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ForgotPassword", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.ForgotPassword(ctx, email, tenantID)
+}
+
+// ResetPassword is an instrumentation middleware wrapper over another interface implementation of ResetPassword.
+func (mw instrumentationMiddleware) ResetPassword(ctx context.Context, token, password, passwordConfirmation string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ResetPassword", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.ResetPassword(ctx, token, password, passwordConfirmation)
 }
 
 // SignIn is an instrumentation middleware wrapper over another interface implementation of SignIn.
-func (mw instrumentationMiddleware) SignIn(username, password, tenantID string) (output *m.User, err error) {
+func (mw instrumentationMiddleware) SignIn(ctx context.Context, username, password, remoteIP, tenantID string) (output *AuthToken, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "SignIn", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mw.next.SignIn(username, password, tenantID)
+	return mw.next.SignIn(ctx, username, password, remoteIP, tenantID)
+}
+
+// VerifyMFA is an instrumentation middleware wrapper over another interface implementation of VerifyMFA.
+func (mw instrumentationMiddleware) VerifyMFA(ctx context.Context, mfaToken, code, remoteIP, tenantID string) (output *AuthToken, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "VerifyMFA", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.VerifyMFA(ctx, mfaToken, code, remoteIP, tenantID)
+}
+
+// EnrollMFA is an instrumentation middleware wrapper over another interface implementation of EnrollMFA.
+func (mw instrumentationMiddleware) EnrollMFA(ctx context.Context, token, tenantID string) (output *MFAEnrollment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "EnrollMFA", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.EnrollMFA(ctx, token, tenantID)
+}
+
+// ConfirmMFA is an instrumentation middleware wrapper over another interface implementation of ConfirmMFA.
+func (mw instrumentationMiddleware) ConfirmMFA(ctx context.Context, token, code, tenantID string) (recoveryCodes []string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ConfirmMFA", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.ConfirmMFA(ctx, token, code, tenantID)
+}
+
+// DisableMFA is an instrumentation middleware wrapper over another interface implementation of DisableMFA.
+func (mw instrumentationMiddleware) DisableMFA(ctx context.Context, accessToken, code, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "DisableMFA", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.DisableMFA(ctx, accessToken, code, tenantID)
+}
+
+// BeginWebAuthnRegistration is an instrumentation middleware wrapper over another interface implementation of BeginWebAuthnRegistration.
+func (mw instrumentationMiddleware) BeginWebAuthnRegistration(ctx context.Context, accessToken, tenantID string) (output *WebAuthnRegistration, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "BeginWebAuthnRegistration", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.BeginWebAuthnRegistration(ctx, accessToken, tenantID)
+}
+
+// FinishWebAuthnRegistration is an instrumentation middleware wrapper over another interface implementation of FinishWebAuthnRegistration.
+func (mw instrumentationMiddleware) FinishWebAuthnRegistration(ctx context.Context, accessToken, token, name string, resp webauthn.RegistrationResponse, tenantID string) (output *m.WebAuthnCredential, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "FinishWebAuthnRegistration", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.FinishWebAuthnRegistration(ctx, accessToken, token, name, resp, tenantID)
+}
+
+// BeginWebAuthnLogin is an instrumentation middleware wrapper over another interface implementation of BeginWebAuthnLogin.
+func (mw instrumentationMiddleware) BeginWebAuthnLogin(ctx context.Context, username, tenantID string) (output *WebAuthnLogin, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "BeginWebAuthnLogin", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.BeginWebAuthnLogin(ctx, username, tenantID)
+}
+
+// FinishWebAuthnLogin is an instrumentation middleware wrapper over another interface implementation of FinishWebAuthnLogin.
+func (mw instrumentationMiddleware) FinishWebAuthnLogin(ctx context.Context, token string, resp webauthn.AssertionResponse, tenantID string) (output *AuthToken, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "FinishWebAuthnLogin", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.FinishWebAuthnLogin(ctx, token, resp, tenantID)
 }
 
 // SignOut is an instrumentation middleware wrapper over another interface implementation of SignOut.
-func (mw instrumentationMiddleware) SignOut(username, tenantID string) (err error) {
+func (mw instrumentationMiddleware) SignOut(ctx context.Context, accessToken string, all bool, tenantID string) (err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "SignOut", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mw.next.SignOut(username, tenantID)
+	return mw.next.SignOut(ctx, accessToken, all, tenantID)
+}
+
+// Refresh is an instrumentation middleware wrapper over another interface implementation of Refresh.
+func (mw instrumentationMiddleware) Refresh(ctx context.Context, refreshToken, tenantID string) (output *AuthToken, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "Refresh", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.Refresh(ctx, refreshToken, tenantID)
+}
+
+// JWKS is an instrumentation middleware wrapper over another interface implementation of JWKS.
+func (mw instrumentationMiddleware) JWKS(ctx context.Context) (output *JWKSet, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "JWKS", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.JWKS(ctx)
+}
+
+// Introspect is an instrumentation middleware wrapper over another interface implementation of Introspect.
+func (mw instrumentationMiddleware) Introspect(ctx context.Context, token, tokenTypeHint, clientID, clientSecret, tenantID string) (output *Introspection, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "Introspect", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.Introspect(ctx, token, tokenTypeHint, clientID, clientSecret, tenantID)
+}
+
+// RevokeToken is an instrumentation middleware wrapper over another interface implementation of RevokeToken.
+func (mw instrumentationMiddleware) RevokeToken(ctx context.Context, token, tokenTypeHint, clientID, clientSecret, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "RevokeToken", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.RevokeToken(ctx, token, tokenTypeHint, clientID, clientSecret, tenantID)
+}
+
+// RegisterClient is an instrumentation middleware wrapper over another interface implementation of RegisterClient.
+func (mw instrumentationMiddleware) RegisterClient(ctx context.Context, name string, redirectURIs, scopes, grantTypes []string, confidential bool, tenantID string) (output *m.Client, secret string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "RegisterClient", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.RegisterClient(ctx, name, redirectURIs, scopes, grantTypes, confidential, tenantID)
+}
+
+// RotateClientSecret is an instrumentation middleware wrapper over another interface implementation of RotateClientSecret.
+func (mw instrumentationMiddleware) RotateClientSecret(ctx context.Context, clientID, tenantID string) (secret string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "RotateClientSecret", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.RotateClientSecret(ctx, clientID, tenantID)
+}
+
+// ValidateAuthorization is an instrumentation middleware wrapper over another interface implementation of ValidateAuthorization.
+func (mw instrumentationMiddleware) ValidateAuthorization(ctx context.Context, ar AuthorizationRequest) (output *m.Client, redirectURL string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ValidateAuthorization", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.ValidateAuthorization(ctx, ar)
+}
+
+// Authorize is an instrumentation middleware wrapper over another interface implementation of Authorize.
+func (mw instrumentationMiddleware) Authorize(ctx context.Context, ar AuthorizationRequest, username, password, otp string, consent bool) (redirectURL string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "Authorize", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.Authorize(ctx, ar, username, password, otp, consent)
+}
+
+// Token is an instrumentation middleware wrapper over another interface implementation of Token.
+func (mw instrumentationMiddleware) Token(ctx context.Context, tr TokenRequest) (output *AuthToken, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "Token", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.Token(ctx, tr)
+}
+
+// OpenIDConfiguration is an instrumentation middleware wrapper over another interface implementation of OpenIDConfiguration.
+func (mw instrumentationMiddleware) OpenIDConfiguration(ctx context.Context) (output *ProviderMetadata, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "OpenIDConfiguration", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.OpenIDConfiguration(ctx)
+}
+
+// UserInfo is an instrumentation middleware wrapper over another interface implementation of UserInfo.
+func (mw instrumentationMiddleware) UserInfo(ctx context.Context, accessToken, tenantID string) (output *UserInfo, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "UserInfo", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.UserInfo(ctx, accessToken, tenantID)
 }
 
 // Create is an instrumentation middleware wrapper over another interface implementation of Create.
-func (mw instrumentationMiddleware) Create(username, password, email, tenantID string) (output *m.User, err error) {
+func (mw instrumentationMiddleware) Create(ctx context.Context, username, password, email, tenantID string) (output *m.User, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "Create", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mw.next.Create(username, password, email, tenantID)
+	return mw.next.Create(ctx, username, password, email, tenantID)
 }
 
 // Update is an instrumentation middleware wrapper over another interface implementation of Create.
-func (mw instrumentationMiddleware) Update(username, password, passwordConfirmation,
+func (mw instrumentationMiddleware) Update(ctx context.Context, username, password, passwordConfirmation,
 	email, emailConfirmation, description,
-	givenName, middleNames, familyName, tenantID string) (err error) {
+	givenName, middleNames, familyName, remoteIP, tenantID string) (err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "Update", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mw.next.Update(username, password, passwordConfirmation, email,
-		emailConfirmation, description, givenName, middleNames, familyName, tenantID)
+	return mw.next.Update(ctx, username, password, passwordConfirmation, email,
+		emailConfirmation, description, givenName, middleNames, familyName, remoteIP, tenantID)
 }
 
 // Remove is an instrumentation middleware wrapper over another interface implementation of Remove.
-func (mw instrumentationMiddleware) Remove(username, email, tenantID string) (err error) {
+func (mw instrumentationMiddleware) Remove(ctx context.Context, username, email, tenantID string) (err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "Remove", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return mw.next.Remove(username, email, tenantID)
+	return mw.next.Remove(ctx, username, email, tenantID)
+}
+
+// Unlock is an instrumentation middleware wrapper over another interface implementation of Unlock.
+func (mw instrumentationMiddleware) Unlock(ctx context.Context, username, remoteIP, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "Unlock", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.Unlock(ctx, username, remoteIP, tenantID)
+}
+
+// CreateRole is an instrumentation middleware wrapper over another interface implementation of CreateRole.
+func (mw instrumentationMiddleware) CreateRole(ctx context.Context, name, description string, permissions []string, tenantID string) (output *m.Role, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "CreateRole", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.CreateRole(ctx, name, description, permissions, tenantID)
+}
+
+// GetRoles is an instrumentation middleware wrapper over another interface implementation of GetRoles.
+func (mw instrumentationMiddleware) GetRoles(ctx context.Context, tenantID string) (output []m.Role, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GetRoles", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.GetRoles(ctx, tenantID)
+}
+
+// UpdateRole is an instrumentation middleware wrapper over another interface implementation of UpdateRole.
+func (mw instrumentationMiddleware) UpdateRole(ctx context.Context, roleID, name, description string, permissions []string, tenantID string) (output *m.Role, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "UpdateRole", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.UpdateRole(ctx, roleID, name, description, permissions, tenantID)
+}
+
+// DeleteRole is an instrumentation middleware wrapper over another interface implementation of DeleteRole.
+func (mw instrumentationMiddleware) DeleteRole(ctx context.Context, roleID, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "DeleteRole", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.DeleteRole(ctx, roleID, tenantID)
+}
+
+// CreatePermission is an instrumentation middleware wrapper over another interface implementation of CreatePermission.
+func (mw instrumentationMiddleware) CreatePermission(ctx context.Context, name, description, tenantID string) (output *m.Permission, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "CreatePermission", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.CreatePermission(ctx, name, description, tenantID)
+}
+
+// GetPermissions is an instrumentation middleware wrapper over another interface implementation of GetPermissions.
+func (mw instrumentationMiddleware) GetPermissions(ctx context.Context, tenantID string) (output []m.Permission, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GetPermissions", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.GetPermissions(ctx, tenantID)
+}
+
+// AssignRole is an instrumentation middleware wrapper over another interface implementation of AssignRole.
+func (mw instrumentationMiddleware) AssignRole(ctx context.Context, username, roleID, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "AssignRole", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.AssignRole(ctx, username, roleID, tenantID)
+}
+
+// UnassignRole is an instrumentation middleware wrapper over another interface implementation of UnassignRole.
+func (mw instrumentationMiddleware) UnassignRole(ctx context.Context, username, roleID, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "UnassignRole", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.UnassignRole(ctx, username, roleID, tenantID)
+}
+
+// GetUserRoles is an instrumentation middleware wrapper over another interface implementation of GetUserRoles.
+func (mw instrumentationMiddleware) GetUserRoles(ctx context.Context, username, tenantID string) (output []m.Role, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GetUserRoles", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.GetUserRoles(ctx, username, tenantID)
+}
+
+// CheckPermission is an instrumentation middleware wrapper over another interface implementation of CheckPermission.
+func (mw instrumentationMiddleware) CheckPermission(ctx context.Context, accessToken, permission, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "CheckPermission", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.CheckPermission(ctx, accessToken, permission, tenantID)
+}
+
+// Decide is an instrumentation middleware wrapper over another interface implementation of Decide.
+func (mw instrumentationMiddleware) Decide(ctx context.Context, accessToken string, ar AccessRequest, tenantID string) (output *policy.Decision, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "Decide", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.Decide(ctx, accessToken, ar, tenantID)
+}
+
+// WriteRelation is an instrumentation middleware wrapper over another interface implementation of WriteRelation.
+func (mw instrumentationMiddleware) WriteRelation(ctx context.Context, object, relation, subject, tenantID string) (output *m.RelationTuple, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "WriteRelation", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.WriteRelation(ctx, object, relation, subject, tenantID)
+}
+
+// DeleteRelation is an instrumentation middleware wrapper over another interface implementation of DeleteRelation.
+func (mw instrumentationMiddleware) DeleteRelation(ctx context.Context, object, relation, subject, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "DeleteRelation", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.DeleteRelation(ctx, object, relation, subject, tenantID)
+}
+
+// CheckRelation is an instrumentation middleware wrapper over another interface implementation of CheckRelation.
+func (mw instrumentationMiddleware) CheckRelation(ctx context.Context, object, relation, subject, tenantID string) (output bool, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "CheckRelation", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.CheckRelation(ctx, object, relation, subject, tenantID)
+}
+
+// ExpandRelation is an instrumentation middleware wrapper over another interface implementation of ExpandRelation.
+func (mw instrumentationMiddleware) ExpandRelation(ctx context.Context, object, relation, tenantID string) (output *rebac.Tree, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ExpandRelation", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.ExpandRelation(ctx, object, relation, tenantID)
+}
+
+// ListObjects is an instrumentation middleware wrapper over another interface implementation of ListObjects.
+func (mw instrumentationMiddleware) ListObjects(ctx context.Context, namespace, relation, subject, tenantID string) (output []string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ListObjects", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.ListObjects(ctx, namespace, relation, subject, tenantID)
+}
+
+// ResolveTenant is an instrumentation middleware wrapper over another interface implementation of ResolveTenant.
+func (mw instrumentationMiddleware) ResolveTenant(ctx context.Context, kind, key string) (output *m.Tenant, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ResolveTenant", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.ResolveTenant(ctx, kind, key)
+}
+
+// CreateTenant is an instrumentation middleware wrapper over another interface implementation of CreateTenant.
+func (mw instrumentationMiddleware) CreateTenant(ctx context.Context, slug, name string, domains []string, settings m.TenantSettings, tenantID string) (output *m.Tenant, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "CreateTenant", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.CreateTenant(ctx, slug, name, domains, settings, tenantID)
+}
+
+// GetTenants is an instrumentation middleware wrapper over another interface implementation of GetTenants.
+func (mw instrumentationMiddleware) GetTenants(ctx context.Context, tenantID string) (output []m.Tenant, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GetTenants", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.GetTenants(ctx, tenantID)
+}
+
+// GetTenant is an instrumentation middleware wrapper over another interface implementation of GetTenant.
+func (mw instrumentationMiddleware) GetTenant(ctx context.Context, slug, tenantID string) (output *m.Tenant, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "GetTenant", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.GetTenant(ctx, slug, tenantID)
+}
+
+// UpdateTenant is an instrumentation middleware wrapper over another interface implementation of UpdateTenant.
+func (mw instrumentationMiddleware) UpdateTenant(ctx context.Context, slug, name string, domains []string, status string, settings m.TenantSettings, tenantID string) (output *m.Tenant, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "UpdateTenant", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.UpdateTenant(ctx, slug, name, domains, status, settings, tenantID)
+}
+
+// DeleteTenant is an instrumentation middleware wrapper over another interface implementation of DeleteTenant.
+func (mw instrumentationMiddleware) DeleteTenant(ctx context.Context, slug, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "DeleteTenant", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mw.next.DeleteTenant(ctx, slug, tenantID)
 }
 
 // Remove is an instrumentation middleware wrapper over another interface implementation of Remove.
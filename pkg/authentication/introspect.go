@@ -0,0 +1,142 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"time"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const (
+	accessTokenHint  = "access_token"
+	refreshTokenHint = "refresh_token"
+)
+
+// errTokenOfOtherClient - Clients can only revoke their own tokens (RFC 7009).
+var errTokenOfOtherClient = oauthError(errUnauthorizedClient, "token was not issued to the client")
+
+// Introspection is a token introspection response (RFC 7662).
+// Inactive tokens carry no other information.
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Aud       string `json:"aud,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+	TenantID  string `json:"tenant,omitempty"`
+}
+
+// introspectAccessToken returns the introspection of an access token
+// or nil if it is not an active access token of the tenant.
+func (gs granicaService) introspectAccessToken(ctx context.Context, token, tenantID string) *Introspection {
+	claims, err := gs.authenticate(ctx, token, tenantID)
+	if err != nil {
+		return nil
+	}
+
+	return &Introspection{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Username:  claims.Username,
+		TokenType: bearerTokenType,
+		Exp:       claims.ExpiresAt,
+		Iat:       claims.IssuedAt,
+		Sub:       claims.Subject,
+		Aud:       claims.Audience,
+		Iss:       claims.Issuer,
+		Jti:       claims.Id,
+		TenantID:  claims.TenantID,
+	}
+}
+
+// introspectRefreshToken returns the introspection of a refresh token
+// or nil if it is not an active refresh token of the tenant.
+func (gs granicaService) introspectRefreshToken(ctx context.Context, token, tenantID string) *Introspection {
+	rt, err := gs.activeRefreshToken(ctx, token, tenantID)
+	if err != nil {
+		return nil
+	}
+
+	return &Introspection{
+		Active:    true,
+		Scope:     rt.Scope,
+		ClientID:  rt.ClientID,
+		TokenType: refreshTokenHint,
+		Exp:       rt.ExpiresAt.Unix(),
+		Iat:       rt.CreatedAt.Unix(),
+		Sub:       rt.UserID.String(),
+		Iss:       gs.cfg.Token.Issuer,
+		TenantID:  rt.TenantID,
+	}
+}
+
+// activeRefreshToken returns the refresh token if it can still be exchanged.
+func (gs granicaService) activeRefreshToken(ctx context.Context, token, tenantID string) (*m.RefreshToken, error) {
+	rt, err := gs.refreshRepo.GetByDigest(ctx, tokenDigest(token))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if rt.TenantID != tenantID || rt.IsRevoked || rt.IsRotated() || rt.IsExpired() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	_, err = gs.sessions.Get(ctx, rt.FamilyID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return rt, nil
+}
+
+// revokeAccessToken revokes an access token of the tenant until it expires.
+// It returns false if the token is not a valid access token.
+// Tokens issued to another client cannot be revoked.
+func (gs granicaService) revokeAccessToken(ctx context.Context, token, clientID, tenantID string) (bool, error) {
+	claims, err := gs.tokens.Parse(token)
+	if err != nil || claims.TenantID != tenantID {
+		return false, nil
+	}
+
+	if claims.ClientID != clientID {
+		return true, errTokenOfOtherClient
+	}
+
+	return true, gs.sessions.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+}
+
+// revokeRefreshToken revokes a refresh token of the tenant along with
+// its family and the session it belongs to.
+// It returns false if the token is not a known refresh token.
+// Tokens issued to another client cannot be revoked.
+func (gs granicaService) revokeRefreshToken(ctx context.Context, token, clientID, tenantID string) (bool, error) {
+	rt, err := gs.refreshRepo.GetByDigest(ctx, tokenDigest(token))
+	if err != nil || rt.TenantID != tenantID {
+		return false, nil
+	}
+
+	if rt.ClientID != clientID {
+		return true, errTokenOfOtherClient
+	}
+
+	err = gs.refreshRepo.RevokeFamily(ctx, rt.FamilyID)
+	if err != nil {
+		return true, err
+	}
+
+	return true, gs.sessions.Revoke(ctx, rt.FamilyID)
+}
@@ -0,0 +1,261 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// registerResourceServer registers a confidential client to introspect tokens with.
+func registerResourceServer(ctx context.Context, t *testing.T, svc *granicaService, tenantID string) (string, string) {
+	client, secret, err := svc.RegisterClient(ctx, "api", nil, nil, []string{clientCredentialsGrant}, true, tenantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.ClientID, secret
+}
+
+// clientTestToken returns the tokens issued to a public client
+// through the authorization code flow.
+func clientTestToken(ctx context.Context, t *testing.T, svc *granicaService) (string, *AuthToken) {
+	ar := authorizeTestClient(ctx, t, svc)
+
+	redirectURL, err := svc.Authorize(ctx, ar, "username", "password", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.Token(ctx, TokenRequest{
+		GrantType:    authorizationCodeGrant,
+		Code:         redirectParams(t, redirectURL).Get("code"),
+		RedirectURI:  ar.RedirectURI,
+		ClientID:     ar.ClientID,
+		CodeVerifier: testVerifier,
+		TenantID:     "localhost",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ar.ClientID, token
+}
+
+func TestIntrospect(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, secret := registerResourceServer(ctx, t, svc, "localhost")
+
+	i, err := svc.Introspect(ctx, token.AccessToken, "", id, secret, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !i.Active || i.Username != "username" || i.Jti == "" {
+		t.Errorf("Introspection: %+v | Expected: active access token", i)
+	}
+
+	i, err = svc.Introspect(ctx, token.RefreshToken, refreshTokenHint, id, secret, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !i.Active || i.TokenType != refreshTokenHint {
+		t.Errorf("Introspection: %+v | Expected: active refresh token", i)
+	}
+
+	otherID, otherSecret := registerResourceServer(ctx, t, svc, "other")
+
+	i, err = svc.Introspect(ctx, token.AccessToken, "", otherID, otherSecret, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if i.Active {
+		t.Error("token must not be active for other tenant")
+	}
+
+	// Clients must authenticate.
+	if _, err := svc.Introspect(ctx, token.AccessToken, "", "", "", "localhost"); !isOAuthError(err, errInvalidClient) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidClient)
+	}
+
+	if _, err := svc.Introspect(ctx, token.AccessToken, "", id, "wrong", "localhost"); !isOAuthError(err, errInvalidClient) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidClient)
+	}
+
+	if _, err := svc.Introspect(ctx, token.AccessToken, "", otherID, otherSecret, "localhost"); !isOAuthError(err, errInvalidClient) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidClient)
+	}
+
+	// Public clients cannot introspect tokens.
+	client, _, err := svc.RegisterClient(ctx, "spa", []string{"https://app.granica.dev/callback"}, nil, nil, false, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Introspect(ctx, token.AccessToken, "", client.ClientID, "", "localhost"); !isOAuthError(err, errUnauthorizedClient) {
+		t.Errorf("Error: %v | Expected: %s", err, errUnauthorizedClient)
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	clientID, token := clientTestToken(ctx, t, svc)
+	id, secret := registerResourceServer(ctx, t, svc, "localhost")
+
+	// Clients must authenticate.
+	if err := svc.RevokeToken(ctx, token.AccessToken, accessTokenHint, "", "", "localhost"); !isOAuthError(err, errInvalidClient) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidClient)
+	}
+
+	// Clients can only revoke their own tokens.
+	if err := svc.RevokeToken(ctx, token.AccessToken, accessTokenHint, id, secret, "localhost"); !isOAuthError(err, errUnauthorizedClient) {
+		t.Errorf("Error: %v | Expected: %s", err, errUnauthorizedClient)
+	}
+
+	i, err := svc.Introspect(ctx, token.AccessToken, "", id, secret, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !i.Active {
+		t.Error("token must not be revoked by another client")
+	}
+
+	err = svc.RevokeToken(ctx, token.AccessToken, accessTokenHint, clientID, "", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i, err = svc.Introspect(ctx, token.AccessToken, "", id, secret, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if i.Active {
+		t.Error("revoked access token must not be active")
+	}
+
+	// Refresh token is unaffected by access token revocation.
+	_, err = svc.Token(ctx, TokenRequest{
+		GrantType:    refreshTokenGrant,
+		ClientID:     clientID,
+		RefreshToken: token.RefreshToken,
+		TenantID:     "localhost",
+	})
+	if err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+
+	// Unknown tokens are ignored.
+	if err := svc.RevokeToken(ctx, "unknown", "", clientID, "", "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestRevokeRefreshTokenEndsSession(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	clientID, token := clientTestToken(ctx, t, svc)
+	id, secret := registerResourceServer(ctx, t, svc, "localhost")
+
+	err := svc.RevokeToken(ctx, token.RefreshToken, refreshTokenHint, clientID, "", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.Token(ctx, TokenRequest{
+		GrantType:    refreshTokenGrant,
+		ClientID:     clientID,
+		RefreshToken: token.RefreshToken,
+		TenantID:     "localhost",
+	})
+	if !isOAuthError(err, errInvalidGrant) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidGrant)
+	}
+
+	i, err := svc.Introspect(ctx, token.AccessToken, "", id, secret, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if i.Active {
+		t.Error("access token of a revoked session must not be active")
+	}
+}
+
+func TestIntrospectHandlerAuthentication(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, secret := registerResourceServer(ctx, t, svc, "localhost")
+
+	handler := IntrospectHandler(svc)
+
+	tests := []struct {
+		id, secret string
+		status     int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{id, "wrong", http.StatusUnauthorized},
+		{id, secret, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		form := url.Values{"token": {token.AccessToken}}
+		req := httptest.NewRequest(http.MethodPost, "http://localhost/oauth/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tt.id != "" {
+			req.SetBasicAuth(url.QueryEscape(tt.id), url.QueryEscape(tt.secret))
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("Status: %d | Expected: %d", rec.Code, tt.status)
+		}
+
+		if tt.status == http.StatusOK && !strings.Contains(rec.Body.String(), `"active":true`) {
+			t.Errorf("Body: %s | Expected: active token", rec.Body.String())
+		}
+	}
+}
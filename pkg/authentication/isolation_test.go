@@ -0,0 +1,127 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	repomem "gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/memory"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+func newTenantUser(username, tenantID string) *m.User {
+	user := &m.User{Username: username, Email: username + "@" + tenantID, TenantID: tenantID}
+	user.ID = uuid.New()
+	user.Identification.TenantID = tenantID
+	return user
+}
+
+func TestTenantUserRepo(t *testing.T) {
+	ctx := context.Background()
+
+	users, _ := repomem.NewRepo(ctx, &config.Config{}, nil)
+	alice := newTenantUser("alice", "acme")
+	mallory := newTenantUser("mallory", "evil")
+	users.Insert(ctx, alice)
+	users.Insert(ctx, mallory)
+
+	if _, err := repo.NewTenantUserRepo(context.Background(), users); err != repo.ErrNoTenant {
+		t.Errorf("Error: %v | Expected: %v", err, repo.ErrNoTenant)
+	}
+
+	evil, err := repo.NewTenantUserRepo(repo.WithTenant(context.Background(), "evil"), users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u, err := evil.Get(ctx, alice.ID); err == nil {
+		t.Errorf("Get: %+v | Expected: error", u)
+	}
+
+	if u, err := evil.GetByUsernameAndTenant(ctx, "alice", "acme"); err == nil {
+		t.Errorf("GetByUsernameAndTenant: %+v | Expected: error", u)
+	}
+
+	if u, err := evil.GetByEmailAndTenant(ctx, "alice@acme", "acme"); err == nil {
+		t.Errorf("GetByEmailAndTenant: %+v | Expected: error", u)
+	}
+
+	all, err := evil.GetAll(ctx)
+	if err != nil || len(all) != 1 || all[0].Username != "mallory" {
+		t.Errorf("GetAll: %v, %v | Expected: mallory only", all, err)
+	}
+
+	// Forged tenant IDs are rejected.
+	if _, err := evil.Insert(ctx, newTenantUser("intruder", "acme")); err != m.ErrCrossTenant {
+		t.Errorf("Insert error: %v | Expected: %v", err, m.ErrCrossTenant)
+	}
+
+	forged := *alice
+	forged.TenantID = "evil"
+	if err := evil.Update(ctx, &forged); err == nil {
+		t.Error("Update: nil | Expected: error")
+	}
+
+	if err := evil.Update(ctx, alice); err != m.ErrCrossTenant {
+		t.Errorf("Update error: %v | Expected: %v", err, m.ErrCrossTenant)
+	}
+
+	if err := evil.Delete(ctx, alice.ID); err == nil {
+		t.Error("Delete: nil | Expected: error")
+	}
+
+	// Rescoping a scoped repo does not leak the previous tenant.
+	acme := repo.ForTenant(evil, "acme")
+	if u, err := acme.Get(ctx, alice.ID); err != nil || u.Username != "alice" {
+		t.Errorf("Get: %v, %v | Expected: alice", u, err)
+	}
+
+	if u, err := acme.Get(ctx, mallory.ID); err == nil {
+		t.Errorf("Get: %+v | Expected: error", u)
+	}
+
+	if all, _ := users.GetAll(ctx); len(all) != 2 {
+		t.Errorf("Users: %d | Expected: 2", len(all))
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@acme.com", "username@acme.com", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "evil"); err == nil {
+		t.Error("expected sign in from another tenant to fail")
+	}
+
+	if _, err := svc.authenticatedUser(ctx, token.AccessToken, "evil"); err == nil {
+		t.Error("expected token from another tenant to be rejected")
+	}
+
+	if err := svc.Cancel(ctx, "username", "password", "127.0.0.1", "evil"); err == nil {
+		t.Error("expected cancel from another tenant to fail")
+	}
+
+	if _, err := svc.authenticatedUser(ctx, token.AccessToken, "acme"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
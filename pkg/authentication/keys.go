@@ -0,0 +1,308 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/go-kit/kit/log"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	c "gitlab.com/mikrowezel/backend/granica/internal/config"
+)
+
+const (
+	keyFileExt = ".pem"
+)
+
+// signingKey is a private key identified by its kid.
+type signingKey struct {
+	ID          string
+	Method      jwt.SigningMethod
+	Private     crypto.Signer
+	ActivatesAt time.Time
+	// thumbprint tells a key apart from a different one under the same kid.
+	thumbprint string
+}
+
+// keyring holds the keys tokens are signed and verified with.
+// All keys are published and accepted for verification, tokens
+// are signed with the newest key that is already active.
+type keyring struct {
+	mu   sync.RWMutex
+	cfg  config.TokenConfig
+	keys map[string]*signingKey
+}
+
+// newKeyring makes a keyring using the configured keys.
+// Keys are read from every PEM file in the keys directory, named after their kid.
+// Otherwise, a single key is read from the private key path. A key is only
+// generated if explicitly allowed for development: tokens signed with it
+// do not survive a restart nor are accepted by other replicas.
+func newKeyring(cfg config.TokenConfig) (*keyring, error) {
+	kr := &keyring{
+		cfg:  cfg,
+		keys: make(map[string]*signingKey),
+	}
+
+	if cfg.KeysDir != "" {
+		err := kr.reload()
+		if err != nil {
+			return nil, err
+		}
+		return kr, nil
+	}
+
+	method, err := signingMethod(cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var key crypto.Signer
+	switch {
+	case cfg.PrivateKeyPath != "":
+		key, err = readSigningKey(cfg.PrivateKeyPath)
+	case cfg.EphemeralKey:
+		key, err = genSigningKey(method)
+	default:
+		err = errors.New("no signing key configured: set the keys dir or the private key path")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sk, err := makeSigningKey("", key, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	kr.keys[sk.ID] = sk
+	return kr, nil
+}
+
+// Signing returns the key new tokens are signed with.
+func (kr *keyring) Signing() (*signingKey, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	now := time.Now()
+	var current *signingKey
+	for _, k := range kr.keys {
+		if k.ActivatesAt.After(now) {
+			continue
+		}
+		if current == nil || k.ActivatesAt.After(current.ActivatesAt) {
+			current = k
+		}
+	}
+
+	if current == nil {
+		return nil, errors.New("no active signing key")
+	}
+
+	return current, nil
+}
+
+// Get returns a key by its kid.
+func (kr *keyring) Get(kid string) (*signingKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	k, ok := kr.keys[kid]
+	return k, ok
+}
+
+// All returns all the keys in the keyring sorted by kid.
+func (kr *keyring) All() []*signingKey {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	keys := make([]*signingKey, 0, len(kr.keys))
+	for _, k := range kr.keys {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// Watch reloads the keys directory periodically until the context is done.
+// A key added to the directory is published right away but only used for signing
+// once its activation delay has passed, giving verifiers time to fetch it.
+// A key removed from the directory is no longer accepted.
+// Keys found on the first load have nobody to wait for and are active right away.
+func (kr *keyring) Watch(ctx context.Context, logger log.Logger) {
+	if kr.cfg.KeysDir == "" || kr.cfg.KeyReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(kr.cfg.KeyReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := kr.reload()
+			if err != nil {
+				logger.Log("level", c.LogLevel.Error, "msg", "Signing keys reload error", "err", err.Error())
+			}
+		}
+	}
+}
+
+// reload replaces the keyring keys with the ones found in the keys directory.
+// Keys already loaded keep their activation time, so that touching their files,
+// as remounting a secret does, does not deactivate them.
+// On error the current keys are kept.
+func (kr *keyring) reload() error {
+	files, err := ioutil.ReadDir(kr.cfg.KeysDir)
+	if err != nil {
+		return err
+	}
+
+	kr.mu.RLock()
+	loaded := kr.keys
+	kr.mu.RUnlock()
+
+	now := time.Now()
+
+	keys := make(map[string]*signingKey)
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != keyFileExt {
+			continue
+		}
+
+		kid := strings.TrimSuffix(f.Name(), keyFileExt)
+		key, err := readSigningKey(filepath.Join(kr.cfg.KeysDir, f.Name()))
+		if err != nil {
+			return fmt.Errorf("key '%s': %s", kid, err.Error())
+		}
+
+		sk, err := makeSigningKey(kid, key, activationTime(f.ModTime(), kr.cfg.KeyActivationDelay, len(loaded) == 0, now))
+		if err != nil {
+			return fmt.Errorf("key '%s': %s", kid, err.Error())
+		}
+
+		if prev, ok := loaded[kid]; ok && prev.thumbprint == sk.thumbprint {
+			sk.ActivatesAt = prev.ActivatesAt
+		}
+
+		keys[kid] = sk
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("no signing keys found in '%s'", kr.cfg.KeysDir)
+	}
+
+	kr.mu.Lock()
+	kr.keys = keys
+	kr.mu.Unlock()
+	return nil
+}
+
+// activationTime returns when a key written at modTime can be used for signing.
+// Keys of the first load are active from the time they were written, or now
+// if their file time is ahead, so that the newest of them signs.
+func activationTime(modTime time.Time, delay time.Duration, first bool, now time.Time) time.Time {
+	if first {
+		if modTime.After(now) {
+			return now
+		}
+		return modTime
+	}
+
+	return modTime.Add(delay)
+}
+
+// makeSigningKey wraps a private key selecting the signing method from its type.
+// If kid is empty the key JWK thumbprint is used.
+func makeSigningKey(kid string, key crypto.Signer, activatesAt time.Time) (*signingKey, error) {
+	var method jwt.SigningMethod
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("unsupported elliptic curve")
+		}
+		method = jwt.SigningMethodES256
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	jwk, err := makeJWK("", method, key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	if kid == "" {
+		kid = jwk.Thumbprint()
+	}
+
+	return &signingKey{
+		ID:          kid,
+		Method:      method,
+		Private:     key,
+		ActivatesAt: activatesAt,
+		thumbprint:  jwk.Thumbprint(),
+	}, nil
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		return jwt.SigningMethodRS256, nil
+	case jwt.SigningMethodES256.Alg():
+		return jwt.SigningMethodES256, nil
+	}
+
+	return nil, fmt.Errorf("unsupported signing algorithm '%s'", alg)
+}
+
+func readSigningKey(path string) (crypto.Signer, error) {
+	keyBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(keyBytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := jwt.ParseECPrivateKeyFromPEM(keyBytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("'%s' is not a PEM encoded RSA or EC private key", path)
+}
+
+func genSigningKey(method jwt.SigningMethod) (crypto.Signer, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case *jwt.SigningMethodECDSA:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+
+	return nil, fmt.Errorf("unsupported signing algorithm '%s'", method.Alg())
+}
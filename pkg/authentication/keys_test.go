@@ -0,0 +1,194 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+func writeECKey(t *testing.T, dir, kid string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, kid+keyFileExt)
+	err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chtimes(path, modTime, modTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "granica-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeECKey(t, dir, "old", time.Now())
+
+	ti, err := newTokenIssuer(config.TokenConfig{
+		Issuer:             "granica",
+		Audience:           "granica",
+		AccessTTL:          time.Minute,
+		KeysDir:            dir,
+		KeyActivationDelay: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken, err := ti.Issue(userClaims(&m.User{Username: "username"}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// New key is published but not used for signing until activated.
+	writeECKey(t, dir, "new", time.Now())
+	err = ti.keys.reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := ti.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS keys: %d | Expected: 2", len(set.Keys))
+	}
+
+	token, err := ti.Issue(userClaims(&m.User{Username: "username"}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if kid := tokenKid(t, token.AccessToken); kid != "old" {
+		t.Errorf("Signing kid: '%s' | Expected: 'old'", kid)
+	}
+
+	// Once active, the newest key signs and older tokens still verify.
+	writeECKey(t, dir, "new", time.Now().Add(-time.Hour))
+	err = ti.keys.reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = ti.Issue(userClaims(&m.User{Username: "username"}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if kid := tokenKid(t, token.AccessToken); kid != "new" {
+		t.Errorf("Signing kid: '%s' | Expected: 'new'", kid)
+	}
+
+	if _, err := ti.Parse(oldToken.AccessToken); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+
+	// Removed keys are no longer accepted.
+	os.Remove(filepath.Join(dir, "old"+keyFileExt))
+	err = ti.keys.reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ti.Parse(oldToken.AccessToken); err == nil {
+		t.Error("expected token signed with a removed key to be rejected")
+	}
+}
+
+func TestKeyringFirstLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "granica-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A fresh deploy: the key was just written.
+	writeECKey(t, dir, "first", time.Now())
+
+	ti, err := newTokenIssuer(config.TokenConfig{
+		Issuer:             "granica",
+		Audience:           "granica",
+		AccessTTL:          time.Minute,
+		KeysDir:            dir,
+		KeyActivationDelay: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := ti.Issue(userClaims(&m.User{Username: "username"}, ""))
+	if err != nil {
+		t.Fatalf("Error: %v | Expected: nil", err)
+	}
+
+	if kid := tokenKid(t, token.AccessToken); kid != "first" {
+		t.Errorf("Signing kid: '%s' | Expected: 'first'", kid)
+	}
+
+	// Touching the file, as remounting a secret does, keeps the key active.
+	later := time.Now().Add(time.Minute)
+	err = os.Chtimes(filepath.Join(dir, "first"+keyFileExt), later, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys added after the first load still wait for their activation.
+	writeECKey(t, dir, "second", time.Now())
+
+	err = ti.keys.reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = ti.Issue(userClaims(&m.User{Username: "username"}, ""))
+	if err != nil {
+		t.Fatalf("Error: %v | Expected: nil", err)
+	}
+
+	if kid := tokenKid(t, token.AccessToken); kid != "first" {
+		t.Errorf("Signing kid: '%s' | Expected: 'first'", kid)
+	}
+}
+
+func tokenKid(t *testing.T, tokenString string) string {
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, &AppClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
@@ -0,0 +1,140 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	c "gitlab.com/mikrowezel/backend/granica/internal/config"
+)
+
+// accountKey is the counter key of an account.
+// Attempts on unknown usernames are counted too so that they behave
+// the same as existing ones.
+func accountKey(username, tenantID string) string {
+	return "account:" + tenantID + ":" + strings.ToLower(username)
+}
+
+// ipKey is the counter key of a remote IP.
+func ipKey(remoteIP string) string {
+	return "ip:" + remoteIP
+}
+
+// checkLockout returns ErrLocked if the account or the remote IP are locked.
+func (gs granicaService) checkLockout(ctx context.Context, username, remoteIP, tenantID string) error {
+	keys := []string{accountKey(username, tenantID)}
+	if remoteIP != "" {
+		keys = append(keys, ipKey(remoteIP))
+	}
+
+	for _, k := range keys {
+		until, err := gs.lockouts.LockedUntil(ctx, k)
+		if err != nil {
+			return err
+		}
+		if !until.IsZero() {
+			return ErrLocked
+		}
+	}
+
+	return nil
+}
+
+// recordFailure counts a failed attempt for the account and the remote IP
+// and locks those that reached their threshold.
+func (gs granicaService) recordFailure(ctx context.Context, username, remoteIP, tenantID string) {
+	cfg := gs.cfg.Auth.Lockout
+	gs.countFailure(ctx, accountKey(username, tenantID), cfg.AccountThreshold)
+	if remoteIP != "" {
+		gs.countFailure(ctx, ipKey(remoteIP), cfg.IPThreshold)
+	}
+}
+
+// recordSuccess clears the failed attempts of the account.
+// Remote IP failures are kept as they may span many accounts.
+func (gs granicaService) recordSuccess(ctx context.Context, username, tenantID string) {
+	err := gs.lockouts.Reset(ctx, accountKey(username, tenantID))
+	if err != nil {
+		gs.logger.Log("level", c.LogLevel.Error, "msg", "cannot reset failed attempts", "err", err.Error())
+	}
+}
+
+func (gs granicaService) countFailure(ctx context.Context, key string, threshold int) {
+	if threshold <= 0 {
+		return
+	}
+
+	failures, err := gs.lockouts.Fail(ctx, key, gs.cfg.Auth.Lockout.Window)
+	if err != nil {
+		gs.logger.Log("level", c.LogLevel.Error, "msg", "cannot count failed attempt", "err", err.Error())
+		return
+	}
+
+	if failures < threshold {
+		return
+	}
+
+	until := time.Now().Add(gs.lockoutDuration(failures - threshold))
+	err = gs.lockouts.Lock(ctx, key, until)
+	if err != nil {
+		gs.logger.Log("level", c.LogLevel.Error, "msg", "cannot lock", "err", err.Error())
+		return
+	}
+
+	gs.logger.Log(
+		"level", c.LogLevel.Info,
+		"event", "lockout",
+		"key", key,
+		"failures", failures,
+		"until", until.Format(time.RFC3339),
+	)
+}
+
+// lockoutDuration doubles the lock duration for every failure past the threshold.
+func (gs granicaService) lockoutDuration(excess int) time.Duration {
+	cfg := gs.cfg.Auth.Lockout
+	max := cfg.MaxDuration
+	if max < cfg.Duration {
+		max = cfg.Duration
+	}
+
+	d := cfg.Duration
+	for i := 0; i < excess && d < max; i++ {
+		d *= 2
+	}
+
+	if d > max {
+		return max
+	}
+
+	return d
+}
+
+// unlock clears the failed attempts and lock of the account and the remote IP.
+func (gs granicaService) unlock(ctx context.Context, username, remoteIP, tenantID string) error {
+	var keys []string
+	if username != "" {
+		keys = append(keys, accountKey(username, tenantID))
+	}
+	if remoteIP != "" {
+		keys = append(keys, ipKey(remoteIP))
+	}
+
+	for _, k := range keys {
+		err := gs.lockouts.Reset(ctx, k)
+		if err != nil {
+			return err
+		}
+
+		gs.logger.Log("level", c.LogLevel.Info, "event", "unlock", "key", k)
+	}
+
+	return nil
+}
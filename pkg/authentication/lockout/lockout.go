@@ -0,0 +1,37 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/lockout/memory"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/lockout/redis"
+)
+
+// CounterStore interface
+// It keeps failed attempt counters and locks by key.
+// Counters expire after the window they are counted in.
+type CounterStore interface {
+	Fail(ctx context.Context, key string, window time.Duration) (failures int, err error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// NewStore makes a new counter store.
+// Counters are kept in the same kind of store as sessions.
+func NewStore(ctx context.Context, cfg *config.Config, logger log.Logger) (CounterStore, error) {
+
+	if cfg.Cache.Type == "redis" {
+		return redis.NewStore(ctx, cfg, logger)
+
+	} else if cfg.Cache.Type == "memory" {
+		return memory.NewStore(ctx, cfg, logger)
+	}
+
+	return nil, errors.New("not a valid counter store type")
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+)
+
+// purgeInterval is how often expired counters of keys not seen again are dropped.
+const purgeInterval = time.Minute
+
+type counter struct {
+	failures  int
+	expiresAt time.Time
+}
+
+// Store is an in-memory implementation of CounterStore interface.
+// Counters are lost on restart and not shared between replicas.
+// Expired entries are ignored when accessed and swept at most once per
+// purge interval, so that counting a failure does not scan all the keys.
+type Store struct {
+	mu       sync.Mutex
+	counters map[string]counter
+	locks    map[string]time.Time
+	purgedAt time.Time
+}
+
+// NewStore makes a new in-memory counter store.
+func NewStore(ctx context.Context, cfg *config.Config, logger log.Logger) (*Store, error) {
+	return &Store{
+		counters: make(map[string]counter),
+		locks:    make(map[string]time.Time),
+		purgedAt: time.Now(),
+	}, nil
+}
+
+// Fail counts a failed attempt for the key and returns the failures in the window.
+func (s *Store) Fail(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.purge(now)
+
+	c := s.counters[key]
+	if now.After(c.expiresAt) {
+		c.failures = 0
+	}
+	c.failures++
+	c.expiresAt = now.Add(window)
+	s.counters[key] = c
+	return c.failures, nil
+}
+
+// Lock the key until the given time.
+func (s *Store) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locks[key] = until
+	return nil
+}
+
+// LockedUntil returns the time the key is locked until, zero if not locked.
+func (s *Store) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.locks[key]
+	if !ok {
+		return time.Time{}, nil
+	}
+	if time.Now().After(until) {
+		delete(s.locks, key)
+		return time.Time{}, nil
+	}
+
+	return until, nil
+}
+
+// Reset clears the failures and lock of the key.
+func (s *Store) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counters, key)
+	delete(s.locks, key)
+	return nil
+}
+
+// purge drops expired counters and locks once the purge interval has passed.
+// Must be called with the lock held.
+func (s *Store) purge(now time.Time) {
+	if now.Sub(s.purgedAt) < purgeInterval {
+		return
+	}
+	s.purgedAt = now
+
+	for k, c := range s.counters {
+		if now.After(c.expiresAt) {
+			delete(s.counters, k)
+		}
+	}
+	for k, until := range s.locks {
+		if now.After(until) {
+			delete(s.locks, k)
+		}
+	}
+}
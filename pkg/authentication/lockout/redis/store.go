@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-redis/redis"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+)
+
+const (
+	keyPrefix = "granica:lockout"
+)
+
+// Store is a Redis implementation of CounterStore interface.
+// Counters and locks are kept under their own keys expiring with them
+// so they are shared by all the replicas.
+type Store struct {
+	client *redis.Client
+}
+
+// NewStore makes a new Redis counter store.
+func NewStore(ctx context.Context, cfg *config.Config, logger log.Logger) (*Store, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", cfg.Cache.Redis.Host, cfg.Cache.Redis.Port),
+		Password: cfg.Cache.Redis.Password,
+	})
+
+	err := client.Ping().Err()
+	if err != nil {
+		logger.Log("level", config.LogLevel.Error, "msg", "Redis connection error", "err", err.Error())
+		return nil, err
+	}
+
+	return &Store{
+		client: client,
+	}, nil
+}
+
+// Fail counts a failed attempt for the key and returns the failures in the window.
+// Increment and expiration are applied atomically.
+func (s *Store) Fail(ctx context.Context, key string, window time.Duration) (int, error) {
+	var incr *redis.IntCmd
+	_, err := s.client.WithContext(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(failuresKey(key))
+		pipe.Expire(failuresKey(key), window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(incr.Val()), nil
+}
+
+// Lock the key until the given time.
+func (s *Store) Lock(ctx context.Context, key string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return s.client.WithContext(ctx).Set(lockKey(key), until.Unix(), ttl).Err()
+}
+
+// LockedUntil returns the time the key is locked until, zero if not locked.
+func (s *Store) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	val, err := s.client.WithContext(ctx).Get(lockKey(key)).Result()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	unix, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(unix, 0), nil
+}
+
+// Reset clears the failures and lock of the key.
+func (s *Store) Reset(ctx context.Context, key string) error {
+	return s.client.WithContext(ctx).Del(failuresKey(key), lockKey(key)).Err()
+}
+
+func failuresKey(key string) string {
+	return fmt.Sprintf("%s:failures:%s", keyPrefix, key)
+}
+
+func lockKey(key string) string {
+	return fmt.Sprintf("%s:lock:%s", keyPrefix, key)
+}
@@ -0,0 +1,208 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+)
+
+// testLockout is the lockout config of the tests: accounts lock on the third
+// failure, remote IPs on the fifth.
+var testLockout = config.LockoutConfig{
+	AccountThreshold: 3,
+	IPThreshold:      5,
+	Duration:         time.Minute,
+	MaxDuration:      time.Hour,
+	Window:           time.Hour,
+}
+
+func TestAccountLockout(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.cfg.Auth.Lockout = testLockout
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.SignIn(ctx, "username", "wrong", "127.0.0.1", "localhost")
+		if err == nil || err == ErrLocked {
+			t.Fatalf("Attempt %d error: %v | Expected: password mismatch", i+1, err)
+		}
+	}
+
+	// Locked accounts are rejected even with the right password, from any IP.
+	_, err = svc.SignIn(ctx, "username", "password", "10.0.0.1", "localhost")
+	if err != ErrLocked {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrLocked)
+	}
+
+	if err := svc.Cancel(ctx, "username", "password", "10.0.0.1", "localhost"); err != ErrLocked {
+		t.Errorf("Cancel error: %v | Expected: %v", err, ErrLocked)
+	}
+
+	err = svc.Unlock(ctx, "username", "", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestIPLockout(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.cfg.Auth.Lockout = testLockout
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Spread over many accounts so that none of them gets locked.
+	for _, username := range []string{"a", "b", "c", "d", "e"} {
+		_, err := svc.SignIn(ctx, username, "wrong", "127.0.0.1", "localhost")
+		if err == nil || err == ErrLocked {
+			t.Fatalf("Attempt for '%s' error: %v | Expected: not found", username, err)
+		}
+	}
+
+	_, err = svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != ErrLocked {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrLocked)
+	}
+
+	_, err = svc.SignIn(ctx, "username", "password", "10.0.0.1", "localhost")
+	if err != nil {
+		t.Errorf("Other IP error: %v | Expected: nil", err)
+	}
+
+	err = svc.Unlock(ctx, "", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestLockoutSuccessResetsFailures(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.cfg.Auth.Lockout = testLockout
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		svc.SignIn(ctx, "username", "wrong", "127.0.0.1", "localhost")
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		svc.SignIn(ctx, "username", "wrong", "127.0.0.1", "localhost")
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestMFAFailuresLockAccount(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.cfg.Auth.Lockout = testLockout
+	svc.cfg.Auth.Lockout.IPThreshold = 3
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enrollTestMFA(ctx, t, svc, token.AccessToken)
+
+	// Passing the password again in between must not clear the failed codes.
+	for i := 0; i < 3; i++ {
+		challenge, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+		if err != nil {
+			t.Fatalf("Sign in %d error: %v | Expected: nil", i+1, err)
+		}
+
+		_, err = svc.VerifyMFA(ctx, challenge.MFAToken, "000000", "127.0.0.1", "localhost")
+		if err != ErrInvalidMFACode {
+			t.Fatalf("Attempt %d error: %v | Expected: %v", i+1, err, ErrInvalidMFACode)
+		}
+	}
+
+	_, err = svc.SignIn(ctx, "username", "password", "10.0.0.1", "localhost")
+	if err != ErrLocked {
+		t.Errorf("Error: %v | Expected: %v", err, ErrLocked)
+	}
+
+	until, err := svc.lockouts.LockedUntil(context.Background(), ipKey("127.0.0.1"))
+	if err != nil || until.IsZero() {
+		t.Errorf("IP locked until: %v, %v | Expected: locked", until, err)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	svc := newTestService(t)
+	svc.cfg.Auth.Lockout = testLockout
+
+	tests := []struct {
+		excess   int
+		expected time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{3, 8 * time.Minute},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if d := svc.lockoutDuration(tt.excess); d != tt.expected {
+			t.Errorf("Excess %d duration: %s | Expected: %s", tt.excess, d, tt.expected)
+		}
+	}
+}
+
+func TestUnlockRequiresKey(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.cfg.Auth.Lockout = testLockout
+
+	if err := svc.Unlock(ctx, "", "", "localhost"); err == nil {
+		t.Error("expected unlock without username nor IP to fail")
+	}
+}
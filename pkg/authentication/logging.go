@@ -8,11 +8,15 @@
 package authentication
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	c "gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/policy"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/rebac"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/webauthn"
 	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
 )
 
@@ -22,7 +26,7 @@ type loggingMiddleware struct {
 }
 
 // SignUp is a logging middleware wrapper over another interface implementation of SignUp.
-func (mw loggingMiddleware) SignUp(username, password, email, emailConfirmation, tenantID string) (output *m.User, err error) {
+func (mw loggingMiddleware) SignUp(ctx context.Context, username, password, email, emailConfirmation, tenantID string) (output *m.User, err error) {
 	defer func(begin time.Time) {
 		input := fmt.Sprintf("{%s, %s, %s, %s, %s}", username, password, email, emailConfirmation, tenantID)
 		mw.logger.Log(
@@ -35,14 +39,14 @@ func (mw loggingMiddleware) SignUp(username, password, email, emailConfirmation,
 		)
 	}(time.Now())
 
-	output, err = mw.next.SignUp(username, password, email, emailConfirmation, tenantID)
+	output, err = mw.next.SignUp(ctx, username, password, email, emailConfirmation, tenantID)
 	return
 }
 
 // Cancel is a logging middleware wrapper over another interface implementation of Cancel.
-func (mw loggingMiddleware) Cancel(username, password, tenantID string) (err error) {
+func (mw loggingMiddleware) Cancel(ctx context.Context, username, password, remoteIP, tenantID string) (err error) {
 	defer func(begin time.Time) {
-		input := fmt.Sprintf("{%s, %s, %s}", username, "********", tenantID)
+		input := fmt.Sprintf("{%s, %s, %s, %s}", username, "********", remoteIP, tenantID)
 		mw.logger.Log(
 			"level", c.LogLevel.Info,
 			"method", "Cancel",
@@ -52,31 +56,232 @@ func (mw loggingMiddleware) Cancel(username, password, tenantID string) (err err
 		)
 	}(time.Now())
 
-	return mw.next.Cancel(username, password, tenantID)
+	return mw.next.Cancel(ctx, username, password, remoteIP, tenantID)
+}
+
+// VerifyEmail is a logging middleware wrapper over another interface implementation of VerifyEmail.
+func (mw loggingMiddleware) VerifyEmail(ctx context.Context, token string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s}", "********")
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "VerifyEmail",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.VerifyEmail(ctx, token)
+}
+
+// ResendEmailVerification is a logging middleware wrapper over another interface implementation of ResendEmailVerification.
+func (mw loggingMiddleware) ResendEmailVerification(ctx context.Context, username, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s}", username, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "ResendEmailVerification",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.ResendEmailVerification(ctx, username, tenantID)
+}
+
+// ForgotPassword is a logging middleware wrapper over another interface implementation of ForgotPassword.
+func (mw loggingMiddleware) ForgotPassword(ctx context.Context, email, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s}", email, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "ForgotPassword",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.ForgotPassword(ctx, email, tenantID)
+}
+
+// ResetPassword is a logging middleware wrapper over another interface implementation of ResetPassword.
+func (mw loggingMiddleware) ResetPassword(ctx context.Context, token, password, passwordConfirmation string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", "********", "********", "********")
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "ResetPassword",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.ResetPassword(ctx, token, password, passwordConfirmation)
 }
 
 // SignIn is a logging middleware wrapper over another interface implementation of SingnIn.
-func (mw loggingMiddleware) SignIn(username, password, tenantID string) (output *m.User, err error) {
+// Issued tokens are credentials and therefore not logged.
+func (mw loggingMiddleware) SignIn(ctx context.Context, username, password, remoteIP, tenantID string) (output *AuthToken, err error) {
 	defer func(begin time.Time) {
-		input := fmt.Sprintf("{%s, %s, %s}", username, password, tenantID)
+		input := fmt.Sprintf("{%s, %s, %s, %s}", username, "********", remoteIP, tenantID)
 		mw.logger.Log(
 			"level", c.LogLevel.Info,
 			"method", "SignIn",
 			"input", input,
-			"output", output,
 			"err", err,
 			"took", time.Since(begin),
 		)
 	}(time.Now())
 
-	output, err = mw.next.SignIn(username, password, tenantID)
+	output, err = mw.next.SignIn(ctx, username, password, remoteIP, tenantID)
 	return
 }
 
-// SignOut is a logging middleware wrapper over another interface implementation of SingnOut.
-func (mw loggingMiddleware) SignOut(username, tenantID string) (err error) {
+// VerifyMFA is a logging middleware wrapper over another interface implementation of VerifyMFA.
+func (mw loggingMiddleware) VerifyMFA(ctx context.Context, mfaToken, code, remoteIP, tenantID string) (output *AuthToken, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s}", "********", "********", remoteIP, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "VerifyMFA",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.VerifyMFA(ctx, mfaToken, code, remoteIP, tenantID)
+	return
+}
+
+// EnrollMFA is a logging middleware wrapper over another interface implementation of EnrollMFA.
+// Enrollment secrets are credentials and therefore not logged.
+func (mw loggingMiddleware) EnrollMFA(ctx context.Context, token, tenantID string) (output *MFAEnrollment, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s}", "********", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "EnrollMFA",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.EnrollMFA(ctx, token, tenantID)
+	return
+}
+
+// ConfirmMFA is a logging middleware wrapper over another interface implementation of ConfirmMFA.
+// Recovery codes are credentials and therefore not logged.
+func (mw loggingMiddleware) ConfirmMFA(ctx context.Context, token, code, tenantID string) (recoveryCodes []string, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", "********", "********", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "ConfirmMFA",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	recoveryCodes, err = mw.next.ConfirmMFA(ctx, token, code, tenantID)
+	return
+}
+
+// DisableMFA is a logging middleware wrapper over another interface implementation of DisableMFA.
+func (mw loggingMiddleware) DisableMFA(ctx context.Context, accessToken, code, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", "********", "********", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "DisableMFA",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.DisableMFA(ctx, accessToken, code, tenantID)
+}
+
+// BeginWebAuthnRegistration is a logging middleware wrapper over another interface implementation of BeginWebAuthnRegistration.
+func (mw loggingMiddleware) BeginWebAuthnRegistration(ctx context.Context, accessToken, tenantID string) (output *WebAuthnRegistration, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s}", "********", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "BeginWebAuthnRegistration",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.BeginWebAuthnRegistration(ctx, accessToken, tenantID)
+	return
+}
+
+// FinishWebAuthnRegistration is a logging middleware wrapper over another interface implementation of FinishWebAuthnRegistration.
+func (mw loggingMiddleware) FinishWebAuthnRegistration(ctx context.Context, accessToken, token, name string, resp webauthn.RegistrationResponse, tenantID string) (output *m.WebAuthnCredential, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s, %s}", "********", "********", name, resp.ID, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "FinishWebAuthnRegistration",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.FinishWebAuthnRegistration(ctx, accessToken, token, name, resp, tenantID)
+	return
+}
+
+// BeginWebAuthnLogin is a logging middleware wrapper over another interface implementation of BeginWebAuthnLogin.
+func (mw loggingMiddleware) BeginWebAuthnLogin(ctx context.Context, username, tenantID string) (output *WebAuthnLogin, err error) {
 	defer func(begin time.Time) {
 		input := fmt.Sprintf("{%s, %s}", username, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "BeginWebAuthnLogin",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.BeginWebAuthnLogin(ctx, username, tenantID)
+	return
+}
+
+// FinishWebAuthnLogin is a logging middleware wrapper over another interface implementation of FinishWebAuthnLogin.
+func (mw loggingMiddleware) FinishWebAuthnLogin(ctx context.Context, token string, resp webauthn.AssertionResponse, tenantID string) (output *AuthToken, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", "********", resp.ID, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "FinishWebAuthnLogin",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.FinishWebAuthnLogin(ctx, token, resp, tenantID)
+	return
+}
+
+// SignOut is a logging middleware wrapper over another interface implementation of SingnOut.
+func (mw loggingMiddleware) SignOut(ctx context.Context, accessToken string, all bool, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %t, %s}", "********", all, tenantID)
 		mw.logger.Log(
 			"level", c.LogLevel.Info,
 			"method", "SignOut",
@@ -86,16 +291,82 @@ func (mw loggingMiddleware) SignOut(username, tenantID string) (err error) {
 		)
 	}(time.Now())
 
-	return mw.next.SignOut(username, tenantID)
+	return mw.next.SignOut(ctx, accessToken, all, tenantID)
 }
 
-// Create is a logging middleware wrapper over another interface implementation of Create.
-func (mw loggingMiddleware) Create(username, password, email, tenantID string) (output *m.User, err error) {
+// Refresh is a logging middleware wrapper over another interface implementation of Refresh.
+func (mw loggingMiddleware) Refresh(ctx context.Context, refreshToken, tenantID string) (output *AuthToken, err error) {
 	defer func(begin time.Time) {
-		input := fmt.Sprintf("{%s, %s, %s, %s}", username, password, email, tenantID)
+		input := fmt.Sprintf("{%s, %s}", "********", tenantID)
 		mw.logger.Log(
 			"level", c.LogLevel.Info,
-			"method", "Create",
+			"method", "Refresh",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Refresh(ctx, refreshToken, tenantID)
+	return
+}
+
+// JWKS is a logging middleware wrapper over another interface implementation of JWKS.
+func (mw loggingMiddleware) JWKS(ctx context.Context) (output *JWKSet, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "JWKS",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.JWKS(ctx)
+	return
+}
+
+// Introspect is a logging middleware wrapper over another interface implementation of Introspect.
+func (mw loggingMiddleware) Introspect(ctx context.Context, token, tokenTypeHint, clientID, clientSecret, tenantID string) (output *Introspection, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s, %s}", "********", tokenTypeHint, clientID, "********", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "Introspect",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Introspect(ctx, token, tokenTypeHint, clientID, clientSecret, tenantID)
+	return
+}
+
+// RevokeToken is a logging middleware wrapper over another interface implementation of RevokeToken.
+func (mw loggingMiddleware) RevokeToken(ctx context.Context, token, tokenTypeHint, clientID, clientSecret, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s, %s}", "********", tokenTypeHint, clientID, "********", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "RevokeToken",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.RevokeToken(ctx, token, tokenTypeHint, clientID, clientSecret, tenantID)
+}
+
+// RegisterClient is a logging middleware wrapper over another interface implementation of RegisterClient.
+// Client secrets are credentials and therefore not logged.
+func (mw loggingMiddleware) RegisterClient(ctx context.Context, name string, redirectURIs, scopes, grantTypes []string, confidential bool, tenantID string) (output *m.Client, secret string, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %v, %v, %v, %t, %s}", name, redirectURIs, scopes, grantTypes, confidential, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "RegisterClient",
 			"input", input,
 			"output", output,
 			"err", err,
@@ -103,44 +374,556 @@ func (mw loggingMiddleware) Create(username, password, email, tenantID string) (
 		)
 	}(time.Now())
 
-	output, err = mw.next.Create(username, password, email, tenantID)
+	output, secret, err = mw.next.RegisterClient(ctx, name, redirectURIs, scopes, grantTypes, confidential, tenantID)
 	return
 }
 
-// Update is a logging middleware wrapper over another interface implementation of Create.
-func (mw loggingMiddleware) Update(username, password, passwordConfirmation,
-	email, emailConfirmation, description, givenName, middleNames, familyName,
-	tenantID string) (err error) {
+// RotateClientSecret is a logging middleware wrapper over another interface implementation of RotateClientSecret.
+// Client secrets are credentials and therefore not logged.
+func (mw loggingMiddleware) RotateClientSecret(ctx context.Context, clientID, tenantID string) (secret string, err error) {
 	defer func(begin time.Time) {
-		input := fmt.Sprintf("{%s, %s, %s, %s}", username, password, email, tenantID)
+		input := fmt.Sprintf("{%s, %s}", clientID, tenantID)
 		mw.logger.Log(
 			"level", c.LogLevel.Info,
-			"method", "Update",
+			"method", "RotateClientSecret",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.RotateClientSecret(ctx, clientID, tenantID)
+}
+
+// ValidateAuthorization is a logging middleware wrapper over another interface implementation of ValidateAuthorization.
+func (mw loggingMiddleware) ValidateAuthorization(ctx context.Context, ar AuthorizationRequest) (output *m.Client, redirectURL string, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s}", ar.ClientID, ar.RedirectURI, ar.Scope, ar.TenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "ValidateAuthorization",
 			"input", input,
 			"err", err,
 			"took", time.Since(begin),
 		)
 	}(time.Now())
 
-	err = mw.next.Update(username, password, passwordConfirmation, email,
-		emailConfirmation, description, givenName, middleNames, familyName, tenantID)
+	output, redirectURL, err = mw.next.ValidateAuthorization(ctx, ar)
 	return
 }
 
-// Remove is a logging middleware wrapper over another interface implementation of Remove.
-func (mw loggingMiddleware) Remove(username, email, tenantID string) (err error) {
+// Authorize is a logging middleware wrapper over another interface implementation of Authorize.
+// Redirect URLs may carry an authorization code and therefore are not logged.
+func (mw loggingMiddleware) Authorize(ctx context.Context, ar AuthorizationRequest, username, password, otp string, consent bool) (redirectURL string, err error) {
 	defer func(begin time.Time) {
-		input := fmt.Sprintf("{%s, %s, %s}", username, email, tenantID)
+		input := fmt.Sprintf("{%s, %s, %s, %s, %s, %s, %t, %s}", ar.ClientID, ar.RedirectURI, ar.Scope, username, "********", "********", consent, ar.TenantID)
 		mw.logger.Log(
 			"level", c.LogLevel.Info,
-			"method", "Remove",
+			"method", "Authorize",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.Authorize(ctx, ar, username, password, otp, consent)
+}
+
+// Token is a logging middleware wrapper over another interface implementation of Token.
+// Codes, verifiers, client secrets and issued tokens are credentials and therefore not logged.
+func (mw loggingMiddleware) Token(ctx context.Context, tr TokenRequest) (output *AuthToken, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s, %s}", tr.GrantType, tr.ClientID, tr.RedirectURI, tr.Scope, tr.TenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "Token",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Token(ctx, tr)
+	return
+}
+
+// OpenIDConfiguration is a logging middleware wrapper over another interface implementation of OpenIDConfiguration.
+func (mw loggingMiddleware) OpenIDConfiguration(ctx context.Context) (output *ProviderMetadata, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "OpenIDConfiguration",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.OpenIDConfiguration(ctx)
+	return
+}
+
+// UserInfo is a logging middleware wrapper over another interface implementation of UserInfo.
+func (mw loggingMiddleware) UserInfo(ctx context.Context, accessToken, tenantID string) (output *UserInfo, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s}", "********", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "UserInfo",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.UserInfo(ctx, accessToken, tenantID)
+	return
+}
+
+// Create is a logging middleware wrapper over another interface implementation of Create.
+func (mw loggingMiddleware) Create(ctx context.Context, username, password, email, tenantID string) (output *m.User, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s}", username, password, email, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "Create",
+			"input", input,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Create(ctx, username, password, email, tenantID)
+	return
+}
+
+// Update is a logging middleware wrapper over another interface implementation of Create.
+func (mw loggingMiddleware) Update(ctx context.Context, username, password, passwordConfirmation,
+	email, emailConfirmation, description, givenName, middleNames, familyName,
+	remoteIP, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s, %s}", username, "********", email, remoteIP, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "Update",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	err = mw.next.Update(ctx, username, password, passwordConfirmation, email,
+		emailConfirmation, description, givenName, middleNames, familyName, remoteIP, tenantID)
+	return
+}
+
+// Remove is a logging middleware wrapper over another interface implementation of Remove.
+func (mw loggingMiddleware) Remove(ctx context.Context, username, email, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", username, email, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "Remove",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.Remove(ctx, username, email, tenantID)
+}
+
+// Unlock is a logging middleware wrapper over another interface implementation of Unlock.
+func (mw loggingMiddleware) Unlock(ctx context.Context, username, remoteIP, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", username, remoteIP, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "Unlock",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.Unlock(ctx, username, remoteIP, tenantID)
+}
+
+// CreateRole is a logging middleware wrapper over another interface implementation of CreateRole.
+func (mw loggingMiddleware) CreateRole(ctx context.Context, name, description string, permissions []string, tenantID string) (output *m.Role, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %v, %s}", name, description, permissions, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "CreateRole",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.CreateRole(ctx, name, description, permissions, tenantID)
+	return
+}
+
+// GetRoles is a logging middleware wrapper over another interface implementation of GetRoles.
+func (mw loggingMiddleware) GetRoles(ctx context.Context, tenantID string) (output []m.Role, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s}", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "GetRoles",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.GetRoles(ctx, tenantID)
+	return
+}
+
+// UpdateRole is a logging middleware wrapper over another interface implementation of UpdateRole.
+func (mw loggingMiddleware) UpdateRole(ctx context.Context, roleID, name, description string, permissions []string, tenantID string) (output *m.Role, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %v, %s}", roleID, name, description, permissions, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "UpdateRole",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.UpdateRole(ctx, roleID, name, description, permissions, tenantID)
+	return
+}
+
+// DeleteRole is a logging middleware wrapper over another interface implementation of DeleteRole.
+func (mw loggingMiddleware) DeleteRole(ctx context.Context, roleID, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s}", roleID, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "DeleteRole",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.DeleteRole(ctx, roleID, tenantID)
+}
+
+// CreatePermission is a logging middleware wrapper over another interface implementation of CreatePermission.
+func (mw loggingMiddleware) CreatePermission(ctx context.Context, name, description, tenantID string) (output *m.Permission, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", name, description, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "CreatePermission",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.CreatePermission(ctx, name, description, tenantID)
+	return
+}
+
+// GetPermissions is a logging middleware wrapper over another interface implementation of GetPermissions.
+func (mw loggingMiddleware) GetPermissions(ctx context.Context, tenantID string) (output []m.Permission, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s}", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "GetPermissions",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.GetPermissions(ctx, tenantID)
+	return
+}
+
+// AssignRole is a logging middleware wrapper over another interface implementation of AssignRole.
+func (mw loggingMiddleware) AssignRole(ctx context.Context, username, roleID, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", username, roleID, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "AssignRole",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.AssignRole(ctx, username, roleID, tenantID)
+}
+
+// UnassignRole is a logging middleware wrapper over another interface implementation of UnassignRole.
+func (mw loggingMiddleware) UnassignRole(ctx context.Context, username, roleID, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", username, roleID, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "UnassignRole",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.UnassignRole(ctx, username, roleID, tenantID)
+}
+
+// GetUserRoles is a logging middleware wrapper over another interface implementation of GetUserRoles.
+func (mw loggingMiddleware) GetUserRoles(ctx context.Context, username, tenantID string) (output []m.Role, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s}", username, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "GetUserRoles",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.GetUserRoles(ctx, username, tenantID)
+	return
+}
+
+// CheckPermission is a logging middleware wrapper over another interface implementation of CheckPermission.
+func (mw loggingMiddleware) CheckPermission(ctx context.Context, accessToken, permission, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", "********", permission, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "CheckPermission",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.CheckPermission(ctx, accessToken, permission, tenantID)
+}
+
+// Decide is a logging middleware wrapper over another interface implementation of Decide.
+func (mw loggingMiddleware) Decide(ctx context.Context, accessToken string, ar AccessRequest, tenantID string) (output *policy.Decision, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s}", "********", ar.Action, ar.Resource.name(), tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "Decide",
+			"input", input,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.Decide(ctx, accessToken, ar, tenantID)
+	return output, err
+}
+
+// WriteRelation is a logging middleware wrapper over another interface implementation of WriteRelation.
+func (mw loggingMiddleware) WriteRelation(ctx context.Context, object, relation, subject, tenantID string) (output *m.RelationTuple, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s}", object, relation, subject, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "WriteRelation",
+			"input", input,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.WriteRelation(ctx, object, relation, subject, tenantID)
+	return output, err
+}
+
+// DeleteRelation is a logging middleware wrapper over another interface implementation of DeleteRelation.
+func (mw loggingMiddleware) DeleteRelation(ctx context.Context, object, relation, subject, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s}", object, relation, subject, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "DeleteRelation",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return mw.next.DeleteRelation(ctx, object, relation, subject, tenantID)
+}
+
+// CheckRelation is a logging middleware wrapper over another interface implementation of CheckRelation.
+func (mw loggingMiddleware) CheckRelation(ctx context.Context, object, relation, subject, tenantID string) (output bool, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s}", object, relation, subject, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "CheckRelation",
+			"input", input,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.CheckRelation(ctx, object, relation, subject, tenantID)
+	return output, err
+}
+
+// ExpandRelation is a logging middleware wrapper over another interface implementation of ExpandRelation.
+func (mw loggingMiddleware) ExpandRelation(ctx context.Context, object, relation, tenantID string) (output *rebac.Tree, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s}", object, relation, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "ExpandRelation",
+			"input", input,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.ExpandRelation(ctx, object, relation, tenantID)
+	return output, err
+}
+
+// ListObjects is a logging middleware wrapper over another interface implementation of ListObjects.
+func (mw loggingMiddleware) ListObjects(ctx context.Context, namespace, relation, subject, tenantID string) (output []string, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %s, %s}", namespace, relation, subject, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "ListObjects",
+			"input", input,
+			"output", output,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.ListObjects(ctx, namespace, relation, subject, tenantID)
+	return output, err
+}
+
+// ResolveTenant is a logging middleware wrapper over another interface implementation of ResolveTenant.
+func (mw loggingMiddleware) ResolveTenant(ctx context.Context, kind, key string) (output *m.Tenant, err error) {
+	defer func(begin time.Time) {
+		logged := key
+		if kind == TenantByToken {
+			logged = "********"
+		}
+		input := fmt.Sprintf("{%s, %s}", kind, logged)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "ResolveTenant",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.ResolveTenant(ctx, kind, key)
+	return output, err
+}
+
+// CreateTenant is a logging middleware wrapper over another interface implementation of CreateTenant.
+func (mw loggingMiddleware) CreateTenant(ctx context.Context, slug, name string, domains []string, settings m.TenantSettings, tenantID string) (output *m.Tenant, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %v, %+v, %s}", slug, name, domains, settings, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "CreateTenant",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.CreateTenant(ctx, slug, name, domains, settings, tenantID)
+	return output, err
+}
+
+// GetTenants is a logging middleware wrapper over another interface implementation of GetTenants.
+func (mw loggingMiddleware) GetTenants(ctx context.Context, tenantID string) (output []m.Tenant, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s}", tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "GetTenants",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.GetTenants(ctx, tenantID)
+	return output, err
+}
+
+// GetTenant is a logging middleware wrapper over another interface implementation of GetTenant.
+func (mw loggingMiddleware) GetTenant(ctx context.Context, slug, tenantID string) (output *m.Tenant, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s}", slug, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "GetTenant",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.GetTenant(ctx, slug, tenantID)
+	return output, err
+}
+
+// UpdateTenant is a logging middleware wrapper over another interface implementation of UpdateTenant.
+func (mw loggingMiddleware) UpdateTenant(ctx context.Context, slug, name string, domains []string, status string, settings m.TenantSettings, tenantID string) (output *m.Tenant, err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s, %v, %s, %+v, %s}", slug, name, domains, status, settings, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "UpdateTenant",
+			"input", input,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.next.UpdateTenant(ctx, slug, name, domains, status, settings, tenantID)
+	return output, err
+}
+
+// DeleteTenant is a logging middleware wrapper over another interface implementation of DeleteTenant.
+func (mw loggingMiddleware) DeleteTenant(ctx context.Context, slug, tenantID string) (err error) {
+	defer func(begin time.Time) {
+		input := fmt.Sprintf("{%s, %s}", slug, tenantID)
+		mw.logger.Log(
+			"level", c.LogLevel.Info,
+			"method", "DeleteTenant",
 			"input", input,
 			"err", err,
 			"took", time.Since(begin),
 		)
 	}(time.Now())
 
-	return mw.next.Remove(username, email, tenantID)
+	return mw.next.DeleteTenant(ctx, slug, tenantID)
 }
 
 // Remove is a logging middleware wrapper over another interface implementation of Remove.
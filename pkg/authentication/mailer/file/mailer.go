@@ -0,0 +1,54 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	c "gitlab.com/mikrowezel/backend/granica/internal/config"
+)
+
+// Mailer is a file implementation of Mailer interface.
+// Messages are appended to a file, or written to the log if no file
+// is configured. Meant for development and tests.
+type Mailer struct {
+	mu     sync.Mutex
+	path   string
+	from   string
+	logger log.Logger
+}
+
+// NewMailer makes a new file mailer.
+func NewMailer(ctx context.Context, cfg *config.Config, logger log.Logger) (*Mailer, error) {
+	return &Mailer{
+		path:   cfg.Mailer.FilePath,
+		from:   cfg.Mailer.From,
+		logger: logger,
+	}, nil
+}
+
+// Send a message.
+func (m *Mailer) Send(to, subject, body string) error {
+	if m.path == "" {
+		m.logger.Log("level", c.LogLevel.Info, "msg", "Mail sent", "from", m.from, "to", to, "subject", subject, "body", body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "From: %s\nTo: %s\nSubject: %s\nDate: %s\n\n%s\n\n",
+		m.from, to, subject, time.Now().Format(time.RFC1123Z), body)
+	return err
+}
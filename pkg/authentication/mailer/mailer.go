@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/log"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/mailer/file"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/mailer/smtp"
+)
+
+// Mailer interface
+// Messages are sent as plain text.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer makes a new mailer.
+func NewMailer(ctx context.Context, cfg *config.Config, logger log.Logger) (Mailer, error) {
+
+	if cfg.Mailer.Type == "smtp" {
+		return smtp.NewMailer(ctx, cfg, logger)
+
+	} else if cfg.Mailer.Type == "file" {
+		return file.NewMailer(ctx, cfg, logger)
+	}
+
+	return nil, errors.New("not a valid mailer type")
+}
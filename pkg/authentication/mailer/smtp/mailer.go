@@ -0,0 +1,59 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+)
+
+// Mailer is a SMTP implementation of Mailer interface.
+// It authenticates with PLAIN auth when a user is configured,
+// the connection is upgraded with STARTTLS if the server supports it.
+type Mailer struct {
+	ctx  context.Context
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewMailer makes a new SMTP mailer.
+func NewMailer(ctx context.Context, cfg *config.Config, logger log.Logger) (*Mailer, error) {
+	var auth smtp.Auth
+	if cfg.Mailer.SMTP.User != "" {
+		auth = smtp.PlainAuth("", cfg.Mailer.SMTP.User, cfg.Mailer.SMTP.Password, cfg.Mailer.SMTP.Host)
+	}
+
+	return &Mailer{
+		ctx:  ctx,
+		addr: fmt.Sprintf("%s:%d", cfg.Mailer.SMTP.Host, cfg.Mailer.SMTP.Port),
+		from: cfg.Mailer.From,
+		auth: auth,
+	}, nil
+}
+
+// Send a message.
+func (m *Mailer) Send(to, subject, body string) error {
+	// Headers must not be injected through the recipient or the subject.
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return errors.New("invalid message header")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(b.String()))
+}
@@ -0,0 +1,321 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const (
+	mfaChallengeAction = "mfa_challenge"
+	mfaEnrollAction    = "mfa_enroll"
+	recoveryCodesCount = 10
+	recoveryCodeLen    = 10
+)
+
+var errMFANotConfigured = errors.New("MFA not configured")
+
+// MFAEnrollment holds what the user needs to set up an authenticator app.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// mfaChallenge returns the token a user signing in with a valid password
+// exchanges for a session along with a second factor.
+// Users required to have a second factor that have not enrolled one yet
+// are only given a token to enroll it.
+func (gs granicaService) mfaChallenge(user *m.User, action string) (*AuthToken, error) {
+	ttl := gs.cfg.Auth.MFAChallengeTTL
+	token, err := gs.tokens.IssueAction(ActionClaims{
+		Action:   action,
+		TenantID: user.TenantID,
+		StandardClaims: jwt.StandardClaims{
+			Subject: user.ID.String(),
+		},
+	}, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthToken{
+		ExpiresIn:     int64(ttl.Seconds()),
+		MFAToken:      token,
+		MFAEnrollment: action == mfaEnrollAction,
+	}, nil
+}
+
+// parseMFAToken returns the user a MFA token for the action was issued to.
+func (gs granicaService) parseMFAToken(ctx context.Context, token, action, tenantID string) (*m.User, *ActionClaims, error) {
+	claims, err := gs.tokens.ParseAction(token, action)
+	if err != nil || claims.TenantID != tenantID {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	revoked, err := gs.sessions.IsTokenRevoked(ctx, claims.Id)
+	if err != nil || revoked {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	user, err := gs.users(tenantID).Get(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	return user, claims, nil
+}
+
+// mfaUser returns the user enrolling a second factor.
+// Either an access token or an enrollment token is accepted.
+func (gs granicaService) mfaUser(ctx context.Context, token, tenantID string) (*m.User, error) {
+	user, err := gs.authenticatedUser(ctx, token, tenantID)
+	if err == nil {
+		return user, nil
+	}
+
+	user, _, err = gs.parseMFAToken(ctx, token, mfaEnrollAction, tenantID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	return user, nil
+}
+
+// enrollMFA generates a new TOTP secret for the user.
+// It is not required at sign in until confirmed with a first code.
+func (gs granicaService) enrollMFA(ctx context.Context, user *m.User) (*MFAEnrollment, error) {
+	if user.IsMFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	box, err := gs.mfaBox()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := genTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := box.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFASecret = sealed
+	user.MFALastStep = 0
+	user.SetUpdateValues(user.ID)
+
+	err = gs.users(user.TenantID).Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    totpURI(gs.cfg.Auth.MFAIssuer, user.Username, secret),
+	}, nil
+}
+
+// confirmMFA enables the second factor once the user proves the authenticator
+// app was set up, and returns the recovery codes.
+// Recovery codes are only shown here, just their digests are stored.
+func (gs granicaService) confirmMFA(ctx context.Context, user *m.User, code string) ([]string, error) {
+	if user.IsMFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if user.MFASecret == "" {
+		return nil, ErrMFANotEnabled
+	}
+
+	secret, err := gs.openMFASecret(user)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := validateTOTP(secret, code, user.MFALastStep, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, digests, err := genRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.IsMFAEnabled = true
+	user.MFALastStep = step
+	user.RecoveryCodes = digests
+	user.SetUpdateValues(user.ID)
+
+	err = gs.users(user.TenantID).Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// verifyMFA opens a session for the user the challenge was issued to
+// if the code is a valid TOTP or an unused recovery code.
+// Challenges are single use once passed. Wrong codes count as failed
+// attempts of the account and the remote IP.
+func (gs granicaService) verifyMFA(ctx context.Context, mfaToken, code, remoteIP, tenantID string) (*AuthToken, error) {
+	user, claims, err := gs.parseMFAToken(ctx, mfaToken, mfaChallengeAction, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gs.checkLockout(ctx, user.Username, remoteIP, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := gs.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		gs.recordFailure(ctx, user.Username, remoteIP, tenantID)
+		return nil, ErrInvalidMFACode
+	}
+
+	err = gs.sessions.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	gs.recordSuccess(ctx, user.Username, tenantID)
+
+	return gs.startSession(ctx, user, "", "")
+}
+
+// disableMFA removes the second factor of the user.
+// Users required to have one cannot disable it.
+func (gs granicaService) disableMFA(ctx context.Context, user *m.User, code string) error {
+	if !user.IsMFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if gs.requiresMFA(ctx, user) {
+		return ErrMFARequired
+	}
+
+	ok, err := gs.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	user.IsMFAEnabled = false
+	user.MFASecret = ""
+	user.MFALastStep = 0
+	user.RecoveryCodes = nil
+	user.SetUpdateValues(user.ID)
+
+	return gs.users(user.TenantID).Update(ctx, user)
+}
+
+// checkSecondFactor - True if the code is a valid TOTP or an unused recovery code.
+// Accepted TOTP codes cannot be replayed and recovery codes are consumed.
+func (gs granicaService) checkSecondFactor(ctx context.Context, user *m.User, code string) (bool, error) {
+	if !user.IsMFAEnabled {
+		return false, nil
+	}
+
+	secret, err := gs.openMFASecret(user)
+	if err != nil {
+		return false, err
+	}
+
+	// Codes are only accepted if still unused when stored, so that
+	// concurrent requests cannot use the same one twice.
+	if step, ok := validateTOTP(secret, code, user.MFALastStep, time.Now()); ok {
+		user.MFALastStep = step
+		user.SetUpdateValues(user.ID)
+		return gs.users(user.TenantID).UseMFAStep(ctx, user, step)
+	}
+
+	digest := tokenDigest(normalizeRecoveryCode(code))
+	for i, rc := range user.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(rc), []byte(digest)) == 1 {
+			user.RecoveryCodes = append(user.RecoveryCodes[:i], user.RecoveryCodes[i+1:]...)
+			user.SetUpdateValues(user.ID)
+			return gs.users(user.TenantID).UseRecoveryCode(ctx, user, digest)
+		}
+	}
+
+	return false, nil
+}
+
+// openMFASecret decrypts the TOTP secret of the user.
+func (gs granicaService) openMFASecret(user *m.User) (string, error) {
+	box, err := gs.mfaBox()
+	if err != nil {
+		return "", err
+	}
+
+	return box.Open(user.MFASecret)
+}
+
+// mfaBox returns the box TOTP secrets are sealed with.
+func (gs granicaService) mfaBox() (*secretBox, error) {
+	if gs.cfg.Auth.MFAKey == "" {
+		return nil, errMFANotConfigured
+	}
+
+	return newSecretBox(gs.cfg.Auth.MFAKey)
+}
+
+// genRecoveryCodes returns a new set of recovery codes along with their digests.
+func genRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodesCount)
+	digests := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		b := make([]byte, recoveryCodeLen)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		raw := strings.ToLower(b32.EncodeToString(b))[:recoveryCodeLen]
+		codes[i] = raw[:recoveryCodeLen/2] + "-" + raw[recoveryCodeLen/2:]
+		digests[i] = tokenDigest(raw)
+	}
+
+	return codes, digests, nil
+}
+
+// normalizeRecoveryCode removes separators and case so that codes can be
+// typed the way they are shown or not.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.Replace(code, "-", "", -1)
+	return strings.Replace(code, " ", "", -1)
+}
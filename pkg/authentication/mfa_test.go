@@ -0,0 +1,279 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 test vector truncated to 6 digits.
+	secret := b32.EncodeToString([]byte("12345678901234567890"))
+
+	code, err := totpCode(secret, totpStep(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != "287082" {
+		t.Errorf("Code: '%s' | Expected: '287082'", code)
+	}
+
+	if _, ok := validateTOTP(secret, code, 0, time.Unix(59, 0)); !ok {
+		t.Error("expected code to be valid")
+	}
+
+	if _, ok := validateTOTP(secret, code, 1, time.Unix(59, 0)); ok {
+		t.Error("expected code of an already used step to be rejected")
+	}
+
+	if _, ok := validateTOTP(secret, code, 0, time.Unix(59+3*totpPeriod, 0)); ok {
+		t.Error("expected code out of the skew window to be rejected")
+	}
+}
+
+func TestSecretBox(t *testing.T) {
+	box, err := newSecretBox("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := box.Seal("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(sealed, "secret") {
+		t.Fatal("secret not encrypted")
+	}
+
+	opened, err := box.Open(sealed)
+	if err != nil || opened != "secret" {
+		t.Fatalf("Opened: '%s', %v | Expected: 'secret'", opened, err)
+	}
+
+	if _, err := newSecretBox("c2hvcnQ="); err == nil {
+		t.Error("expected short key to be rejected")
+	}
+}
+
+// enrollTestMFA enrolls and confirms a second factor for the signed in user
+// returning its TOTP secret and recovery codes.
+func enrollTestMFA(ctx context.Context, t *testing.T, svc *granicaService, token string) (string, []string) {
+	enrollment, err := svc.EnrollMFA(ctx, token, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(enrollment.URI)
+	if err != nil || u.Scheme != "otpauth" || u.Query().Get("secret") != enrollment.Secret {
+		t.Fatalf("Provisioning URI: '%s'", enrollment.URI)
+	}
+
+	code, err := totpCode(enrollment.Secret, totpStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := svc.ConfirmMFA(ctx, token, code, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codes) != recoveryCodesCount {
+		t.Fatalf("Recovery codes: %d | Expected: %d", len(codes), recoveryCodesCount)
+	}
+
+	return enrollment.Secret, codes
+}
+
+func TestMFASignIn(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, codes := enrollTestMFA(ctx, t, svc, token.AccessToken)
+
+	user, _ := svc.repo.GetByUsernameAndTenant(ctx, "username", "localhost")
+	if strings.Contains(user.MFASecret, secret) {
+		t.Fatal("TOTP secret stored in plain text")
+	}
+
+	challenge, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if challenge.MFAToken == "" || challenge.AccessToken != "" || challenge.RefreshToken != "" {
+		t.Fatalf("Sign in: %+v | Expected: MFA challenge only", challenge)
+	}
+
+	// Challenges are not access tokens.
+	if _, err := svc.authenticate(context.Background(), challenge.MFAToken, "localhost"); err == nil {
+		t.Fatal("expected MFA token to be rejected as access token")
+	}
+
+	// The code used to confirm the enrollment cannot be replayed.
+	used, _ := totpCode(secret, user.MFALastStep)
+	if _, err := svc.VerifyMFA(ctx, challenge.MFAToken, used, "", "localhost"); err != ErrInvalidMFACode {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrInvalidMFACode)
+	}
+
+	next, _ := totpCode(secret, totpStep(time.Now())+1)
+	signedIn, err := svc.VerifyMFA(ctx, challenge.MFAToken, next, "", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.authenticate(context.Background(), signedIn.AccessToken, "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+
+	// Challenges are single use.
+	if _, err := svc.VerifyMFA(ctx, challenge.MFAToken, codes[0], "", "localhost"); err != ErrInvalidMFAToken {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrInvalidMFAToken)
+	}
+
+	// Recovery codes work once.
+	challenge, _ = svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if _, err := svc.VerifyMFA(ctx, challenge.MFAToken, strings.ToUpper(codes[0]), "", "localhost"); err != nil {
+		t.Fatalf("Error: %v | Expected: nil", err)
+	}
+
+	challenge, _ = svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if _, err := svc.VerifyMFA(ctx, challenge.MFAToken, codes[0], "", "localhost"); err != ErrInvalidMFACode {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrInvalidMFACode)
+	}
+
+	if err := svc.DisableMFA(ctx, signedIn.AccessToken, codes[1], "localhost"); err != nil {
+		t.Fatalf("Error: %v | Expected: nil", err)
+	}
+
+	token, err = svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil || token.AccessToken == "" {
+		t.Fatalf("Sign in: %+v, %v | Expected: access token", token, err)
+	}
+}
+
+func TestSecondFactorReplay(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, codes := enrollTestMFA(ctx, t, svc, token.AccessToken)
+	next, _ := totpCode(secret, totpStep(time.Now())+1)
+
+	// Concurrent requests read the user before either of them stores the used code.
+	for _, code := range []string{next, codes[0]} {
+		first, _ := svc.repo.GetByUsernameAndTenant(ctx, "username", "localhost")
+		second, _ := svc.repo.GetByUsernameAndTenant(ctx, "username", "localhost")
+
+		if ok, err := svc.checkSecondFactor(ctx, first, code); !ok || err != nil {
+			t.Fatalf("First use: %t, %v | Expected: true", ok, err)
+		}
+
+		if ok, err := svc.checkSecondFactor(ctx, second, code); ok || err != nil {
+			t.Errorf("Replay: %t, %v | Expected: false", ok, err)
+		}
+	}
+}
+
+func TestMFARequired(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	user, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user.RequireMFA = true
+	svc.repo.Update(ctx, user)
+
+	challenge, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !challenge.MFAEnrollment || challenge.AccessToken != "" {
+		t.Fatalf("Sign in: %+v | Expected: MFA enrollment only", challenge)
+	}
+
+	// Enrollment tokens do not pass the second factor.
+	if _, err := svc.VerifyMFA(ctx, challenge.MFAToken, "000000", "", "localhost"); err != ErrInvalidMFAToken {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrInvalidMFAToken)
+	}
+
+	_, codes := enrollTestMFA(ctx, t, svc, challenge.MFAToken)
+
+	challenge, err = svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil || challenge.MFAEnrollment || challenge.MFAToken == "" {
+		t.Fatalf("Sign in: %+v, %v | Expected: MFA challenge", challenge, err)
+	}
+
+	signedIn, err := svc.VerifyMFA(ctx, challenge.MFAToken, codes[0], "", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.DisableMFA(ctx, signedIn.AccessToken, codes[1], "localhost"); err != ErrMFARequired {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrMFARequired)
+	}
+}
+
+func TestAuthorizeRequiresSecondFactor(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	ar := authorizeTestClient(ctx, t, svc)
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, codes := enrollTestMFA(ctx, t, svc, token.AccessToken)
+
+	if _, err := svc.Authorize(ctx, ar, "username", "password", "", true); err != ErrUnauthorized {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrUnauthorized)
+	}
+
+	redirectURL, err := svc.Authorize(ctx, ar, "username", "password", codes[0], true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := redirectParams(t, redirectURL).Get("code"); code == "" {
+		t.Errorf("Redirect: '%s' | Expected: code", redirectURL)
+	}
+}
@@ -0,0 +1,397 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// OAuth error codes (RFC 6749).
+const (
+	errInvalidRequest          = "invalid_request"
+	errInvalidClient           = "invalid_client"
+	errInvalidGrant            = "invalid_grant"
+	errInvalidScope            = "invalid_scope"
+	errUnauthorizedClient      = "unauthorized_client"
+	errUnsupportedGrantType    = "unsupported_grant_type"
+	errUnsupportedResponseType = "unsupported_response_type"
+	errAccessDenied            = "access_denied"
+	errServerError             = "server_error"
+)
+
+const (
+	codeResponseType       = "code"
+	authorizationCodeGrant = "authorization_code"
+	refreshTokenGrant      = "refresh_token"
+	clientCredentialsGrant = "client_credentials"
+	pkceS256               = "S256"
+	minCodeVerifierLen     = 43
+	maxCodeVerifierLen     = 128
+)
+
+// OAuthError is an OAuth 2.0 error response.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+func oauthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// toOAuthError returns request errors as they are
+// and hides any other one behind a server error.
+func toOAuthError(err error) *OAuthError {
+	oerr, ok := err.(*OAuthError)
+	if !ok {
+		return oauthError(errServerError, "")
+	}
+	return oerr
+}
+
+// AuthorizationRequest holds the parameters of an authorization endpoint request.
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+	RemoteIP            string
+	TenantID            string
+}
+
+// TokenRequest holds the parameters of a token endpoint request.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	ClientID     string
+	ClientSecret string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	TenantID     string
+}
+
+// defaultGrantTypes are the grant types clients registered without any are allowed.
+var defaultGrantTypes = []string{authorizationCodeGrant, refreshTokenGrant}
+
+// checkGrantTypes validates the grant types a client is registered with.
+func checkGrantTypes(grantTypes, redirectURIs []string, confidential bool) *OAuthError {
+	for _, gt := range grantTypes {
+		switch gt {
+		case authorizationCodeGrant:
+			if len(redirectURIs) == 0 {
+				return oauthError(errInvalidRequest, "redirect URIs required")
+			}
+		case refreshTokenGrant:
+		case clientCredentialsGrant:
+			if !confidential {
+				return oauthError(errInvalidRequest, "client credentials grant requires a confidential client")
+			}
+		default:
+			return oauthError(errInvalidRequest, fmt.Sprintf("grant type '%s' not supported", gt))
+		}
+	}
+	return nil
+}
+
+// authenticateClient authenticates the client of a token request.
+// The client must be allowed the requested grant type.
+func (gs granicaService) authenticateClient(ctx context.Context, tr TokenRequest) (*m.Client, error) {
+	client, err := gs.verifyClient(ctx, tr.ClientID, tr.ClientSecret, tr.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.AllowsGrant(tr.GrantType) {
+		return nil, oauthError(errUnauthorizedClient, "")
+	}
+
+	return client, nil
+}
+
+// verifyClient checks the credentials of an active client of the tenant.
+// Confidential clients must present their secret and public clients must not
+// present any.
+func (gs granicaService) verifyClient(ctx context.Context, clientID, clientSecret, tenantID string) (*m.Client, error) {
+	client, err := gs.clientRepo.GetByClientIDAndTenant(ctx, clientID, tenantID)
+	if err != nil || !client.IsActive {
+		return nil, oauthError(errInvalidClient, "")
+	}
+
+	if client.IsConfidential() {
+		if clientSecret == "" || !passwordMatches(client.SecretDigest, clientSecret) {
+			return nil, oauthError(errInvalidClient, "")
+		}
+	} else if clientSecret != "" {
+		return nil, oauthError(errInvalidClient, "")
+	}
+
+	return client, nil
+}
+
+// genClientSecret returns a new client secret along with its digest.
+func genClientSecret() (string, string, error) {
+	secret, err := genOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	digest, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, string(digest), nil
+}
+
+// authorizationRedirect resolves the client and the redirect URI of an
+// authorization request. Errors at this stage must be shown to the user
+// and never redirected to the client.
+func (gs granicaService) authorizationRedirect(ctx context.Context, ar AuthorizationRequest) (*m.Client, string, error) {
+	client, err := gs.clientRepo.GetByClientIDAndTenant(ctx, ar.ClientID, ar.TenantID)
+	if err != nil || !client.IsActive {
+		return nil, "", oauthError(errInvalidRequest, "unknown client")
+	}
+
+	if ar.RedirectURI == "" && len(client.RedirectURIs) == 1 {
+		return client, client.RedirectURIs[0], nil
+	}
+
+	if !client.HasRedirectURI(ar.RedirectURI) {
+		return nil, "", oauthError(errInvalidRequest, "redirect URI not registered")
+	}
+
+	return client, ar.RedirectURI, nil
+}
+
+// checkAuthorization validates the parameters of an authorization request
+// whose errors are reported back to the client and returns the granted scope.
+// Authorization codes are only issued to requests carrying a S256 PKCE challenge.
+func checkAuthorization(client *m.Client, ar AuthorizationRequest) (string, *OAuthError) {
+	if ar.ResponseType != codeResponseType {
+		return "", oauthError(errUnsupportedResponseType, "")
+	}
+
+	if !client.AllowsGrant(authorizationCodeGrant) {
+		return "", oauthError(errUnauthorizedClient, "")
+	}
+
+	if ar.CodeChallenge == "" {
+		return "", oauthError(errInvalidRequest, "code challenge required")
+	}
+
+	if ar.CodeChallengeMethod != pkceS256 {
+		return "", oauthError(errInvalidRequest, "transform algorithm not supported")
+	}
+
+	return grantScope(client, ar.Scope)
+}
+
+// grantScope returns the scope granted to the client for the requested one.
+// If no scope is requested all the scopes the client is allowed are granted.
+func grantScope(client *m.Client, requested string) (string, *OAuthError) {
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		return strings.Join(client.Scopes, " "), nil
+	}
+
+	for _, s := range scopes {
+		if !client.AllowsScope(s) {
+			return "", oauthError(errInvalidScope, fmt.Sprintf("scope '%s' not allowed", s))
+		}
+	}
+
+	return strings.Join(scopes, " "), nil
+}
+
+// newAuthorizationCode generates an authorization code for the user.
+// The opaque value is returned to be handed to the client, the model only
+// keeps its digest.
+func (gs granicaService) newAuthorizationCode(user *m.User, client *m.Client, ar AuthorizationRequest, scope string) (string, *m.AuthorizationCode, error) {
+	raw, err := genOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	code := &m.AuthorizationCode{
+		Digest:              tokenDigest(raw),
+		ClientID:            client.ClientID,
+		UserID:              user.ID,
+		TenantID:            user.TenantID,
+		RedirectURI:         ar.RedirectURI,
+		Scope:               scope,
+		CodeChallenge:       ar.CodeChallenge,
+		CodeChallengeMethod: ar.CodeChallengeMethod,
+		Nonce:               ar.Nonce,
+		AuthTime:            now,
+		ExpiresAt:           now.Add(gs.cfg.Token.CodeTTL),
+	}
+
+	return raw, code, nil
+}
+
+// exchangeCode redeems an authorization code issued to the client
+// for an access and a refresh token, and an ID token if the openid scope
+// was granted.
+// Codes are consumed on first use whatever the outcome of the exchange.
+func (gs granicaService) exchangeCode(ctx context.Context, client *m.Client, tr TokenRequest) (*AuthToken, error) {
+	if tr.Code == "" || tr.CodeVerifier == "" {
+		return nil, oauthError(errInvalidRequest, "code and code verifier required")
+	}
+
+	code, err := gs.sessions.ConsumeCode(ctx, tokenDigest(tr.Code))
+	if err != nil {
+		return nil, oauthError(errInvalidGrant, "invalid authorization code")
+	}
+
+	if code.IsExpired() || code.TenantID != client.TenantID || code.ClientID != client.ClientID {
+		return nil, oauthError(errInvalidGrant, "invalid authorization code")
+	}
+
+	// Redirect URI must be the same presented to the authorization endpoint, if any.
+	if code.RedirectURI != tr.RedirectURI {
+		return nil, oauthError(errInvalidGrant, "redirect URI mismatch")
+	}
+
+	if !verifyCodeChallenge(tr.CodeVerifier, code.CodeChallenge) {
+		return nil, oauthError(errInvalidGrant, "code verifier mismatch")
+	}
+
+	user, err := gs.users(code.TenantID).Get(ctx, code.UserID)
+	if err != nil {
+		return nil, oauthError(errInvalidGrant, "invalid authorization code")
+	}
+
+	token, err := gs.startSession(ctx, user, client.ClientID, code.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasScope(code.Scope, openIDScope) {
+		token.IDToken, err = gs.idToken(user, client.ClientID, code.Scope, code.Nonce, code.AuthTime, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return token, nil
+}
+
+// issueClientToken issues an access token to a client acting on its own behalf.
+// No refresh token is issued, the client can always request a new access token.
+func (gs granicaService) issueClientToken(client *m.Client, tr TokenRequest) (*AuthToken, error) {
+	scope, oerr := grantScope(client, tr.Scope)
+	if oerr != nil {
+		return nil, oerr
+	}
+
+	return gs.tokens.Issue(clientClaims(client, scope))
+}
+
+// verifyCodeChallenge - True if the verifier matches a S256 PKCE challenge (RFC 7636).
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < minCodeVerifierLen || len(verifier) > maxCodeVerifierLen {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// redirectWith returns the redirect URI with the params added to its query.
+func redirectWith(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	q := u.Query()
+	for k, vs := range params {
+		for _, v := range vs {
+			if v != "" {
+				q.Set(k, v)
+			}
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// codeRedirect returns the redirect URI carrying an authorization code.
+func codeRedirect(redirectURI, code, state string) string {
+	return redirectWith(redirectURI, url.Values{"code": {code}, "state": {state}})
+}
+
+// errorRedirect returns the redirect URI carrying an authorization error.
+func errorRedirect(redirectURI, state string, e *OAuthError) string {
+	return redirectWith(redirectURI, url.Values{
+		"error":             {e.Code},
+		"error_description": {e.Description},
+		"state":             {state},
+	})
+}
+
+// validRedirectURI - True if the URI can be registered as a client redirect URI.
+// It must be absolute and must not include a fragment. Only https, http on
+// the loopback interface (RFC 8252) and the private-use schemes allowed for
+// native apps can be used, so that clients are never redirected to scripts
+// or inline documents as javascript: or data: URIs are.
+func validRedirectURI(uri string, schemes []string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() || u.Fragment != "" {
+		return false
+	}
+
+	switch u.Scheme {
+	case "https":
+		return u.Host != ""
+	case "http":
+		return isLoopback(u.Hostname())
+	}
+
+	for _, s := range schemes {
+		if strings.EqualFold(u.Scheme, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoopback - True if the host is the loopback interface.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,392 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func testChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authorizeTestClient signs a user up, registers a client and returns
+// an authorization request for it.
+func authorizeTestClient(ctx context.Context, t *testing.T, svc *granicaService) AuthorizationRequest {
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _, err := svc.RegisterClient(ctx, "spa", []string{"https://app.granica.dev/callback"}, []string{"profile", "email"}, nil, false, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return AuthorizationRequest{
+		ResponseType:        codeResponseType,
+		ClientID:            client.ClientID,
+		RedirectURI:         "https://app.granica.dev/callback",
+		Scope:               "profile",
+		State:               "xyz",
+		CodeChallenge:       testChallenge(testVerifier),
+		CodeChallengeMethod: pkceS256,
+		TenantID:            "localhost",
+	}
+}
+
+func redirectParams(t *testing.T, redirectURL string) url.Values {
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func TestAuthorizationCodeWithPKCE(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	ar := authorizeTestClient(ctx, t, svc)
+
+	redirectURL, err := svc.Authorize(ctx, ar, "username", "password", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := redirectParams(t, redirectURL)
+	if params.Get("state") != "xyz" || params.Get("code") == "" {
+		t.Fatalf("Redirect: '%s' | Expected: code and state", redirectURL)
+	}
+
+	tr := TokenRequest{
+		GrantType:    authorizationCodeGrant,
+		Code:         params.Get("code"),
+		RedirectURI:  ar.RedirectURI,
+		ClientID:     ar.ClientID,
+		CodeVerifier: testVerifier,
+		TenantID:     "localhost",
+	}
+
+	token, err := svc.Token(ctx, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.Scope != "profile" || token.RefreshToken == "" {
+		t.Errorf("Token: %+v | Expected: scope 'profile' and a refresh token", token)
+	}
+
+	claims, err := svc.tokens.Parse(token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.ClientID != ar.ClientID || claims.Scope != "profile" {
+		t.Errorf("Claims: %+v | Expected: client '%s' and scope 'profile'", claims, ar.ClientID)
+	}
+
+	// Codes can only be used once.
+	if _, err := svc.Token(ctx, tr); !isOAuthError(err, errInvalidGrant) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidGrant)
+	}
+
+	// Refresh tokens issued to a client can only be used by it.
+	other, _, err := svc.RegisterClient(ctx, "other", []string{"https://other.granica.dev/callback"}, nil, nil, false, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := TokenRequest{GrantType: refreshTokenGrant, RefreshToken: token.RefreshToken, ClientID: other.ClientID, TenantID: "localhost"}
+	if _, err := svc.Token(ctx, rt); !isOAuthError(err, errInvalidGrant) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidGrant)
+	}
+
+	rt.ClientID = ar.ClientID
+	refreshed, err := svc.Token(ctx, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if refreshed.Scope != "profile" {
+		t.Errorf("Scope: '%s' | Expected: 'profile'", refreshed.Scope)
+	}
+}
+
+func TestAuthorizationCodeWrongVerifier(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	ar := authorizeTestClient(ctx, t, svc)
+
+	redirectURL, err := svc.Authorize(ctx, ar, "username", "password", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := TokenRequest{
+		GrantType:    authorizationCodeGrant,
+		Code:         redirectParams(t, redirectURL).Get("code"),
+		RedirectURI:  ar.RedirectURI,
+		ClientID:     ar.ClientID,
+		CodeVerifier: "qZ7sGmDp2c9b3Lw1xRk8vYt4NhUe6JfA0oBiCqWzX5M",
+		TenantID:     "localhost",
+	}
+
+	if _, err := svc.Token(ctx, tr); !isOAuthError(err, errInvalidGrant) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidGrant)
+	}
+}
+
+func TestAuthorizeRejections(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	ar := authorizeTestClient(ctx, t, svc)
+
+	// Unregistered redirect URIs are never redirected to.
+	bad := ar
+	bad.RedirectURI = "https://evil.dev/callback"
+	if _, err := svc.Authorize(ctx, bad, "username", "password", "", true); !isOAuthError(err, errInvalidRequest) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidRequest)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(ar *AuthorizationRequest)
+		consent bool
+		err     string
+	}{
+		{"no challenge", func(ar *AuthorizationRequest) { ar.CodeChallenge = "" }, true, errInvalidRequest},
+		{"plain challenge", func(ar *AuthorizationRequest) { ar.CodeChallengeMethod = "plain" }, true, errInvalidRequest},
+		{"scope not allowed", func(ar *AuthorizationRequest) { ar.Scope = "admin" }, true, errInvalidScope},
+		{"consent denied", func(ar *AuthorizationRequest) {}, false, errAccessDenied},
+	}
+
+	for _, tt := range tests {
+		req := ar
+		tt.modify(&req)
+
+		redirectURL, err := svc.Authorize(ctx, req, "username", "password", "", tt.consent)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		params := redirectParams(t, redirectURL)
+		if params.Get("error") != tt.err || params.Get("state") != "xyz" {
+			t.Errorf("%s: redirect '%s' | Expected error: '%s'", tt.name, redirectURL, tt.err)
+		}
+	}
+
+	if _, err := svc.Authorize(ctx, ar, "username", "wrong", "", true); err != ErrUnauthorized {
+		t.Errorf("Error: %v | Expected: %v", err, ErrUnauthorized)
+	}
+}
+
+func TestValidRedirectURI(t *testing.T) {
+	schemes := []string{"dev.granica.app"}
+
+	tests := []struct {
+		uri   string
+		valid bool
+	}{
+		{"https://app.granica.dev/callback", true},
+		{"http://127.0.0.1:8400/callback", true},
+		{"http://[::1]:8400/callback", true},
+		{"http://localhost/callback", true},
+		{"dev.granica.app:/callback", true},
+		{"http://app.granica.dev/callback", false},
+		{"https://app.granica.dev/callback#state", false},
+		{"https:/callback", false},
+		{"/callback", false},
+		{"javascript:alert(document.cookie)", false},
+		{"data:text/html,<script>alert(1)</script>", false},
+		{"com.evil.app:/callback", false},
+	}
+
+	for _, tt := range tests {
+		if valid := validRedirectURI(tt.uri, schemes); valid != tt.valid {
+			t.Errorf("URI: '%s' | Valid: %t | Expected: %t", tt.uri, valid, tt.valid)
+		}
+	}
+}
+
+func TestRegisterClientHandlerRequiresPermission(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	user, err := svc.SignUp(ctx, "admin", "password", "admin@granica.dev", "admin@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc.cfg.Auth.Admins = []string{user.ID.String()}
+
+	admin, err := svc.SignIn(ctx, "admin", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := RegisterClientHandler(svc)
+
+	tests := []struct {
+		token  string
+		status int
+	}{
+		{"", http.StatusUnauthorized},
+		{other.AccessToken, http.StatusForbidden},
+		{admin.AccessToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost/oauth/clients", strings.NewReader(`{"name":"spa","redirectURIs":["https://app.granica.dev/callback"]}`))
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("Status: %d | Expected: %d", rec.Code, tt.status)
+		}
+
+		var res registerClientResponse
+		json.NewDecoder(rec.Body).Decode(&res)
+		if tt.status != http.StatusOK {
+			if res.Client != nil {
+				t.Errorf("Client: %+v | Expected: none", res.Client)
+			}
+			continue
+		}
+
+		if res.Client == nil {
+			t.Fatal("Client: none | Expected: registered by the admin")
+		}
+
+		if _, err := svc.clientRepo.GetByClientIDAndTenant(ctx, res.Client.ClientID, "localhost"); err != nil {
+			t.Errorf("Client '%s' not stored: %v", res.Client.ClientID, err)
+		}
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	client, secret, err := svc.RegisterClient(ctx, "worker", nil, []string{"users:read", "users:write"}, []string{clientCredentialsGrant}, true, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if secret == "" || client.SecretDigest == secret {
+		t.Fatal("confidential client must be issued a secret and only store its digest")
+	}
+
+	tr := TokenRequest{
+		GrantType:    clientCredentialsGrant,
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		Scope:        "users:read",
+		TenantID:     "localhost",
+	}
+
+	token, err := svc.Token(ctx, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.RefreshToken != "" || token.Scope != "users:read" {
+		t.Errorf("Token: %+v | Expected: scope 'users:read' and no refresh token", token)
+	}
+
+	i, err := svc.Introspect(ctx, token.AccessToken, "", client.ClientID, secret, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !i.Active || i.Sub != client.ClientID || i.ClientID != client.ClientID {
+		t.Errorf("Introspection: %+v | Expected: active token of client '%s'", i, client.ClientID)
+	}
+
+	wrong := tr
+	wrong.ClientSecret = "wrong"
+	if _, err := svc.Token(ctx, wrong); !isOAuthError(err, errInvalidClient) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidClient)
+	}
+
+	wrong = tr
+	wrong.Scope = "admin"
+	if _, err := svc.Token(ctx, wrong); !isOAuthError(err, errInvalidScope) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidScope)
+	}
+
+	// Previous secret is no longer accepted after rotation.
+	rotated, err := svc.RotateClientSecret(ctx, client.ClientID, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Token(ctx, tr); !isOAuthError(err, errInvalidClient) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidClient)
+	}
+
+	tr.ClientSecret = rotated
+	if _, err := svc.Token(ctx, tr); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestClientCredentialsRequiresConfidentialClient(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, _, err := svc.RegisterClient(ctx, "worker", nil, nil, []string{clientCredentialsGrant}, false, "localhost")
+	if !isOAuthError(err, errInvalidRequest) {
+		t.Errorf("Error: %v | Expected: %s", err, errInvalidRequest)
+	}
+
+	client, _, err := svc.RegisterClient(ctx, "spa", []string{"https://app.granica.dev/callback"}, nil, nil, false, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := TokenRequest{GrantType: clientCredentialsGrant, ClientID: client.ClientID, TenantID: "localhost"}
+	if _, err := svc.Token(ctx, tr); !isOAuthError(err, errUnauthorizedClient) {
+		t.Errorf("Error: %v | Expected: %s", err, errUnauthorizedClient)
+	}
+}
+
+func isOAuthError(err error, code string) bool {
+	oerr, ok := err.(*OAuthError)
+	return ok && oerr.Code == code
+}
@@ -0,0 +1,167 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// OpenID Connect scopes.
+const (
+	openIDScope  = "openid"
+	profileScope = "profile"
+	emailScope   = "email"
+)
+
+// ProviderMetadata is the OpenID provider configuration (OpenID Connect Discovery 1.0).
+type ProviderMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// UserClaims are the OpenID standard claims about a user (OpenID Connect Core 1.0, 5.1).
+// Only the claims of the granted scopes are released.
+type UserClaims struct {
+	Name              string `json:"name,omitempty"`
+	GivenName         string `json:"given_name,omitempty"`
+	MiddleName        string `json:"middle_name,omitempty"`
+	FamilyName        string `json:"family_name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	UpdatedAt         int64  `json:"updated_at,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+}
+
+// UserInfo is the userinfo endpoint response.
+type UserInfo struct {
+	Subject string `json:"sub"`
+	UserClaims
+}
+
+// IDTokenClaims provides the claims of ID tokens.
+type IDTokenClaims struct {
+	Nonce    string `json:"nonce,omitempty"`
+	AuthTime int64  `json:"auth_time,omitempty"`
+	AtHash   string `json:"at_hash,omitempty"`
+	UserClaims
+	jwt.StandardClaims
+}
+
+// providerMetadata returns the OpenID provider configuration.
+// Endpoints are published relative to the issuer, so it must be
+// the public base URL of the service.
+func (gs granicaService) providerMetadata() *ProviderMetadata {
+	base := strings.TrimSuffix(gs.cfg.Token.Issuer, "/")
+
+	var algs []string
+	seen := map[string]bool{}
+	for _, k := range gs.tokens.keys.All() {
+		if alg := k.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+
+	return &ProviderMetadata{
+		Issuer:                            gs.cfg.Token.Issuer,
+		AuthorizationEndpoint:             base + "/oauth/authorize",
+		TokenEndpoint:                     base + "/oauth/token",
+		UserinfoEndpoint:                  base + "/userinfo",
+		JWKSURI:                           base + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             base + "/oauth/introspect",
+		RevocationEndpoint:                base + "/oauth/revoke",
+		ScopesSupported:                   []string{openIDScope, profileScope, emailScope},
+		ResponseTypesSupported:            []string{codeResponseType},
+		GrantTypesSupported:               []string{authorizationCodeGrant, refreshTokenGrant, clientCredentialsGrant},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algs,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{pkceS256},
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "at_hash",
+			"name", "given_name", "middle_name", "family_name", "preferred_username", "updated_at",
+			"email", "email_verified",
+		},
+	}
+}
+
+// userClaimsFor maps the user to the standard claims released for the scope.
+func userClaimsFor(user *m.User, scope string) UserClaims {
+	var uc UserClaims
+	if hasScope(scope, profileScope) {
+		uc.Name = strings.Join(strings.Fields(user.GivenName+" "+user.MiddleNames+" "+user.FamilyName), " ")
+		uc.GivenName = user.GivenName
+		uc.MiddleName = user.MiddleNames
+		uc.FamilyName = user.FamilyName
+		uc.PreferredUsername = user.Username
+		if !user.UpdatedAt.IsZero() {
+			uc.UpdatedAt = user.UpdatedAt.Unix()
+		}
+	}
+
+	if hasScope(scope, emailScope) {
+		verified := user.IsEmailVerified
+		uc.Email = user.Email
+		uc.EmailVerified = &verified
+	}
+
+	return uc
+}
+
+// idToken issues an ID token for the user to the client along with
+// the access token it is bound to.
+func (gs granicaService) idToken(user *m.User, clientID, scope, nonce string, authTime time.Time, accessToken string) (string, error) {
+	claims := IDTokenClaims{
+		Nonce:      nonce,
+		AuthTime:   authTime.Unix(),
+		AtHash:     atHash(accessToken),
+		UserClaims: userClaimsFor(user, scope),
+		StandardClaims: jwt.StandardClaims{
+			Subject:  user.ID.String(),
+			Audience: clientID,
+		},
+	}
+
+	return gs.tokens.IssueIDToken(claims)
+}
+
+// atHash returns the access token hash of ID tokens.
+// All supported signing algorithms use SHA-256.
+func atHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
+// hasScope - True if the scope is one of the space separated scopes.
+func hasScope(scopes, scope string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+func parseIDToken(t *testing.T, svc *granicaService, idToken string) *IDTokenClaims {
+	claims := &IDTokenClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := svc.tokens.keys.Get(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key '%s'", kid)
+		}
+		return key.Private.Public(), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestOpenIDConnect(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	ar := authorizeTestClient(ctx, t, svc)
+
+	user, err := svc.repo.GetByUsernameAndTenant(ctx, "username", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.GivenName = "Ada"
+	user.FamilyName = "Lovelace"
+	if err := svc.repo.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := svc.clientRepo.GetByClientIDAndTenant(ctx, ar.ClientID, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Scopes = append(client.Scopes, openIDScope)
+	if err := svc.clientRepo.Update(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+
+	ar.Scope = "openid profile"
+	ar.Nonce = "n-0S6_WzA2Mj"
+
+	redirectURL, err := svc.Authorize(ctx, ar, "username", "password", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.Token(ctx, TokenRequest{
+		GrantType:    authorizationCodeGrant,
+		Code:         redirectParams(t, redirectURL).Get("code"),
+		RedirectURI:  ar.RedirectURI,
+		ClientID:     ar.ClientID,
+		CodeVerifier: testVerifier,
+		TenantID:     "localhost",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.IDToken == "" {
+		t.Fatal("expected an ID token for the openid scope")
+	}
+
+	claims := parseIDToken(t, svc, token.IDToken)
+	if claims.Audience != ar.ClientID || claims.Nonce != ar.Nonce || claims.Subject != user.ID.String() {
+		t.Errorf("ID token claims: %+v | Expected: aud '%s', nonce '%s'", claims, ar.ClientID, ar.Nonce)
+	}
+
+	if claims.AtHash != atHash(token.AccessToken) || claims.AuthTime == 0 {
+		t.Errorf("ID token claims: %+v | Expected: at_hash and auth_time", claims)
+	}
+
+	if claims.GivenName != "Ada" || claims.FamilyName != "Lovelace" || claims.Name != "Ada Lovelace" {
+		t.Errorf("ID token claims: %+v | Expected: profile claims", claims)
+	}
+
+	// Email scope was not granted.
+	if claims.Email != "" || claims.EmailVerified != nil {
+		t.Errorf("Email: '%s', %v | Expected: none", claims.Email, claims.EmailVerified)
+	}
+
+	ui, err := svc.UserInfo(ctx, token.AccessToken, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ui.Subject != user.ID.String() || ui.PreferredUsername != "username" || ui.GivenName != "Ada" {
+		t.Errorf("User info: %+v | Expected: profile of '%s'", ui, user.ID)
+	}
+
+	// First party tokens carry no openid scope.
+	first, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.UserInfo(ctx, first.AccessToken, "localhost"); err != ErrInsufficientScope {
+		t.Errorf("Error: %v | Expected: %v", err, ErrInsufficientScope)
+	}
+}
+
+func TestOpenIDConfiguration(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.cfg.Token.Issuer = "https://granica.dev/"
+
+	md, err := svc.OpenIDConfiguration(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if md.TokenEndpoint != "https://granica.dev/oauth/token" || md.JWKSURI != "https://granica.dev/.well-known/jwks.json" {
+		t.Errorf("Metadata: %+v | Expected: endpoints relative to the issuer", md)
+	}
+
+	if len(md.IDTokenSigningAlgValuesSupported) != 1 || md.IDTokenSigningAlgValuesSupported[0] != "ES256" {
+		t.Errorf("Signing algs: %v | Expected: [ES256]", md.IDTokenSigningAlgValuesSupported)
+	}
+}
+
+func TestEmailClaims(t *testing.T) {
+	user := &m.User{Username: "username", Email: "username@granica.dev"}
+
+	tests := []struct {
+		verified bool
+		json     string
+	}{
+		{false, `{"email":"username@granica.dev","email_verified":false}`},
+		{true, `{"email":"username@granica.dev","email_verified":true}`},
+	}
+
+	for _, tt := range tests {
+		user.IsEmailVerified = tt.verified
+
+		b, err := json.Marshal(userClaimsFor(user, "openid email"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != tt.json {
+			t.Errorf("Claims: %s | Expected: %s", b, tt.json)
+		}
+	}
+
+	b, _ := json.Marshal(userClaimsFor(user, "openid"))
+	if string(b) != "{}" {
+		t.Errorf("Claims: %s | Expected: {}", b)
+	}
+}
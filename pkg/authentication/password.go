@@ -0,0 +1,127 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	c "gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// sendPasswordReset mails the user a link to reset its password.
+// Only the last requested reset is valid, previous ones are discarded.
+func (gs granicaService) sendPasswordReset(ctx context.Context, user *m.User) error {
+	raw, err := genOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := gs.cfg.Auth.PasswordResetTTL
+	now := time.Now()
+	reset := &m.PasswordReset{
+		ID:        uuid.New(),
+		Digest:    tokenDigest(raw),
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+
+	err = gs.resetRepo.DeleteByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = gs.resetRepo.Insert(ctx, reset)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/password/reset?token=%s", strings.TrimSuffix(gs.cfg.Token.Issuer, "/"), url.QueryEscape(raw))
+	body := fmt.Sprintf("Hi %s,\n\nA password reset was requested for your account. Reset it following this link:\n\n%s\n\n"+
+		"The link expires in %s. If you did not request it you can ignore this message.\n", user.Username, link, ttl)
+
+	return gs.mailer.Send(user.Email, "Reset your password", body)
+}
+
+// resetPassword sets a new password for the user the reset token was mailed to
+// and signs the user out of every session.
+// The password must follow the policy of the tenant and not be a recent one.
+// Reset tokens are consumed on first use whatever the outcome.
+func (gs granicaService) resetPassword(ctx context.Context, token, password, passwordConfirmation string) error {
+	reset, err := gs.resetRepo.Consume(ctx, tokenDigest(token))
+	if err != nil || reset.IsExpired() {
+		return ErrInvalidResetToken
+	}
+
+	if password != passwordConfirmation {
+		return errors.New("password confirmation doesn't match")
+	}
+
+	user, err := gs.users(reset.TenantID).Get(ctx, reset.UserID)
+	if err != nil || user.TenantID != reset.TenantID {
+		return ErrInvalidResetToken
+	}
+
+	err = gs.setPassword(ctx, user, password)
+	if err != nil {
+		return err
+	}
+
+	// The reset link was delivered to the user address.
+	user.IsEmailVerified = true
+	user.SetUpdatedBy(user.ID)
+	user.SetUpdatedAt()
+
+	err = gs.users(user.TenantID).Update(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	return gs.sessions.RevokeAll(ctx, user.ID)
+}
+
+// hashPassword sets the digest of the user password,
+// made with the configured hasher.
+func (gs granicaService) hashPassword(user *m.User) error {
+	digest, err := gs.hasher.Hash(user.Password)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordDigest = digest
+	return nil
+}
+
+// rehashPassword replaces the digest of the user if it was made with
+// another algorithm or weaker parameters than the configured ones.
+// The password has been verified, so failures are only logged.
+func (gs granicaService) rehashPassword(ctx context.Context, user *m.User, password string) {
+	if !gs.hasher.NeedsRehash(user.PasswordDigest) {
+		return
+	}
+
+	digest, err := gs.hasher.Hash(password)
+	if err != nil {
+		gs.logger.Log("level", c.LogLevel.Error, "msg", "cannot rehash password", "err", err.Error())
+		return
+	}
+
+	user.PasswordDigest = digest
+	err = gs.users(user.TenantID).Update(ctx, user)
+	if err != nil {
+		gs.logger.Log("level", c.LogLevel.Error, "msg", "cannot rehash password", "err", err.Error())
+	}
+}
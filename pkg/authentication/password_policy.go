@@ -0,0 +1,193 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const (
+	// Shorter user info would reject too many passwords.
+	minUserInfoLen = 3
+)
+
+// ErrPasswordReused is returned when a new password is one of the last ones of the user.
+var ErrPasswordReused = errors.New("password was used recently")
+
+// PasswordPolicyError lists the rules a password breaks.
+type PasswordPolicyError struct {
+	Violations []string `json:"violations"`
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet policy: " + strings.Join(e.Violations, ", ")
+}
+
+// PasswordPolicy validates passwords against the rules of a tenant.
+type PasswordPolicy struct {
+	config.PasswordPolicyConfig
+	breached BreachedList
+}
+
+// passwordPolicy returns the policy of the tenant, or the default one
+// if the tenant has none of its own. Policies set in the tenant settings
+// take precedence over the configured ones.
+func (gs granicaService) passwordPolicy(ctx context.Context, tenantID string) PasswordPolicy {
+	cfg := gs.cfg.Auth.PasswordPolicy
+	if p := gs.tenantSettings(ctx, tenantID).PasswordPolicy; p != nil {
+		cfg = passwordPolicyConfig(p)
+	} else if tcfg, ok := cfg.Tenants[tenantID]; ok {
+		cfg = tcfg
+	}
+	cfg.Tenants = nil
+
+	return PasswordPolicy{PasswordPolicyConfig: cfg, breached: gs.breached}
+}
+
+// Validate returns a PasswordPolicyError listing the rules the password
+// of the user breaks.
+func (p PasswordPolicy) Validate(password string, user *m.User) error {
+	if password == "" {
+		return &PasswordPolicyError{Violations: []string{"password required"}}
+	}
+
+	var violations []string
+	n := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && n < p.MinLength {
+		violations = append(violations, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+
+	if p.MaxLength > 0 && n > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("at most %d characters", p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	if p.RequireUpper && !upper {
+		violations = append(violations, "an uppercase letter")
+	}
+
+	if p.RequireLower && !lower {
+		violations = append(violations, "a lowercase letter")
+	}
+
+	if p.RequireDigit && !digit {
+		violations = append(violations, "a digit")
+	}
+
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "a symbol")
+	}
+
+	if p.DisallowUserInfo && containsUserInfo(password, user) {
+		violations = append(violations, "no username or email")
+	}
+
+	if p.DisallowBreached && p.breached != nil {
+		breached, err := isBreached(p.breached, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, "not known to be breached")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// CheckReuse returns ErrPasswordReused if the password is the current one
+// of the user or one of the last HistorySize ones.
+func (p PasswordPolicy) CheckReuse(password string, user *m.User) error {
+	if p.HistorySize <= 0 {
+		return nil
+	}
+
+	if user.PasswordDigest != "" && passwordMatches(user.PasswordDigest, password) {
+		return ErrPasswordReused
+	}
+
+	for i, digest := range user.PasswordHistory {
+		if i >= p.HistorySize {
+			break
+		}
+		if passwordMatches(digest, password) {
+			return ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// setPassword changes the password of the user if the policy of its tenant
+// allows it. The replaced digest is kept in the history of the user.
+func (gs granicaService) setPassword(ctx context.Context, user *m.User, password string) error {
+	policy := gs.passwordPolicy(ctx, user.TenantID)
+	err := policy.Validate(password, user)
+	if err != nil {
+		return err
+	}
+
+	err = policy.CheckReuse(password, user)
+	if err != nil {
+		return err
+	}
+
+	if policy.HistorySize > 0 && user.PasswordDigest != "" {
+		history := append([]string{user.PasswordDigest}, user.PasswordHistory...)
+		if len(history) > policy.HistorySize {
+			history = history[:policy.HistorySize]
+		}
+		user.PasswordHistory = history
+	}
+
+	user.Password = password
+	return gs.hashPassword(user)
+}
+
+// containsUserInfo - True if the password contains the username or the email of the user.
+func containsUserInfo(password string, user *m.User) bool {
+	password = strings.ToLower(password)
+	info := []string{user.Username, user.Email}
+	if i := strings.IndexByte(user.Email, '@'); i > 0 {
+		info = append(info, user.Email[:i])
+	}
+
+	for _, s := range info {
+		s = strings.ToLower(s)
+		if utf8.RuneCountInString(s) >= minUserInfoLen && strings.Contains(password, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,293 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := PasswordPolicy{PasswordPolicyConfig: config.PasswordPolicyConfig{
+		MinLength:        8,
+		MaxLength:        16,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUserInfo: true,
+	}}
+
+	user := &m.User{Username: "username", Email: "someone@granica.dev"}
+
+	tests := []struct {
+		password   string
+		violations int
+	}{
+		{"", 1},
+		{"Sh0rt!", 1},
+		{"Longer-than-16-chars!", 1},
+		{"nouppercase1!", 1},
+		{"NOLOWERCASE1!", 1},
+		{"NoDigits-here", 1},
+		{"NoSymbols123", 1},
+		{"My-username-1", 1},
+		{"Someone-1234", 1},
+		{"lowercase", 3},
+		{"Valid-pass-123", 0},
+	}
+
+	for _, tt := range tests {
+		err := policy.Validate(tt.password, user)
+		if tt.violations == 0 {
+			if err != nil {
+				t.Errorf("Password '%s' error: %v | Expected: nil", tt.password, err)
+			}
+			continue
+		}
+
+		perr, ok := err.(*PasswordPolicyError)
+		if !ok {
+			t.Errorf("Password '%s' error: %v | Expected: policy error", tt.password, err)
+			continue
+		}
+
+		if len(perr.Violations) != tt.violations {
+			t.Errorf("Password '%s' violations: %v | Expected: %d", tt.password, perr.Violations, tt.violations)
+		}
+	}
+}
+
+func TestPasswordPolicyBreached(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "granica-breached")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sum := sha1.Sum([]byte("correct horse battery staple"))
+	path := filepath.Join(dir, "breached.txt")
+	err = ioutil.WriteFile(path, []byte(hex.EncodeToString(sum[:])+":42\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := loadBreachedList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := newTestService(t)
+	svc.breached = list
+	svc.cfg.Auth.PasswordPolicy.DisallowBreached = true
+
+	_, err = svc.SignUp(ctx, "username", "correct horse battery staple", "username@granica.dev", "username@granica.dev", "localhost")
+	if _, ok := err.(*PasswordPolicyError); !ok {
+		t.Fatalf("Error: %v | Expected: policy error", err)
+	}
+
+	_, err = svc.SignUp(ctx, "username", "correct horse battery stapler", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestBreachedListRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "granica-breached")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var digests []string
+	for i := 0; i < 500; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("password-%d", i)))
+		digests = append(digests, strings.ToUpper(hex.EncodeToString(sum[:])))
+	}
+	// Digests sharing a prefix.
+	digests = append(digests, "00000"+strings.Repeat("A", 35), "00000"+strings.Repeat("B", 35))
+	sort.Strings(digests)
+
+	// Lines as in the downloads: counts, CRLF, and some of them lowercase.
+	var b strings.Builder
+	for i, d := range digests {
+		if i%3 == 0 {
+			d = strings.ToLower(d)
+		}
+		fmt.Fprintf(&b, "%s:%d\r\n", d, i+1)
+	}
+
+	path := filepath.Join(dir, "breached.txt")
+	err = ioutil.WriteFile(path, []byte(b.String()), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := loadBreachedList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range digests {
+		suffixes, err := list.Range(strings.ToLower(d[:breachedPrefixLen]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found := false
+		for _, s := range suffixes {
+			found = found || s == d[breachedPrefixLen:]
+		}
+
+		if !found {
+			t.Errorf("Digest '%s' not found in its range: %v", d, suffixes)
+		}
+	}
+
+	if suffixes, _ := list.Range("00000"); len(suffixes) != 2 {
+		t.Errorf("Range '00000': %v | Expected: 2 suffixes", suffixes)
+	}
+
+	for _, prefix := range []string{"FFFFF", "0000F"} {
+		if suffixes, _ := list.Range(prefix); len(suffixes) != 0 {
+			t.Errorf("Range '%s': %v | Expected: none", prefix, suffixes)
+		}
+	}
+}
+
+func TestBreachedListInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "granica-breached")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "breached.txt")
+	err = ioutil.WriteFile(path, []byte("password\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadBreachedList(path); err == nil {
+		t.Error("a list of plain passwords must be rejected")
+	}
+}
+
+func TestUpdateDoesNotValidateCurrentPassword(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The password is kept, so a stricter policy does not get in the way.
+	svc.cfg.Auth.PasswordPolicy.MinLength = 12
+
+	err = svc.Update(ctx, "username", "password", "password", "username@granica.dev", "username@granica.dev",
+		"", "Given", "", "Family", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestPasswordPolicyPerTenant(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.cfg.Auth.PasswordPolicy.MinLength = 8
+	svc.cfg.Auth.PasswordPolicy.Tenants = map[string]config.PasswordPolicyConfig{
+		"strict.granica.dev": {MinLength: 12},
+	}
+
+	if _, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost"); err != nil {
+		t.Errorf("Default tenant error: %v | Expected: nil", err)
+	}
+
+	if _, err := svc.Create(ctx, "username", "password", "username@granica.dev", "strict.granica.dev"); err == nil {
+		t.Error("expected password too short for tenant policy to be rejected")
+	}
+
+	if _, err := svc.Create(ctx, "username", "long-password", "username@granica.dev", "strict.granica.dev"); err != nil {
+		t.Errorf("Strict tenant error: %v | Expected: nil", err)
+	}
+}
+
+func TestPasswordHistory(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.cfg.Auth.PasswordPolicy.HistorySize = 2
+
+	_, err := svc.SignUp(ctx, "username", "password-1", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reset := func(password string) error {
+		err := svc.ForgotPassword(ctx, "username@granica.dev", "localhost")
+		if err != nil {
+			t.Fatal(err)
+		}
+		token := lastMailedToken(t, svc, "username@granica.dev")
+		return svc.ResetPassword(ctx, token, password, password)
+	}
+
+	if err := reset("password-1"); err != ErrPasswordReused {
+		t.Fatalf("Current password error: %v | Expected: %v", err, ErrPasswordReused)
+	}
+
+	for _, password := range []string{"password-2", "password-3"} {
+		if err := reset(password); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// History keeps the current and the last two passwords.
+	if err := reset("password-2"); err != ErrPasswordReused {
+		t.Errorf("Previous password error: %v | Expected: %v", err, ErrPasswordReused)
+	}
+
+	if err := reset("password-1"); err != ErrPasswordReused {
+		t.Errorf("Older password error: %v | Expected: %v", err, ErrPasswordReused)
+	}
+
+	if err := reset("password-4"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+
+	if err := reset("password-1"); err != nil {
+		t.Errorf("Expired history password error: %v | Expected: nil", err)
+	}
+}
+
+func TestSignUpRequiresPassword(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "", "username@granica.dev", "username@granica.dev", "localhost")
+	if err == nil {
+		t.Error("expected empty password to be rejected")
+	}
+}
@@ -0,0 +1,101 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signedIn, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = svc.ForgotPassword(ctx, "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := lastMailedToken(t, svc, "username@granica.dev")
+
+	if err := svc.ResetPassword(ctx, token, "new-password", "new-password"); err != nil {
+		t.Fatalf("Error: %v | Expected: nil", err)
+	}
+
+	// Reset tokens are single use.
+	if err := svc.ResetPassword(ctx, token, "other-password", "other-password"); err != ErrInvalidResetToken {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrInvalidResetToken)
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err == nil {
+		t.Error("expected old password to be rejected")
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "new-password", "127.0.0.1", "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+
+	// Sessions opened before the reset are revoked.
+	if _, err := svc.Refresh(ctx, signedIn.RefreshToken, "localhost"); err != ErrInvalidRefreshToken {
+		t.Errorf("Error: %v | Expected: %v", err, ErrInvalidRefreshToken)
+	}
+
+	if _, err := svc.authenticate(context.Background(), signedIn.AccessToken, "localhost"); err == nil {
+		t.Error("expected access token of a revoked session to be rejected")
+	}
+}
+
+func TestPasswordResetKeepsOnlyLastToken(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc.ForgotPassword(ctx, "username@granica.dev", "localhost")
+	first := lastMailedToken(t, svc, "username@granica.dev")
+
+	svc.ForgotPassword(ctx, "username@granica.dev", "localhost")
+	second := lastMailedToken(t, svc, "username@granica.dev")
+
+	if err := svc.ResetPassword(ctx, first, "new-password", "new-password"); err != ErrInvalidResetToken {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrInvalidResetToken)
+	}
+
+	if err := svc.ResetPassword(ctx, second, "new-password", "other-password"); err == nil {
+		t.Fatal("expected mismatched confirmation to be rejected")
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	if err := svc.ForgotPassword(ctx, "nobody@granica.dev", "localhost"); err != nil {
+		t.Fatalf("Error: %v | Expected: nil", err)
+	}
+
+	if n := len(svc.mailer.(*fakeMailer).sent); n != 0 {
+		t.Errorf("Messages sent: %d | Expected: 0", n)
+	}
+}
@@ -0,0 +1,48 @@
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Set holds the policies of each tenant.
+type Set map[string][]Policy
+
+// Load reads the policies of each tenant from a YAML or JSON file
+// mapping tenant IDs to their list of policies.
+func Load(path string) (Set, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(b)
+}
+
+// Parse decodes and validates a YAML or JSON policy set.
+func Parse(b []byte) (Set, error) {
+	var set Set
+	err := yaml.Unmarshal(b, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	for tenantID, policies := range set {
+		ids := map[string]bool{}
+		for _, p := range policies {
+			err := p.Validate()
+			if err != nil {
+				return nil, fmt.Errorf("tenant '%s': %s", tenantID, err.Error())
+			}
+
+			if ids[p.ID] {
+				return nil, fmt.Errorf("tenant '%s': duplicated policy '%s'", tenantID, p.ID)
+			}
+			ids[p.ID] = true
+		}
+	}
+
+	return set, nil
+}
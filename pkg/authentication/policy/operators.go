@@ -0,0 +1,266 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	earthRadiusKm = 6371.0
+)
+
+// Operators
+const (
+	EqOp          = "eq"
+	NeOp          = "ne"
+	InOp          = "in"
+	NotInOp       = "notIn"
+	ContainsOp    = "contains"
+	StartsWithOp  = "startsWith"
+	GtOp          = "gt"
+	LtOp          = "lt"
+	CIDROp        = "cidr"
+	TimeBetweenOp = "timeBetween"
+	WithinKmOp    = "withinKm"
+)
+
+// operator compares an attribute with the value of a condition.
+// Attributes that are missing or of an unexpected type never match.
+type operator func(attr, value interface{}) bool
+
+var operators = map[string]operator{
+	EqOp:          eq,
+	NeOp:          func(attr, value interface{}) bool { return attr != nil && !eq(attr, value) },
+	InOp:          in,
+	NotInOp:       func(attr, value interface{}) bool { return attr != nil && !in(attr, value) },
+	ContainsOp:    contains,
+	StartsWithOp:  startsWith,
+	GtOp:          func(attr, value interface{}) bool { c, ok := compare(attr, value); return ok && c > 0 },
+	LtOp:          func(attr, value interface{}) bool { c, ok := compare(attr, value); return ok && c < 0 },
+	CIDROp:        inCIDR,
+	TimeBetweenOp: timeBetween,
+	WithinKmOp:    withinKm,
+}
+
+// eq - True if both values are equal once normalized.
+// Strings are compared as they are, other values as numbers if they are any.
+func eq(attr, value interface{}) bool {
+	if attr == nil || value == nil {
+		return false
+	}
+
+	if _, ok := attr.(string); ok {
+		return attr == fmt.Sprint(value)
+	}
+
+	if a, ok := number(attr); ok {
+		b, ok := number(value)
+		return ok && a == b
+	}
+
+	return fmt.Sprint(attr) == fmt.Sprint(value)
+}
+
+// in - True if the attribute is one of the listed values or,
+// if it is a list, any of its items is.
+func in(attr, value interface{}) bool {
+	for _, a := range list(attr) {
+		for _, v := range list(value) {
+			if eq(a, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contains - True if the attribute is a list holding the value
+// or a string containing it.
+func contains(attr, value interface{}) bool {
+	if s, ok := attr.(string); ok {
+		return value != nil && strings.Contains(s, fmt.Sprint(value))
+	}
+
+	for _, a := range list(attr) {
+		if eq(a, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// startsWith - True if the attribute is a string starting with the value.
+func startsWith(attr, value interface{}) bool {
+	s, ok := attr.(string)
+	return ok && value != nil && strings.HasPrefix(s, fmt.Sprint(value))
+}
+
+// compare returns the sign of attr - value.
+// Not ok if any of them is not a number.
+func compare(attr, value interface{}) (int, bool) {
+	a, ok := number(attr)
+	if !ok {
+		return 0, false
+	}
+
+	b, ok := number(value)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case a > b:
+		return 1, true
+	case a < b:
+		return -1, true
+	}
+	return 0, true
+}
+
+// inCIDR - True if the attribute is an IP within any of the listed networks.
+func inCIDR(attr, value interface{}) bool {
+	s, ok := attr.(string)
+	if !ok {
+		return false
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+
+	for _, v := range list(value) {
+		_, network, err := net.ParseCIDR(fmt.Sprint(v))
+		if err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeBetween - True if the attribute is a time whose UTC time of day
+// is within the ['HH:MM', 'HH:MM'] range of the value.
+// Ranges ending before they start span midnight.
+func timeBetween(attr, value interface{}) bool {
+	t, ok := attr.(time.Time)
+	if !ok {
+		return false
+	}
+
+	bounds := list(value)
+	if len(bounds) != 2 {
+		return false
+	}
+
+	from, ok := minuteOfDay(fmt.Sprint(bounds[0]))
+	if !ok {
+		return false
+	}
+
+	to, ok := minuteOfDay(fmt.Sprint(bounds[1]))
+	if !ok {
+		return false
+	}
+
+	t = t.UTC()
+	now := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return now >= from && now <= to
+	}
+	return now >= from || now <= to
+}
+
+// withinKm - True if the attribute is a [longitude, latitude] point
+// within the distance of the [longitude, latitude, km] value.
+func withinKm(attr, value interface{}) bool {
+	point := floats(attr)
+	area := floats(value)
+	if len(point) != 2 || len(area) != 3 {
+		return false
+	}
+
+	return distanceKm(point[0], point[1], area[0], area[1]) <= area[2]
+}
+
+// distanceKm returns the great-circle distance between two points.
+func distanceKm(lng1, lat1, lng2, lat2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// minuteOfDay parses a 'HH:MM' time of day.
+func minuteOfDay(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// number returns the value as a float if it is a number.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case uint64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// floats returns the value as a list of floats, or nil if any of its items is not a number.
+func floats(v interface{}) []float64 {
+	if fs, ok := v.([]float64); ok {
+		return fs
+	}
+
+	var fs []float64
+	for _, item := range list(v) {
+		f, ok := number(item)
+		if !ok {
+			return nil
+		}
+		fs = append(fs, f)
+	}
+	return fs
+}
+
+// list returns the value as a list of items.
+func list(v interface{}) []interface{} {
+	switch l := v.(type) {
+	case []interface{}:
+		return l
+	case []string:
+		items := make([]interface{}, len(l))
+		for i, s := range l {
+			items[i] = s
+		}
+		return items
+	case []float64:
+		items := make([]interface{}, len(l))
+		for i, f := range l {
+			items[i] = f
+		}
+		return items
+	case nil:
+		return nil
+	}
+	return []interface{}{v}
+}
@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Effects
+const (
+	Allow = "allow"
+	Deny  = "deny"
+)
+
+// Attribute namespaces
+const (
+	SubjectNS  = "subject"
+	ResourceNS = "resource"
+	ContextNS  = "context"
+	ActionAttr = "action"
+)
+
+// Policy allows or denies actions over resources when all of its conditions hold.
+// Actions and resources are matched by name, '*' matches any of them
+// and a trailing '*' any with the same prefix (e.g. 'documents:*').
+type Policy struct {
+	ID          string      `yaml:"id" json:"id"`
+	Description string      `yaml:"description" json:"description"`
+	Effect      string      `yaml:"effect" json:"effect"`
+	Actions     []string    `yaml:"actions" json:"actions"`
+	Resources   []string    `yaml:"resources" json:"resources"`
+	Conditions  []Condition `yaml:"conditions" json:"conditions"`
+}
+
+// Condition compares an attribute with a value or, if ValueFrom is set,
+// with another attribute (e.g. 'resource.owner' eq 'subject.id').
+type Condition struct {
+	Attribute string      `yaml:"attribute" json:"attribute"`
+	Operator  string      `yaml:"operator" json:"operator"`
+	Value     interface{} `yaml:"value" json:"value"`
+	ValueFrom string      `yaml:"valueFrom" json:"valueFrom"`
+}
+
+// Attributes are the values conditions are evaluated against,
+// keyed by their dotted name (e.g. 'subject.roles', 'context.ip').
+type Attributes map[string]interface{}
+
+// Decision is the outcome of evaluating the policies of a tenant.
+type Decision struct {
+	Allowed bool     `json:"allowed"`
+	Reasons []string `json:"reasons"`
+}
+
+// Validate returns an error if the policy is not well formed.
+func (p Policy) Validate() error {
+	if p.ID == "" {
+		return fmt.Errorf("policy without ID")
+	}
+
+	if p.Effect != Allow && p.Effect != Deny {
+		return fmt.Errorf("policy '%s': effect must be '%s' or '%s'", p.ID, Allow, Deny)
+	}
+
+	if len(p.Actions) == 0 || len(p.Resources) == 0 {
+		return fmt.Errorf("policy '%s': actions and resources required", p.ID)
+	}
+
+	for _, c := range p.Conditions {
+		if c.Attribute == "" {
+			return fmt.Errorf("policy '%s': condition without attribute", p.ID)
+		}
+
+		if _, ok := operators[c.Operator]; !ok {
+			return fmt.Errorf("policy '%s': unknown operator '%s'", p.ID, c.Operator)
+		}
+	}
+
+	return nil
+}
+
+// Evaluate decides if the action over the resource is allowed by the policies.
+// A matching deny policy overrides any allow one and, if none of them
+// match, the request is denied.
+// Reasons name the policies the decision was taken on.
+func Evaluate(policies []Policy, action, resource string, attrs Attributes) Decision {
+	var allows, denies []string
+	for _, p := range policies {
+		if !matchAny(p.Actions, action) || !matchAny(p.Resources, resource) {
+			continue
+		}
+
+		if !p.holds(attrs) {
+			continue
+		}
+
+		if p.Effect == Deny {
+			denies = append(denies, fmt.Sprintf("policy '%s' denies", p.ID))
+		} else {
+			allows = append(allows, fmt.Sprintf("policy '%s' allows", p.ID))
+		}
+	}
+
+	if len(denies) > 0 {
+		return Decision{Allowed: false, Reasons: denies}
+	}
+
+	if len(allows) > 0 {
+		return Decision{Allowed: true, Reasons: allows}
+	}
+
+	return Decision{Allowed: false, Reasons: []string{"no policy allows the request"}}
+}
+
+// holds - True if all the conditions of the policy hold for the attributes.
+func (p Policy) holds(attrs Attributes) bool {
+	for _, c := range p.Conditions {
+		value := c.Value
+		if c.ValueFrom != "" {
+			value = attrs[c.ValueFrom]
+		}
+
+		op := operators[c.Operator]
+		if op == nil || !op(attrs[c.Attribute], value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchAny - True if the name matches any of the patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if p == "*" || p == name {
+			return true
+		}
+
+		if strings.HasSuffix(p, "*") && strings.HasPrefix(name, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
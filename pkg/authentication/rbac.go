@@ -0,0 +1,186 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// Built-in permissions.
+const (
+	usersCreatePermission    = "users:create"
+	usersUpdatePermission    = "users:update"
+	usersRemovePermission    = "users:remove"
+	usersUnlockPermission    = "users:unlock"
+	rolesReadPermission      = "roles:read"
+	rolesWritePermission     = "roles:write"
+	rolesAssignPermission    = "roles:assign"
+	clientsWritePermission   = "clients:write"
+	relationsReadPermission  = "relations:read"
+	relationsWritePermission = "relations:write"
+	tenantsReadPermission    = "tenants:read"
+	tenantsWritePermission   = "tenants:write"
+)
+
+var builtInPermissions = []m.Permission{
+	{Name: usersCreatePermission, Description: "Create users"},
+	{Name: usersUpdatePermission, Description: "Update users"},
+	{Name: usersRemovePermission, Description: "Remove users"},
+	{Name: usersUnlockPermission, Description: "Unlock accounts and IPs"},
+	{Name: rolesReadPermission, Description: "List roles, permissions and assignments"},
+	{Name: rolesWritePermission, Description: "Create, update and delete roles and permissions"},
+	{Name: rolesAssignPermission, Description: "Assign roles to users"},
+	{Name: clientsWritePermission, Description: "Register OAuth clients and rotate their secrets"},
+	{Name: relationsReadPermission, Description: "Check, expand and list relations"},
+	{Name: relationsWritePermission, Description: "Write and delete relation tuples"},
+	{Name: tenantsReadPermission, Description: "List tenants, only in the system tenant"},
+	{Name: tenantsWritePermission, Description: "Register, update and delete tenants, only in the system tenant"},
+}
+
+// requirePermission is an endpoint middleware that lets through only
+// the requests whose bearer token grants the permission.
+// Permissions are read from the token claims, so role changes apply
+// to tokens issued after them.
+func requirePermission(svc GranicaService, permission string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			accessToken, _ := ctx.Value(accessTokenContextKey).(string)
+			tenantID, _ := repo.TenantFromContext(ctx)
+
+			err := svc.CheckPermission(ctx, accessToken, permission, tenantID)
+			if err != nil {
+				return nil, err
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// userRoles returns the names of the roles assigned to the user
+// and the permissions they grant.
+// Admins are granted every permission.
+func (gs granicaService) userRoles(ctx context.Context, user *m.User) ([]string, []string, error) {
+	var roles, permissions []string
+	if gs.isAdmin(user) {
+		permissions = append(permissions, m.AllPermissions)
+	}
+
+	assignments, err := gs.assignmentRepo.GetByUser(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, a := range assignments {
+		role, err := gs.roleRepo.Get(ctx, a.RoleID)
+		if err != nil || role.TenantID != user.TenantID {
+			continue
+		}
+
+		roles = append(roles, role.Name)
+		for _, p := range role.Permissions {
+			if !seen[p] {
+				seen[p] = true
+				permissions = append(permissions, p)
+			}
+		}
+	}
+
+	return roles, permissions, nil
+}
+
+// grantRoles adds the roles of the user and their permissions to the claims.
+func (gs granicaService) grantRoles(ctx context.Context, claims *AppClaims, user *m.User) error {
+	roles, permissions, err := gs.userRoles(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	claims.Roles = roles
+	claims.Permissions = permissions
+	return nil
+}
+
+// isAdmin - True if the user is one of the configured admins.
+func (gs granicaService) isAdmin(user *m.User) bool {
+	for _, id := range gs.cfg.Auth.Admins {
+		if id == user.ID.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRolePermissions returns an error if any of the permissions is neither
+// built-in nor defined by the tenant. Wildcards are allowed.
+func (gs granicaService) checkRolePermissions(ctx context.Context, permissions []string, tenantID string) error {
+	for _, p := range permissions {
+		if !validPermissionName(p) {
+			return fmt.Errorf("invalid permission '%s'", p)
+		}
+
+		if p == m.AllPermissions || strings.HasSuffix(p, ":*") || isBuiltInPermission(p) {
+			continue
+		}
+
+		_, err := gs.permissionRepo.GetByNameAndTenant(ctx, p, tenantID)
+		if err != nil {
+			return fmt.Errorf("unknown permission '%s'", p)
+		}
+	}
+
+	return nil
+}
+
+// tenantRole returns the role of the tenant with the ID.
+func (gs granicaService) tenantRole(ctx context.Context, roleID, tenantID string) (*m.Role, error) {
+	id, err := uuid.Parse(roleID)
+	if err != nil {
+		return nil, errors.New("invalid role ID")
+	}
+
+	role, err := gs.roleRepo.Get(ctx, id)
+	if err != nil || role.TenantID != tenantID {
+		return nil, errors.New("role not found")
+	}
+
+	return role, nil
+}
+
+// validPermissionName - True if the name is '*' or has the 'resource:action' form.
+func validPermissionName(name string) bool {
+	if name == m.AllPermissions {
+		return true
+	}
+
+	parts := strings.Split(name, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return false
+	}
+
+	return !strings.ContainsAny(name, " \t\n") && !strings.Contains(parts[0], "*")
+}
+
+// isBuiltInPermission - True if the permission is a built-in one.
+func isBuiltInPermission(name string) bool {
+	for _, p := range builtInPermissions {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRolePermissions(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.CheckPermission(ctx, token.AccessToken, usersCreatePermission, "localhost"); err != ErrForbidden {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrForbidden)
+	}
+
+	if _, err := svc.CreateRole(ctx, "manager", "", []string{"reports:read"}, "localhost"); err == nil {
+		t.Fatal("expected role with unknown permission to be rejected")
+	}
+
+	_, err = svc.CreatePermission(ctx, "reports:read", "Read reports", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	role, err := svc.CreateRole(ctx, "manager", "", []string{"users:*", "reports:read"}, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.CreateRole(ctx, "manager", "", nil, "localhost"); err == nil {
+		t.Error("expected duplicated role name to be rejected")
+	}
+
+	err = svc.AssignRole(ctx, "username", role.ID.String(), "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Roles are granted in the tokens issued after the assignment.
+	token, err = svc.Refresh(ctx, token.RefreshToken, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{usersCreatePermission, usersRemovePermission, "reports:read"} {
+		if err := svc.CheckPermission(ctx, token.AccessToken, p, "localhost"); err != nil {
+			t.Errorf("Permission '%s' error: %v | Expected: nil", p, err)
+		}
+	}
+
+	if err := svc.CheckPermission(ctx, token.AccessToken, rolesWritePermission, "localhost"); err != ErrForbidden {
+		t.Errorf("Error: %v | Expected: %v", err, ErrForbidden)
+	}
+
+	// Tokens of other tenants are not accepted.
+	if err := svc.CheckPermission(ctx, token.AccessToken, usersCreatePermission, "other.granica.dev"); err != ErrUnauthorized {
+		t.Errorf("Error: %v | Expected: %v", err, ErrUnauthorized)
+	}
+
+	roles, err := svc.GetUserRoles(ctx, "username", "localhost")
+	if err != nil || len(roles) != 1 {
+		t.Fatalf("Roles: %v, %v | Expected: 1 role", roles, err)
+	}
+
+	err = svc.DeleteRole(ctx, role.ID.String(), "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roles, _ = svc.GetUserRoles(ctx, "username", "localhost")
+	if len(roles) != 0 {
+		t.Errorf("Roles: %d | Expected: 0", len(roles))
+	}
+}
+
+func TestAdminPermissions(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	user, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.cfg.Auth.Admins = []string{user.ID.String()}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.CheckPermission(ctx, token.AccessToken, rolesWritePermission, "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestRequirePermissionMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	user, err := svc.SignUp(ctx, "admin", "password", "admin@granica.dev", "admin@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc.cfg.Auth.Admins = []string{user.ID.String()}
+
+	admin, err := svc.SignIn(ctx, "admin", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := CreateRoleHandler(svc)
+
+	tests := []struct {
+		token  string
+		status int
+	}{
+		{"", http.StatusUnauthorized},
+		{other.AccessToken, http.StatusForbidden},
+		{admin.AccessToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost/roles/create", strings.NewReader(`{"name":"auditor","permissions":["roles:read"]}`))
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("Status: %d | Expected: %d", rec.Code, tt.status)
+		}
+	}
+
+	if _, err := svc.roleRepo.GetByNameAndTenant(ctx, "auditor", "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: role created", err)
+	}
+}
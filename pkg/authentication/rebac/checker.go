@@ -0,0 +1,272 @@
+package rebac
+
+import (
+	"errors"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const (
+	// maxDepth bounds the rewrites and usersets followed in a row.
+	maxDepth = 25
+)
+
+// ErrMaxDepth is returned when answering takes following too many relations.
+var ErrMaxDepth = errors.New("relation depth limit exceeded")
+
+// TupleReader reads the relation tuples of a tenant.
+type TupleReader interface {
+	// Read returns the tuples with the object and relation.
+	Read(object, relation string) ([]m.RelationTuple, error)
+	// Objects returns the objects of the namespace having any tuple.
+	Objects(namespace string) ([]string, error)
+}
+
+// Tree is the expanded set of subjects having a relation with an object.
+// Subjects are the ones stated by tuples, Children the nested sets.
+type Tree struct {
+	Object   string   `json:"object"`
+	Relation string   `json:"relation"`
+	Subjects []string `json:"subjects,omitempty"`
+	Children []*Tree  `json:"children,omitempty"`
+}
+
+// Checker answers relation questions over the tuples read
+// according to the rewrites of the namespaces.
+type Checker struct {
+	namespaces Namespaces
+	tuples     TupleReader
+}
+
+// NewChecker makes a new checker.
+func NewChecker(namespaces Namespaces, tuples TupleReader) *Checker {
+	return &Checker{namespaces: namespaces, tuples: tuples}
+}
+
+// Check - True if the subject has the relation with the object,
+// directly, through a subject set or through a rewrite.
+func (c *Checker) Check(object, relation, subject string) (bool, error) {
+	return c.check(newSearch(newCachedReader(c.tuples), subject), object, relation, 0)
+}
+
+// search is the state of answering whether a subject has relations.
+// Each object relation is only followed once, a revisit being either a
+// cycle or a relation already found not to hold, so that cyclic tuples
+// answer false and checks stay linear in the relations reachable.
+type search struct {
+	tuples  *cachedReader
+	subject string
+	visited map[string]bool
+}
+
+func newSearch(tuples *cachedReader, subject string) *search {
+	return &search{
+		tuples:  tuples,
+		subject: subject,
+		visited: make(map[string]bool),
+	}
+}
+
+func (c *Checker) check(s *search, object, relation string, depth int) (bool, error) {
+	if depth > maxDepth {
+		return false, ErrMaxDepth
+	}
+
+	rel, err := c.namespaces.Relation(m.Namespace(object), relation)
+	if err != nil {
+		return false, err
+	}
+
+	node := object + "#" + relation
+	if node == s.subject {
+		return true, nil
+	}
+
+	if s.visited[node] {
+		return false, nil
+	}
+	s.visited[node] = true
+
+	tuples, err := s.tuples.Read(object, relation)
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range tuples {
+		if t.Subject == s.subject {
+			return true, nil
+		}
+
+		setObject, setRelation := m.SplitSubject(t.Subject)
+		if setRelation == "" {
+			continue
+		}
+
+		ok, err := c.check(s, setObject, setRelation, depth+1)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	for _, rw := range rel.Union {
+		ok, err := c.rewrite(s, object, rw, depth)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+// rewrite checks the subject against the computed relation of the rewrite.
+func (c *Checker) rewrite(s *search, object string, rw Rewrite, depth int) (bool, error) {
+	if rw.Tupleset == "" {
+		return c.check(s, object, rw.ComputedUserset, depth+1)
+	}
+
+	tuples, err := s.tuples.Read(object, rw.Tupleset)
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range tuples {
+		target, _ := m.SplitSubject(t.Subject)
+		ok, err := c.check(s, target, rw.ComputedUserset, depth+1)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+// Expand returns the tree of subjects having the relation with the object.
+// Relations already being expanded higher in the tree are left as leaves
+// so that cyclic tuples end.
+func (c *Checker) Expand(object, relation string) (*Tree, error) {
+	return c.expand(object, relation, make(map[string]bool), 0)
+}
+
+func (c *Checker) expand(object, relation string, path map[string]bool, depth int) (*Tree, error) {
+	if depth > maxDepth {
+		return nil, ErrMaxDepth
+	}
+
+	rel, err := c.namespaces.Relation(m.Namespace(object), relation)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &Tree{Object: object, Relation: relation}
+
+	node := object + "#" + relation
+	if path[node] {
+		return tree, nil
+	}
+	path[node] = true
+	defer delete(path, node)
+
+	tuples, err := c.tuples.Read(object, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tuples {
+		setObject, setRelation := m.SplitSubject(t.Subject)
+		if setRelation == "" {
+			tree.Subjects = append(tree.Subjects, t.Subject)
+			continue
+		}
+
+		child, err := c.expand(setObject, setRelation, path, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		tree.Children = append(tree.Children, child)
+	}
+
+	for _, rw := range rel.Union {
+		if rw.Tupleset == "" {
+			child, err := c.expand(object, rw.ComputedUserset, path, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			tree.Children = append(tree.Children, child)
+			continue
+		}
+
+		tuples, err := c.tuples.Read(object, rw.Tupleset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tuples {
+			target, _ := m.SplitSubject(t.Subject)
+			child, err := c.expand(target, rw.ComputedUserset, path, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			tree.Children = append(tree.Children, child)
+		}
+	}
+
+	return tree, nil
+}
+
+// ListObjects returns the objects of the namespace the subject has the relation with.
+// Tuples are read once for all the objects checked, and objects whose check
+// exceeds the depth limit are left out rather than failing the whole list.
+func (c *Checker) ListObjects(namespace, relation, subject string) ([]string, error) {
+	_, err := c.namespaces.Relation(namespace, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := c.tuples.Objects(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples := newCachedReader(c.tuples)
+	objects := []string{}
+	for _, object := range candidates {
+		ok, err := c.check(newSearch(tuples, subject), object, relation, 0)
+		if err == ErrMaxDepth {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			objects = append(objects, object)
+		}
+	}
+
+	return objects, nil
+}
+
+// cachedReader reads the tuples of each object relation only once.
+type cachedReader struct {
+	TupleReader
+	read map[string][]m.RelationTuple
+}
+
+func newCachedReader(tuples TupleReader) *cachedReader {
+	return &cachedReader{TupleReader: tuples, read: make(map[string][]m.RelationTuple)}
+}
+
+// Read returns the tuples with the object and relation.
+func (r *cachedReader) Read(object, relation string) ([]m.RelationTuple, error) {
+	key := object + "#" + relation
+	if tuples, ok := r.read[key]; ok {
+		return tuples, nil
+	}
+
+	tuples, err := r.TupleReader.Read(object, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	r.read[key] = tuples
+	return tuples, nil
+}
@@ -0,0 +1,86 @@
+package rebac
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Namespaces maps namespace names to the relations their objects can have.
+type Namespaces map[string]Namespace
+
+// Namespace maps relation names to how they are computed.
+type Namespace map[string]Relation
+
+// Relation lists the rewrites whose subjects, along with the ones
+// of the tuples stating the relation, have the relation.
+type Relation struct {
+	Union []Rewrite `yaml:"union" json:"union"`
+}
+
+// Rewrite makes the subjects of another relation have this one.
+// Without Tupleset it is the relation of the same object
+// (e.g. editors are viewers), with it the relation of the objects
+// the tupleset relation points at (e.g. viewers of the parent folder).
+type Rewrite struct {
+	ComputedUserset string `yaml:"computedUserset" json:"computedUserset"`
+	Tupleset        string `yaml:"tupleset" json:"tupleset"`
+}
+
+// Load reads the namespaces configuration from a YAML or JSON file.
+func Load(path string) (Namespaces, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(b)
+}
+
+// Parse decodes and validates a YAML or JSON namespaces configuration.
+func Parse(b []byte) (Namespaces, error) {
+	var ns Namespaces
+	err := yaml.Unmarshal(b, &ns)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, n := range ns {
+		for rel, r := range n {
+			for _, rw := range r.Union {
+				if rw.ComputedUserset == "" {
+					return nil, fmt.Errorf("namespace '%s' relation '%s': computedUserset required", name, rel)
+				}
+
+				if rw.Tupleset == "" {
+					if _, ok := n[rw.ComputedUserset]; !ok {
+						return nil, fmt.Errorf("namespace '%s' relation '%s': unknown relation '%s'", name, rel, rw.ComputedUserset)
+					}
+					continue
+				}
+
+				if _, ok := n[rw.Tupleset]; !ok {
+					return nil, fmt.Errorf("namespace '%s' relation '%s': unknown tupleset '%s'", name, rel, rw.Tupleset)
+				}
+			}
+		}
+	}
+
+	return ns, nil
+}
+
+// Relation returns the definition of the relation of the namespace.
+func (ns Namespaces) Relation(namespace, relation string) (Relation, error) {
+	n, ok := ns[namespace]
+	if !ok {
+		return Relation{}, fmt.Errorf("unknown namespace '%s'", namespace)
+	}
+
+	r, ok := n[relation]
+	if !ok {
+		return Relation{}, fmt.Errorf("unknown relation '%s' of namespace '%s'", relation, namespace)
+	}
+
+	return r, nil
+}
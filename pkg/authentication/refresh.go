@@ -0,0 +1,150 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const (
+	opaqueTokenBytes = 32
+)
+
+// refresh exchanges a refresh token issued to a client for a new access token.
+// First party tokens are not issued to any client and have an empty client ID.
+func (gs granicaService) refresh(ctx context.Context, refreshToken, clientID, tenantID string) (*AuthToken, error) {
+	current, err := gs.refreshRepo.GetByDigest(ctx, tokenDigest(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if current.TenantID != tenantID || current.ClientID != clientID || current.IsRevoked {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if current.IsRotated() {
+		err = gs.refreshRepo.RevokeFamily(ctx, current.FamilyID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if current.IsExpired() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Family ID is the ID of the session opened at sign in.
+	s, err := gs.sessions.Get(ctx, current.FamilyID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := gs.users(current.TenantID).Get(ctx, current.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	raw, next, err := gs.newRefreshToken(user, current.FamilyID, current.ClientID, current.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// Concurrent use of the same token: only one rotation can win.
+	ok, err := gs.refreshRepo.Rotate(ctx, current.ID, next.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		err = gs.refreshRepo.RevokeFamily(ctx, current.FamilyID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	err = gs.refreshRepo.Insert(ctx, next)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := userClaims(user, current.FamilyID.String())
+	claims.ClientID = current.ClientID
+	claims.Scope = current.Scope
+
+	err = gs.grantRoles(ctx, &claims, user)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := gs.tokens.Issue(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// User authenticated when the session was opened.
+	if current.ClientID != "" && hasScope(current.Scope, openIDScope) {
+		token.IDToken, err = gs.idToken(user, current.ClientID, current.Scope, "", s.CreatedAt, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	token.RefreshToken = raw
+	return token, nil
+}
+
+// newRefreshToken generates a refresh token for the user in the given family.
+// The opaque value is returned to be handed to the client, the model only
+// keeps its digest.
+func (gs granicaService) newRefreshToken(user *m.User, familyID uuid.UUID, clientID, scope string) (string, *m.RefreshToken, error) {
+	raw, err := genOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	rt := &m.RefreshToken{
+		ID:        uuid.New(),
+		Digest:    tokenDigest(raw),
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		ClientID:  clientID,
+		Scope:     scope,
+		ExpiresAt: now.Add(gs.cfg.Token.RefreshTTL),
+		CreatedAt: now,
+	}
+
+	return raw, rt, nil
+}
+
+// genOpaqueToken returns a random URL safe token.
+func genOpaqueToken() (string, error) {
+	b := make([]byte, opaqueTokenBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// tokenDigest returns the hex encoded SHA-256 digest of an opaque token.
+func tokenDigest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,67 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := svc.Refresh(ctx, first.RefreshToken, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	if _, err := svc.Refresh(ctx, first.RefreshToken, "localhost"); err != ErrRefreshTokenReused {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrRefreshTokenReused)
+	}
+
+	// Reuse revokes the whole family, including the latest token.
+	if _, err := svc.Refresh(ctx, second.RefreshToken, "localhost"); err != ErrInvalidRefreshToken {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestRefreshRejectsOtherTenant(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Refresh(ctx, token.RefreshToken, "other"); err != ErrInvalidRefreshToken {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrInvalidRefreshToken)
+	}
+}
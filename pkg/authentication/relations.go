@@ -0,0 +1,132 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/rebac"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// tenantTuples reads the relation tuples of a tenant
+// within the context of the request they are read for.
+type tenantTuples struct {
+	ctx      context.Context
+	repo     repo.RelationTupleRepo
+	tenantID string
+}
+
+func (t tenantTuples) Read(object, relation string) ([]m.RelationTuple, error) {
+	return t.repo.GetByObject(t.ctx, t.tenantID, object, relation)
+}
+
+func (t tenantTuples) Objects(namespace string) ([]string, error) {
+	return t.repo.GetObjects(t.ctx, t.tenantID, namespace)
+}
+
+// checker returns a relation checker over the tuples of the tenant.
+func (gs granicaService) checker(ctx context.Context, tenantID string) *rebac.Checker {
+	return rebac.NewChecker(gs.namespaces, tenantTuples{ctx: ctx, repo: gs.tupleRepo, tenantID: tenantID})
+}
+
+// WriteRelation states that the subject has the relation with the object.
+// Writing an existing tuple returns it unchanged.
+func (gs granicaService) WriteRelation(ctx context.Context, object, relation, subject, tenantID string) (*m.RelationTuple, error) {
+	err := gs.validateTuple(object, relation, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	tuple, err := gs.tupleRepo.Get(ctx, tenantID, object, relation, subject)
+	if err == nil {
+		return tuple, nil
+	}
+
+	tuple = &m.RelationTuple{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		Object:    object,
+		Relation:  relation,
+		Subject:   subject,
+		CreatedAt: time.Now(),
+	}
+
+	err = gs.tupleRepo.Insert(ctx, tuple)
+	if err != nil {
+		return nil, err
+	}
+
+	return tuple, nil
+}
+
+// DeleteRelation deletes the tuple stating that the subject has the relation with the object.
+// Relations the subject has through other tuples are kept.
+func (gs granicaService) DeleteRelation(ctx context.Context, object, relation, subject, tenantID string) error {
+	_, err := gs.tupleRepo.Get(ctx, tenantID, object, relation, subject)
+	if err != nil {
+		return errors.New("relation not found")
+	}
+
+	return gs.tupleRepo.Delete(ctx, tenantID, object, relation, subject)
+}
+
+// CheckRelation - True if the subject has the relation with the object.
+func (gs granicaService) CheckRelation(ctx context.Context, object, relation, subject, tenantID string) (bool, error) {
+	if !m.ValidObject(object) || !m.ValidSubject(subject) {
+		return false, m.ErrInvalidRelationTuple
+	}
+
+	return gs.checker(ctx, tenantID).Check(object, relation, subject)
+}
+
+// ExpandRelation returns the tree of subjects having the relation with the object.
+func (gs granicaService) ExpandRelation(ctx context.Context, object, relation, tenantID string) (*rebac.Tree, error) {
+	if !m.ValidObject(object) {
+		return nil, m.ErrInvalidRelationTuple
+	}
+
+	return gs.checker(ctx, tenantID).Expand(object, relation)
+}
+
+// ListObjects returns the objects of the namespace the subject has the relation with.
+func (gs granicaService) ListObjects(ctx context.Context, namespace, relation, subject, tenantID string) ([]string, error) {
+	if !m.ValidSubject(subject) {
+		return nil, m.ErrInvalidRelationTuple
+	}
+
+	return gs.checker(ctx, tenantID).ListObjects(namespace, relation, subject)
+}
+
+// validateTuple returns an error if the tuple is malformed or its relations
+// are not defined by the namespaces configuration.
+func (gs granicaService) validateTuple(object, relation, subject string) error {
+	_, err := m.ParseRelationTuple(object + "#" + relation + "@" + subject)
+	if err != nil {
+		return err
+	}
+
+	_, err = gs.namespaces.Relation(m.Namespace(object), relation)
+	if err != nil {
+		return err
+	}
+
+	setObject, setRelation := m.SplitSubject(subject)
+	if setRelation != "" {
+		_, err = gs.namespaces.Relation(m.Namespace(setObject), setRelation)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
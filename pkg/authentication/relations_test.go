@@ -0,0 +1,323 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/rebac"
+	repomem "gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/memory"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// testTuples are the relations the tests check, on the namespaces of configs.
+var testTuples = []string{
+	"group:eng#member@user:ann",
+	"group:eng#member@user:bob",
+	"folder:specs#owner@user:carl",
+	"folder:specs#viewer@group:eng#member",
+	"document:api#parent@folder:specs",
+	"document:api#editor@user:bob",
+	"document:notes#owner@user:dan",
+}
+
+// writeTestTuples writes the relation tuples in the system tenant.
+func writeTestTuples(ctx context.Context, t *testing.T, svc *granicaService, tuples []string) {
+	for _, s := range tuples {
+		tuple, err := m.ParseRelationTuple(s)
+		if err != nil {
+			t.Fatalf("Tuple '%s' error: %v", s, err)
+		}
+
+		_, err = svc.WriteRelation(ctx, tuple.Object, tuple.Relation, tuple.Subject, "localhost")
+		if err != nil {
+			t.Fatalf("Tuple '%s' error: %v", s, err)
+		}
+	}
+}
+
+func TestCheckRelation(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	namespaces, err := rebac.Load("../../configs/namespaces.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.namespaces = namespaces
+	writeTestTuples(ctx, t, svc, testTuples)
+
+	tests := []struct {
+		tuple   string
+		allowed bool
+	}{
+		{"document:api#editor@user:bob", true},
+		{"document:api#viewer@user:bob", true},
+		{"document:api#viewer@user:ann", true},
+		{"document:api#editor@user:ann", false},
+		{"document:api#editor@user:carl", true},
+		{"document:api#owner@user:carl", false},
+		{"document:notes#viewer@user:dan", true},
+		{"document:notes#viewer@user:ann", false},
+		{"folder:specs#viewer@group:eng#member", true},
+	}
+
+	for _, tt := range tests {
+		tuple, err := m.ParseRelationTuple(tt.tuple)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		allowed, err := svc.CheckRelation(ctx, tuple.Object, tuple.Relation, tuple.Subject, "localhost")
+		if err != nil {
+			t.Errorf("Check '%s' error: %v", tt.tuple, err)
+			continue
+		}
+
+		if allowed != tt.allowed {
+			t.Errorf("Check '%s': %t | Expected: %t", tt.tuple, allowed, tt.allowed)
+		}
+	}
+
+	// Tuples are tenant scoped.
+	allowed, err := svc.CheckRelation(ctx, "document:api", "viewer", "user:bob", "other.granica.dev")
+	if err != nil || allowed {
+		t.Errorf("Other tenant check: %t, %v | Expected: false, nil", allowed, err)
+	}
+
+	if _, err := svc.CheckRelation(ctx, "document:api", "commenter", "user:bob", "localhost"); err == nil {
+		t.Error("expected check of an unknown relation to fail")
+	}
+}
+
+func TestWriteRelationValidation(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	namespaces, err := rebac.Load("../../configs/namespaces.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.namespaces = namespaces
+	writeTestTuples(ctx, t, svc, testTuples)
+
+	invalid := [][3]string{
+		{"document", "viewer", "user:ann"},
+		{"document:api", "commenter", "user:ann"},
+		{"spreadsheet:q3", "viewer", "user:ann"},
+		{"document:api", "viewer", "group:eng#admin"},
+		{"document:api", "viewer", "user:ann#"},
+	}
+
+	for _, tt := range invalid {
+		if _, err := svc.WriteRelation(ctx, tt[0], tt[1], tt[2], "localhost"); err == nil {
+			t.Errorf("Tuple '%s#%s@%s' written | Expected: error", tt[0], tt[1], tt[2])
+		}
+	}
+
+	before, _ := svc.tupleRepo.GetByObject(ctx, "localhost", "document:api", "editor")
+	if _, err := svc.WriteRelation(ctx, "document:api", "editor", "user:bob", "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	after, _ := svc.tupleRepo.GetByObject(ctx, "localhost", "document:api", "editor")
+	if len(after) != len(before) {
+		t.Errorf("Tuples: %d | Expected: %d", len(after), len(before))
+	}
+}
+
+func TestDeleteRelation(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	namespaces, err := rebac.Load("../../configs/namespaces.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.namespaces = namespaces
+	writeTestTuples(ctx, t, svc, testTuples)
+
+	err = svc.DeleteRelation(ctx, "folder:specs", "viewer", "group:eng#member", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allowed, err := svc.CheckRelation(ctx, "document:api", "viewer", "user:ann", "localhost")
+	if err != nil || allowed {
+		t.Errorf("Check: %t, %v | Expected: false, nil", allowed, err)
+	}
+
+	// Bob is still a direct editor.
+	allowed, err = svc.CheckRelation(ctx, "document:api", "viewer", "user:bob", "localhost")
+	if err != nil || !allowed {
+		t.Errorf("Check: %t, %v | Expected: true, nil", allowed, err)
+	}
+
+	if err := svc.DeleteRelation(ctx, "folder:specs", "viewer", "group:eng#member", "localhost"); err == nil {
+		t.Error("expected deleting a missing tuple to fail")
+	}
+}
+
+func TestExpandRelation(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	namespaces, err := rebac.Load("../../configs/namespaces.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.namespaces = namespaces
+	writeTestTuples(ctx, t, svc, testTuples)
+
+	tree, err := svc.ExpandRelation(ctx, "document:api", "viewer", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var subjects []string
+	var collect func(*rebac.Tree)
+	collect = func(t *rebac.Tree) {
+		subjects = append(subjects, t.Subjects...)
+		for _, c := range t.Children {
+			collect(c)
+		}
+	}
+	collect(tree)
+	sort.Strings(subjects)
+
+	expected := []string{"user:ann", "user:bob", "user:bob", "user:carl", "user:carl"}
+	if !reflect.DeepEqual(subjects, expected) {
+		t.Errorf("Subjects: %v | Expected: %v", subjects, expected)
+	}
+}
+
+func TestListObjects(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	namespaces, err := rebac.Load("../../configs/namespaces.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.namespaces = namespaces
+	writeTestTuples(ctx, t, svc, testTuples)
+
+	tests := []struct {
+		relation string
+		subject  string
+		objects  []string
+	}{
+		{"viewer", "user:ann", []string{"document:api"}},
+		{"viewer", "user:dan", []string{"document:notes"}},
+		{"editor", "user:carl", []string{"document:api"}},
+		{"owner", "user:ann", []string{}},
+	}
+
+	for _, tt := range tests {
+		objects, err := svc.ListObjects(ctx, "document", tt.relation, tt.subject, "localhost")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(objects, tt.objects) {
+			t.Errorf("Objects '%s' of '%s': %v | Expected: %v", tt.relation, tt.subject, objects, tt.objects)
+		}
+	}
+}
+
+func TestRelationCycles(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	namespaces, err := rebac.Load("../../configs/namespaces.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.namespaces = namespaces
+	writeTestTuples(ctx, t, svc, []string{
+		"group:a#member@group:b#member",
+		"group:b#member@group:a#member",
+		"group:b#member@user:eve",
+		"folder:x#parent@folder:y",
+		"folder:y#parent@folder:x",
+		"folder:y#owner@user:eve",
+	})
+
+	tests := []struct {
+		tuple   string
+		allowed bool
+	}{
+		{"group:a#member@user:eve", true},
+		{"group:a#member@user:ann", false},
+		{"folder:x#viewer@user:eve", true},
+		{"folder:x#viewer@user:ann", false},
+	}
+
+	for _, tt := range tests {
+		tuple, err := m.ParseRelationTuple(tt.tuple)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ok, err := svc.CheckRelation(ctx, tuple.Object, tuple.Relation, tuple.Subject, "localhost")
+		if err != nil || ok != tt.allowed {
+			t.Errorf("Check '%s': %v, %v | Expected: %v", tt.tuple, ok, err, tt.allowed)
+		}
+	}
+
+	objects, err := svc.ListObjects(ctx, "folder", "viewer", "user:eve", "localhost")
+	if err != nil || !reflect.DeepEqual(objects, []string{"folder:x", "folder:y"}) {
+		t.Errorf("Objects: %v, %v | Expected: [folder:x folder:y]", objects, err)
+	}
+
+	if _, err := svc.ExpandRelation(ctx, "folder:x", "viewer", "localhost"); err != nil {
+		t.Errorf("Expand error: %v | Expected: nil", err)
+	}
+}
+
+func TestRelationDepthLimit(t *testing.T) {
+	ctx := context.Background()
+
+	namespaces, err := rebac.Parse([]byte(`
+node:
+  next: {}
+  reaches:
+    union:
+      - tupleset: next
+        computedUserset: reaches
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A chain longer than the depth limit, without cycles.
+	repo := repomem.NewRelationTupleRepo()
+	for i := 0; i < 30; i++ {
+		repo.Insert(ctx, &m.RelationTuple{
+			TenantID: "localhost",
+			Object:   fmt.Sprintf("node:%d", i),
+			Relation: "next",
+			Subject:  fmt.Sprintf("node:%d", i+1),
+		})
+	}
+
+	checker := rebac.NewChecker(namespaces, tenantTuples{ctx: ctx, repo: repo, tenantID: "localhost"})
+	if _, err := checker.Check("node:0", "reaches", "user:ann"); err != rebac.ErrMaxDepth {
+		t.Errorf("Error: %v | Expected: %v", err, rebac.ErrMaxDepth)
+	}
+}
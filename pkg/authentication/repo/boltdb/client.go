@@ -0,0 +1,82 @@
+package boltdb
+
+import (
+	"context"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// clientsBucket holds the OAuth clients by tenant and client ID.
+var clientsBucket = []byte("clients")
+
+// ClientRepo is a file backed implementation of ClientRepo interface.
+type ClientRepo struct {
+	db *bolt.DB
+}
+
+// NewClientRepo makes a new OAuth client repo on the shared file.
+func NewClientRepo(db *bolt.DB) *ClientRepo {
+	return &ClientRepo{db: db}
+}
+
+// Insert a client in ClientRepo.
+func (r *ClientRepo) Insert(ctx context.Context, client *m.Client) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, clientsBucket, indexKey(client.TenantID, client.ClientID), client)
+	})
+}
+
+// GetByClientIDAndTenant gets a client from repo by its client ID and tenant.
+func (r *ClientRepo) GetByClientIDAndTenant(ctx context.Context, clientID, tenantID string) (*m.Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var client m.Client
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return load(tx, clientsBucket, indexKey(tenantID, clientID), &client)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+// Update a client in ClientRepo.
+func (r *ClientRepo) Update(ctx context.Context, client *m.Client) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		// Clients are updated by ID, the key of the stored one may differ.
+		var current []byte
+		err := forEach(tx, clientsBucket, nil, func(key, doc []byte) error {
+			var c m.Client
+			err := bson.Unmarshal(doc, &c)
+			if err == nil && c.ID == client.ID {
+				current = append([]byte(nil), key...)
+				return errStop
+			}
+			return err
+		})
+		if err != nil || current == nil {
+			return err
+		}
+
+		err = tx.Bucket(clientsBucket).Delete(current)
+		if err != nil {
+			return err
+		}
+
+		return put(tx, clientsBucket, indexKey(client.TenantID, client.ClientID), client)
+	})
+}
@@ -0,0 +1,89 @@
+package boltdb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// passwordResetsBucket holds the password resets by digest.
+var passwordResetsBucket = []byte("password_resets")
+
+// PasswordResetRepo is a file backed implementation of PasswordResetRepo interface.
+type PasswordResetRepo struct {
+	db *bolt.DB
+}
+
+// NewPasswordResetRepo makes a new password reset repo on the shared file.
+func NewPasswordResetRepo(db *bolt.DB) *PasswordResetRepo {
+	return &PasswordResetRepo{db: db}
+}
+
+// Insert a password reset in PasswordResetRepo.
+func (r *PasswordResetRepo) Insert(ctx context.Context, reset *m.PasswordReset) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, passwordResetsBucket, []byte(reset.Digest), reset)
+	})
+}
+
+// Consume gets and deletes a password reset by its digest.
+// Lookup and deletion are atomic so a reset can only be consumed once.
+func (r *PasswordResetRepo) Consume(ctx context.Context, digest string) (*m.PasswordReset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var reset m.PasswordReset
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		err := load(tx, passwordResetsBucket, []byte(digest), &reset)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(passwordResetsBucket).Delete([]byte(digest))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &reset, nil
+}
+
+// DeleteByUser deletes all the pending password resets of a user.
+func (r *PasswordResetRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		var keys [][]byte
+		err := forEach(tx, passwordResetsBucket, nil, func(key, doc []byte) error {
+			var reset m.PasswordReset
+			err := bson.Unmarshal(doc, &reset)
+			if err == nil && reset.UserID == userID {
+				keys = append(keys, append([]byte(nil), key...))
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			err := tx.Bucket(passwordResetsBucket).Delete(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package boltdb
+
+import (
+	"context"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// permissionsBucket holds the permissions by tenant and name.
+var permissionsBucket = []byte("permissions")
+
+// PermissionRepo is a file backed implementation of PermissionRepo interface.
+type PermissionRepo struct {
+	db *bolt.DB
+}
+
+// NewPermissionRepo makes a new permission repo on the shared file.
+func NewPermissionRepo(db *bolt.DB) *PermissionRepo {
+	return &PermissionRepo{db: db}
+}
+
+// Insert a permission in PermissionRepo.
+func (r *PermissionRepo) Insert(ctx context.Context, permission *m.Permission) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, permissionsBucket, indexKey(permission.TenantID, permission.Name), permission)
+	})
+}
+
+// GetByNameAndTenant gets a permission by its name and tenant.
+func (r *PermissionRepo) GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Permission, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var permission m.Permission
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return load(tx, permissionsBucket, indexKey(tenantID, name), &permission)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &permission, nil
+}
+
+// GetByTenant gets all the permissions defined by a tenant.
+func (r *PermissionRepo) GetByTenant(ctx context.Context, tenantID string) ([]m.Permission, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var permissions []m.Permission
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return forEach(tx, permissionsBucket, indexKey(tenantID, ""), func(_, doc []byte) error {
+			var p m.Permission
+			err := bson.Unmarshal(doc, &p)
+			if err == nil && p.TenantID == tenantID {
+				permissions = append(permissions, p)
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
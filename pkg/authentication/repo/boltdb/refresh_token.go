@@ -0,0 +1,127 @@
+package boltdb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// Buckets of the refresh tokens, by ID, and of their digest index.
+var (
+	refreshTokensBucket       = []byte("refresh_tokens")
+	refreshTokenDigestsBucket = []byte("refresh_tokens_by_digest")
+)
+
+// RefreshTokenRepo is a file backed implementation of RefreshTokenRepo interface.
+type RefreshTokenRepo struct {
+	db *bolt.DB
+}
+
+// NewRefreshTokenRepo makes a new refresh token repo on the shared file.
+func NewRefreshTokenRepo(db *bolt.DB) *RefreshTokenRepo {
+	return &RefreshTokenRepo{db: db}
+}
+
+// Insert a refresh token in RefreshTokenRepo.
+func (r *RefreshTokenRepo) Insert(ctx context.Context, token *m.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		err := put(tx, refreshTokensBucket, token.ID[:], token)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(refreshTokenDigestsBucket).Put([]byte(token.Digest), token.ID[:])
+	})
+}
+
+// GetByDigest gets a refresh token from repo by its digest.
+func (r *RefreshTokenRepo) GetByDigest(ctx context.Context, digest string) (*m.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var token m.RefreshToken
+	err := r.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(refreshTokenDigestsBucket).Get([]byte(digest))
+		if id == nil {
+			return m.ErrNotFound
+		}
+
+		return load(tx, refreshTokensBucket, id, &token)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Rotate marks a refresh token as replaced by a new one.
+// It returns false if the token had already been rotated or revoked.
+func (r *RefreshTokenRepo) Rotate(ctx context.Context, id, replacedBy uuid.UUID) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var ok bool
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		var token m.RefreshToken
+		err := load(tx, refreshTokensBucket, id[:], &token)
+		if err == m.ErrNotFound || token.IsRotated() || token.IsRevoked {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		token.ReplacedBy = replacedBy
+		ok = true
+		return put(tx, refreshTokensBucket, id[:], &token)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// RevokeFamily revokes all refresh tokens sharing a family.
+func (r *RefreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		var family []m.RefreshToken
+		err := forEach(tx, refreshTokensBucket, nil, func(_, doc []byte) error {
+			var token m.RefreshToken
+			err := bson.Unmarshal(doc, &token)
+			if err == nil && token.FamilyID == familyID {
+				family = append(family, token)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		// Buckets cannot be written while iterated.
+		for _, token := range family {
+			token.IsRevoked = true
+			err := put(tx, refreshTokensBucket, token.ID[:], &token)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,117 @@
+package boltdb
+
+import (
+	"context"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// tuplesBucket holds the relation tuples by tenant, object, relation and subject.
+var tuplesBucket = []byte("relation_tuples")
+
+// RelationTupleRepo is a file backed implementation of RelationTupleRepo interface.
+type RelationTupleRepo struct {
+	db *bolt.DB
+}
+
+// NewRelationTupleRepo makes a new relation tuple repo on the shared file.
+func NewRelationTupleRepo(db *bolt.DB) *RelationTupleRepo {
+	return &RelationTupleRepo{db: db}
+}
+
+// Insert a relation tuple in RelationTupleRepo.
+func (r *RelationTupleRepo) Insert(ctx context.Context, tuple *m.RelationTuple) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, tuplesBucket, tupleKey(tuple.TenantID, tuple.Object, tuple.Relation, tuple.Subject), tuple)
+	})
+}
+
+// Get gets a relation tuple of the tenant.
+func (r *RelationTupleRepo) Get(ctx context.Context, tenantID, object, relation, subject string) (*m.RelationTuple, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var tuple m.RelationTuple
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return load(tx, tuplesBucket, tupleKey(tenantID, object, relation, subject), &tuple)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &tuple, nil
+}
+
+// GetByObject gets the relation tuples of the tenant with the object and relation.
+func (r *RelationTupleRepo) GetByObject(ctx context.Context, tenantID, object, relation string) ([]m.RelationTuple, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var tuples []m.RelationTuple
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return forEach(tx, tuplesBucket, tupleKey(tenantID, object, relation, ""), func(_, doc []byte) error {
+			var t m.RelationTuple
+			err := bson.Unmarshal(doc, &t)
+			if err == nil {
+				tuples = append(tuples, t)
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tuples, nil
+}
+
+// GetObjects gets the distinct objects of the namespace the tenant has tuples of.
+func (r *RelationTupleRepo) GetObjects(ctx context.Context, tenantID, namespace string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Keys are sorted so the tuples of an object are next to each other.
+	var objects []string
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return forEach(tx, tuplesBucket, indexKey(tenantID, namespace+":"), func(_, doc []byte) error {
+			var t m.RelationTuple
+			err := bson.Unmarshal(doc, &t)
+			if err == nil && (len(objects) == 0 || objects[len(objects)-1] != t.Object) {
+				objects = append(objects, t.Object)
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// Delete a relation tuple of the tenant.
+func (r *RelationTupleRepo) Delete(ctx context.Context, tenantID, object, relation, subject string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(tuplesBucket).Delete(tupleKey(tenantID, object, relation, subject))
+	})
+}
+
+// tupleKey joins the fields of a tuple so that the tuples
+// of an object and relation share a prefix.
+func tupleKey(tenantID, object, relation, subject string) []byte {
+	return []byte(tenantID + "\x00" + object + "\x00" + relation + "\x00" + subject)
+}
@@ -0,0 +1,496 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// Buckets of the users and of their username and email indexes.
+// Index keys are the tenant and the indexed value; their values the user ID.
+var (
+	usersBucket     = []byte("users")
+	usernamesBucket = []byte("users_by_username")
+	emailsBucket    = []byte("users_by_email")
+)
+
+// buckets are all the buckets of the file, one or more per repo.
+var buckets = [][]byte{
+	usersBucket, usernamesBucket, emailsBucket,
+	refreshTokensBucket, refreshTokenDigestsBucket, passwordResetsBucket,
+	clientsBucket, credentialsBucket, rolesBucket, permissionsBucket,
+	assignmentsBucket, tuplesBucket, tenantsBucket,
+}
+
+const (
+	// openTimeout is how long to wait for the file lock of another process.
+	openTimeout = 5 * time.Second
+	// backupLayout sorts backups of the same file by the time they were made.
+	backupLayout = "20060102T150405.000000000"
+)
+
+// UserRepo is a file backed implementation of UserRepo interface,
+// for single node deployments.
+// Users and their indexes are written in the same transaction, synced to
+// disk before it returns, so that a crash never leaves them out of step.
+// Like in the Mongo one, the ID of the users and their username and email
+// within their tenant are unique.
+type UserRepo struct {
+	db *bolt.DB
+}
+
+// Open opens the repo file, creating it and its buckets if they do not exist.
+// A backup of existing files is made before anything is written to them.
+// The file is meant to be shared by all the repos of the service.
+func Open(ctx context.Context, cfg *config.Config, logger log.Logger) (*bolt.DB, error) {
+	path := cfg.Repo.Bolt.Path
+
+	_, err := os.Stat(path)
+	existed := err == nil
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("cannot open '%s': %s", path, err.Error())
+	}
+
+	// Backed up before any write so that it can be restored untouched.
+	if existed && cfg.Repo.Bolt.Backups > 0 {
+		backup, err := backup(db, path, cfg.Repo.Bolt.Backups)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("cannot back up '%s': %s", path, err.Error())
+		}
+		logger.Log("level", config.LogLevel.Info, "msg", "Bolt file backed up", "backup", backup)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			_, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	logger.Log("level", config.LogLevel.Info, "msg", "Bolt file opened", "path", path)
+
+	return db, nil
+}
+
+// NewRepo makes a new user repo on the shared file.
+func NewRepo(db *bolt.DB) *UserRepo {
+	return &UserRepo{db: db}
+}
+
+// Close the repo file, shared by all the repos opened on it.
+func (r *UserRepo) Close() error {
+	return r.db.Close()
+}
+
+// Insert a user in UserRepo.
+func (r *UserRepo) Insert(ctx context.Context, user *m.User) (id interface{}, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	doc, err := bson.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		users := tx.Bucket(usersBucket)
+		if users.Get(user.ID[:]) != nil || collides(tx, user) {
+			return m.ErrDuplicateUser
+		}
+
+		err := users.Put(user.ID[:], doc)
+		if err != nil {
+			return err
+		}
+
+		return putIndexes(tx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user.ID, nil
+}
+
+// GetAll users from repo.
+func (r *UserRepo) GetAll(ctx context.Context) ([]m.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var users []m.User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(usersBucket).ForEach(func(_, doc []byte) error {
+			var u m.User
+			err := bson.Unmarshal(doc, &u)
+			if err != nil {
+				return err
+			}
+			users = append(users, u)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Users are stored by ID.
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+
+	return users, nil
+}
+
+// Get a user from repo by its ID.
+func (r *UserRepo) Get(ctx context.Context, id interface{}) (*m.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	uid, ok := toUUID(id)
+	if !ok {
+		return nil, m.ErrUserNotFound
+	}
+
+	var user *m.User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		user, err = get(tx, uid[:])
+		return err
+	})
+
+	return user, err
+}
+
+// GetByUsernameAndTenant gets a user from repo by its username and tenant.
+func (r *UserRepo) GetByUsernameAndTenant(ctx context.Context, username, tenantID string) (*m.User, error) {
+	return r.getByIndex(ctx, usernamesBucket, indexKey(tenantID, username))
+}
+
+// GetByEmailAndTenant gets a user from repo by its email and tenant.
+func (r *UserRepo) GetByEmailAndTenant(ctx context.Context, email, tenantID string) (*m.User, error) {
+	if email == "" {
+		return nil, m.ErrUserNotFound
+	}
+
+	return r.getByIndex(ctx, emailsBucket, indexKey(tenantID, email))
+}
+
+func (r *UserRepo) getByIndex(ctx context.Context, index, key []byte) (*m.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var user *m.User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(index).Get(key)
+		if id == nil {
+			return m.ErrUserNotFound
+		}
+
+		var err error
+		user, err = get(tx, id)
+		return err
+	})
+
+	return user, err
+}
+
+// Update a user in UserRepo.
+// Users cannot be moved to another tenant.
+func (r *UserRepo) Update(ctx context.Context, user *m.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	doc, err := bson.Marshal(user)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		current, err := get(tx, user.ID[:])
+		if err != nil {
+			return err
+		}
+
+		if current.TenantID != user.TenantID {
+			return m.ErrUserNotFound
+		}
+
+		if collides(tx, user) {
+			return m.ErrDuplicateUser
+		}
+
+		err = deleteIndexes(tx, current)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(usersBucket).Put(user.ID[:], doc)
+		if err != nil {
+			return err
+		}
+
+		return putIndexes(tx, user)
+	})
+}
+
+// UseMFAStep sets the last TOTP step of the user if it is past the stored one.
+func (r *UserRepo) UseMFAStep(ctx context.Context, user *m.User, step int64) (bool, error) {
+	return r.use(ctx, user, func(current *m.User) bool {
+		if current.MFALastStep >= step {
+			return false
+		}
+		current.MFALastStep = step
+		return true
+	})
+}
+
+// UseRecoveryCode removes the recovery code digest if the user still has it.
+func (r *UserRepo) UseRecoveryCode(ctx context.Context, user *m.User, digest string) (bool, error) {
+	return r.use(ctx, user, func(current *m.User) bool {
+		for i, rc := range current.RecoveryCodes {
+			if rc == digest {
+				current.RecoveryCodes = append(current.RecoveryCodes[:i], current.RecoveryCodes[i+1:]...)
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// use applies the change to the stored user in the transaction it is read in.
+func (r *UserRepo) use(ctx context.Context, user *m.User, change func(*m.User) bool) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var ok bool
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		current, err := get(tx, user.ID[:])
+		if err == m.ErrUserNotFound || (err == nil && current.TenantID != user.TenantID) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !change(current) {
+			return nil
+		}
+
+		current.UpdatedBy, current.UpdatedAt = user.UpdatedBy, user.UpdatedAt
+		ok = true
+		return put(tx, usersBucket, user.ID[:], current)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// Delete a user from repo.
+func (r *UserRepo) Delete(ctx context.Context, id interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	uid, ok := toUUID(id)
+	if !ok {
+		return m.ErrUserNotFound
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		current, err := get(tx, uid[:])
+		if err != nil {
+			return err
+		}
+
+		err = deleteIndexes(tx, current)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(usersBucket).Delete(uid[:])
+	})
+}
+
+func get(tx *bolt.Tx, id []byte) (*m.User, error) {
+	doc := tx.Bucket(usersBucket).Get(id)
+	if doc == nil {
+		return nil, m.ErrUserNotFound
+	}
+
+	// Values are only valid during the transaction; Unmarshal copies them.
+	var user m.User
+	err := bson.Unmarshal(doc, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// collides - True if another user of the tenant has the username or the email of the user.
+// Users without email do not collide.
+func collides(tx *bolt.Tx, user *m.User) bool {
+	taken := func(index []byte, value string) bool {
+		id := tx.Bucket(index).Get(indexKey(user.TenantID, value))
+		return id != nil && !bytes.Equal(id, user.ID[:])
+	}
+
+	return taken(usernamesBucket, user.Username) || (user.Email != "" && taken(emailsBucket, user.Email))
+}
+
+func putIndexes(tx *bolt.Tx, user *m.User) error {
+	err := tx.Bucket(usernamesBucket).Put(indexKey(user.TenantID, user.Username), user.ID[:])
+	if err != nil {
+		return err
+	}
+
+	if user.Email == "" {
+		return nil
+	}
+
+	return tx.Bucket(emailsBucket).Put(indexKey(user.TenantID, user.Email), user.ID[:])
+}
+
+func deleteIndexes(tx *bolt.Tx, user *m.User) error {
+	err := tx.Bucket(usernamesBucket).Delete(indexKey(user.TenantID, user.Username))
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(emailsBucket).Delete(indexKey(user.TenantID, user.Email))
+}
+
+// indexKey - Tenant and value, separated by a byte neither of them contains.
+func indexKey(tenantID, value string) []byte {
+	return []byte(tenantID + "\x00" + value)
+}
+
+// errStop ends a forEach loop early without error.
+var errStop = errors.New("stop")
+
+// put writes the document of a model under the key.
+func put(tx *bolt.Tx, bucket, key []byte, model interface{}) error {
+	doc, err := bson.Marshal(model)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucket).Put(key, doc)
+}
+
+// load reads the document under the key into the model.
+// It returns ErrNotFound if there is none.
+func load(tx *bolt.Tx, bucket, key []byte, model interface{}) error {
+	doc := tx.Bucket(bucket).Get(key)
+	if doc == nil {
+		return m.ErrNotFound
+	}
+	return bson.Unmarshal(doc, model)
+}
+
+// forEach calls fn with the key and document of every model in the bucket
+// whose key has the prefix, until fn returns an error.
+func forEach(tx *bolt.Tx, bucket, prefix []byte, fn func(key, doc []byte) error) error {
+	c := tx.Bucket(bucket).Cursor()
+	for k, doc := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, doc = c.Next() {
+		err := fn(k, doc)
+		if err == errStop {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toUUID(id interface{}) (uuid.UUID, bool) {
+	switch id := id.(type) {
+	case uuid.UUID:
+		return id, true
+	case string:
+		uid, err := uuid.Parse(id)
+		return uid, err == nil
+	}
+	return uuid.Nil, false
+}
+
+// backup writes a consistent copy of the file next to it and removes
+// the oldest backups beyond the ones to keep.
+// The copy is written under a temporary name and renamed once synced,
+// so that a crash never leaves a partial backup behind.
+func backup(db *bolt.DB, path string, keep int) (string, error) {
+	name := fmt.Sprintf("%s.%s.bak", path, time.Now().UTC().Format(backupLayout))
+	tmp := name + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(f)
+		return err
+	})
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	err = os.Rename(tmp, name)
+	if err != nil {
+		return "", err
+	}
+
+	backups, err := filepath.Glob(path + ".*.bak")
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(backups)
+	for len(backups) > keep {
+		err = os.Remove(backups[0])
+		if err != nil {
+			return "", err
+		}
+		backups = backups[1:]
+	}
+
+	return name, nil
+}
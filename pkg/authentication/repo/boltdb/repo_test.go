@@ -0,0 +1,204 @@
+package boltdb_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/boltdb"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/repotest"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+func testConfig(t *testing.T, backups int) (*config.Config, func()) {
+	dir, err := ioutil.TempDir("", "granica-bolt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Repo.Bolt.Path = filepath.Join(dir, "granica.db")
+	cfg.Repo.Bolt.Backups = backups
+	return cfg, func() { os.RemoveAll(dir) }
+}
+
+func open(t *testing.T, cfg *config.Config) *boltdb.UserRepo {
+	db, err := boltdb.Open(context.Background(), cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return boltdb.NewRepo(db)
+}
+
+func TestUserRepo(t *testing.T) {
+	// Each subtest gets a file of its own, removed once all of them ran.
+	var cleanups []func()
+	defer func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+	}()
+
+	repotest.TestUserRepo(t, func(t *testing.T) repo.UserRepo {
+		cfg, cleanup := testConfig(t, 0)
+		r := open(t, cfg)
+		cleanups = append(cleanups, func() {
+			r.Close()
+			cleanup()
+		})
+		return r
+	})
+}
+
+func TestSideRepos(t *testing.T) {
+	var cleanups []func()
+	defer func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+	}()
+
+	repotest.TestSideRepos(t, func(t *testing.T) *repo.Repos {
+		cfg, cleanup := testConfig(t, 0)
+		cfg.Repo.Type = "bolt"
+		repos, err := repo.NewRepos(context.Background(), cfg, log.NewNopLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		cleanups = append(cleanups, func() {
+			repos.Users.(*boltdb.UserRepo).Close()
+			cleanup()
+		})
+		return repos
+	})
+}
+
+func TestReopen(t *testing.T) {
+	ctx := context.Background()
+	cfg, cleanup := testConfig(t, 0)
+	defer cleanup()
+
+	r := open(t, cfg)
+	user := repotest.NewUser("alice", "alice@acme.com", "acme")
+	if _, err := r.Insert(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	user.Username = "alicia"
+	user.Email = ""
+	if err := r.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	r = open(t, cfg)
+	defer r.Close()
+
+	u, err := r.GetByUsernameAndTenant(ctx, "alicia", "acme")
+	if err != nil || u.ID != user.ID {
+		t.Fatalf("get by username after reopen: %v", err)
+	}
+
+	// Indexes of the previous values are removed on update.
+	if _, err := r.GetByUsernameAndTenant(ctx, "alice", "acme"); err != m.ErrUserNotFound {
+		t.Errorf("get by previous username: want ErrUserNotFound, got %v", err)
+	}
+	if _, err := r.GetByEmailAndTenant(ctx, "alice@acme.com", "acme"); err != m.ErrUserNotFound {
+		t.Errorf("get by previous email: want ErrUserNotFound, got %v", err)
+	}
+
+	if _, err := r.Insert(ctx, repotest.NewUser("alice", "alice@acme.com", "acme")); err != nil {
+		t.Errorf("insert with freed username and email: %v", err)
+	}
+}
+
+func TestBackups(t *testing.T) {
+	ctx := context.Background()
+	cfg, cleanup := testConfig(t, 2)
+	defer cleanup()
+
+	backups := func() []string {
+		b, err := filepath.Glob(cfg.Repo.Bolt.Path + ".*.bak")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	r := open(t, cfg)
+	user := repotest.NewUser("alice", "alice@acme.com", "acme")
+	if _, err := r.Insert(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	if n := len(backups()); n != 0 {
+		t.Errorf("backups of a new file: want 0, got %d", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		open(t, cfg).Close()
+	}
+
+	b := backups()
+	if len(b) != 2 {
+		t.Fatalf("backups kept: want 2, got %d", len(b))
+	}
+
+	// Backups are usable repo files.
+	bcfg := *cfg
+	bcfg.Repo.Bolt.Path = b[len(b)-1]
+	bcfg.Repo.Bolt.Backups = 0
+	br := open(t, &bcfg)
+	defer br.Close()
+
+	if _, err := br.Get(ctx, user.ID); err != nil {
+		t.Errorf("get from backup: %v", err)
+	}
+}
+
+func TestBackupBeforeWrite(t *testing.T) {
+	cfg, cleanup := testConfig(t, 1)
+	defer cleanup()
+
+	// A file written by a version that only had the users buckets.
+	db, err := bolt.Open(cfg.Repo.Bolt.Path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucket([]byte("users"))
+		return err
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	open(t, cfg).Close()
+
+	b, err := filepath.Glob(cfg.Repo.Bolt.Path + ".*.bak")
+	if err != nil || len(b) != 1 {
+		t.Fatalf("backups: %v, %v", b, err)
+	}
+
+	db, err = bolt.Open(b[0], 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Buckets created on open are not in the backup.
+	db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte("tenants")) != nil {
+			t.Error("backup was taken after the buckets were created")
+		}
+		return nil
+	})
+}
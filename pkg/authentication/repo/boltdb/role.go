@@ -0,0 +1,124 @@
+package boltdb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// rolesBucket holds the roles by ID.
+var rolesBucket = []byte("roles")
+
+// RoleRepo is a file backed implementation of RoleRepo interface.
+type RoleRepo struct {
+	db *bolt.DB
+}
+
+// NewRoleRepo makes a new role repo on the shared file.
+func NewRoleRepo(db *bolt.DB) *RoleRepo {
+	return &RoleRepo{db: db}
+}
+
+// Insert a role in RoleRepo.
+func (r *RoleRepo) Insert(ctx context.Context, role *m.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, rolesBucket, role.ID[:], role)
+	})
+}
+
+// Get a role by its ID.
+func (r *RoleRepo) Get(ctx context.Context, id uuid.UUID) (*m.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var role m.Role
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return load(tx, rolesBucket, id[:], &role)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// GetByNameAndTenant gets a role by its name and tenant.
+func (r *RoleRepo) GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Role, error) {
+	roles, err := r.find(ctx, func(role *m.Role) bool {
+		return role.Name == name && role.TenantID == tenantID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roles) == 0 {
+		return nil, m.ErrNotFound
+	}
+
+	return &roles[0], nil
+}
+
+// GetByTenant gets all the roles of a tenant.
+func (r *RoleRepo) GetByTenant(ctx context.Context, tenantID string) ([]m.Role, error) {
+	return r.find(ctx, func(role *m.Role) bool {
+		return role.TenantID == tenantID
+	})
+}
+
+// find returns the roles matching.
+func (r *RoleRepo) find(ctx context.Context, match func(*m.Role) bool) ([]m.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var roles []m.Role
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return forEach(tx, rolesBucket, nil, func(_, doc []byte) error {
+			var role m.Role
+			err := bson.Unmarshal(doc, &role)
+			if err == nil && match(&role) {
+				roles = append(roles, role)
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// Update a role in RoleRepo.
+func (r *RoleRepo) Update(ctx context.Context, role *m.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(rolesBucket).Get(role.ID[:]) == nil {
+			return nil
+		}
+		return put(tx, rolesBucket, role.ID[:], role)
+	})
+}
+
+// Delete a role from RoleRepo.
+func (r *RoleRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(rolesBucket).Delete(id[:])
+	})
+}
@@ -0,0 +1,107 @@
+package boltdb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// assignmentsBucket holds the role assignments by user and role.
+var assignmentsBucket = []byte("role_assignments")
+
+// RoleAssignmentRepo is a file backed implementation of RoleAssignmentRepo interface.
+type RoleAssignmentRepo struct {
+	db *bolt.DB
+}
+
+// NewRoleAssignmentRepo makes a new role assignment repo on the shared file.
+func NewRoleAssignmentRepo(db *bolt.DB) *RoleAssignmentRepo {
+	return &RoleAssignmentRepo{db: db}
+}
+
+// Insert an assignment in RoleAssignmentRepo.
+func (r *RoleAssignmentRepo) Insert(ctx context.Context, assignment *m.RoleAssignment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, assignmentsBucket, assignmentKey(assignment.UserID, assignment.RoleID), assignment)
+	})
+}
+
+// GetByUser gets all the role assignments of a user.
+func (r *RoleAssignmentRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]m.RoleAssignment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var assignments []m.RoleAssignment
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return forEach(tx, assignmentsBucket, userID[:], func(_, doc []byte) error {
+			var a m.RoleAssignment
+			err := bson.Unmarshal(doc, &a)
+			if err == nil {
+				assignments = append(assignments, a)
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// Delete the assignment of a role to a user.
+func (r *RoleAssignmentRepo) Delete(ctx context.Context, userID, roleID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(assignmentsBucket).Delete(assignmentKey(userID, roleID))
+	})
+}
+
+// DeleteByRole deletes all the assignments of a role.
+func (r *RoleAssignmentRepo) DeleteByRole(ctx context.Context, roleID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		var keys [][]byte
+		err := forEach(tx, assignmentsBucket, nil, func(key, doc []byte) error {
+			var a m.RoleAssignment
+			err := bson.Unmarshal(doc, &a)
+			if err == nil && a.RoleID == roleID {
+				keys = append(keys, append([]byte(nil), key...))
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			err := tx.Bucket(assignmentsBucket).Delete(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// assignmentKey is the user ID followed by the role ID
+// so that the assignments of a user share a prefix.
+func assignmentKey(userID, roleID uuid.UUID) []byte {
+	return append(append([]byte(nil), userID[:]...), roleID[:]...)
+}
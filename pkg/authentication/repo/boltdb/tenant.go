@@ -0,0 +1,118 @@
+package boltdb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// tenantsBucket holds the tenants by ID.
+var tenantsBucket = []byte("tenants")
+
+// TenantRepo is a file backed implementation of TenantRepo interface.
+type TenantRepo struct {
+	db *bolt.DB
+}
+
+// NewTenantRepo makes a new tenant repo on the shared file.
+func NewTenantRepo(db *bolt.DB) *TenantRepo {
+	return &TenantRepo{db: db}
+}
+
+// Insert a tenant in TenantRepo.
+func (r *TenantRepo) Insert(ctx context.Context, tenant *m.Tenant) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, tenantsBucket, tenant.ID[:], tenant)
+	})
+}
+
+// GetBySlug gets a tenant by its slug.
+func (r *TenantRepo) GetBySlug(ctx context.Context, slug string) (*m.Tenant, error) {
+	return r.find(ctx, func(t *m.Tenant) bool {
+		return t.Slug == slug
+	})
+}
+
+// GetByDomain gets the tenant the domain belongs to.
+func (r *TenantRepo) GetByDomain(ctx context.Context, domain string) (*m.Tenant, error) {
+	return r.find(ctx, func(t *m.Tenant) bool {
+		for _, d := range t.Domains {
+			if d == domain {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *TenantRepo) find(ctx context.Context, match func(*m.Tenant) bool) (*m.Tenant, error) {
+	tenants, err := r.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tenants {
+		if match(&t) {
+			return &t, nil
+		}
+	}
+
+	return nil, m.ErrNotFound
+}
+
+// GetAll gets all the tenants.
+func (r *TenantRepo) GetAll(ctx context.Context) ([]m.Tenant, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var tenants []m.Tenant
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return forEach(tx, tenantsBucket, nil, func(_, doc []byte) error {
+			var t m.Tenant
+			err := bson.Unmarshal(doc, &t)
+			if err == nil {
+				tenants = append(tenants, t)
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tenants, nil
+}
+
+// Update a tenant in TenantRepo.
+func (r *TenantRepo) Update(ctx context.Context, tenant *m.Tenant) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(tenantsBucket).Get(tenant.ID[:]) == nil {
+			return nil
+		}
+		return put(tx, tenantsBucket, tenant.ID[:], tenant)
+	})
+}
+
+// Delete a tenant from TenantRepo.
+func (r *TenantRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(tenantsBucket).Delete(id[:])
+	})
+}
@@ -0,0 +1,104 @@
+package boltdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// credentialsBucket holds the WebAuthn credentials by tenant and credential ID.
+var credentialsBucket = []byte("webauthn_credentials")
+
+// WebAuthnCredentialRepo is a file backed implementation of WebAuthnCredentialRepo interface.
+type WebAuthnCredentialRepo struct {
+	db *bolt.DB
+}
+
+// NewWebAuthnCredentialRepo makes a new WebAuthn credential repo on the shared file.
+func NewWebAuthnCredentialRepo(db *bolt.DB) *WebAuthnCredentialRepo {
+	return &WebAuthnCredentialRepo{db: db}
+}
+
+// Insert a credential in WebAuthnCredentialRepo.
+func (r *WebAuthnCredentialRepo) Insert(ctx context.Context, credential *m.WebAuthnCredential) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, credentialsBucket, indexKey(credential.TenantID, credential.CredentialID), credential)
+	})
+}
+
+// GetByCredentialIDAndTenant gets a credential by its authenticator assigned ID.
+func (r *WebAuthnCredentialRepo) GetByCredentialIDAndTenant(ctx context.Context, credentialID, tenantID string) (*m.WebAuthnCredential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var credential m.WebAuthnCredential
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return load(tx, credentialsBucket, indexKey(tenantID, credentialID), &credential)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// GetByUser gets all the credentials of a user.
+func (r *WebAuthnCredentialRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]m.WebAuthnCredential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var credentials []m.WebAuthnCredential
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return forEach(tx, credentialsBucket, nil, func(_, doc []byte) error {
+			var c m.WebAuthnCredential
+			err := bson.Unmarshal(doc, &c)
+			if err == nil && c.UserID == userID {
+				credentials = append(credentials, c)
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// UpdateSignCount records the signature counter of the last use of a credential.
+func (r *WebAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id uuid.UUID, signCount uint32, usedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		var key []byte
+		var credential m.WebAuthnCredential
+		err := forEach(tx, credentialsBucket, nil, func(k, doc []byte) error {
+			err := bson.Unmarshal(doc, &credential)
+			if err == nil && credential.ID == id {
+				key = append([]byte(nil), k...)
+				return errStop
+			}
+			return err
+		})
+		if err != nil || key == nil {
+			return err
+		}
+
+		credential.SignCount = signCount
+		credential.LastUsedAt = usedAt
+		return put(tx, credentialsBucket, key, &credential)
+	})
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// ClientRepo is an in-memory implementation of ClientRepo interface.
+type ClientRepo struct {
+	mu      sync.RWMutex
+	clients []m.Client
+}
+
+// NewClientRepo makes a new in-memory OAuth client repo.
+func NewClientRepo() *ClientRepo {
+	return &ClientRepo{}
+}
+
+// Insert a client in ClientRepo.
+func (r *ClientRepo) Insert(ctx context.Context, client *m.Client) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clients = append(r.clients, cloneClient(client))
+	return nil
+}
+
+// GetByClientIDAndTenant gets a client from repo by its client ID and tenant.
+func (r *ClientRepo) GetByClientIDAndTenant(ctx context.Context, clientID, tenantID string) (*m.Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, c := range r.clients {
+		if c.ClientID == clientID && c.TenantID == tenantID {
+			c = cloneClient(&c)
+			return &c, nil
+		}
+	}
+
+	return nil, m.ErrNotFound
+}
+
+// Update a client in ClientRepo.
+func (r *ClientRepo) Update(ctx context.Context, client *m.Client) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, c := range r.clients {
+		if c.ID == client.ID {
+			r.clients[i] = cloneClient(client)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func cloneClient(client *m.Client) m.Client {
+	c := *client
+	c.RedirectURIs = append([]string(nil), client.RedirectURIs...)
+	c.Scopes = append([]string(nil), client.Scopes...)
+	c.GrantTypes = append([]string(nil), client.GrantTypes...)
+	return c
+}
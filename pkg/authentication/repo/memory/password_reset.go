@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// PasswordResetRepo is an in-memory implementation of PasswordResetRepo interface.
+type PasswordResetRepo struct {
+	mu     sync.Mutex
+	resets []m.PasswordReset
+}
+
+// NewPasswordResetRepo makes a new in-memory password reset repo.
+func NewPasswordResetRepo() *PasswordResetRepo {
+	return &PasswordResetRepo{}
+}
+
+// Insert a password reset in PasswordResetRepo.
+func (r *PasswordResetRepo) Insert(ctx context.Context, reset *m.PasswordReset) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resets = append(r.resets, *reset)
+	return nil
+}
+
+// Consume gets and deletes a password reset by its digest.
+// Lookup and deletion are atomic so a reset can only be consumed once.
+func (r *PasswordResetRepo) Consume(ctx context.Context, digest string) (*m.PasswordReset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, pr := range r.resets {
+		if pr.Digest == digest {
+			r.resets = append(r.resets[:i], r.resets[i+1:]...)
+			return &pr, nil
+		}
+	}
+
+	return nil, m.ErrNotFound
+}
+
+// DeleteByUser deletes all the pending password resets of a user.
+func (r *PasswordResetRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.resets[:0]
+	for _, pr := range r.resets {
+		if pr.UserID != userID {
+			kept = append(kept, pr)
+		}
+	}
+	r.resets = kept
+
+	return nil
+}
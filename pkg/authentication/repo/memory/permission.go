@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// PermissionRepo is an in-memory implementation of PermissionRepo interface.
+type PermissionRepo struct {
+	mu          sync.RWMutex
+	permissions []m.Permission
+}
+
+// NewPermissionRepo makes a new in-memory permission repo.
+func NewPermissionRepo() *PermissionRepo {
+	return &PermissionRepo{}
+}
+
+// Insert a permission in PermissionRepo.
+func (r *PermissionRepo) Insert(ctx context.Context, permission *m.Permission) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.permissions = append(r.permissions, *permission)
+	return nil
+}
+
+// GetByNameAndTenant gets a permission by its name and tenant.
+func (r *PermissionRepo) GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Permission, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.permissions {
+		if p.Name == name && p.TenantID == tenantID {
+			return &p, nil
+		}
+	}
+
+	return nil, m.ErrNotFound
+}
+
+// GetByTenant gets all the permissions defined by a tenant.
+func (r *PermissionRepo) GetByTenant(ctx context.Context, tenantID string) ([]m.Permission, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var permissions []m.Permission
+	for _, p := range r.permissions {
+		if p.TenantID == tenantID {
+			permissions = append(permissions, p)
+		}
+	}
+
+	return permissions, nil
+}
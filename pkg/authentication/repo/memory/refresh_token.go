@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// RefreshTokenRepo is an in-memory implementation of RefreshTokenRepo interface.
+type RefreshTokenRepo struct {
+	mu     sync.RWMutex
+	tokens []m.RefreshToken
+}
+
+// NewRefreshTokenRepo makes a new in-memory refresh token repo.
+func NewRefreshTokenRepo() *RefreshTokenRepo {
+	return &RefreshTokenRepo{}
+}
+
+// Insert a refresh token in RefreshTokenRepo.
+func (r *RefreshTokenRepo) Insert(ctx context.Context, token *m.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens = append(r.tokens, *token)
+	return nil
+}
+
+// GetByDigest gets a refresh token from repo by its digest.
+func (r *RefreshTokenRepo) GetByDigest(ctx context.Context, digest string) (*m.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.tokens {
+		if t.Digest == digest {
+			return &t, nil
+		}
+	}
+
+	return nil, m.ErrNotFound
+}
+
+// Rotate marks a refresh token as replaced by a new one.
+// It returns false if the token had already been rotated or revoked.
+func (r *RefreshTokenRepo) Rotate(ctx context.Context, id, replacedBy uuid.UUID) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tokens {
+		t := &r.tokens[i]
+		if t.ID == id && !t.IsRotated() && !t.IsRevoked {
+			t.ReplacedBy = replacedBy
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// RevokeFamily revokes all refresh tokens sharing a family.
+func (r *RefreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tokens {
+		if r.tokens[i].FamilyID == familyID {
+			r.tokens[i].IsRevoked = true
+		}
+	}
+
+	return nil
+}
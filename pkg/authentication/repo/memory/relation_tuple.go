@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// RelationTupleRepo is an in-memory implementation of RelationTupleRepo interface.
+type RelationTupleRepo struct {
+	mu     sync.RWMutex
+	tuples []m.RelationTuple
+}
+
+// NewRelationTupleRepo makes a new in-memory relation tuple repo.
+func NewRelationTupleRepo() *RelationTupleRepo {
+	return &RelationTupleRepo{}
+}
+
+// Insert a relation tuple in RelationTupleRepo.
+func (r *RelationTupleRepo) Insert(ctx context.Context, tuple *m.RelationTuple) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tuples = append(r.tuples, *tuple)
+	return nil
+}
+
+// Get gets a relation tuple of the tenant.
+func (r *RelationTupleRepo) Get(ctx context.Context, tenantID, object, relation, subject string) (*m.RelationTuple, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.tuples {
+		if t.TenantID == tenantID && t.Object == object && t.Relation == relation && t.Subject == subject {
+			return &t, nil
+		}
+	}
+
+	return nil, m.ErrNotFound
+}
+
+// GetByObject gets the relation tuples of the tenant with the object and relation.
+func (r *RelationTupleRepo) GetByObject(ctx context.Context, tenantID, object, relation string) ([]m.RelationTuple, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tuples []m.RelationTuple
+	for _, t := range r.tuples {
+		if t.TenantID == tenantID && t.Object == object && t.Relation == relation {
+			tuples = append(tuples, t)
+		}
+	}
+
+	return tuples, nil
+}
+
+// GetObjects gets the distinct objects of the namespace the tenant has tuples of.
+func (r *RelationTupleRepo) GetObjects(ctx context.Context, tenantID, namespace string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var objects []string
+	for _, t := range r.tuples {
+		if t.TenantID == tenantID && strings.HasPrefix(t.Object, namespace+":") && !seen[t.Object] {
+			seen[t.Object] = true
+			objects = append(objects, t.Object)
+		}
+	}
+
+	return objects, nil
+}
+
+// Delete a relation tuple of the tenant.
+func (r *RelationTupleRepo) Delete(ctx context.Context, tenantID, object, relation, subject string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, t := range r.tuples {
+		if t.TenantID == tenantID && t.Object == object && t.Relation == relation && t.Subject == subject {
+			r.tuples = append(r.tuples[:i], r.tuples[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// UserRepo is an in-memory implementation of UserRepo interface.
+// Users are lost on restart and not shared between replicas.
+// Like in the Mongo one, the ID of the users and their username and email
+// within their tenant are unique.
+type UserRepo struct {
+	mu    sync.RWMutex
+	users map[uuid.UUID]m.User
+}
+
+// NewRepo makes a new in-memory user repo.
+func NewRepo(ctx context.Context, cfg *config.Config, logger log.Logger) (*UserRepo, error) {
+	return &UserRepo{
+		users: make(map[uuid.UUID]m.User),
+	}, nil
+}
+
+// Insert a user in UserRepo.
+func (r *UserRepo) Insert(ctx context.Context, user *m.User) (id interface{}, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; ok || r.collides(user) {
+		return nil, m.ErrDuplicateUser
+	}
+
+	r.users[user.ID] = clone(user)
+	return user.ID, nil
+}
+
+// GetAll users from repo.
+func (r *UserRepo) GetAll(ctx context.Context) ([]m.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []m.User
+	for _, u := range r.users {
+		users = append(users, clone(&u))
+	}
+
+	// Map iteration order is random.
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+
+	return users, nil
+}
+
+// Get a user from repo by its ID.
+func (r *UserRepo) Get(ctx context.Context, id interface{}) (*m.User, error) {
+	return r.find(ctx, func(u *m.User) bool {
+		return u.ID == id
+	})
+}
+
+// GetByUsernameAndTenant gets a user from repo by its username and tenant.
+func (r *UserRepo) GetByUsernameAndTenant(ctx context.Context, username, tenantID string) (*m.User, error) {
+	return r.find(ctx, func(u *m.User) bool {
+		return u.Username == username && u.TenantID == tenantID
+	})
+}
+
+// GetByEmailAndTenant gets a user from repo by its email and tenant.
+func (r *UserRepo) GetByEmailAndTenant(ctx context.Context, email, tenantID string) (*m.User, error) {
+	return r.find(ctx, func(u *m.User) bool {
+		return u.Email == email && u.TenantID == tenantID
+	})
+}
+
+// find returns a copy of the first user matching.
+func (r *UserRepo) find(ctx context.Context, match func(*m.User) bool) (*m.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if match(&u) {
+			c := clone(&u)
+			return &c, nil
+		}
+	}
+
+	return nil, m.ErrUserNotFound
+}
+
+// Update a user in UserRepo.
+// Users cannot be moved to another tenant.
+func (r *UserRepo) Update(ctx context.Context, user *m.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.users[user.ID]
+	if !ok || current.TenantID != user.TenantID {
+		return m.ErrUserNotFound
+	}
+
+	if r.collides(user) {
+		return m.ErrDuplicateUser
+	}
+
+	r.users[user.ID] = clone(user)
+	return nil
+}
+
+// UseMFAStep sets the last TOTP step of the user if it is past the stored one.
+func (r *UserRepo) UseMFAStep(ctx context.Context, user *m.User, step int64) (bool, error) {
+	return r.use(ctx, user, func(current *m.User) bool {
+		if current.MFALastStep >= step {
+			return false
+		}
+		current.MFALastStep = step
+		return true
+	})
+}
+
+// UseRecoveryCode removes the recovery code digest if the user still has it.
+func (r *UserRepo) UseRecoveryCode(ctx context.Context, user *m.User, digest string) (bool, error) {
+	return r.use(ctx, user, func(current *m.User) bool {
+		for i, rc := range current.RecoveryCodes {
+			if rc == digest {
+				codes := append([]string{}, current.RecoveryCodes[:i]...)
+				current.RecoveryCodes = append(codes, current.RecoveryCodes[i+1:]...)
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// use applies the change to the stored user, under the same lock it is checked.
+func (r *UserRepo) use(ctx context.Context, user *m.User, change func(*m.User) bool) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.users[user.ID]
+	if !ok || current.TenantID != user.TenantID || !change(&current) {
+		return false, nil
+	}
+
+	current.UpdatedBy, current.UpdatedAt = user.UpdatedBy, user.UpdatedAt
+	r.users[user.ID] = current
+	return true, nil
+}
+
+// Delete a user from repo.
+func (r *UserRepo) Delete(ctx context.Context, id interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for uid, u := range r.users {
+		if u.ID == id {
+			delete(r.users, uid)
+			return nil
+		}
+	}
+
+	return m.ErrUserNotFound
+}
+
+// collides - True if another user of the tenant has the username or the email of the user.
+// Users without email do not collide.
+func (r *UserRepo) collides(user *m.User) bool {
+	for _, u := range r.users {
+		if u.ID == user.ID || u.TenantID != user.TenantID {
+			continue
+		}
+
+		if u.Username == user.Username || (user.Email != "" && u.Email == user.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy of the user not sharing the slices the service
+// changes in place, so that stored users only change on update.
+func clone(user *m.User) m.User {
+	c := *user
+	c.PasswordHistory = append([]string(nil), user.PasswordHistory...)
+	c.RecoveryCodes = append([]string(nil), user.RecoveryCodes...)
+	return c
+}
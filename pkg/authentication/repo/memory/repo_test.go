@@ -0,0 +1,37 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/memory"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/repotest"
+)
+
+func TestUserRepo(t *testing.T) {
+	repotest.TestUserRepo(t, func(t *testing.T) repo.UserRepo {
+		r, err := memory.NewRepo(context.Background(), &config.Config{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	})
+}
+
+func TestSideRepos(t *testing.T) {
+	repotest.TestSideRepos(t, func(t *testing.T) *repo.Repos {
+		return &repo.Repos{
+			RefreshTokens:       memory.NewRefreshTokenRepo(),
+			Clients:             memory.NewClientRepo(),
+			PasswordResets:      memory.NewPasswordResetRepo(),
+			WebAuthnCredentials: memory.NewWebAuthnCredentialRepo(),
+			Roles:               memory.NewRoleRepo(),
+			Permissions:         memory.NewPermissionRepo(),
+			RoleAssignments:     memory.NewRoleAssignmentRepo(),
+			RelationTuples:      memory.NewRelationTupleRepo(),
+			Tenants:             memory.NewTenantRepo(),
+		}
+	})
+}
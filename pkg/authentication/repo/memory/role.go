@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// RoleRepo is an in-memory implementation of RoleRepo interface.
+type RoleRepo struct {
+	mu    sync.RWMutex
+	roles []m.Role
+}
+
+// NewRoleRepo makes a new in-memory role repo.
+func NewRoleRepo() *RoleRepo {
+	return &RoleRepo{}
+}
+
+// Insert a role in RoleRepo.
+func (r *RoleRepo) Insert(ctx context.Context, role *m.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.roles = append(r.roles, cloneRole(role))
+	return nil
+}
+
+// Get a role by its ID.
+func (r *RoleRepo) Get(ctx context.Context, id uuid.UUID) (*m.Role, error) {
+	return r.find(ctx, func(role *m.Role) bool {
+		return role.ID == id
+	})
+}
+
+// GetByNameAndTenant gets a role by its name and tenant.
+func (r *RoleRepo) GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Role, error) {
+	return r.find(ctx, func(role *m.Role) bool {
+		return role.Name == name && role.TenantID == tenantID
+	})
+}
+
+func (r *RoleRepo) find(ctx context.Context, match func(*m.Role) bool) (*m.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, role := range r.roles {
+		if match(&role) {
+			role = cloneRole(&role)
+			return &role, nil
+		}
+	}
+
+	return nil, m.ErrNotFound
+}
+
+// GetByTenant gets all the roles of a tenant.
+func (r *RoleRepo) GetByTenant(ctx context.Context, tenantID string) ([]m.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var roles []m.Role
+	for _, role := range r.roles {
+		if role.TenantID == tenantID {
+			roles = append(roles, cloneRole(&role))
+		}
+	}
+
+	return roles, nil
+}
+
+// Update a role in RoleRepo.
+func (r *RoleRepo) Update(ctx context.Context, role *m.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.roles {
+		if r.roles[i].ID == role.ID {
+			r.roles[i] = cloneRole(role)
+		}
+	}
+
+	return nil
+}
+
+// Delete a role from RoleRepo.
+func (r *RoleRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.roles {
+		if r.roles[i].ID == id {
+			r.roles = append(r.roles[:i], r.roles[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func cloneRole(role *m.Role) m.Role {
+	c := *role
+	c.Permissions = append([]string(nil), role.Permissions...)
+	return c
+}
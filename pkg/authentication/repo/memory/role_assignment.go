@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// RoleAssignmentRepo is an in-memory implementation of RoleAssignmentRepo interface.
+type RoleAssignmentRepo struct {
+	mu          sync.RWMutex
+	assignments []m.RoleAssignment
+}
+
+// NewRoleAssignmentRepo makes a new in-memory role assignment repo.
+func NewRoleAssignmentRepo() *RoleAssignmentRepo {
+	return &RoleAssignmentRepo{}
+}
+
+// Insert an assignment in RoleAssignmentRepo.
+func (r *RoleAssignmentRepo) Insert(ctx context.Context, assignment *m.RoleAssignment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.assignments = append(r.assignments, *assignment)
+	return nil
+}
+
+// GetByUser gets all the role assignments of a user.
+func (r *RoleAssignmentRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]m.RoleAssignment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var assignments []m.RoleAssignment
+	for _, a := range r.assignments {
+		if a.UserID == userID {
+			assignments = append(assignments, a)
+		}
+	}
+
+	return assignments, nil
+}
+
+// Delete the assignment of a role to a user.
+func (r *RoleAssignmentRepo) Delete(ctx context.Context, userID, roleID uuid.UUID) error {
+	return r.delete(ctx, func(a *m.RoleAssignment) bool {
+		return a.UserID == userID && a.RoleID == roleID
+	})
+}
+
+// DeleteByRole deletes all the assignments of a role.
+func (r *RoleAssignmentRepo) DeleteByRole(ctx context.Context, roleID uuid.UUID) error {
+	return r.delete(ctx, func(a *m.RoleAssignment) bool {
+		return a.RoleID == roleID
+	})
+}
+
+func (r *RoleAssignmentRepo) delete(ctx context.Context, match func(*m.RoleAssignment) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.assignments[:0]
+	for _, a := range r.assignments {
+		if !match(&a) {
+			kept = append(kept, a)
+		}
+	}
+	r.assignments = kept
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// TenantRepo is an in-memory implementation of TenantRepo interface.
+type TenantRepo struct {
+	mu      sync.RWMutex
+	tenants []m.Tenant
+}
+
+// NewTenantRepo makes a new in-memory tenant repo.
+func NewTenantRepo() *TenantRepo {
+	return &TenantRepo{}
+}
+
+// Insert a tenant in TenantRepo.
+func (r *TenantRepo) Insert(ctx context.Context, tenant *m.Tenant) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tenants = append(r.tenants, cloneTenant(tenant))
+	return nil
+}
+
+// GetBySlug gets a tenant by its slug.
+func (r *TenantRepo) GetBySlug(ctx context.Context, slug string) (*m.Tenant, error) {
+	return r.find(ctx, func(t *m.Tenant) bool {
+		return t.Slug == slug
+	})
+}
+
+// GetByDomain gets the tenant the domain belongs to.
+func (r *TenantRepo) GetByDomain(ctx context.Context, domain string) (*m.Tenant, error) {
+	return r.find(ctx, func(t *m.Tenant) bool {
+		for _, d := range t.Domains {
+			if d == domain {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *TenantRepo) find(ctx context.Context, match func(*m.Tenant) bool) (*m.Tenant, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.tenants {
+		if match(&t) {
+			t = cloneTenant(&t)
+			return &t, nil
+		}
+	}
+
+	return nil, m.ErrNotFound
+}
+
+// GetAll gets all the tenants.
+func (r *TenantRepo) GetAll(ctx context.Context) ([]m.Tenant, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tenants []m.Tenant
+	for _, t := range r.tenants {
+		tenants = append(tenants, cloneTenant(&t))
+	}
+
+	return tenants, nil
+}
+
+// Update a tenant in TenantRepo.
+func (r *TenantRepo) Update(ctx context.Context, tenant *m.Tenant) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tenants {
+		if r.tenants[i].ID == tenant.ID {
+			r.tenants[i] = cloneTenant(tenant)
+		}
+	}
+
+	return nil
+}
+
+// Delete a tenant from TenantRepo.
+func (r *TenantRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tenants {
+		if r.tenants[i].ID == id {
+			r.tenants = append(r.tenants[:i], r.tenants[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// cloneTenant returns a copy of the tenant not sharing its domains
+// nor its settings with the stored one.
+func cloneTenant(tenant *m.Tenant) m.Tenant {
+	c := *tenant
+	c.Domains = append([]string(nil), tenant.Domains...)
+	c.Settings.SignInMethods = append([]string(nil), tenant.Settings.SignInMethods...)
+	if p := tenant.Settings.PasswordPolicy; p != nil {
+		policy := *p
+		c.Settings.PasswordPolicy = &policy
+	}
+	return c
+}
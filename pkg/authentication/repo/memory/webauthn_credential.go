@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// WebAuthnCredentialRepo is an in-memory implementation of WebAuthnCredentialRepo interface.
+type WebAuthnCredentialRepo struct {
+	mu          sync.RWMutex
+	credentials []m.WebAuthnCredential
+}
+
+// NewWebAuthnCredentialRepo makes a new in-memory WebAuthn credential repo.
+func NewWebAuthnCredentialRepo() *WebAuthnCredentialRepo {
+	return &WebAuthnCredentialRepo{}
+}
+
+// Insert a credential in WebAuthnCredentialRepo.
+func (r *WebAuthnCredentialRepo) Insert(ctx context.Context, credential *m.WebAuthnCredential) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.credentials = append(r.credentials, cloneCredential(credential))
+	return nil
+}
+
+// GetByCredentialIDAndTenant gets a credential by its authenticator assigned ID.
+func (r *WebAuthnCredentialRepo) GetByCredentialIDAndTenant(ctx context.Context, credentialID, tenantID string) (*m.WebAuthnCredential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, c := range r.credentials {
+		if c.CredentialID == credentialID && c.TenantID == tenantID {
+			c = cloneCredential(&c)
+			return &c, nil
+		}
+	}
+
+	return nil, m.ErrNotFound
+}
+
+// GetByUser gets all the credentials of a user.
+func (r *WebAuthnCredentialRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]m.WebAuthnCredential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var credentials []m.WebAuthnCredential
+	for _, c := range r.credentials {
+		if c.UserID == userID {
+			credentials = append(credentials, cloneCredential(&c))
+		}
+	}
+
+	return credentials, nil
+}
+
+// UpdateSignCount records the signature counter of the last use of a credential.
+func (r *WebAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id uuid.UUID, signCount uint32, usedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.credentials {
+		if r.credentials[i].ID == id {
+			r.credentials[i].SignCount = signCount
+			r.credentials[i].LastUsedAt = usedAt
+		}
+	}
+
+	return nil
+}
+
+func cloneCredential(credential *m.WebAuthnCredential) m.WebAuthnCredential {
+	c := *credential
+	c.PublicKey = append([]byte(nil), credential.PublicKey...)
+	c.AAGUID = append([]byte(nil), credential.AAGUID...)
+	return c
+}
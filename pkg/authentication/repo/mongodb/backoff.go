@@ -19,8 +19,8 @@ const (
 )
 
 // Retry implements a retry mechanism in order to create a connected repo client.
-func Retry(ctx context.Context, cfg *config.Config, logger log.Logger) chan *UserRepo {
-	result := make(chan *UserRepo)
+func Retry(ctx context.Context, cfg *config.Config, logger log.Logger) chan *mongo.Client {
+	result := make(chan *mongo.Client)
 
 	bo := backoff.WithMaxRetries(backoff.NewExponentialBackOff(), uint64(maxTries))
 
@@ -33,12 +33,7 @@ func Retry(ctx context.Context, cfg *config.Config, logger log.Logger) chan *Use
 
 			if err == nil {
 				logger.Log("level", c.LogLevel.Info, "msg", "Mongo connection established")
-
-				r := &UserRepo{
-					conn: conn,
-					coll: conn.Database("granica").Collection("users"),
-				}
-				result <- r
+				result <- conn
 				return
 			}
 
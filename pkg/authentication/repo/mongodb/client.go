@@ -0,0 +1,62 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// ClientRepo is a Mongo implementation of ClientRepo interface.
+type ClientRepo struct {
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewClientRepo makes a new OAuth client repo on the shared connection.
+func NewClientRepo(conn *mongo.Client, cfg *config.Config) *ClientRepo {
+	return &ClientRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("clients"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a client in ClientRepo.
+func (r *ClientRepo) Insert(ctx context.Context, client *m.Client) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.InsertOne(ctx, client)
+	return err
+}
+
+// GetByClientIDAndTenant gets a client from repo by its client ID and tenant.
+func (r *ClientRepo) GetByClientIDAndTenant(ctx context.Context, clientID, tenantID string) (*m.Client, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var client m.Client
+
+	filter := bson.M{"client_id": clientID, "tenant_id": tenantID}
+	err := r.coll.FindOne(ctx, filter).Decode(&client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+// Update a client in ClientRepo.
+func (r *ClientRepo) Update(ctx context.Context, client *m.Client) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"_id": client.ID}
+	_, err := r.coll.ReplaceOne(ctx, filter, client)
+	return err
+}
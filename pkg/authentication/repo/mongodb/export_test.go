@@ -0,0 +1,32 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// NewTestRepo makes a user repo over an emptied collection of its own,
+// so that tests do not touch the users of the database.
+func NewTestRepo(ctx context.Context, cfg *config.Config, logger log.Logger) (*UserRepo, error) {
+	conn, err := Connect(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewRepo(ctx, conn, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.coll = r.conn.Database(dbName).Collection("users_test")
+
+	_, err = r.coll.DeleteMany(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, r.createIndexes(ctx)
+}
@@ -0,0 +1,64 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// PasswordResetRepo is a Mongo implementation of PasswordResetRepo interface.
+type PasswordResetRepo struct {
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewPasswordResetRepo makes a new password reset repo on the shared connection.
+func NewPasswordResetRepo(conn *mongo.Client, cfg *config.Config) *PasswordResetRepo {
+	return &PasswordResetRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("password_resets"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a password reset in PasswordResetRepo.
+func (r *PasswordResetRepo) Insert(ctx context.Context, reset *m.PasswordReset) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.InsertOne(ctx, reset)
+	return err
+}
+
+// Consume gets and deletes a password reset by its digest.
+// Lookup and deletion are atomic so a reset can only be consumed once.
+func (r *PasswordResetRepo) Consume(ctx context.Context, digest string) (*m.PasswordReset, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	var reset m.PasswordReset
+
+	filter := bson.M{"digest": digest}
+	err := r.coll.FindOneAndDelete(ctx, filter).Decode(&reset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reset, nil
+}
+
+// DeleteByUser deletes all the pending password resets of a user.
+func (r *PasswordResetRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID}
+	_, err := r.coll.DeleteMany(ctx, filter)
+	return err
+}
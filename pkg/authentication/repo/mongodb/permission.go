@@ -0,0 +1,78 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// PermissionRepo is a Mongo implementation of PermissionRepo interface.
+type PermissionRepo struct {
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewPermissionRepo makes a new permission repo on the shared connection.
+func NewPermissionRepo(conn *mongo.Client, cfg *config.Config) *PermissionRepo {
+	return &PermissionRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("permissions"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a permission in PermissionRepo.
+func (r *PermissionRepo) Insert(ctx context.Context, permission *m.Permission) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.InsertOne(ctx, permission)
+	return err
+}
+
+// GetByNameAndTenant gets a permission by its name and tenant.
+func (r *PermissionRepo) GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Permission, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var permission m.Permission
+
+	filter := bson.M{"name": name, "tenant_id": tenantID}
+	err := r.coll.FindOne(ctx, filter).Decode(&permission)
+	if err != nil {
+		return nil, err
+	}
+
+	return &permission, nil
+}
+
+// GetByTenant gets all the permissions defined by a tenant.
+func (r *PermissionRepo) GetByTenant(ctx context.Context, tenantID string) ([]m.Permission, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var permissions []m.Permission
+
+	filter := bson.M{"tenant_id": tenantID}
+	cur, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var permission m.Permission
+		err := cur.Decode(&permission)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, cur.Err()
+}
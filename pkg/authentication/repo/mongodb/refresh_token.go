@@ -0,0 +1,80 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// RefreshTokenRepo is a Mongo implementation of RefreshTokenRepo interface.
+type RefreshTokenRepo struct {
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewRefreshTokenRepo makes a new refresh token repo on the shared connection.
+func NewRefreshTokenRepo(conn *mongo.Client, cfg *config.Config) *RefreshTokenRepo {
+	return &RefreshTokenRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("refresh_tokens"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a refresh token in RefreshTokenRepo.
+func (r *RefreshTokenRepo) Insert(ctx context.Context, token *m.RefreshToken) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.InsertOne(ctx, token)
+	return err
+}
+
+// GetByDigest gets a refresh token from repo by its digest.
+func (r *RefreshTokenRepo) GetByDigest(ctx context.Context, digest string) (*m.RefreshToken, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var token m.RefreshToken
+
+	filter := bson.M{"digest": digest}
+	err := r.coll.FindOne(ctx, filter).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Rotate marks a refresh token as replaced by a new one.
+// It returns false if the token had already been rotated or revoked.
+func (r *RefreshTokenRepo) Rotate(ctx context.Context, id, replacedBy uuid.UUID) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "replaced_by": uuid.Nil, "is_revoked": false}
+	update := bson.M{"$set": bson.M{"replaced_by": replacedBy}}
+	res, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount == 1, nil
+}
+
+// RevokeFamily revokes all refresh tokens sharing a family.
+func (r *RefreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"family_id": familyID}
+	update := bson.M{"$set": bson.M{"is_revoked": true}}
+	_, err := r.coll.UpdateMany(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,111 @@
+package mongodb
+
+import (
+	"context"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// RelationTupleRepo is a Mongo implementation of RelationTupleRepo interface.
+type RelationTupleRepo struct {
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewRelationTupleRepo makes a new relation tuple repo on the shared connection.
+func NewRelationTupleRepo(conn *mongo.Client, cfg *config.Config) *RelationTupleRepo {
+	return &RelationTupleRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("relation_tuples"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a relation tuple in RelationTupleRepo.
+func (r *RelationTupleRepo) Insert(ctx context.Context, tuple *m.RelationTuple) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.InsertOne(ctx, tuple)
+	return err
+}
+
+// Get gets a relation tuple of the tenant.
+func (r *RelationTupleRepo) Get(ctx context.Context, tenantID, object, relation, subject string) (*m.RelationTuple, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var tuple m.RelationTuple
+
+	filter := bson.M{"tenant_id": tenantID, "object": object, "relation": relation, "subject": subject}
+	err := r.coll.FindOne(ctx, filter).Decode(&tuple)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tuple, nil
+}
+
+// GetByObject gets the relation tuples of the tenant with the object and relation.
+func (r *RelationTupleRepo) GetByObject(ctx context.Context, tenantID, object, relation string) ([]m.RelationTuple, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var tuples []m.RelationTuple
+
+	filter := bson.M{"tenant_id": tenantID, "object": object, "relation": relation}
+	cur, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var tuple m.RelationTuple
+		err := cur.Decode(&tuple)
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	return tuples, cur.Err()
+}
+
+// GetObjects gets the distinct objects of the namespace the tenant has tuples of.
+func (r *RelationTupleRepo) GetObjects(ctx context.Context, tenantID, namespace string) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	prefix := "^" + regexp.QuoteMeta(namespace+":")
+	filter := bson.M{"tenant_id": tenantID, "object": bson.M{"$regex": prefix}}
+	values, err := r.coll.Distinct(ctx, "object", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			objects = append(objects, s)
+		}
+	}
+
+	return objects, nil
+}
+
+// Delete a relation tuple of the tenant.
+func (r *RelationTupleRepo) Delete(ctx context.Context, tenantID, object, relation, subject string) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"tenant_id": tenantID, "object": object, "relation": relation, "subject": subject}
+	_, err := r.coll.DeleteOne(ctx, filter)
+	return err
+}
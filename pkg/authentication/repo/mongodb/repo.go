@@ -2,62 +2,148 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"gitlab.com/mikrowezel/backend/granica/internal/config"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	// c "gitlab.com/mikrowezel/backend/granica/internal/config"
 	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
 )
 
+const (
+	dbName = "granica"
+)
+
+// User document fields.
+// The user ID is stored in its embedded identification.
+const (
+	userIDField   = "identification._id"
+	tenantIDField = "tenant_id"
+)
+
+// duplicateKeyCode is the code of the errors of writes breaking a unique index.
+const duplicateKeyCode = 11000
+
+var errNoConn = errors.New("cannot connect to MongoDB")
+
 // UserRepo is a Mongo implementation of UserRepo interface.
+// Repos bound to a tenant only read and write the users of that tenant.
 type UserRepo struct {
-	ctx  context.Context
-	conn *mongo.Client
-	coll *mongo.Collection
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+	tenantID string
+}
+
+// Connect returns a client connected to the configured Mongo server,
+// retrying with backoff while it cannot be reached.
+// The client is meant to be shared by all the repos of the service.
+func Connect(ctx context.Context, cfg *config.Config, logger log.Logger) (*mongo.Client, error) {
+	conn := <-Retry(ctx, cfg, logger)
+	if conn == nil {
+		return nil, errNoConn
+	}
+
+	return conn, nil
+}
+
+// NewRepo makes a new user repo on the shared connection.
+func NewRepo(ctx context.Context, conn *mongo.Client, cfg *config.Config) (*UserRepo, error) {
+	r := &UserRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("users"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+
+	err := r.createIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// createIndexes makes the ID of the users, and their username
+// and email within their tenant, unique.
+func (r *UserRepo) createIndexes(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: userIDField, Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: tenantIDField, Value: 1}, {Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: tenantIDField, Value: 1}, {Key: "email", Value: 1}},
+			// Users without email do not collide.
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"email": bson.M{"$gt": ""}}),
+		},
+	})
+	return err
 }
 
-// NewRepo makes a new user repo.
-func NewRepo(ctx context.Context, cfg *config.Config, logger log.Logger) (*UserRepo, error) {
-	// conn, err := getConn(cfg)
-	// if err != nil {
-	// 	return nil, err
-	// }
+// ForTenant returns a copy of the repo bound to the tenant.
+func (r *UserRepo) ForTenant(tenantID string) *UserRepo {
+	scoped := *r
+	scoped.tenantID = tenantID
+	return &scoped
+}
 
-	// return &UserRepo{
-	// 	conn: conn,
-	// 	coll: conn.Database("granica").Collection("users"),
-	// }, nil
-	return <-Retry(ctx, cfg, logger), nil
+// scope adds the tenant of the repo, if bound to one, to the filter.
+func (r *UserRepo) scope(filter bson.M) bson.M {
+	if r.tenantID != "" {
+		filter[tenantIDField] = r.tenantID
+	}
+	return filter
+}
 
+// checkTenant returns ErrCrossTenant if the repo is bound to a tenant other than the user one.
+func (r *UserRepo) checkTenant(user *m.User) error {
+	if r.tenantID != "" && user.TenantID != r.tenantID {
+		return m.ErrCrossTenant
+	}
+	return nil
 }
 
 // Insert a user in UserRepo.
-func (r *UserRepo) Insert(user *m.User) (id interface{}, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *UserRepo) Insert(ctx context.Context, user *m.User) (id interface{}, err error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
 	defer cancel()
 
-	res, err := r.coll.InsertOne(ctx, user)
+	err = r.checkTenant(user)
 	if err != nil {
 		return nil, err
 	}
+
+	res, err := r.coll.InsertOne(ctx, user)
+	if err != nil {
+		return nil, writeError(err)
+	}
 	return res.InsertedID, nil
 }
 
 // GetAll users from repo.
-func (r *UserRepo) GetAll() ([]m.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *UserRepo) GetAll(ctx context.Context) ([]m.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
 	defer cancel()
 
 	var users []m.User
 
-	cur, err := r.coll.Find(ctx, bson.D{})
+	cur, err := r.coll.Find(ctx, r.scope(bson.M{}))
 	if err != nil {
 		return users, err
 	}
+	defer cur.Close(ctx)
 
 	for cur.Next(ctx) {
 		u := m.User{}
@@ -67,34 +153,42 @@ func (r *UserRepo) GetAll() ([]m.User, error) {
 		}
 		users = append(users, u)
 	}
-	return users, nil
+	return users, cur.Err()
 }
 
 // Get a users from repo by its ID.
-func (r *UserRepo) Get(id interface{}) (*m.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+func (r *UserRepo) Get(ctx context.Context, id interface{}) (*m.User, error) {
+	return r.getOne(ctx, bson.M{userIDField: id})
+}
 
-	var user m.User
+// GetByUsernameAndTenant gets a user from repo by its username and tenant.
+func (r *UserRepo) GetByUsernameAndTenant(ctx context.Context, username, tenantID string) (*m.User, error) {
+	if r.tenantID != "" && tenantID != r.tenantID {
+		return nil, m.ErrCrossTenant
+	}
 
-	filter := bson.M{"ID": id}
-	err := r.coll.FindOne(ctx, filter).Decode(&user)
-	if err != nil {
-		return nil, err
+	return r.getOne(ctx, bson.M{"username": username, tenantIDField: tenantID})
+}
+
+// GetByEmailAndTenant gets a user from repo by its email and tenant.
+func (r *UserRepo) GetByEmailAndTenant(ctx context.Context, email, tenantID string) (*m.User, error) {
+	if r.tenantID != "" && tenantID != r.tenantID {
+		return nil, m.ErrCrossTenant
 	}
 
-	return &user, nil
+	return r.getOne(ctx, bson.M{"email": email, tenantIDField: tenantID})
 }
 
-// Get a users from repo by its username and tenant.
-func (r *UserRepo) GetByUsernameAndTenant(username, tenantID string) (*m.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *UserRepo) getOne(ctx context.Context, filter bson.M) (*m.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
 	defer cancel()
 
 	var user m.User
 
-	filter := bson.M{"Username": username, "TenantID": tenantID}
-	err := r.coll.FindOne(ctx, filter).Decode(&user)
+	err := r.coll.FindOne(ctx, r.scope(filter)).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, m.ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -103,28 +197,108 @@ func (r *UserRepo) GetByUsernameAndTenant(username, tenantID string) (*m.User, e
 }
 
 // Update a user in UserRepo.
-func (r *UserRepo) Update(user *m.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// Users cannot be moved to another tenant.
+func (r *UserRepo) Update(ctx context.Context, user *m.User) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
 	defer cancel()
 
-	filter := bson.M{"ID": user.ID}
-	_, err := r.coll.UpdateOne(ctx, filter, user)
+	err := r.checkTenant(user)
 	if err != nil {
 		return err
 	}
+
+	filter := r.scope(bson.M{userIDField: user.ID, tenantIDField: user.TenantID})
+	res, err := r.coll.ReplaceOne(ctx, filter, user)
+	if err != nil {
+		return writeError(err)
+	}
+
+	if res.MatchedCount == 0 {
+		return m.ErrUserNotFound
+	}
+
 	return nil
 }
 
+// UseMFAStep sets the last TOTP step of the user if it is past the stored one.
+func (r *UserRepo) UseMFAStep(ctx context.Context, user *m.User, step int64) (bool, error) {
+	return r.use(ctx, user,
+		bson.M{"mfa_last_step": bson.M{"$lt": step}},
+		bson.M{"$set": bson.M{"mfa_last_step": step}})
+}
+
+// UseRecoveryCode removes the recovery code digest if the user still has it.
+func (r *UserRepo) UseRecoveryCode(ctx context.Context, user *m.User, digest string) (bool, error) {
+	return r.use(ctx, user,
+		bson.M{"recovery_codes": digest},
+		bson.M{"$pull": bson.M{"recovery_codes": digest}})
+}
+
+// use updates the user only if it still matches the condition, in a single write.
+func (r *UserRepo) use(ctx context.Context, user *m.User, cond, update bson.M) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	err := r.checkTenant(user)
+	if err != nil {
+		return false, err
+	}
+
+	filter := r.scope(bson.M{userIDField: user.ID, tenantIDField: user.TenantID})
+	for k, v := range cond {
+		filter[k] = v
+	}
+
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["audit.updated_by"] = user.UpdatedBy
+	set["audit.updated_at"] = user.UpdatedAt
+
+	res, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount == 1, nil
+}
+
 // Delete a user from repo.
-func (r *UserRepo) Delete(id interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *UserRepo) Delete(ctx context.Context, id interface{}) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
 	defer cancel()
 
-	filter := bson.M{"ID": id}
-	_, err := r.coll.DeleteOne(ctx, filter)
+	filter := r.scope(bson.M{userIDField: id})
+	res, err := r.coll.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
 
+	if res.DeletedCount == 0 {
+		return m.ErrUserNotFound
+	}
+
 	return nil
 }
+
+// writeError returns ErrDuplicateUser if the write broke a unique index.
+func writeError(err error) error {
+	if we, ok := err.(mongo.WriteException); ok {
+		for _, e := range we.WriteErrors {
+			if e.Code == duplicateKeyCode {
+				return m.ErrDuplicateUser
+			}
+		}
+	}
+	return err
+}
+
+// withTimeout returns a copy of the context cancelled after the timeout, if any.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
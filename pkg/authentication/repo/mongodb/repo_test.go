@@ -0,0 +1,40 @@
+package mongodb_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/mongodb"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/repotest"
+)
+
+// TestUserRepo runs against the server of MONGODB_TEST_HOST, if set.
+func TestUserRepo(t *testing.T) {
+	host := os.Getenv("MONGODB_TEST_HOST")
+	if host == "" {
+		t.Skip("MONGODB_TEST_HOST not set")
+	}
+
+	cfg := &config.Config{}
+	cfg.Repo.MongoDB.Host = host
+	cfg.Repo.MongoDB.Port, _ = strconv.Atoi(os.Getenv("MONGODB_TEST_PORT"))
+	if cfg.Repo.MongoDB.Port == 0 {
+		cfg.Repo.MongoDB.Port = 27017
+	}
+	cfg.Repo.Timeouts.Read = 5 * time.Second
+	cfg.Repo.Timeouts.Write = 5 * time.Second
+
+	repotest.TestUserRepo(t, func(t *testing.T) repo.UserRepo {
+		r, err := mongodb.NewTestRepo(context.Background(), cfg, log.NewNopLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	})
+}
@@ -0,0 +1,115 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// RoleRepo is a Mongo implementation of RoleRepo interface.
+type RoleRepo struct {
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewRoleRepo makes a new role repo on the shared connection.
+func NewRoleRepo(conn *mongo.Client, cfg *config.Config) *RoleRepo {
+	return &RoleRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("roles"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a role in RoleRepo.
+func (r *RoleRepo) Insert(ctx context.Context, role *m.Role) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.InsertOne(ctx, role)
+	return err
+}
+
+// Get a role by its ID.
+func (r *RoleRepo) Get(ctx context.Context, id uuid.UUID) (*m.Role, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var role m.Role
+
+	filter := bson.M{"_id": id}
+	err := r.coll.FindOne(ctx, filter).Decode(&role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// GetByNameAndTenant gets a role by its name and tenant.
+func (r *RoleRepo) GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Role, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var role m.Role
+
+	filter := bson.M{"name": name, "tenant_id": tenantID}
+	err := r.coll.FindOne(ctx, filter).Decode(&role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// GetByTenant gets all the roles of a tenant.
+func (r *RoleRepo) GetByTenant(ctx context.Context, tenantID string) ([]m.Role, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var roles []m.Role
+
+	filter := bson.M{"tenant_id": tenantID}
+	cur, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var role m.Role
+		err := cur.Decode(&role)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, cur.Err()
+}
+
+// Update a role in RoleRepo.
+func (r *RoleRepo) Update(ctx context.Context, role *m.Role) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"_id": role.ID}
+	_, err := r.coll.ReplaceOne(ctx, filter, role)
+	return err
+}
+
+// Delete a role from RoleRepo.
+func (r *RoleRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+	_, err := r.coll.DeleteOne(ctx, filter)
+	return err
+}
@@ -0,0 +1,83 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// RoleAssignmentRepo is a Mongo implementation of RoleAssignmentRepo interface.
+type RoleAssignmentRepo struct {
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewRoleAssignmentRepo makes a new role assignment repo on the shared connection.
+func NewRoleAssignmentRepo(conn *mongo.Client, cfg *config.Config) *RoleAssignmentRepo {
+	return &RoleAssignmentRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("role_assignments"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert an assignment in RoleAssignmentRepo.
+func (r *RoleAssignmentRepo) Insert(ctx context.Context, assignment *m.RoleAssignment) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.InsertOne(ctx, assignment)
+	return err
+}
+
+// GetByUser gets all the role assignments of a user.
+func (r *RoleAssignmentRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]m.RoleAssignment, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var assignments []m.RoleAssignment
+
+	filter := bson.M{"user_id": userID}
+	cur, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var assignment m.RoleAssignment
+		err := cur.Decode(&assignment)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, cur.Err()
+}
+
+// Delete the assignment of a role to a user.
+func (r *RoleAssignmentRepo) Delete(ctx context.Context, userID, roleID uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "role_id": roleID}
+	_, err := r.coll.DeleteMany(ctx, filter)
+	return err
+}
+
+// DeleteByRole deletes all the assignments of a role.
+func (r *RoleAssignmentRepo) DeleteByRole(ctx context.Context, roleID uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"role_id": roleID}
+	_, err := r.coll.DeleteMany(ctx, filter)
+	return err
+}
@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// TenantRepo is a Mongo implementation of TenantRepo interface.
+type TenantRepo struct {
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewTenantRepo makes a new tenant repo on the shared connection.
+func NewTenantRepo(conn *mongo.Client, cfg *config.Config) *TenantRepo {
+	return &TenantRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("tenants"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a tenant in TenantRepo.
+func (r *TenantRepo) Insert(ctx context.Context, tenant *m.Tenant) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.InsertOne(ctx, tenant)
+	return err
+}
+
+// GetBySlug gets a tenant by its slug.
+func (r *TenantRepo) GetBySlug(ctx context.Context, slug string) (*m.Tenant, error) {
+	return r.getOne(ctx, bson.M{"slug": slug})
+}
+
+// GetByDomain gets the tenant the domain belongs to.
+func (r *TenantRepo) GetByDomain(ctx context.Context, domain string) (*m.Tenant, error) {
+	return r.getOne(ctx, bson.M{"domains": domain})
+}
+
+func (r *TenantRepo) getOne(ctx context.Context, filter bson.M) (*m.Tenant, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var tenant m.Tenant
+
+	err := r.coll.FindOne(ctx, filter).Decode(&tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenant, nil
+}
+
+// GetAll gets all the tenants.
+func (r *TenantRepo) GetAll(ctx context.Context) ([]m.Tenant, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var tenants []m.Tenant
+
+	cur, err := r.coll.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var tenant m.Tenant
+		err := cur.Decode(&tenant)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	return tenants, cur.Err()
+}
+
+// Update a tenant in TenantRepo.
+func (r *TenantRepo) Update(ctx context.Context, tenant *m.Tenant) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.ReplaceOne(ctx, bson.M{"_id": tenant.ID}, tenant)
+	return err
+}
+
+// Delete a tenant from TenantRepo.
+func (r *TenantRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
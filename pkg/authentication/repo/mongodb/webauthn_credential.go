@@ -0,0 +1,91 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// WebAuthnCredentialRepo is a Mongo implementation of WebAuthnCredentialRepo interface.
+type WebAuthnCredentialRepo struct {
+	conn     *mongo.Client
+	coll     *mongo.Collection
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewWebAuthnCredentialRepo makes a new WebAuthn credential repo on the shared connection.
+func NewWebAuthnCredentialRepo(conn *mongo.Client, cfg *config.Config) *WebAuthnCredentialRepo {
+	return &WebAuthnCredentialRepo{
+		conn:     conn,
+		coll:     conn.Database(dbName).Collection("webauthn_credentials"),
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a credential in WebAuthnCredentialRepo.
+func (r *WebAuthnCredentialRepo) Insert(ctx context.Context, credential *m.WebAuthnCredential) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.coll.InsertOne(ctx, credential)
+	return err
+}
+
+// GetByCredentialIDAndTenant gets a credential by its authenticator assigned ID.
+func (r *WebAuthnCredentialRepo) GetByCredentialIDAndTenant(ctx context.Context, credentialID, tenantID string) (*m.WebAuthnCredential, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var credential m.WebAuthnCredential
+
+	filter := bson.M{"credential_id": credentialID, "tenant_id": tenantID}
+	err := r.coll.FindOne(ctx, filter).Decode(&credential)
+	if err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// GetByUser gets all the credentials of a user.
+func (r *WebAuthnCredentialRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]m.WebAuthnCredential, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var credentials []m.WebAuthnCredential
+
+	filter := bson.M{"user_id": userID}
+	cur, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var credential m.WebAuthnCredential
+		err := cur.Decode(&credential)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, cur.Err()
+}
+
+// UpdateSignCount records the signature counter of the last use of a credential.
+func (r *WebAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id uuid.UUID, signCount uint32, usedAt time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": bson.M{"sign_count": signCount, "last_used_at": usedAt}}
+	_, err := r.coll.UpdateOne(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+var clientColumns = []string{
+	"id", "client_id", "secret_digest", "name", "tenant_id",
+	"redirect_uris", "scopes", "grant_types", "is_active", "created_at", "updated_at",
+}
+
+var (
+	selectClients = "SELECT " + strings.Join(clientColumns, ", ") + " FROM clients"
+	insertClient  = fmt.Sprintf("INSERT INTO clients (%s) VALUES (%s)",
+		strings.Join(clientColumns, ", "), placeholders(1, len(clientColumns)))
+	updateClient = fmt.Sprintf("UPDATE clients SET (%s) = ROW(%s) WHERE id = $1",
+		strings.Join(clientColumns[1:], ", "), placeholders(2, len(clientColumns)))
+)
+
+// ClientRepo is a PostgreSQL implementation of ClientRepo interface.
+type ClientRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewClientRepo makes a new OAuth client repo on the shared database handle.
+func NewClientRepo(db *sql.DB, cfg *config.Config) *ClientRepo {
+	return &ClientRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a client in ClientRepo.
+func (r *ClientRepo) Insert(ctx context.Context, client *m.Client) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, insertClient, clientValues(client)...)
+	return err
+}
+
+// GetByClientIDAndTenant gets a client from repo by its client ID and tenant.
+func (r *ClientRepo) GetByClientIDAndTenant(ctx context.Context, clientID, tenantID string) (*m.Client, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var c m.Client
+
+	err := r.db.QueryRowContext(ctx, selectClients+" WHERE client_id = $1 AND tenant_id = $2", clientID, tenantID).Scan(
+		&c.ID, &c.ClientID, &c.SecretDigest, &c.Name, &c.TenantID,
+		pq.Array(&c.RedirectURIs), pq.Array(&c.Scopes), pq.Array(&c.GrantTypes), &c.IsActive, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &c, nil
+}
+
+// Update a client in ClientRepo.
+func (r *ClientRepo) Update(ctx context.Context, client *m.Client) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, updateClient, clientValues(client)...)
+	return err
+}
+
+// clientValues returns the values of the client columns.
+func clientValues(c *m.Client) []interface{} {
+	return []interface{}{
+		c.ID, c.ClientID, c.SecretDigest, c.Name, c.TenantID,
+		pq.Array(nonNil(c.RedirectURIs)), pq.Array(nonNil(c.Scopes)), pq.Array(nonNil(c.GrantTypes)), c.IsActive, c.CreatedAt, c.UpdatedAt,
+	}
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/kit/log"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+)
+
+// DSN returns the connection URL of the database.
+var DSN = dsn
+
+// NewTestRepo makes a user repo over an emptied users table.
+func NewTestRepo(ctx context.Context, cfg *config.Config, logger log.Logger) (*UserRepo, error) {
+	db, err := Connect(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewRepo(db, cfg)
+	_, err = r.db.ExecContext(ctx, "TRUNCATE users")
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// NewTestDB connects to a database whose tables are emptied.
+func NewTestDB(ctx context.Context, cfg *config.Config, logger log.Logger) (*sql.DB, error) {
+	db, err := Connect(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.ExecContext(ctx, `TRUNCATE users, refresh_tokens, clients, password_resets, webauthn_credentials,
+		roles, permissions, role_assignments, relation_tuples, tenants`)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// DB returns the database of the repo.
+func (r *UserRepo) DB() *sql.DB {
+	return r.db
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Migration modes
+const (
+	// MigrateMode applies the pending migrations.
+	MigrateMode = "m"
+	// RollbackMode reverts the last applied migration.
+	RollbackMode = "r"
+	// NoneMode leaves the schema as it is.
+	NoneMode = "n"
+)
+
+// migrationLock is the key of the advisory lock that keeps replicas
+// from migrating the same database at once.
+const migrationLock = 7470691
+
+// Migration is a versioned schema change and the statements that revert it.
+// Applied migrations must never change: the schema is changed by adding new ones.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations returns the migrations of the repo schema ordered by version.
+func Migrations() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// Migrate applies the pending migrations or reverts the last applied one, as the mode says.
+// Each migration runs in its own transaction, so that a failed one leaves
+// the schema as the previous one left it.
+func Migrate(ctx context.Context, db *sql.DB, migrations []Migration, mode string) error {
+	if mode == NoneMode {
+		return nil
+	}
+
+	if mode != MigrateMode && mode != RollbackMode {
+		return fmt.Errorf("unknown migration mode '%s'", mode)
+	}
+
+	err := validateMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLock)
+	if err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLock)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if mode == RollbackMode {
+		last, ok := lastApplied(migrations, applied)
+		if !ok {
+			return nil
+		}
+		return run(ctx, conn, last.Down, "DELETE FROM schema_migrations WHERE version = $1", last.Version)
+	}
+
+	for _, mg := range pending(migrations, applied) {
+		err := run(ctx, conn, mg.Up, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", mg.Version, mg.Name)
+		if err != nil {
+			return fmt.Errorf("migration %d '%s': %s", mg.Version, mg.Name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// run executes the statements of a migration and records it in a transaction.
+func run(ctx context.Context, conn *sql.Conn, statements, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, statements)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, record, args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		err := rows.Scan(&version)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// validateMigrations returns an error if versions are not unique and ascending
+// or any migration cannot be applied and reverted.
+func validateMigrations(migrations []Migration) error {
+	for i, mg := range migrations {
+		if mg.Version <= 0 || mg.Name == "" {
+			return fmt.Errorf("migration %d: version and name required", i)
+		}
+
+		if i > 0 && mg.Version <= migrations[i-1].Version {
+			return fmt.Errorf("migration %d '%s': versions must be ascending", mg.Version, mg.Name)
+		}
+
+		if mg.Up == "" || mg.Down == "" {
+			return fmt.Errorf("migration %d '%s': up and down statements required", mg.Version, mg.Name)
+		}
+	}
+
+	return nil
+}
+
+// pending returns the migrations not applied yet, in order.
+func pending(migrations []Migration, applied map[int]bool) []Migration {
+	var p []Migration
+	for _, mg := range migrations {
+		if !applied[mg.Version] {
+			p = append(p, mg)
+		}
+	}
+	return p
+}
+
+// lastApplied returns the applied migration with the highest version.
+func lastApplied(migrations []Migration, applied map[int]bool) (Migration, bool) {
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if applied[migrations[i].Version] {
+			return migrations[i], true
+		}
+	}
+	return Migration{}, false
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidateMigrations(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []Migration
+		valid      bool
+	}{
+		{"shipped", migrations, true},
+		{"none", nil, true},
+		{"no_version", []Migration{{Name: "a", Up: "u", Down: "d"}}, false},
+		{"no_name", []Migration{{Version: 1, Up: "u", Down: "d"}}, false},
+		{"no_down", []Migration{{Version: 1, Name: "a", Up: "u"}}, false},
+		{"repeated", []Migration{
+			{Version: 1, Name: "a", Up: "u", Down: "d"},
+			{Version: 1, Name: "b", Up: "u", Down: "d"},
+		}, false},
+		{"descending", []Migration{
+			{Version: 2, Name: "a", Up: "u", Down: "d"},
+			{Version: 1, Name: "b", Up: "u", Down: "d"},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMigrations(tt.migrations)
+			if (err == nil) != tt.valid {
+				t.Errorf("valid: want %t, got error %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestPendingAndLastApplied(t *testing.T) {
+	ms := []Migration{
+		{Version: 1, Name: "a"},
+		{Version: 2, Name: "b"},
+		{Version: 3, Name: "c"},
+	}
+
+	p := pending(ms, map[int]bool{1: true})
+	if len(p) != 2 || p[0].Version != 2 || p[1].Version != 3 {
+		t.Errorf("pending: want [2 3], got %v", p)
+	}
+
+	last, ok := lastApplied(ms, map[int]bool{1: true, 2: true})
+	if !ok || last.Version != 2 {
+		t.Errorf("last applied: want 2, got %d", last.Version)
+	}
+
+	_, ok = lastApplied(ms, map[int]bool{})
+	if ok {
+		t.Error("last applied: want none")
+	}
+}
+
+func TestMigrateModes(t *testing.T) {
+	// None mode never touches the database.
+	err := Migrate(context.Background(), nil, migrations, NoneMode)
+	if err != nil {
+		t.Errorf("none mode: %s", err.Error())
+	}
+
+	err = Migrate(context.Background(), nil, migrations, "x")
+	if err == nil {
+		t.Error("unknown mode: want error")
+	}
+}
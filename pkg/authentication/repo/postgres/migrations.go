@@ -0,0 +1,230 @@
+package postgres
+
+// migrations of the repo schema.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_users",
+		Up: `
+CREATE TABLE users (
+	id                  UUID PRIMARY KEY,
+	tenant_id           TEXT NOT NULL,
+	name_key            TEXT NOT NULL DEFAULT '',
+	slug                TEXT NOT NULL DEFAULT '',
+	username            TEXT NOT NULL,
+	password_digest     TEXT NOT NULL DEFAULT '',
+	password_history    TEXT[] NOT NULL DEFAULT '{}',
+	email               TEXT NOT NULL DEFAULT '',
+	is_email_verified   BOOLEAN NOT NULL DEFAULT FALSE,
+	mfa_secret          TEXT NOT NULL DEFAULT '',
+	mfa_last_step       BIGINT NOT NULL DEFAULT 0,
+	is_mfa_enabled      BOOLEAN NOT NULL DEFAULT FALSE,
+	require_mfa         BOOLEAN NOT NULL DEFAULT FALSE,
+	recovery_codes      TEXT[] NOT NULL DEFAULT '{}',
+	description         TEXT NOT NULL DEFAULT '',
+	given_name          TEXT NOT NULL DEFAULT '',
+	middle_names        TEXT NOT NULL DEFAULT '',
+	family_name         TEXT NOT NULL DEFAULT '',
+	context_id          TEXT NOT NULL DEFAULT '',
+	last_known_ip       TEXT NOT NULL DEFAULT '',
+	last_known_location JSONB,
+	geohash             TEXT NOT NULL DEFAULT '',
+	starts_at           TIMESTAMPTZ NOT NULL,
+	ends_at             TIMESTAMPTZ NOT NULL,
+	is_active           BOOLEAN NOT NULL DEFAULT FALSE,
+	is_logical_deleted  BOOLEAN NOT NULL DEFAULT FALSE,
+	created_by          UUID NOT NULL,
+	updated_by          UUID NOT NULL,
+	created_at          TIMESTAMPTZ NOT NULL,
+	updated_at          TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX users_tenant_id_username_key ON users (tenant_id, username);
+
+-- Users without email do not collide.
+CREATE UNIQUE INDEX users_tenant_id_email_key ON users (tenant_id, email) WHERE email <> '';
+`,
+		Down: `
+DROP TABLE users;
+`,
+	},
+	{
+		Version: 2,
+		Name:    "create_refresh_tokens",
+		Up: `
+CREATE TABLE refresh_tokens (
+	id          UUID PRIMARY KEY,
+	digest      TEXT NOT NULL,
+	family_id   UUID NOT NULL,
+	user_id     UUID NOT NULL,
+	tenant_id   TEXT NOT NULL,
+	client_id   TEXT NOT NULL DEFAULT '',
+	scope       TEXT NOT NULL DEFAULT '',
+	replaced_by UUID NOT NULL,
+	is_revoked  BOOLEAN NOT NULL DEFAULT FALSE,
+	expires_at  TIMESTAMPTZ NOT NULL,
+	created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX refresh_tokens_digest_idx ON refresh_tokens (digest);
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+`,
+		Down: `
+DROP TABLE refresh_tokens;
+`,
+	},
+	{
+		Version: 3,
+		Name:    "create_clients",
+		Up: `
+CREATE TABLE clients (
+	id            UUID PRIMARY KEY,
+	client_id     TEXT NOT NULL,
+	secret_digest TEXT NOT NULL DEFAULT '',
+	name          TEXT NOT NULL DEFAULT '',
+	tenant_id     TEXT NOT NULL,
+	redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+	scopes        TEXT[] NOT NULL DEFAULT '{}',
+	grant_types   TEXT[] NOT NULL DEFAULT '{}',
+	is_active     BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at    TIMESTAMPTZ NOT NULL,
+	updated_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX clients_tenant_id_client_id_idx ON clients (tenant_id, client_id);
+`,
+		Down: `
+DROP TABLE clients;
+`,
+	},
+	{
+		Version: 4,
+		Name:    "create_password_resets",
+		Up: `
+CREATE TABLE password_resets (
+	id         UUID PRIMARY KEY,
+	digest     TEXT NOT NULL,
+	user_id    UUID NOT NULL,
+	tenant_id  TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX password_resets_digest_idx ON password_resets (digest);
+CREATE INDEX password_resets_user_id_idx ON password_resets (user_id);
+`,
+		Down: `
+DROP TABLE password_resets;
+`,
+	},
+	{
+		Version: 5,
+		Name:    "create_webauthn_credentials",
+		Up: `
+CREATE TABLE webauthn_credentials (
+	id                 UUID PRIMARY KEY,
+	credential_id      TEXT NOT NULL,
+	user_id            UUID NOT NULL,
+	tenant_id          TEXT NOT NULL,
+	name               TEXT NOT NULL DEFAULT '',
+	public_key         BYTEA NOT NULL,
+	algorithm          BIGINT NOT NULL,
+	sign_count         BIGINT NOT NULL DEFAULT 0,
+	aaguid             BYTEA,
+	attestation_format TEXT NOT NULL DEFAULT '',
+	created_at         TIMESTAMPTZ NOT NULL,
+	last_used_at       TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX webauthn_credentials_tenant_id_credential_id_idx ON webauthn_credentials (tenant_id, credential_id);
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
+`,
+		Down: `
+DROP TABLE webauthn_credentials;
+`,
+	},
+	{
+		Version: 6,
+		Name:    "create_roles_and_permissions",
+		Up: `
+CREATE TABLE roles (
+	id          UUID PRIMARY KEY,
+	tenant_id   TEXT NOT NULL,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	permissions TEXT[] NOT NULL DEFAULT '{}',
+	created_at  TIMESTAMPTZ NOT NULL,
+	updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX roles_tenant_id_name_idx ON roles (tenant_id, name);
+
+CREATE TABLE permissions (
+	id          UUID PRIMARY KEY,
+	tenant_id   TEXT NOT NULL,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX permissions_tenant_id_name_idx ON permissions (tenant_id, name);
+
+CREATE TABLE role_assignments (
+	id         UUID PRIMARY KEY,
+	tenant_id  TEXT NOT NULL,
+	user_id    UUID NOT NULL,
+	role_id    UUID NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX role_assignments_user_id_idx ON role_assignments (user_id);
+CREATE INDEX role_assignments_role_id_idx ON role_assignments (role_id);
+`,
+		Down: `
+DROP TABLE role_assignments;
+DROP TABLE permissions;
+DROP TABLE roles;
+`,
+	},
+	{
+		Version: 7,
+		Name:    "create_relation_tuples",
+		Up: `
+CREATE TABLE relation_tuples (
+	id         UUID PRIMARY KEY,
+	tenant_id  TEXT NOT NULL,
+	object     TEXT NOT NULL,
+	relation   TEXT NOT NULL,
+	subject    TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX relation_tuples_tenant_id_object_relation_idx ON relation_tuples (tenant_id, object, relation);
+`,
+		Down: `
+DROP TABLE relation_tuples;
+`,
+	},
+	{
+		Version: 8,
+		Name:    "create_tenants",
+		Up: `
+CREATE TABLE tenants (
+	id         UUID PRIMARY KEY,
+	slug       TEXT NOT NULL,
+	name       TEXT NOT NULL DEFAULT '',
+	domains    TEXT[] NOT NULL DEFAULT '{}',
+	status     TEXT NOT NULL,
+	settings   JSONB NOT NULL DEFAULT '{}',
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX tenants_slug_idx ON tenants (slug);
+CREATE INDEX tenants_domains_idx ON tenants USING GIN (domains);
+`,
+		Down: `
+DROP TABLE tenants;
+`,
+	},
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// PasswordResetRepo is a PostgreSQL implementation of PasswordResetRepo interface.
+type PasswordResetRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewPasswordResetRepo makes a new password reset repo on the shared database handle.
+func NewPasswordResetRepo(db *sql.DB, cfg *config.Config) *PasswordResetRepo {
+	return &PasswordResetRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a password reset in PasswordResetRepo.
+func (r *PasswordResetRepo) Insert(ctx context.Context, reset *m.PasswordReset) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO password_resets (id, digest, user_id, tenant_id, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		reset.ID, reset.Digest, reset.UserID, reset.TenantID, reset.ExpiresAt, reset.CreatedAt)
+	return err
+}
+
+// Consume gets and deletes a password reset by its digest.
+// Lookup and deletion are atomic so a reset can only be consumed once.
+func (r *PasswordResetRepo) Consume(ctx context.Context, digest string) (*m.PasswordReset, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	var pr m.PasswordReset
+
+	err := r.db.QueryRowContext(ctx,
+		"DELETE FROM password_resets WHERE id = (SELECT id FROM password_resets WHERE digest = $1 LIMIT 1) "+
+			"RETURNING id, digest, user_id, tenant_id, expires_at, created_at", digest).Scan(
+		&pr.ID, &pr.Digest, &pr.UserID, &pr.TenantID, &pr.ExpiresAt, &pr.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &pr, nil
+}
+
+// DeleteByUser deletes all the pending password resets of a user.
+func (r *PasswordResetRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM password_resets WHERE user_id = $1", userID)
+	return err
+}
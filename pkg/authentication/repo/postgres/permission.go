@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const selectPermissions = "SELECT id, tenant_id, name, description, created_at FROM permissions"
+
+// PermissionRepo is a PostgreSQL implementation of PermissionRepo interface.
+type PermissionRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewPermissionRepo makes a new permission repo on the shared database handle.
+func NewPermissionRepo(db *sql.DB, cfg *config.Config) *PermissionRepo {
+	return &PermissionRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a permission in PermissionRepo.
+func (r *PermissionRepo) Insert(ctx context.Context, permission *m.Permission) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	p := permission
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO permissions (id, tenant_id, name, description, created_at) VALUES ($1, $2, $3, $4, $5)",
+		p.ID, p.TenantID, p.Name, p.Description, p.CreatedAt)
+	return err
+}
+
+// GetByNameAndTenant gets a permission by its name and tenant.
+func (r *PermissionRepo) GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Permission, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var p m.Permission
+
+	err := r.db.QueryRowContext(ctx, selectPermissions+" WHERE name = $1 AND tenant_id = $2", name, tenantID).Scan(
+		&p.ID, &p.TenantID, &p.Name, &p.Description, &p.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &p, nil
+}
+
+// GetByTenant gets all the permissions defined by a tenant.
+func (r *PermissionRepo) GetByTenant(ctx context.Context, tenantID string) ([]m.Permission, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, selectPermissions+" WHERE tenant_id = $1 ORDER BY created_at", tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []m.Permission
+	for rows.Next() {
+		var p m.Permission
+		err := rows.Scan(&p.ID, &p.TenantID, &p.Name, &p.Description, &p.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+
+	return permissions, rows.Err()
+}
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+var refreshTokenColumns = []string{
+	"id", "digest", "family_id", "user_id", "tenant_id", "client_id",
+	"scope", "replaced_by", "is_revoked", "expires_at", "created_at",
+}
+
+var (
+	selectRefreshTokens = "SELECT " + strings.Join(refreshTokenColumns, ", ") + " FROM refresh_tokens"
+	insertRefreshToken  = fmt.Sprintf("INSERT INTO refresh_tokens (%s) VALUES (%s)",
+		strings.Join(refreshTokenColumns, ", "), placeholders(1, len(refreshTokenColumns)))
+)
+
+// RefreshTokenRepo is a PostgreSQL implementation of RefreshTokenRepo interface.
+type RefreshTokenRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewRefreshTokenRepo makes a new refresh token repo on the shared database handle.
+func NewRefreshTokenRepo(db *sql.DB, cfg *config.Config) *RefreshTokenRepo {
+	return &RefreshTokenRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a refresh token in RefreshTokenRepo.
+func (r *RefreshTokenRepo) Insert(ctx context.Context, token *m.RefreshToken) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, insertRefreshToken,
+		token.ID, token.Digest, token.FamilyID, token.UserID, token.TenantID, token.ClientID,
+		token.Scope, token.ReplacedBy, token.IsRevoked, token.ExpiresAt, token.CreatedAt)
+	return err
+}
+
+// GetByDigest gets a refresh token from repo by its digest.
+func (r *RefreshTokenRepo) GetByDigest(ctx context.Context, digest string) (*m.RefreshToken, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var t m.RefreshToken
+
+	err := r.db.QueryRowContext(ctx, selectRefreshTokens+" WHERE digest = $1", digest).Scan(
+		&t.ID, &t.Digest, &t.FamilyID, &t.UserID, &t.TenantID, &t.ClientID,
+		&t.Scope, &t.ReplacedBy, &t.IsRevoked, &t.ExpiresAt, &t.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &t, nil
+}
+
+// Rotate marks a refresh token as replaced by a new one.
+// It returns false if the token had already been rotated or revoked.
+func (r *RefreshTokenRepo) Rotate(ctx context.Context, id, replacedBy uuid.UUID) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET replaced_by = $1 WHERE id = $2 AND replaced_by = $3 AND NOT is_revoked",
+		replacedBy, id, uuid.Nil)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// RevokeFamily revokes all refresh tokens sharing a family.
+func (r *RefreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE refresh_tokens SET is_revoked = TRUE WHERE family_id = $1", familyID)
+	return err
+}
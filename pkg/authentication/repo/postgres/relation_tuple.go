@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const selectRelationTuples = "SELECT id, tenant_id, object, relation, subject, created_at FROM relation_tuples"
+
+// RelationTupleRepo is a PostgreSQL implementation of RelationTupleRepo interface.
+type RelationTupleRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewRelationTupleRepo makes a new relation tuple repo on the shared database handle.
+func NewRelationTupleRepo(db *sql.DB, cfg *config.Config) *RelationTupleRepo {
+	return &RelationTupleRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a relation tuple in RelationTupleRepo.
+func (r *RelationTupleRepo) Insert(ctx context.Context, tuple *m.RelationTuple) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	t := tuple
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO relation_tuples (id, tenant_id, object, relation, subject, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		t.ID, t.TenantID, t.Object, t.Relation, t.Subject, t.CreatedAt)
+	return err
+}
+
+// Get gets a relation tuple of the tenant.
+func (r *RelationTupleRepo) Get(ctx context.Context, tenantID, object, relation, subject string) (*m.RelationTuple, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var t m.RelationTuple
+
+	err := r.db.QueryRowContext(ctx,
+		selectRelationTuples+" WHERE tenant_id = $1 AND object = $2 AND relation = $3 AND subject = $4 LIMIT 1",
+		tenantID, object, relation, subject).Scan(
+		&t.ID, &t.TenantID, &t.Object, &t.Relation, &t.Subject, &t.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &t, nil
+}
+
+// GetByObject gets the relation tuples of the tenant with the object and relation.
+func (r *RelationTupleRepo) GetByObject(ctx context.Context, tenantID, object, relation string) ([]m.RelationTuple, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		selectRelationTuples+" WHERE tenant_id = $1 AND object = $2 AND relation = $3 ORDER BY created_at",
+		tenantID, object, relation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tuples []m.RelationTuple
+	for rows.Next() {
+		var t m.RelationTuple
+		err := rows.Scan(&t.ID, &t.TenantID, &t.Object, &t.Relation, &t.Subject, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, t)
+	}
+
+	return tuples, rows.Err()
+}
+
+// GetObjects gets the distinct objects of the namespace the tenant has tuples of.
+func (r *RelationTupleRepo) GetObjects(ctx context.Context, tenantID, namespace string) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	// The prefix is compared as is, namespaces may hold LIKE wildcards.
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT DISTINCT object FROM relation_tuples WHERE tenant_id = $1 AND left(object, length($2)) = $2",
+		tenantID, namespace+":")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []string
+	for rows.Next() {
+		var object string
+		err := rows.Scan(&object)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	return objects, rows.Err()
+}
+
+// Delete a relation tuple of the tenant.
+func (r *RelationTupleRepo) Delete(ctx context.Context, tenantID, object, relation, subject string) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"DELETE FROM relation_tuples WHERE tenant_id = $1 AND object = $2 AND relation = $3 AND subject = $4",
+		tenantID, object, relation, subject)
+	return err
+}
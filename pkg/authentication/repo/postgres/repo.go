@@ -0,0 +1,390 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/lib/pq"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// uniqueViolation is the code of the errors of writes breaking a unique index.
+const uniqueViolation = "23505"
+
+// userColumns are the columns of the users table in the order users are written and scanned.
+var userColumns = []string{
+	"id", "tenant_id", "name_key", "slug", "username",
+	"password_digest", "password_history", "email", "is_email_verified",
+	"mfa_secret", "mfa_last_step", "is_mfa_enabled", "require_mfa", "recovery_codes",
+	"description", "given_name", "middle_names", "family_name",
+	"context_id", "last_known_ip", "last_known_location", "geohash",
+	"starts_at", "ends_at", "is_active", "is_logical_deleted",
+	"created_by", "updated_by", "created_at", "updated_at",
+}
+
+var (
+	selectUsers = "SELECT " + strings.Join(userColumns, ", ") + " FROM users"
+	insertUser  = fmt.Sprintf("INSERT INTO users (%s) VALUES (%s)", strings.Join(userColumns, ", "), placeholders(1, len(userColumns)))
+	updateUser  = fmt.Sprintf("UPDATE users SET (%s) = ROW(%s) WHERE id = $1 AND tenant_id = $2",
+		strings.Join(userColumns[2:], ", "), placeholders(3, len(userColumns)))
+)
+
+// UserRepo is a PostgreSQL implementation of UserRepo interface.
+// Repos bound to a tenant only read and write the users of that tenant.
+type UserRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+	tenantID string
+}
+
+// Connect returns a database handle for the configured Postgres server.
+// The schema is migrated as the repo migration config says.
+// The handle is meant to be shared by all the repos of the service.
+func Connect(ctx context.Context, cfg *config.Config, logger log.Logger) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn(cfg.Repo.Postgres))
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	err = Migrate(ctx, db, migrations, cfg.Repo.Migration)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	logger.Log("level", config.LogLevel.Info, "msg", "Postgres connection established", "migration", cfg.Repo.Migration)
+
+	return db, nil
+}
+
+// dsn returns the connection URL of the database.
+// Values are escaped so that any password, spaces and quotes included, is sent as is.
+func dsn(pg config.PostgresConfig) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(pg.User, pg.Password),
+		Host:     net.JoinHostPort(pg.Host, strconv.Itoa(pg.Port)),
+		Path:     "/" + pg.Db,
+		RawQuery: url.Values{"sslmode": {pg.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// NewRepo makes a new user repo on the shared database handle.
+func NewRepo(db *sql.DB, cfg *config.Config) *UserRepo {
+	return &UserRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// ForTenant returns a copy of the repo bound to the tenant.
+func (r *UserRepo) ForTenant(tenantID string) *UserRepo {
+	scoped := *r
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+// scope adds the tenant of the repo, if bound to one, to the query conditions.
+func (r *UserRepo) scope(cond string, args ...interface{}) (string, []interface{}) {
+	if r.tenantID != "" {
+		args = append(args, r.tenantID)
+		cond = fmt.Sprintf("%s AND tenant_id = $%d", cond, len(args))
+	}
+	return cond, args
+}
+
+// checkTenant returns ErrCrossTenant if the repo is bound to a tenant other than the user one.
+func (r *UserRepo) checkTenant(tenantID string) error {
+	if r.tenantID != "" && tenantID != r.tenantID {
+		return m.ErrCrossTenant
+	}
+	return nil
+}
+
+// Insert a user in UserRepo.
+func (r *UserRepo) Insert(ctx context.Context, user *m.User) (id interface{}, err error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	err = r.checkTenant(user.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := userValues(user)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.db.ExecContext(ctx, insertUser, values...)
+	if err != nil {
+		return nil, writeError(err)
+	}
+
+	return user.ID, nil
+}
+
+// GetAll users from repo.
+func (r *UserRepo) GetAll(ctx context.Context) ([]m.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	cond, args := r.scope("TRUE")
+	rows, err := r.db.QueryContext(ctx, selectUsers+" WHERE "+cond+" ORDER BY created_at", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []m.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+// Get a user from repo by its ID.
+func (r *UserRepo) Get(ctx context.Context, id interface{}) (*m.User, error) {
+	return r.getOne(ctx, "id = $1", id)
+}
+
+// GetByUsernameAndTenant gets a user from repo by its username and tenant.
+func (r *UserRepo) GetByUsernameAndTenant(ctx context.Context, username, tenantID string) (*m.User, error) {
+	err := r.checkTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.getOne(ctx, "username = $1 AND tenant_id = $2", username, tenantID)
+}
+
+// GetByEmailAndTenant gets a user from repo by its email and tenant.
+func (r *UserRepo) GetByEmailAndTenant(ctx context.Context, email, tenantID string) (*m.User, error) {
+	err := r.checkTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.getOne(ctx, "email = $1 AND tenant_id = $2", email, tenantID)
+}
+
+func (r *UserRepo) getOne(ctx context.Context, cond string, args ...interface{}) (*m.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	cond, args = r.scope(cond, args...)
+	user, err := scanUser(r.db.QueryRowContext(ctx, selectUsers+" WHERE "+cond, args...))
+	if err == sql.ErrNoRows {
+		return nil, m.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Update a user in UserRepo.
+// Users cannot be moved to another tenant.
+func (r *UserRepo) Update(ctx context.Context, user *m.User) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	err := r.checkTenant(user.TenantID)
+	if err != nil {
+		return err
+	}
+
+	values, err := userValues(user)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.ExecContext(ctx, updateUser, values...)
+	if err != nil {
+		return writeError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return m.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UseMFAStep sets the last TOTP step of the user if it is past the stored one.
+func (r *UserRepo) UseMFAStep(ctx context.Context, user *m.User, step int64) (bool, error) {
+	return r.use(ctx, user, "mfa_last_step = $5", "mfa_last_step < $5", step)
+}
+
+// UseRecoveryCode removes the recovery code digest if the user still has it.
+func (r *UserRepo) UseRecoveryCode(ctx context.Context, user *m.User, digest string) (bool, error) {
+	return r.use(ctx, user, "recovery_codes = array_remove(recovery_codes, $5)", "$5 = ANY(recovery_codes)", digest)
+}
+
+// use updates the user only if it still matches the condition, in a single statement.
+// The value of the change is the fifth argument.
+func (r *UserRepo) use(ctx context.Context, user *m.User, set, cond string, value interface{}) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	err := r.checkTenant(user.TenantID)
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf("UPDATE users SET %s, updated_by = $3, updated_at = $4 WHERE id = $1 AND tenant_id = $2 AND %s", set, cond)
+	res, err := r.db.ExecContext(ctx, query, user.ID, user.TenantID, user.UpdatedBy, user.UpdatedAt, value)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// Delete a user from repo.
+func (r *UserRepo) Delete(ctx context.Context, id interface{}) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	cond, args := r.scope("id = $1", id)
+	res, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE "+cond, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return m.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// userValues returns the values of the user columns.
+func userValues(u *m.User) ([]interface{}, error) {
+	location, err := json.Marshal(u.LastKnownLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{
+		u.ID, u.TenantID, u.NameKey, u.Slug, u.Username,
+		u.PasswordDigest, pq.Array(nonNil(u.PasswordHistory)), u.Email, u.IsEmailVerified,
+		u.MFASecret, u.MFALastStep, u.IsMFAEnabled, u.RequireMFA, pq.Array(nonNil(u.RecoveryCodes)),
+		u.Description, u.GivenName, u.MiddleNames, u.FamilyName,
+		u.ContextID, u.LastKnownIP, location, u.Geohash,
+		u.StartsAt, u.EndsAt, u.IsActive, u.IsLogicalDeleted,
+		u.CreatedBy, u.UpdatedBy, u.CreatedAt, u.UpdatedAt,
+	}, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser reads a user from a row of the user columns.
+func scanUser(row scanner) (*m.User, error) {
+	var u m.User
+	var location []byte
+
+	err := row.Scan(
+		&u.ID, &u.TenantID, &u.NameKey, &u.Slug, &u.Username,
+		&u.PasswordDigest, pq.Array(&u.PasswordHistory), &u.Email, &u.IsEmailVerified,
+		&u.MFASecret, &u.MFALastStep, &u.IsMFAEnabled, &u.RequireMFA, pq.Array(&u.RecoveryCodes),
+		&u.Description, &u.GivenName, &u.MiddleNames, &u.FamilyName,
+		&u.ContextID, &u.LastKnownIP, &location, &u.Geohash,
+		&u.StartsAt, &u.EndsAt, &u.IsActive, &u.IsLogicalDeleted,
+		&u.CreatedBy, &u.UpdatedBy, &u.CreatedAt, &u.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(location) > 0 {
+		err = json.Unmarshal(location, &u.LastKnownLocation)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	u.Identification.TenantID = u.TenantID
+	return &u, nil
+}
+
+// writeError returns ErrDuplicateUser if the write broke a unique index.
+func writeError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return m.ErrDuplicateUser
+	}
+	return err
+}
+
+// notFound returns ErrNotFound if the query found no rows.
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return m.ErrNotFound
+	}
+	return err
+}
+
+// placeholders returns the query placeholders from $from to $to.
+func placeholders(from, to int) string {
+	ph := make([]string, 0, to-from+1)
+	for i := from; i <= to; i++ {
+		ph = append(ph, fmt.Sprintf("$%d", i))
+	}
+	return strings.Join(ph, ", ")
+}
+
+// nonNil returns an empty slice for nil ones, as array columns are not null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// withTimeout returns a copy of the context cancelled after the timeout, if any.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
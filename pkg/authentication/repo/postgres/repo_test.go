@@ -0,0 +1,157 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/postgres"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/repotest"
+)
+
+// testConfig returns the config of the server of POSTGRES_TEST_HOST, if set.
+func testConfig(t *testing.T) *config.Config {
+	host := os.Getenv("POSTGRES_TEST_HOST")
+	if host == "" {
+		t.Skip("POSTGRES_TEST_HOST not set")
+	}
+
+	cfg := &config.Config{}
+	cfg.Repo.Postgres.Host = host
+	cfg.Repo.Postgres.Port, _ = strconv.Atoi(os.Getenv("POSTGRES_TEST_PORT"))
+	if cfg.Repo.Postgres.Port == 0 {
+		cfg.Repo.Postgres.Port = 5432
+	}
+	cfg.Repo.Postgres.Db = "granica_test"
+	cfg.Repo.Postgres.User = "granica"
+	cfg.Repo.Postgres.Password = os.Getenv("POSTGRES_TEST_PASSWORD")
+	cfg.Repo.Postgres.SSLMode = "disable"
+	cfg.Repo.Migration = postgres.MigrateMode
+	cfg.Repo.Timeouts.Read = 5 * time.Second
+	cfg.Repo.Timeouts.Write = 5 * time.Second
+	return cfg
+}
+
+func TestUserRepo(t *testing.T) {
+	cfg := testConfig(t)
+
+	repotest.TestUserRepo(t, func(t *testing.T) repo.UserRepo {
+		r, err := postgres.NewTestRepo(context.Background(), cfg, log.NewNopLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	})
+}
+
+func TestSideRepos(t *testing.T) {
+	cfg := testConfig(t)
+
+	repotest.TestSideRepos(t, func(t *testing.T) *repo.Repos {
+		db, err := postgres.NewTestDB(context.Background(), cfg, log.NewNopLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &repo.Repos{
+			RefreshTokens:       postgres.NewRefreshTokenRepo(db, cfg),
+			Clients:             postgres.NewClientRepo(db, cfg),
+			PasswordResets:      postgres.NewPasswordResetRepo(db, cfg),
+			WebAuthnCredentials: postgres.NewWebAuthnCredentialRepo(db, cfg),
+			Roles:               postgres.NewRoleRepo(db, cfg),
+			Permissions:         postgres.NewPermissionRepo(db, cfg),
+			RoleAssignments:     postgres.NewRoleAssignmentRepo(db, cfg),
+			RelationTuples:      postgres.NewRelationTupleRepo(db, cfg),
+			Tenants:             postgres.NewTenantRepo(db, cfg),
+		}
+	})
+}
+
+func TestMigrateRollback(t *testing.T) {
+	cfg := testConfig(t)
+	ctx := context.Background()
+
+	r, err := postgres.NewTestRepo(ctx, cfg, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := r.DB()
+	migrations := postgres.Migrations()
+
+	for range migrations {
+		err = postgres.Migrate(ctx, db, migrations, postgres.RollbackMode)
+		if err != nil {
+			t.Fatalf("rollback: %s", err.Error())
+		}
+	}
+
+	for _, name := range []string{"users", "refresh_tokens", "tenants"} {
+		var table sql.NullString
+		err = db.QueryRowContext(ctx, "SELECT to_regclass($1)::text", name).Scan(&table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if table.Valid {
+			t.Errorf("%s table not dropped on rollback", name)
+		}
+	}
+
+	// Nothing left to roll back.
+	err = postgres.Migrate(ctx, db, migrations, postgres.RollbackMode)
+	if err != nil {
+		t.Errorf("empty rollback: %s", err.Error())
+	}
+
+	// Migrating twice applies each migration once.
+	for i := 0; i < 2; i++ {
+		err = postgres.Migrate(ctx, db, migrations, postgres.MigrateMode)
+		if err != nil {
+			t.Fatalf("migrate: %s", err.Error())
+		}
+	}
+
+	var applied int
+	err = db.QueryRowContext(ctx, "SELECT count(*) FROM schema_migrations").Scan(&applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("applied migrations: want %d, got %d", len(migrations), applied)
+	}
+}
+
+func TestDSN(t *testing.T) {
+	pg := config.PostgresConfig{
+		Host:     "localhost",
+		Port:     5432,
+		Db:       "granica",
+		User:     "granica",
+		Password: "p@ss word' sslmode=disable/?#",
+		SSLMode:  "verify-full",
+	}
+
+	u, err := url.Parse(postgres.DSN(pg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password, _ := u.User.Password()
+	if password != pg.Password {
+		t.Errorf("Password: '%s' | Expected: '%s'", password, pg.Password)
+	}
+
+	if u.Host != "localhost:5432" || u.Path != "/granica" || u.User.Username() != "granica" {
+		t.Errorf("DSN: '%s' | Expected: localhost:5432/granica as granica", u)
+	}
+
+	if mode := u.Query().Get("sslmode"); mode != pg.SSLMode {
+		t.Errorf("SSL mode: '%s' | Expected: '%s'", mode, pg.SSLMode)
+	}
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+var roleColumns = []string{
+	"id", "tenant_id", "name", "description", "permissions", "created_at", "updated_at",
+}
+
+var (
+	selectRoles = "SELECT " + strings.Join(roleColumns, ", ") + " FROM roles"
+	insertRole  = fmt.Sprintf("INSERT INTO roles (%s) VALUES (%s)",
+		strings.Join(roleColumns, ", "), placeholders(1, len(roleColumns)))
+	updateRole = fmt.Sprintf("UPDATE roles SET (%s) = ROW(%s) WHERE id = $1",
+		strings.Join(roleColumns[1:], ", "), placeholders(2, len(roleColumns)))
+)
+
+// RoleRepo is a PostgreSQL implementation of RoleRepo interface.
+type RoleRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewRoleRepo makes a new role repo on the shared database handle.
+func NewRoleRepo(db *sql.DB, cfg *config.Config) *RoleRepo {
+	return &RoleRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a role in RoleRepo.
+func (r *RoleRepo) Insert(ctx context.Context, role *m.Role) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, insertRole, roleValues(role)...)
+	return err
+}
+
+// Get a role by its ID.
+func (r *RoleRepo) Get(ctx context.Context, id uuid.UUID) (*m.Role, error) {
+	return r.getOne(ctx, "id = $1", id)
+}
+
+// GetByNameAndTenant gets a role by its name and tenant.
+func (r *RoleRepo) GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Role, error) {
+	return r.getOne(ctx, "name = $1 AND tenant_id = $2", name, tenantID)
+}
+
+func (r *RoleRepo) getOne(ctx context.Context, cond string, args ...interface{}) (*m.Role, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	role, err := scanRole(r.db.QueryRowContext(ctx, selectRoles+" WHERE "+cond, args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return role, nil
+}
+
+// GetByTenant gets all the roles of a tenant.
+func (r *RoleRepo) GetByTenant(ctx context.Context, tenantID string) ([]m.Role, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, selectRoles+" WHERE tenant_id = $1 ORDER BY created_at", tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []m.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+
+	return roles, rows.Err()
+}
+
+// Update a role in RoleRepo.
+func (r *RoleRepo) Update(ctx context.Context, role *m.Role) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, updateRole, roleValues(role)...)
+	return err
+}
+
+// Delete a role from RoleRepo.
+func (r *RoleRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM roles WHERE id = $1", id)
+	return err
+}
+
+// roleValues returns the values of the role columns.
+func roleValues(role *m.Role) []interface{} {
+	return []interface{}{
+		role.ID, role.TenantID, role.Name, role.Description, pq.Array(nonNil(role.Permissions)), role.CreatedAt, role.UpdatedAt,
+	}
+}
+
+// scanRole reads a role from a row of the role columns.
+func scanRole(row scanner) (*m.Role, error) {
+	var role m.Role
+
+	err := row.Scan(&role.ID, &role.TenantID, &role.Name, &role.Description, pq.Array(&role.Permissions), &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
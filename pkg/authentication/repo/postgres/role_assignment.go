@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// RoleAssignmentRepo is a PostgreSQL implementation of RoleAssignmentRepo interface.
+type RoleAssignmentRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewRoleAssignmentRepo makes a new role assignment repo on the shared database handle.
+func NewRoleAssignmentRepo(db *sql.DB, cfg *config.Config) *RoleAssignmentRepo {
+	return &RoleAssignmentRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert an assignment in RoleAssignmentRepo.
+func (r *RoleAssignmentRepo) Insert(ctx context.Context, assignment *m.RoleAssignment) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	a := assignment
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO role_assignments (id, tenant_id, user_id, role_id, created_at) VALUES ($1, $2, $3, $4, $5)",
+		a.ID, a.TenantID, a.UserID, a.RoleID, a.CreatedAt)
+	return err
+}
+
+// GetByUser gets all the role assignments of a user.
+func (r *RoleAssignmentRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]m.RoleAssignment, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, tenant_id, user_id, role_id, created_at FROM role_assignments WHERE user_id = $1 ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []m.RoleAssignment
+	for rows.Next() {
+		var a m.RoleAssignment
+		err := rows.Scan(&a.ID, &a.TenantID, &a.UserID, &a.RoleID, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+
+	return assignments, rows.Err()
+}
+
+// Delete the assignment of a role to a user.
+func (r *RoleAssignmentRepo) Delete(ctx context.Context, userID, roleID uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM role_assignments WHERE user_id = $1 AND role_id = $2", userID, roleID)
+	return err
+}
+
+// DeleteByRole deletes all the assignments of a role.
+func (r *RoleAssignmentRepo) DeleteByRole(ctx context.Context, roleID uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM role_assignments WHERE role_id = $1", roleID)
+	return err
+}
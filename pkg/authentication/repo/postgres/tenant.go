@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+var tenantColumns = []string{
+	"id", "slug", "name", "domains", "status", "settings", "created_at", "updated_at",
+}
+
+var (
+	selectTenants = "SELECT " + strings.Join(tenantColumns, ", ") + " FROM tenants"
+	insertTenant  = fmt.Sprintf("INSERT INTO tenants (%s) VALUES (%s)",
+		strings.Join(tenantColumns, ", "), placeholders(1, len(tenantColumns)))
+	updateTenant = fmt.Sprintf("UPDATE tenants SET (%s) = ROW(%s) WHERE id = $1",
+		strings.Join(tenantColumns[1:], ", "), placeholders(2, len(tenantColumns)))
+)
+
+// TenantRepo is a PostgreSQL implementation of TenantRepo interface.
+type TenantRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewTenantRepo makes a new tenant repo on the shared database handle.
+func NewTenantRepo(db *sql.DB, cfg *config.Config) *TenantRepo {
+	return &TenantRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a tenant in TenantRepo.
+func (r *TenantRepo) Insert(ctx context.Context, tenant *m.Tenant) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	values, err := tenantValues(tenant)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, insertTenant, values...)
+	return err
+}
+
+// GetBySlug gets a tenant by its slug.
+func (r *TenantRepo) GetBySlug(ctx context.Context, slug string) (*m.Tenant, error) {
+	return r.getOne(ctx, "slug = $1", slug)
+}
+
+// GetByDomain gets the tenant the domain belongs to.
+func (r *TenantRepo) GetByDomain(ctx context.Context, domain string) (*m.Tenant, error) {
+	return r.getOne(ctx, "domains @> ARRAY[$1]::TEXT[]", domain)
+}
+
+func (r *TenantRepo) getOne(ctx context.Context, cond string, args ...interface{}) (*m.Tenant, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	tenant, err := scanTenant(r.db.QueryRowContext(ctx, selectTenants+" WHERE "+cond+" LIMIT 1", args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return tenant, nil
+}
+
+// GetAll gets all the tenants.
+func (r *TenantRepo) GetAll(ctx context.Context) ([]m.Tenant, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, selectTenants+" ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []m.Tenant
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, *tenant)
+	}
+
+	return tenants, rows.Err()
+}
+
+// Update a tenant in TenantRepo.
+func (r *TenantRepo) Update(ctx context.Context, tenant *m.Tenant) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	values, err := tenantValues(tenant)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, updateTenant, values...)
+	return err
+}
+
+// Delete a tenant from TenantRepo.
+func (r *TenantRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM tenants WHERE id = $1", id)
+	return err
+}
+
+// tenantValues returns the values of the tenant columns.
+// Settings are stored as JSON.
+func tenantValues(t *m.Tenant) ([]interface{}, error) {
+	settings, err := json.Marshal(t.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{
+		t.ID, t.Slug, t.Name, pq.Array(nonNil(t.Domains)), t.Status, settings, t.CreatedAt, t.UpdatedAt,
+	}, nil
+}
+
+// scanTenant reads a tenant from a row of the tenant columns.
+func scanTenant(row scanner) (*m.Tenant, error) {
+	var t m.Tenant
+	var settings []byte
+
+	err := row.Scan(&t.ID, &t.Slug, &t.Name, pq.Array(&t.Domains), &t.Status, &settings, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(settings, &t.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
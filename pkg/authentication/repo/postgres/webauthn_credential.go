@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+var credentialColumns = []string{
+	"id", "credential_id", "user_id", "tenant_id", "name", "public_key",
+	"algorithm", "sign_count", "aaguid", "attestation_format", "created_at", "last_used_at",
+}
+
+var (
+	selectCredentials = "SELECT " + strings.Join(credentialColumns, ", ") + " FROM webauthn_credentials"
+	insertCredential  = fmt.Sprintf("INSERT INTO webauthn_credentials (%s) VALUES (%s)",
+		strings.Join(credentialColumns, ", "), placeholders(1, len(credentialColumns)))
+)
+
+// WebAuthnCredentialRepo is a PostgreSQL implementation of WebAuthnCredentialRepo interface.
+type WebAuthnCredentialRepo struct {
+	db       *sql.DB
+	timeouts config.RepoTimeoutsConfig
+}
+
+// NewWebAuthnCredentialRepo makes a new WebAuthn credential repo on the shared database handle.
+func NewWebAuthnCredentialRepo(db *sql.DB, cfg *config.Config) *WebAuthnCredentialRepo {
+	return &WebAuthnCredentialRepo{
+		db:       db,
+		timeouts: cfg.Repo.Timeouts,
+	}
+}
+
+// Insert a credential in WebAuthnCredentialRepo.
+func (r *WebAuthnCredentialRepo) Insert(ctx context.Context, credential *m.WebAuthnCredential) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	c := credential
+	_, err := r.db.ExecContext(ctx, insertCredential,
+		c.ID, c.CredentialID, c.UserID, c.TenantID, c.Name, c.PublicKey,
+		c.Algorithm, int64(c.SignCount), c.AAGUID, c.AttestationFormat, c.CreatedAt, c.LastUsedAt)
+	return err
+}
+
+// GetByCredentialIDAndTenant gets a credential by its authenticator assigned ID.
+func (r *WebAuthnCredentialRepo) GetByCredentialIDAndTenant(ctx context.Context, credentialID, tenantID string) (*m.WebAuthnCredential, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	row := r.db.QueryRowContext(ctx, selectCredentials+" WHERE credential_id = $1 AND tenant_id = $2", credentialID, tenantID)
+	credential, err := scanCredential(row)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return credential, nil
+}
+
+// GetByUser gets all the credentials of a user.
+func (r *WebAuthnCredentialRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]m.WebAuthnCredential, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, selectCredentials+" WHERE user_id = $1 ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []m.WebAuthnCredential
+	for rows.Next() {
+		credential, err := scanCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, *credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+// UpdateSignCount records the signature counter of the last use of a credential.
+func (r *WebAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id uuid.UUID, signCount uint32, usedAt time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE webauthn_credentials SET sign_count = $1, last_used_at = $2 WHERE id = $3",
+		int64(signCount), usedAt, id)
+	return err
+}
+
+// scanCredential reads a credential from a row of the credential columns.
+func scanCredential(row scanner) (*m.WebAuthnCredential, error) {
+	var c m.WebAuthnCredential
+	var signCount int64
+
+	err := row.Scan(
+		&c.ID, &c.CredentialID, &c.UserID, &c.TenantID, &c.Name, &c.PublicKey,
+		&c.Algorithm, &signCount, &c.AAGUID, &c.AttestationFormat, &c.CreatedAt, &c.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SignCount = uint32(signCount)
+	return &c, nil
+}
@@ -4,34 +4,229 @@ import (
 	// c "gitlab.com/mikrowezel/backend/granica/internal/config"
 	"context"
 	"errors"
+	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
 
 	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/boltdb"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/memory"
 	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/mongodb"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/postgres"
 	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
 )
 
 // UserRepo interface
 type UserRepo interface {
-	Insert(*m.User) (id interface{}, err error)
-	Get(id interface{}) (*m.User, error)
-	GetAll() ([]m.User, error)
-	GetByUsernameAndTenant(username, tenantID string) (*m.User, error)
-	Update(*m.User) error
-	Delete(id interface{}) error
+	Insert(ctx context.Context, user *m.User) (id interface{}, err error)
+	Get(ctx context.Context, id interface{}) (*m.User, error)
+	GetAll(ctx context.Context) ([]m.User, error)
+	GetByUsernameAndTenant(ctx context.Context, username, tenantID string) (*m.User, error)
+	GetByEmailAndTenant(ctx context.Context, email, tenantID string) (*m.User, error)
+	Update(ctx context.Context, user *m.User) error
+	// UseMFAStep sets the last TOTP step the user signed in with if it is past the stored one.
+	// It returns false if the step, or a later one, had already been used.
+	UseMFAStep(ctx context.Context, user *m.User, step int64) (bool, error)
+	// UseRecoveryCode removes the recovery code digest from the ones of the user.
+	// It returns false if the code had already been used.
+	UseRecoveryCode(ctx context.Context, user *m.User, digest string) (bool, error)
+	Delete(ctx context.Context, id interface{}) error
 }
 
-// NewRepo makes a new user repo.
-func NewRepo(ctx context.Context, cfg *config.Config, logger log.Logger) (UserRepo, error) {
+// RefreshTokenRepo interface
+type RefreshTokenRepo interface {
+	Insert(ctx context.Context, token *m.RefreshToken) error
+	GetByDigest(ctx context.Context, digest string) (*m.RefreshToken, error)
+	Rotate(ctx context.Context, id, replacedBy uuid.UUID) (ok bool, err error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+}
+
+// PasswordResetRepo interface
+type PasswordResetRepo interface {
+	Insert(ctx context.Context, reset *m.PasswordReset) error
+	Consume(ctx context.Context, digest string) (*m.PasswordReset, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// ClientRepo interface
+type ClientRepo interface {
+	Insert(ctx context.Context, client *m.Client) error
+	GetByClientIDAndTenant(ctx context.Context, clientID, tenantID string) (*m.Client, error)
+	Update(ctx context.Context, client *m.Client) error
+}
+
+// WebAuthnCredentialRepo interface
+type WebAuthnCredentialRepo interface {
+	Insert(ctx context.Context, credential *m.WebAuthnCredential) error
+	GetByCredentialIDAndTenant(ctx context.Context, credentialID, tenantID string) (*m.WebAuthnCredential, error)
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]m.WebAuthnCredential, error)
+	UpdateSignCount(ctx context.Context, id uuid.UUID, signCount uint32, usedAt time.Time) error
+}
+
+// RoleRepo interface
+type RoleRepo interface {
+	Insert(ctx context.Context, role *m.Role) error
+	Get(ctx context.Context, id uuid.UUID) (*m.Role, error)
+	GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Role, error)
+	GetByTenant(ctx context.Context, tenantID string) ([]m.Role, error)
+	Update(ctx context.Context, role *m.Role) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// PermissionRepo interface
+type PermissionRepo interface {
+	Insert(ctx context.Context, permission *m.Permission) error
+	GetByNameAndTenant(ctx context.Context, name, tenantID string) (*m.Permission, error)
+	GetByTenant(ctx context.Context, tenantID string) ([]m.Permission, error)
+}
+
+// RoleAssignmentRepo interface
+type RoleAssignmentRepo interface {
+	Insert(ctx context.Context, assignment *m.RoleAssignment) error
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]m.RoleAssignment, error)
+	Delete(ctx context.Context, userID, roleID uuid.UUID) error
+	DeleteByRole(ctx context.Context, roleID uuid.UUID) error
+}
+
+// RelationTupleRepo interface
+type RelationTupleRepo interface {
+	Insert(ctx context.Context, tuple *m.RelationTuple) error
+	Get(ctx context.Context, tenantID, object, relation, subject string) (*m.RelationTuple, error)
+	GetByObject(ctx context.Context, tenantID, object, relation string) ([]m.RelationTuple, error)
+	GetObjects(ctx context.Context, tenantID, namespace string) ([]string, error)
+	Delete(ctx context.Context, tenantID, object, relation, subject string) error
+}
+
+// TenantRepo interface
+type TenantRepo interface {
+	Insert(ctx context.Context, tenant *m.Tenant) error
+	GetBySlug(ctx context.Context, slug string) (*m.Tenant, error)
+	GetByDomain(ctx context.Context, domain string) (*m.Tenant, error)
+	GetAll(ctx context.Context) ([]m.Tenant, error)
+	Update(ctx context.Context, tenant *m.Tenant) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// Repos are the repos of the service, all of the configured repo type.
+type Repos struct {
+	Users               UserRepo
+	RefreshTokens       RefreshTokenRepo
+	Clients             ClientRepo
+	PasswordResets      PasswordResetRepo
+	WebAuthnCredentials WebAuthnCredentialRepo
+	Roles               RoleRepo
+	Permissions         PermissionRepo
+	RoleAssignments     RoleAssignmentRepo
+	RelationTuples      RelationTupleRepo
+	Tenants             TenantRepo
+}
+
+// NewRepos makes the repos of the service.
+// Repos of the same type share their connection.
+func NewRepos(ctx context.Context, cfg *config.Config, logger log.Logger) (*Repos, error) {
 
 	if cfg.Repo.Type == "mongodb" {
-		return mongodb.NewRepo(ctx, cfg, logger)
+		return newMongoDBRepos(ctx, cfg, logger)
+
+	} else if cfg.Repo.Type == "memory" {
+		return newMemoryRepos(ctx, cfg, logger)
+
+	} else if cfg.Repo.Type == "postgres" {
+		return newPostgresRepos(ctx, cfg, logger)
+
+	} else if cfg.Repo.Type == "bolt" {
+		return newBoltRepos(ctx, cfg, logger)
 
 	} else if cfg.Repo.Type == "dgraph" {
-		// return dgraph.NewRepo(ctx, cfg, logger)
+		// return dgraph.NewRepos(ctx, cfg, logger)
 		return nil, errors.New("not a valid repo type")
 	}
 
 	return nil, errors.New("not a valid repo type")
 }
+
+func newMongoDBRepos(ctx context.Context, cfg *config.Config, logger log.Logger) (*Repos, error) {
+	conn, err := mongodb.Connect(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := mongodb.NewRepo(ctx, conn, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repos{
+		Users:               users,
+		RefreshTokens:       mongodb.NewRefreshTokenRepo(conn, cfg),
+		Clients:             mongodb.NewClientRepo(conn, cfg),
+		PasswordResets:      mongodb.NewPasswordResetRepo(conn, cfg),
+		WebAuthnCredentials: mongodb.NewWebAuthnCredentialRepo(conn, cfg),
+		Roles:               mongodb.NewRoleRepo(conn, cfg),
+		Permissions:         mongodb.NewPermissionRepo(conn, cfg),
+		RoleAssignments:     mongodb.NewRoleAssignmentRepo(conn, cfg),
+		RelationTuples:      mongodb.NewRelationTupleRepo(conn, cfg),
+		Tenants:             mongodb.NewTenantRepo(conn, cfg),
+	}, nil
+}
+
+func newMemoryRepos(ctx context.Context, cfg *config.Config, logger log.Logger) (*Repos, error) {
+	users, err := memory.NewRepo(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repos{
+		Users:               users,
+		RefreshTokens:       memory.NewRefreshTokenRepo(),
+		Clients:             memory.NewClientRepo(),
+		PasswordResets:      memory.NewPasswordResetRepo(),
+		WebAuthnCredentials: memory.NewWebAuthnCredentialRepo(),
+		Roles:               memory.NewRoleRepo(),
+		Permissions:         memory.NewPermissionRepo(),
+		RoleAssignments:     memory.NewRoleAssignmentRepo(),
+		RelationTuples:      memory.NewRelationTupleRepo(),
+		Tenants:             memory.NewTenantRepo(),
+	}, nil
+}
+
+func newPostgresRepos(ctx context.Context, cfg *config.Config, logger log.Logger) (*Repos, error) {
+	db, err := postgres.Connect(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repos{
+		Users:               postgres.NewRepo(db, cfg),
+		RefreshTokens:       postgres.NewRefreshTokenRepo(db, cfg),
+		Clients:             postgres.NewClientRepo(db, cfg),
+		PasswordResets:      postgres.NewPasswordResetRepo(db, cfg),
+		WebAuthnCredentials: postgres.NewWebAuthnCredentialRepo(db, cfg),
+		Roles:               postgres.NewRoleRepo(db, cfg),
+		Permissions:         postgres.NewPermissionRepo(db, cfg),
+		RoleAssignments:     postgres.NewRoleAssignmentRepo(db, cfg),
+		RelationTuples:      postgres.NewRelationTupleRepo(db, cfg),
+		Tenants:             postgres.NewTenantRepo(db, cfg),
+	}, nil
+}
+
+func newBoltRepos(ctx context.Context, cfg *config.Config, logger log.Logger) (*Repos, error) {
+	db, err := boltdb.Open(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repos{
+		Users:               boltdb.NewRepo(db),
+		RefreshTokens:       boltdb.NewRefreshTokenRepo(db),
+		Clients:             boltdb.NewClientRepo(db),
+		PasswordResets:      boltdb.NewPasswordResetRepo(db),
+		WebAuthnCredentials: boltdb.NewWebAuthnCredentialRepo(db),
+		Roles:               boltdb.NewRoleRepo(db),
+		Permissions:         boltdb.NewPermissionRepo(db),
+		RoleAssignments:     boltdb.NewRoleAssignmentRepo(db),
+		RelationTuples:      boltdb.NewRelationTupleRepo(db),
+		Tenants:             boltdb.NewTenantRepo(db),
+	}, nil
+}
@@ -0,0 +1,415 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// NewRepos returns empty repos for a test.
+// Only the repos other than users are checked, TestUserRepo covers those.
+type NewRepos func(t *testing.T) *repo.Repos
+
+// TestSideRepos checks that the repos other than users made by newRepos
+// behave like the reference ones: models are copied in and out, lookups
+// honor every key they are given and missing models are reported with an error.
+func TestSideRepos(t *testing.T, newRepos NewRepos) {
+	tests := []struct {
+		name string
+		test func(*testing.T, *repo.Repos)
+	}{
+		{"RefreshTokens", testRefreshTokens},
+		{"PasswordResets", testPasswordResets},
+		{"Clients", testClients},
+		{"WebAuthnCredentials", testWebAuthnCredentials},
+		{"Roles", testRoles},
+		{"Permissions", testPermissions},
+		{"RoleAssignments", testRoleAssignments},
+		{"RelationTuples", testRelationTuples},
+		{"Tenants", testTenants},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepos(t))
+		})
+	}
+}
+
+// now returns the current time with the precision every repo keeps.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func check(t *testing.T, op string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", op, err)
+	}
+}
+
+func testRefreshTokens(t *testing.T, rs *repo.Repos) {
+	ctx := context.Background()
+	r := rs.RefreshTokens
+
+	token := &m.RefreshToken{
+		ID:        uuid.New(),
+		Digest:    "digest",
+		FamilyID:  uuid.New(),
+		UserID:    uuid.New(),
+		TenantID:  "acme",
+		ExpiresAt: now().Add(time.Hour),
+		CreatedAt: now(),
+	}
+	check(t, "Insert", r.Insert(ctx, token))
+
+	got, err := r.GetByDigest(ctx, "digest")
+	check(t, "GetByDigest", err)
+	if got.ID != token.ID || got.FamilyID != token.FamilyID || !got.ExpiresAt.Equal(token.ExpiresAt) {
+		t.Errorf("GetByDigest: %+v | Expected: %+v", got, token)
+	}
+
+	if got, err := r.GetByDigest(ctx, "other"); err == nil {
+		t.Errorf("GetByDigest: %+v | Expected: error", got)
+	}
+
+	next := uuid.New()
+	if ok, err := r.Rotate(ctx, token.ID, next); !ok || err != nil {
+		t.Fatalf("Rotate: %v, %v | Expected: true", ok, err)
+	}
+
+	// Tokens are rotated only once.
+	if ok, err := r.Rotate(ctx, token.ID, uuid.New()); ok || err != nil {
+		t.Errorf("Rotate again: %v, %v | Expected: false", ok, err)
+	}
+
+	sibling := *token
+	sibling.ID = uuid.New()
+	sibling.Digest = "sibling"
+	check(t, "Insert", r.Insert(ctx, &sibling))
+	check(t, "RevokeFamily", r.RevokeFamily(ctx, token.FamilyID))
+
+	for _, digest := range []string{"digest", "sibling"} {
+		got, err := r.GetByDigest(ctx, digest)
+		check(t, "GetByDigest", err)
+		if !got.IsRevoked {
+			t.Errorf("Token '%s' not revoked", digest)
+		}
+	}
+
+	if got, _ := r.GetByDigest(ctx, "digest"); got.ReplacedBy != next {
+		t.Errorf("ReplacedBy: %s | Expected: %s", got.ReplacedBy, next)
+	}
+
+	// Revoked tokens cannot be rotated.
+	if ok, err := r.Rotate(ctx, sibling.ID, uuid.New()); ok || err != nil {
+		t.Errorf("Rotate revoked: %v, %v | Expected: false", ok, err)
+	}
+}
+
+func testPasswordResets(t *testing.T, rs *repo.Repos) {
+	ctx := context.Background()
+	r := rs.PasswordResets
+
+	userID := uuid.New()
+	for _, digest := range []string{"first", "second"} {
+		reset := &m.PasswordReset{ID: uuid.New(), Digest: digest, UserID: userID, TenantID: "acme", ExpiresAt: now().Add(time.Hour), CreatedAt: now()}
+		check(t, "Insert", r.Insert(ctx, reset))
+	}
+
+	got, err := r.Consume(ctx, "first")
+	check(t, "Consume", err)
+	if got.UserID != userID || got.Digest != "first" {
+		t.Errorf("Consume: %+v | Expected: reset of %s", got, userID)
+	}
+
+	// Resets are consumed only once.
+	if got, err := r.Consume(ctx, "first"); err == nil {
+		t.Errorf("Consume again: %+v | Expected: error", got)
+	}
+
+	check(t, "DeleteByUser", r.DeleteByUser(ctx, userID))
+	if got, err := r.Consume(ctx, "second"); err == nil {
+		t.Errorf("Consume deleted: %+v | Expected: error", got)
+	}
+}
+
+func testClients(t *testing.T, rs *repo.Repos) {
+	ctx := context.Background()
+	r := rs.Clients
+
+	client := &m.Client{
+		ID:           uuid.New(),
+		ClientID:     "client",
+		SecretDigest: "digest",
+		TenantID:     "acme",
+		RedirectURIs: []string{"https://app.granica.dev/callback"},
+		Scopes:       []string{"users:read"},
+		GrantTypes:   []string{"authorization_code"},
+		IsActive:     true,
+		CreatedAt:    now(),
+	}
+	check(t, "Insert", r.Insert(ctx, client))
+
+	// Changes are only stored on update.
+	client.Scopes[0] = "users:write"
+
+	got, err := r.GetByClientIDAndTenant(ctx, "client", "acme")
+	check(t, "GetByClientIDAndTenant", err)
+	if got.ID != client.ID || !got.IsActive || !got.HasRedirectURI("https://app.granica.dev/callback") || !got.AllowsScope("users:read") {
+		t.Errorf("GetByClientIDAndTenant: %+v | Expected: %+v", got, client)
+	}
+
+	if got, err := r.GetByClientIDAndTenant(ctx, "client", "other"); err == nil {
+		t.Errorf("GetByClientIDAndTenant other tenant: %+v | Expected: error", got)
+	}
+
+	got.Scopes = append(got.Scopes, "openid")
+	got.IsActive = false
+	check(t, "Update", r.Update(ctx, got))
+
+	got, err = r.GetByClientIDAndTenant(ctx, "client", "acme")
+	check(t, "GetByClientIDAndTenant", err)
+	if got.IsActive || !got.AllowsScope("openid") {
+		t.Errorf("Updated: %+v | Expected: inactive with openid scope", got)
+	}
+}
+
+func testWebAuthnCredentials(t *testing.T, rs *repo.Repos) {
+	ctx := context.Background()
+	r := rs.WebAuthnCredentials
+
+	userID := uuid.New()
+	for _, id := range []string{"first", "second"} {
+		credential := &m.WebAuthnCredential{
+			ID:           uuid.New(),
+			CredentialID: id,
+			UserID:       userID,
+			TenantID:     "acme",
+			PublicKey:    []byte{1, 2, 3},
+			Algorithm:    -7,
+			SignCount:    1,
+			CreatedAt:    now(),
+		}
+		check(t, "Insert", r.Insert(ctx, credential))
+	}
+
+	got, err := r.GetByCredentialIDAndTenant(ctx, "first", "acme")
+	check(t, "GetByCredentialIDAndTenant", err)
+	if got.UserID != userID || got.Algorithm != -7 || string(got.PublicKey) != string([]byte{1, 2, 3}) {
+		t.Errorf("GetByCredentialIDAndTenant: %+v", got)
+	}
+
+	if got, err := r.GetByCredentialIDAndTenant(ctx, "first", "other"); err == nil {
+		t.Errorf("GetByCredentialIDAndTenant other tenant: %+v | Expected: error", got)
+	}
+
+	usedAt := now()
+	check(t, "UpdateSignCount", r.UpdateSignCount(ctx, got.ID, 42, usedAt))
+
+	all, err := r.GetByUser(ctx, userID)
+	check(t, "GetByUser", err)
+	if len(all) != 2 {
+		t.Fatalf("GetByUser: %d | Expected: 2", len(all))
+	}
+
+	for _, c := range all {
+		if c.CredentialID == "first" && (c.SignCount != 42 || !c.LastUsedAt.Equal(usedAt)) {
+			t.Errorf("Sign count: %d, %s | Expected: 42, %s", c.SignCount, c.LastUsedAt, usedAt)
+		}
+	}
+}
+
+func testRoles(t *testing.T, rs *repo.Repos) {
+	ctx := context.Background()
+	r := rs.Roles
+
+	role := &m.Role{ID: uuid.New(), TenantID: "acme", Name: "admin", Permissions: []string{"users:*"}, CreatedAt: now()}
+	check(t, "Insert", r.Insert(ctx, role))
+	check(t, "Insert", r.Insert(ctx, &m.Role{ID: uuid.New(), TenantID: "other", Name: "admin", CreatedAt: now()}))
+
+	got, err := r.Get(ctx, role.ID)
+	check(t, "Get", err)
+	if got.Name != "admin" || !m.PermissionGranted(got.Permissions, "users:read") {
+		t.Errorf("Get: %+v | Expected: %+v", got, role)
+	}
+
+	got, err = r.GetByNameAndTenant(ctx, "admin", "acme")
+	check(t, "GetByNameAndTenant", err)
+	if got.ID != role.ID {
+		t.Errorf("GetByNameAndTenant: %+v | Expected: %+v", got, role)
+	}
+
+	got.Permissions = []string{"users:read"}
+	check(t, "Update", r.Update(ctx, got))
+
+	roles, err := r.GetByTenant(ctx, "acme")
+	check(t, "GetByTenant", err)
+	if len(roles) != 1 || len(roles[0].Permissions) != 1 || roles[0].Permissions[0] != "users:read" {
+		t.Errorf("GetByTenant: %+v | Expected: the updated role only", roles)
+	}
+
+	check(t, "Delete", r.Delete(ctx, role.ID))
+	if got, err := r.Get(ctx, role.ID); err == nil {
+		t.Errorf("Get deleted: %+v | Expected: error", got)
+	}
+}
+
+func testPermissions(t *testing.T, rs *repo.Repos) {
+	ctx := context.Background()
+	r := rs.Permissions
+
+	for _, name := range []string{"reports:read", "reports:write"} {
+		check(t, "Insert", r.Insert(ctx, &m.Permission{ID: uuid.New(), TenantID: "acme", Name: name, CreatedAt: now()}))
+	}
+
+	got, err := r.GetByNameAndTenant(ctx, "reports:read", "acme")
+	check(t, "GetByNameAndTenant", err)
+	if got.Name != "reports:read" {
+		t.Errorf("GetByNameAndTenant: %+v", got)
+	}
+
+	if got, err := r.GetByNameAndTenant(ctx, "reports:read", "other"); err == nil {
+		t.Errorf("GetByNameAndTenant other tenant: %+v | Expected: error", got)
+	}
+
+	all, err := r.GetByTenant(ctx, "acme")
+	check(t, "GetByTenant", err)
+	if len(all) != 2 {
+		t.Errorf("GetByTenant: %d | Expected: 2", len(all))
+	}
+}
+
+func testRoleAssignments(t *testing.T, rs *repo.Repos) {
+	ctx := context.Background()
+	r := rs.RoleAssignments
+
+	userID, admin, viewer := uuid.New(), uuid.New(), uuid.New()
+	for _, roleID := range []uuid.UUID{admin, viewer} {
+		check(t, "Insert", r.Insert(ctx, &m.RoleAssignment{ID: uuid.New(), TenantID: "acme", UserID: userID, RoleID: roleID, CreatedAt: now()}))
+	}
+	check(t, "Insert", r.Insert(ctx, &m.RoleAssignment{ID: uuid.New(), TenantID: "acme", UserID: uuid.New(), RoleID: viewer, CreatedAt: now()}))
+
+	all, err := r.GetByUser(ctx, userID)
+	check(t, "GetByUser", err)
+	if len(all) != 2 {
+		t.Errorf("GetByUser: %d | Expected: 2", len(all))
+	}
+
+	check(t, "Delete", r.Delete(ctx, userID, admin))
+	check(t, "DeleteByRole", r.DeleteByRole(ctx, viewer))
+
+	all, err = r.GetByUser(ctx, userID)
+	check(t, "GetByUser", err)
+	if len(all) != 0 {
+		t.Errorf("GetByUser: %+v | Expected: none", all)
+	}
+}
+
+func testRelationTuples(t *testing.T, rs *repo.Repos) {
+	ctx := context.Background()
+	r := rs.RelationTuples
+
+	tuples := []m.RelationTuple{
+		{TenantID: "acme", Object: "document:readme", Relation: "viewer", Subject: "user:ann"},
+		{TenantID: "acme", Object: "document:readme", Relation: "viewer", Subject: "group:eng#member"},
+		{TenantID: "acme", Object: "document:api", Relation: "owner", Subject: "user:bob"},
+		{TenantID: "acme", Object: "folder:specs", Relation: "viewer", Subject: "user:ann"},
+		{TenantID: "other", Object: "document:secret", Relation: "viewer", Subject: "user:ann"},
+	}
+	for i := range tuples {
+		tuples[i].ID = uuid.New()
+		tuples[i].CreatedAt = now()
+		check(t, "Insert", r.Insert(ctx, &tuples[i]))
+	}
+
+	got, err := r.Get(ctx, "acme", "document:readme", "viewer", "user:ann")
+	check(t, "Get", err)
+	if got.ID != tuples[0].ID {
+		t.Errorf("Get: %+v | Expected: %+v", got, tuples[0])
+	}
+
+	if got, err := r.Get(ctx, "other", "document:readme", "viewer", "user:ann"); err == nil {
+		t.Errorf("Get other tenant: %+v | Expected: error", got)
+	}
+
+	viewers, err := r.GetByObject(ctx, "acme", "document:readme", "viewer")
+	check(t, "GetByObject", err)
+	if len(viewers) != 2 {
+		t.Errorf("GetByObject: %d | Expected: 2", len(viewers))
+	}
+
+	objects, err := r.GetObjects(ctx, "acme", "document")
+	check(t, "GetObjects", err)
+	sort.Strings(objects)
+	if len(objects) != 2 || objects[0] != "document:api" || objects[1] != "document:readme" {
+		t.Errorf("GetObjects: %v | Expected: [document:api document:readme]", objects)
+	}
+
+	check(t, "Delete", r.Delete(ctx, "acme", "document:readme", "viewer", "user:ann"))
+	if got, err := r.Get(ctx, "acme", "document:readme", "viewer", "user:ann"); err == nil {
+		t.Errorf("Get deleted: %+v | Expected: error", got)
+	}
+}
+
+func testTenants(t *testing.T, rs *repo.Repos) {
+	ctx := context.Background()
+	r := rs.Tenants
+
+	tenant := &m.Tenant{
+		ID:      uuid.New(),
+		Slug:    "acme",
+		Name:    "Acme",
+		Domains: []string{"acme.com", "auth.acme.com"},
+		Status:  m.TenantActive,
+		Settings: m.TenantSettings{
+			PasswordPolicy: &m.TenantPasswordPolicy{MinLength: 12},
+			SignInMethods:  []string{m.SignInWebAuthn},
+		},
+		CreatedAt: now(),
+	}
+	check(t, "Insert", r.Insert(ctx, tenant))
+	check(t, "Insert", r.Insert(ctx, &m.Tenant{ID: uuid.New(), Slug: "other", Domains: []string{"other.com"}, Status: m.TenantActive, CreatedAt: now().Add(time.Second)}))
+
+	got, err := r.GetBySlug(ctx, "acme")
+	check(t, "GetBySlug", err)
+	if got.ID != tenant.ID || got.Settings.PasswordPolicy == nil || got.Settings.PasswordPolicy.MinLength != 12 ||
+		got.Settings.AllowsSignIn(m.SignInPassword) {
+		t.Errorf("GetBySlug: %+v | Expected: %+v", got, tenant)
+	}
+
+	got, err = r.GetByDomain(ctx, "auth.acme.com")
+	check(t, "GetByDomain", err)
+	if got.ID != tenant.ID {
+		t.Errorf("GetByDomain: %+v | Expected: %+v", got, tenant)
+	}
+
+	if got, err := r.GetByDomain(ctx, "acme.org"); err == nil {
+		t.Errorf("GetByDomain unknown: %+v | Expected: error", got)
+	}
+
+	got.Status = m.TenantSuspended
+	got.Domains = []string{"acme.org"}
+	check(t, "Update", r.Update(ctx, got))
+
+	if got, err := r.GetByDomain(ctx, "acme.org"); err != nil || got.IsActive() {
+		t.Errorf("GetByDomain updated: %+v, %v | Expected: suspended tenant", got, err)
+	}
+
+	all, err := r.GetAll(ctx)
+	check(t, "GetAll", err)
+	if len(all) != 2 {
+		t.Errorf("GetAll: %d | Expected: 2", len(all))
+	}
+
+	check(t, "Delete", r.Delete(ctx, tenant.ID))
+	if got, err := r.GetBySlug(ctx, "acme"); err == nil {
+		t.Errorf("GetBySlug deleted: %+v | Expected: error", got)
+	}
+}
@@ -0,0 +1,415 @@
+// Package repotest holds the conformance tests repo implementations must pass.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// NewUserRepo returns an empty user repo for a test.
+type NewUserRepo func(t *testing.T) repo.UserRepo
+
+// TestUserRepo checks that the user repos made by newRepo behave like the reference ones:
+// users are copied in and out, their ID and their username and email within their tenant
+// are unique, missing users are reported with ErrUserNotFound and tenant scoped repos
+// neither read nor write users of other tenants.
+func TestUserRepo(t *testing.T, newRepo NewUserRepo) {
+	tests := []struct {
+		name string
+		test func(*testing.T, repo.UserRepo)
+	}{
+		{"InsertAndGet", testInsertAndGet},
+		{"NotFound", testNotFound},
+		{"Duplicates", testDuplicates},
+		{"Update", testUpdate},
+		{"SecondFactor", testSecondFactor},
+		{"Delete", testDelete},
+		{"GetAll", testGetAll},
+		{"Copies", testCopies},
+		{"TenantScope", testTenantScope},
+		{"CancelledContext", testCancelledContext},
+		{"Concurrency", testConcurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepo(t))
+		})
+	}
+}
+
+// NewUser returns a user ready to be inserted.
+func NewUser(username, email, tenantID string) *m.User {
+	user := &m.User{
+		Username:       username,
+		Email:          email,
+		TenantID:       tenantID,
+		PasswordDigest: "digest",
+		RecoveryCodes:  []string{"code"},
+	}
+	user.ID = uuid.New()
+	user.Identification.TenantID = tenantID
+	user.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	return user
+}
+
+func insert(t *testing.T, r repo.UserRepo, users ...*m.User) {
+	t.Helper()
+	for _, u := range users {
+		if _, err := r.Insert(context.Background(), u); err != nil {
+			t.Fatalf("Insert '%s': %v", u.Username, err)
+		}
+	}
+}
+
+func testInsertAndGet(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+	user := NewUser("username", "username@granica.dev", "acme")
+	insert(t, r, user)
+
+	got := []func() (*m.User, error){
+		func() (*m.User, error) { return r.Get(ctx, user.ID) },
+		func() (*m.User, error) { return r.GetByUsernameAndTenant(ctx, "username", "acme") },
+		func() (*m.User, error) { return r.GetByEmailAndTenant(ctx, "username@granica.dev", "acme") },
+	}
+
+	for i, get := range got {
+		u, err := get()
+		if err != nil {
+			t.Fatalf("Get %d: %v", i, err)
+		}
+
+		if u.ID != user.ID || u.Username != user.Username || u.Email != user.Email ||
+			u.TenantID != user.TenantID || u.PasswordDigest != user.PasswordDigest || len(u.RecoveryCodes) != 1 {
+			t.Errorf("Get %d: %+v | Expected: %+v", i, u, user)
+		}
+	}
+}
+
+func testNotFound(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+	insert(t, r, NewUser("username", "username@granica.dev", "acme"))
+
+	got := []func() (*m.User, error){
+		func() (*m.User, error) { return r.Get(ctx, uuid.New()) },
+		func() (*m.User, error) { return r.GetByUsernameAndTenant(ctx, "other", "acme") },
+		func() (*m.User, error) { return r.GetByUsernameAndTenant(ctx, "username", "other") },
+		func() (*m.User, error) { return r.GetByEmailAndTenant(ctx, "username@granica.dev", "other") },
+	}
+
+	for i, get := range got {
+		if u, err := get(); err != m.ErrUserNotFound {
+			t.Errorf("Get %d: %v, %v | Expected: %v", i, u, err, m.ErrUserNotFound)
+		}
+	}
+}
+
+func testDuplicates(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+	user := NewUser("username", "username@granica.dev", "acme")
+	insert(t, r, user)
+
+	sameID := NewUser("other", "other@granica.dev", "acme")
+	sameID.ID = user.ID
+
+	for _, u := range []*m.User{
+		sameID,
+		NewUser("username", "other@granica.dev", "acme"),
+		NewUser("other", "username@granica.dev", "acme"),
+	} {
+		if _, err := r.Insert(ctx, u); err != m.ErrDuplicateUser {
+			t.Errorf("Insert '%s' <%s>: %v | Expected: %v", u.Username, u.Email, err, m.ErrDuplicateUser)
+		}
+	}
+
+	// Usernames and emails are unique within a tenant and
+	// users without email do not collide.
+	insert(t, r,
+		NewUser("username", "username@granica.dev", "other"),
+		NewUser("no-email", "", "acme"),
+		NewUser("no-email-either", "", "acme"),
+	)
+}
+
+func testUpdate(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+	user := NewUser("username", "username@granica.dev", "acme")
+	other := NewUser("other", "other@granica.dev", "acme")
+	insert(t, r, user, other)
+
+	user.Email = "new@granica.dev"
+	user.IsEmailVerified = true
+	if err := r.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := r.GetByEmailAndTenant(ctx, "new@granica.dev", "acme")
+	if err != nil || u.ID != user.ID || !u.IsEmailVerified {
+		t.Errorf("Updated: %+v, %v | Expected: %+v", u, err, user)
+	}
+
+	if err := r.Update(ctx, NewUser("missing", "", "acme")); err != m.ErrUserNotFound {
+		t.Errorf("Update missing: %v | Expected: %v", err, m.ErrUserNotFound)
+	}
+
+	taken := *user
+	taken.Username = "other"
+	if err := r.Update(ctx, &taken); err != m.ErrDuplicateUser {
+		t.Errorf("Update taken: %v | Expected: %v", err, m.ErrDuplicateUser)
+	}
+
+	moved := *user
+	moved.TenantID = "other"
+	if err := r.Update(ctx, &moved); err != m.ErrUserNotFound {
+		t.Errorf("Update moved: %v | Expected: %v", err, m.ErrUserNotFound)
+	}
+}
+
+func testSecondFactor(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+	user := NewUser("username", "username@granica.dev", "acme")
+	user.RecoveryCodes = []string{"first", "second"}
+	insert(t, r, user)
+
+	uses := []struct {
+		name string
+		use  func() (bool, error)
+		ok   bool
+	}{
+		{"step", func() (bool, error) { return r.UseMFAStep(ctx, user, 10) }, true},
+		{"same step", func() (bool, error) { return r.UseMFAStep(ctx, user, 10) }, false},
+		{"earlier step", func() (bool, error) { return r.UseMFAStep(ctx, user, 9) }, false},
+		{"code", func() (bool, error) { return r.UseRecoveryCode(ctx, user, "first") }, true},
+		{"same code", func() (bool, error) { return r.UseRecoveryCode(ctx, user, "first") }, false},
+		{"unknown code", func() (bool, error) { return r.UseRecoveryCode(ctx, user, "unknown") }, false},
+	}
+
+	for _, u := range uses {
+		ok, err := u.use()
+		if err != nil || ok != u.ok {
+			t.Errorf("Use %s: %t, %v | Expected: %t", u.name, ok, err, u.ok)
+		}
+	}
+
+	u, err := r.Get(ctx, user.ID)
+	if err != nil || u.MFALastStep != 10 || len(u.RecoveryCodes) != 1 || u.RecoveryCodes[0] != "second" {
+		t.Errorf("Used: %+v, %v | Expected: step 10 and code 'second'", u, err)
+	}
+
+	// Only one of the concurrent uses of the same step or code succeeds.
+	const n = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	used := 0
+
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		for _, use := range []func() (bool, error){
+			func() (bool, error) { return r.UseMFAStep(ctx, user, 11) },
+			func() (bool, error) { return r.UseRecoveryCode(ctx, user, "second") },
+		} {
+			go func(use func() (bool, error)) {
+				defer wg.Done()
+				ok, err := use()
+				if err != nil {
+					t.Errorf("Use: %v | Expected: nil", err)
+				}
+				if ok {
+					mu.Lock()
+					used++
+					mu.Unlock()
+				}
+			}(use)
+		}
+	}
+	wg.Wait()
+
+	if used != 2 {
+		t.Errorf("Used: %d | Expected: 2", used)
+	}
+}
+
+func testDelete(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+	user := NewUser("username", "username@granica.dev", "acme")
+	insert(t, r, user)
+
+	if err := r.Delete(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Get(ctx, user.ID); err != m.ErrUserNotFound {
+		t.Errorf("Get deleted: %v | Expected: %v", err, m.ErrUserNotFound)
+	}
+
+	if err := r.Delete(ctx, user.ID); err != m.ErrUserNotFound {
+		t.Errorf("Delete deleted: %v | Expected: %v", err, m.ErrUserNotFound)
+	}
+
+	// Deleted usernames can be taken again.
+	insert(t, r, NewUser("username", "username@granica.dev", "acme"))
+}
+
+func testGetAll(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+
+	users, err := r.GetAll(ctx)
+	if err != nil || len(users) != 0 {
+		t.Errorf("GetAll: %d, %v | Expected: 0", len(users), err)
+	}
+
+	insert(t, r,
+		NewUser("username", "username@granica.dev", "acme"),
+		NewUser("other", "other@granica.dev", "acme"),
+		NewUser("username", "username@granica.dev", "other"),
+	)
+
+	users, err = r.GetAll(ctx)
+	if err != nil || len(users) != 3 {
+		t.Errorf("GetAll: %d, %v | Expected: 3", len(users), err)
+	}
+}
+
+func testCopies(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+	user := NewUser("username", "username@granica.dev", "acme")
+	insert(t, r, user)
+
+	// Changes not saved through Update are not seen by later reads.
+	user.Username = "changed"
+	u, err := r.Get(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u.Email = "changed@granica.dev"
+	u.RecoveryCodes[0] = "used"
+
+	u, err = r.Get(ctx, user.ID)
+	if err != nil || u.Username != "username" || u.Email != "username@granica.dev" || u.RecoveryCodes[0] != "code" {
+		t.Errorf("Get: %+v, %v | Expected: stored user unchanged", u, err)
+	}
+}
+
+func testTenantScope(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+	alice := NewUser("alice", "alice@acme.com", "acme")
+	mallory := NewUser("mallory", "mallory@evil.com", "evil")
+	insert(t, r, alice, mallory)
+
+	evil := repo.ForTenant(r, "evil")
+
+	if u, err := evil.Get(ctx, alice.ID); err != m.ErrUserNotFound {
+		t.Errorf("Get: %v, %v | Expected: %v", u, err, m.ErrUserNotFound)
+	}
+
+	if _, err := evil.GetByUsernameAndTenant(ctx, "alice", "acme"); err != m.ErrCrossTenant {
+		t.Errorf("GetByUsernameAndTenant: %v | Expected: %v", err, m.ErrCrossTenant)
+	}
+
+	if _, err := evil.GetByEmailAndTenant(ctx, "alice@acme.com", "acme"); err != m.ErrCrossTenant {
+		t.Errorf("GetByEmailAndTenant: %v | Expected: %v", err, m.ErrCrossTenant)
+	}
+
+	users, err := evil.GetAll(ctx)
+	if err != nil || len(users) != 1 || users[0].ID != mallory.ID {
+		t.Errorf("GetAll: %v, %v | Expected: mallory only", users, err)
+	}
+
+	if _, err := evil.Insert(ctx, NewUser("intruder", "", "acme")); err != m.ErrCrossTenant {
+		t.Errorf("Insert: %v | Expected: %v", err, m.ErrCrossTenant)
+	}
+
+	if err := evil.Update(ctx, alice); err != m.ErrCrossTenant {
+		t.Errorf("Update: %v | Expected: %v", err, m.ErrCrossTenant)
+	}
+
+	forged := *alice
+	forged.TenantID = "evil"
+	if err := evil.Update(ctx, &forged); err != m.ErrUserNotFound {
+		t.Errorf("Update forged: %v | Expected: %v", err, m.ErrUserNotFound)
+	}
+
+	if err := evil.Delete(ctx, alice.ID); err != m.ErrUserNotFound {
+		t.Errorf("Delete: %v | Expected: %v", err, m.ErrUserNotFound)
+	}
+
+	u, err := repo.ForTenant(r, "acme").Get(ctx, alice.ID)
+	if err != nil || u.Username != "alice" {
+		t.Errorf("Get: %v, %v | Expected: alice unchanged", u, err)
+	}
+}
+
+func testCancelledContext(t *testing.T, r repo.UserRepo) {
+	user := NewUser("username", "username@granica.dev", "acme")
+	insert(t, r, user)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := r.Insert(ctx, NewUser("other", "", "acme")); err == nil {
+		t.Error("Insert: nil | Expected: error")
+	}
+
+	if _, err := r.Get(ctx, user.ID); err == nil {
+		t.Error("Get: nil | Expected: error")
+	}
+
+	if err := r.Update(ctx, user); err == nil {
+		t.Error("Update: nil | Expected: error")
+	}
+
+	if err := r.Delete(ctx, user.ID); err == nil {
+		t.Error("Delete: nil | Expected: error")
+	}
+}
+
+func testConcurrency(t *testing.T, r repo.UserRepo) {
+	ctx := context.Background()
+	const n = 20
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	inserted := 0
+
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := r.Insert(ctx, NewUser(fmt.Sprintf("user-%d", i), "", "acme")); err != nil {
+				t.Errorf("Insert: %v | Expected: nil", err)
+			}
+		}(i)
+
+		// Only one of the users with the same username is inserted.
+		go func() {
+			defer wg.Done()
+			_, err := r.Insert(ctx, NewUser("same", "", "acme"))
+			if err == nil {
+				mu.Lock()
+				inserted++
+				mu.Unlock()
+			} else if err != m.ErrDuplicateUser {
+				t.Errorf("Insert: %v | Expected: %v", err, m.ErrDuplicateUser)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if inserted != 1 {
+		t.Errorf("Inserted: %d | Expected: 1", inserted)
+	}
+
+	users, err := r.GetAll(ctx)
+	if err != nil || len(users) != n+1 {
+		t.Errorf("GetAll: %d, %v | Expected: %d", len(users), err, n+1)
+	}
+}
@@ -0,0 +1,166 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/mongodb"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo/postgres"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// ErrNoTenant is returned when a tenant scoped repo is requested
+// for a context not bound to a tenant.
+var ErrNoTenant = errors.New("no tenant in context")
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of the context bound to the tenant.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant the context is bound to.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// NewTenantUserRepo returns the user repo scoped to the tenant of the context.
+func NewTenantUserRepo(ctx context.Context, r UserRepo) (UserRepo, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	return ForTenant(r, tenantID), nil
+}
+
+// ForTenant returns the user repo scoped to the tenant.
+// Mongo and Postgres repos filter their queries by tenant. Other repos are wrapped
+// so that users of other tenants are neither returned nor written.
+func ForTenant(r UserRepo, tenantID string) UserRepo {
+	switch r := r.(type) {
+	case *mongodb.UserRepo:
+		return r.ForTenant(tenantID)
+	case *postgres.UserRepo:
+		return r.ForTenant(tenantID)
+	case *tenantUserRepo:
+		return &tenantUserRepo{repo: r.repo, tenantID: tenantID}
+	}
+
+	return &tenantUserRepo{repo: r, tenantID: tenantID}
+}
+
+// tenantUserRepo is a user repo that only reads and writes the users of a tenant.
+// Users of other tenants are reported as not found.
+type tenantUserRepo struct {
+	repo     UserRepo
+	tenantID string
+}
+
+func (r *tenantUserRepo) Insert(ctx context.Context, user *m.User) (interface{}, error) {
+	if user.TenantID != r.tenantID {
+		return nil, m.ErrCrossTenant
+	}
+
+	return r.repo.Insert(ctx, user)
+}
+
+func (r *tenantUserRepo) Get(ctx context.Context, id interface{}) (*m.User, error) {
+	user, err := r.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.check(user)
+}
+
+func (r *tenantUserRepo) GetAll(ctx context.Context) ([]m.User, error) {
+	all, err := r.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []m.User
+	for _, u := range all {
+		if u.TenantID == r.tenantID {
+			users = append(users, u)
+		}
+	}
+
+	return users, nil
+}
+
+func (r *tenantUserRepo) GetByUsernameAndTenant(ctx context.Context, username, tenantID string) (*m.User, error) {
+	if tenantID != r.tenantID {
+		return nil, m.ErrCrossTenant
+	}
+
+	user, err := r.repo.GetByUsernameAndTenant(ctx, username, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.check(user)
+}
+
+func (r *tenantUserRepo) GetByEmailAndTenant(ctx context.Context, email, tenantID string) (*m.User, error) {
+	if tenantID != r.tenantID {
+		return nil, m.ErrCrossTenant
+	}
+
+	user, err := r.repo.GetByEmailAndTenant(ctx, email, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.check(user)
+}
+
+// Update checks the stored user too, so that users of other tenants
+// cannot be overwritten through a forged tenant ID.
+func (r *tenantUserRepo) Update(ctx context.Context, user *m.User) error {
+	if user.TenantID != r.tenantID {
+		return m.ErrCrossTenant
+	}
+
+	_, err := r.Get(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return r.repo.Update(ctx, user)
+}
+
+func (r *tenantUserRepo) UseMFAStep(ctx context.Context, user *m.User, step int64) (bool, error) {
+	if user.TenantID != r.tenantID {
+		return false, m.ErrCrossTenant
+	}
+
+	return r.repo.UseMFAStep(ctx, user, step)
+}
+
+func (r *tenantUserRepo) UseRecoveryCode(ctx context.Context, user *m.User, digest string) (bool, error) {
+	if user.TenantID != r.tenantID {
+		return false, m.ErrCrossTenant
+	}
+
+	return r.repo.UseRecoveryCode(ctx, user, digest)
+}
+
+func (r *tenantUserRepo) Delete(ctx context.Context, id interface{}) error {
+	_, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return r.repo.Delete(ctx, id)
+}
+
+func (r *tenantUserRepo) check(user *m.User) (*m.User, error) {
+	if user.TenantID != r.tenantID {
+		return nil, m.ErrUserNotFound
+	}
+	return user, nil
+}
@@ -10,12 +10,23 @@ package authentication
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
 	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	c "gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/hasher"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/lockout"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/mailer"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/policy"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/rebac"
 	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/session"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/webauthn"
 	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -25,35 +36,146 @@ var (
 // ErrServer is a general server error
 var ErrServer = errors.New("Internal server error")
 
+// ErrUnauthorized is returned when a request lacks valid credentials.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or revoked.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// ErrEmailNotVerified is returned on sign in when verified emails are required.
+var ErrEmailNotVerified = errors.New("email not verified")
+
+// ErrInvalidVerificationToken is returned when an email verification token is not valid.
+var ErrInvalidVerificationToken = errors.New("invalid verification token")
+
+// ErrInvalidResetToken is returned when a password reset token is unknown, expired or used.
+var ErrInvalidResetToken = errors.New("invalid reset token")
+
+// ErrInsufficientScope is returned when an access token lacks the scope a request requires.
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// ErrForbidden is returned when an authenticated caller lacks the permission required.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrLocked is returned when an account or remote IP is locked after too many failed attempts.
+var ErrLocked = errors.New("too many failed attempts, try again later")
+
+// ErrRefreshTokenReused is returned when an already rotated refresh token is presented.
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+// ErrMFARequired is returned when a user required to have a second factor has not enrolled one.
+var ErrMFARequired = errors.New("second factor required")
+
+// ErrMFAAlreadyEnabled is returned when enrolling a second factor while having one.
+var ErrMFAAlreadyEnabled = errors.New("second factor already enabled")
+
+// ErrMFANotEnabled is returned when managing a second factor not yet enrolled.
+var ErrMFANotEnabled = errors.New("second factor not enabled")
+
+// ErrInvalidMFAToken is returned when a MFA token is not valid.
+var ErrInvalidMFAToken = errors.New("invalid MFA token")
+
+// ErrInvalidMFACode is returned when a second factor code does not match.
+var ErrInvalidMFACode = errors.New("invalid MFA code")
+
 // GranicaService provides authentication and authorization services
 type GranicaService interface {
-	SignUp(username, password, email, emailConfirmation, tenantID string) (*m.User, error)
-	Cancel(username, password, tenantID string) error
-	SignIn(username, password, tenantID string) (*m.User, error)
-	SignOut(username, tenantID string) error
-	Create(username, password, email, tenantID string) (*m.User, error)
-	Update(username, password, passwordConfirmation,
+	SignUp(ctx context.Context, username, password, email, emailConfirmation, tenantID string) (*m.User, error)
+	Cancel(ctx context.Context, username, password, remoteIP, tenantID string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendEmailVerification(ctx context.Context, username, tenantID string) error
+	ForgotPassword(ctx context.Context, email, tenantID string) error
+	ResetPassword(ctx context.Context, token, password, passwordConfirmation string) error
+	SignIn(ctx context.Context, username, password, remoteIP, tenantID string) (*AuthToken, error)
+	VerifyMFA(ctx context.Context, mfaToken, code, remoteIP, tenantID string) (*AuthToken, error)
+	EnrollMFA(ctx context.Context, token, tenantID string) (*MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, token, code, tenantID string) (recoveryCodes []string, err error)
+	DisableMFA(ctx context.Context, accessToken, code, tenantID string) error
+	BeginWebAuthnRegistration(ctx context.Context, accessToken, tenantID string) (*WebAuthnRegistration, error)
+	FinishWebAuthnRegistration(ctx context.Context, accessToken, token, name string, resp webauthn.RegistrationResponse, tenantID string) (*m.WebAuthnCredential, error)
+	BeginWebAuthnLogin(ctx context.Context, username, tenantID string) (*WebAuthnLogin, error)
+	FinishWebAuthnLogin(ctx context.Context, token string, resp webauthn.AssertionResponse, tenantID string) (*AuthToken, error)
+	SignOut(ctx context.Context, accessToken string, all bool, tenantID string) error
+	Refresh(ctx context.Context, refreshToken, tenantID string) (*AuthToken, error)
+	JWKS(ctx context.Context) (*JWKSet, error)
+	Introspect(ctx context.Context, token, tokenTypeHint, clientID, clientSecret, tenantID string) (*Introspection, error)
+	RevokeToken(ctx context.Context, token, tokenTypeHint, clientID, clientSecret, tenantID string) error
+	RegisterClient(ctx context.Context, name string, redirectURIs, scopes, grantTypes []string, confidential bool, tenantID string) (client *m.Client, secret string, err error)
+	RotateClientSecret(ctx context.Context, clientID, tenantID string) (secret string, err error)
+	ValidateAuthorization(ctx context.Context, ar AuthorizationRequest) (client *m.Client, redirectURL string, err error)
+	Authorize(ctx context.Context, ar AuthorizationRequest, username, password, otp string, consent bool) (redirectURL string, err error)
+	Token(ctx context.Context, tr TokenRequest) (*AuthToken, error)
+	OpenIDConfiguration(ctx context.Context) (*ProviderMetadata, error)
+	UserInfo(ctx context.Context, accessToken, tenantID string) (*UserInfo, error)
+	Create(ctx context.Context, username, password, email, tenantID string) (*m.User, error)
+	Update(ctx context.Context, username, password, passwordConfirmation,
 		email, emailConfirmation, description,
-		givenName, middleNames, familyName, tenantID string) error
-	Remove(username, email, tenantID string) error
+		givenName, middleNames, familyName, remoteIP, tenantID string) error
+	Remove(ctx context.Context, username, email, tenantID string) error
+	Unlock(ctx context.Context, username, remoteIP, tenantID string) error
+	CreateRole(ctx context.Context, name, description string, permissions []string, tenantID string) (*m.Role, error)
+	GetRoles(ctx context.Context, tenantID string) ([]m.Role, error)
+	UpdateRole(ctx context.Context, roleID, name, description string, permissions []string, tenantID string) (*m.Role, error)
+	DeleteRole(ctx context.Context, roleID, tenantID string) error
+	CreatePermission(ctx context.Context, name, description, tenantID string) (*m.Permission, error)
+	GetPermissions(ctx context.Context, tenantID string) ([]m.Permission, error)
+	AssignRole(ctx context.Context, username, roleID, tenantID string) error
+	UnassignRole(ctx context.Context, username, roleID, tenantID string) error
+	GetUserRoles(ctx context.Context, username, tenantID string) ([]m.Role, error)
+	CheckPermission(ctx context.Context, accessToken, permission, tenantID string) error
+	Decide(ctx context.Context, accessToken string, ar AccessRequest, tenantID string) (*policy.Decision, error)
+	WriteRelation(ctx context.Context, object, relation, subject, tenantID string) (*m.RelationTuple, error)
+	DeleteRelation(ctx context.Context, object, relation, subject, tenantID string) error
+	CheckRelation(ctx context.Context, object, relation, subject, tenantID string) (bool, error)
+	ExpandRelation(ctx context.Context, object, relation, tenantID string) (*rebac.Tree, error)
+	ListObjects(ctx context.Context, namespace, relation, subject, tenantID string) ([]string, error)
+	ResolveTenant(ctx context.Context, kind, key string) (*m.Tenant, error)
+	CreateTenant(ctx context.Context, slug, name string, domains []string, settings m.TenantSettings, tenantID string) (*m.Tenant, error)
+	GetTenants(ctx context.Context, tenantID string) ([]m.Tenant, error)
+	GetTenant(ctx context.Context, slug, tenantID string) (*m.Tenant, error)
+	UpdateTenant(ctx context.Context, slug, name string, domains []string, status string, settings m.TenantSettings, tenantID string) (*m.Tenant, error)
+	DeleteTenant(ctx context.Context, slug, tenantID string) error
 	Logger() log.Logger
 }
 
 type granicaService struct {
-	name    string
-	ctx     context.Context
-	cfg     *config.Config
-	logger  log.Logger
-	repo    repo.UserRepo
-	code    int
-	message string
-	err     error
+	name           string
+	ctx            context.Context
+	cfg            *config.Config
+	logger         log.Logger
+	repo           repo.UserRepo
+	refreshRepo    repo.RefreshTokenRepo
+	clientRepo     repo.ClientRepo
+	resetRepo      repo.PasswordResetRepo
+	credentialRepo repo.WebAuthnCredentialRepo
+	roleRepo       repo.RoleRepo
+	permissionRepo repo.PermissionRepo
+	assignmentRepo repo.RoleAssignmentRepo
+	policies       policy.Set
+	tupleRepo      repo.RelationTupleRepo
+	namespaces     rebac.Namespaces
+	tenantRepo     repo.TenantRepo
+	sessions       session.SessionStore
+	tokens         *tokenIssuer
+	mailer         mailer.Mailer
+	lockouts       lockout.CounterStore
+	breached       BreachedList
+	hasher         hasher.PasswordHasher
+	code           int
+	message        string
+	err            error
 }
 
 // Interface implementation
 
 // SignUp signs a user up using username, password, email and tenant.
-func (gs granicaService) SignUp(username, password, email, emailConfirmation, tenantID string) (*m.User, error) {
+// The account is created with an unverified email address and
+// a verification link is mailed to it.
+func (gs granicaService) SignUp(ctx context.Context, username, password, email, emailConfirmation, tenantID string) (*m.User, error) {
+	if email != emailConfirmation {
+		return nil, errors.New("email confirmation doesn't match")
+	}
+
 	user := m.User{
 		Username: username,
 		Password: password,
@@ -61,26 +183,40 @@ func (gs granicaService) SignUp(username, password, email, emailConfirmation, te
 		TenantID: tenantID,
 	}
 
-	_, err := gs.repo.Insert(&user)
+	err := gs.passwordPolicy(ctx, tenantID).Validate(password, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	user.SetCreateValues(tenantID)
+
+	err = gs.hashPassword(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gs.users(tenantID).Insert(ctx, &user)
 	if err != nil {
 		return nil, err
 	}
 
+	// User can ask for the verification to be sent again.
+	err = gs.sendEmailVerification(&user)
+	if err != nil {
+		gs.logger.Log("level", c.LogLevel.Error, "msg", "cannot send email verification", "err", err.Error())
+	}
+
 	return &user, nil
 }
 
 // Cancel lets the user cancel his/her account.
-func (gs granicaService) Cancel(username, password, tenantID string) error {
-	user, err := gs.repo.GetByUsernameAndTenant(username, tenantID)
+func (gs granicaService) Cancel(ctx context.Context, username, password, remoteIP, tenantID string) error {
+	user, err := gs.checkPassword(ctx, username, password, remoteIP, tenantID)
 	if err != nil {
 		return err
 	}
 
-	if !passwordMatches(user.PasswordDigest, password) {
-		return errors.New("password doesn't match")
-	}
-
-	err = gs.repo.Delete(user.ID)
+	err = gs.users(tenantID).Delete(ctx, user.ID)
 	if err != nil {
 		return err
 	}
@@ -88,28 +224,479 @@ func (gs granicaService) Cancel(username, password, tenantID string) error {
 	return nil
 }
 
+// VerifyEmail verifies the email address of a user using the token mailed to it.
+func (gs granicaService) VerifyEmail(ctx context.Context, token string) error {
+	return gs.verifyEmail(ctx, token)
+}
+
+// ResendEmailVerification mails the verification link again.
+// Unknown users are silently ignored so that accounts cannot be probed.
+func (gs granicaService) ResendEmailVerification(ctx context.Context, username, tenantID string) error {
+	user, err := gs.users(tenantID).GetByUsernameAndTenant(ctx, username, tenantID)
+	if err != nil || user.IsEmailVerified {
+		return nil
+	}
+
+	return gs.sendEmailVerification(user)
+}
+
+// ForgotPassword mails a password reset link to the user with the email.
+// Unknown emails are silently ignored so that accounts cannot be probed.
+func (gs granicaService) ForgotPassword(ctx context.Context, email, tenantID string) error {
+	user, err := gs.users(tenantID).GetByEmailAndTenant(ctx, email, tenantID)
+	if err != nil {
+		return nil
+	}
+
+	return gs.sendPasswordReset(ctx, user)
+}
+
+// ResetPassword sets a new password using the token of a reset link.
+// All the user sessions are revoked.
+func (gs granicaService) ResetPassword(ctx context.Context, token, password, passwordConfirmation string) error {
+	return gs.resetPassword(ctx, token, password, passwordConfirmation)
+}
+
 // SignIn lets a user sign in providing username/email, password and tenant.
-func (gs granicaService) SignIn(username, password, tenantID string) (*m.User, error) {
-	user, err := gs.repo.GetByUsernameAndTenant(username, tenantID)
+// On success it opens a session and returns a signed access token and
+// a refresh token that starts a new token family bound to that session.
+// Users with a second factor are instead returned a MFA token to be passed
+// to VerifyMFA along with a code. Users required to have a second factor
+// that have not enrolled one are returned a MFA token to enroll it.
+func (gs granicaService) SignIn(ctx context.Context, username, password, remoteIP, tenantID string) (*AuthToken, error) {
+	err := gs.checkSignInMethod(ctx, m.SignInPassword, tenantID)
 	if err != nil {
 		return nil, err
 	}
 
-	if !passwordMatches(user.PasswordDigest, password) {
-		return nil, errors.New("password doesn't match")
+	user, err := gs.verifyCredentials(ctx, username, password, remoteIP, tenantID)
+	if err != nil {
+		return nil, err
 	}
 
-	return user, nil
+	if user.IsMFAEnabled {
+		return gs.mfaChallenge(user, mfaChallengeAction)
+	}
+
+	gs.recordSuccess(ctx, user.Username, tenantID)
+
+	if gs.requiresMFA(ctx, user) {
+		return gs.mfaChallenge(user, mfaEnrollAction)
+	}
+
+	return gs.startSession(ctx, user, "", "")
 }
 
-// SignOut lets a user sign out.
-func (gs granicaService) SignOut(username, tenantID string) error {
-	// TODO: Close session implementation.
-	return nil
+// VerifyMFA completes a sign in passing the second factor.
+// The code can be a TOTP code or one of the recovery codes.
+func (gs granicaService) VerifyMFA(ctx context.Context, mfaToken, code, remoteIP, tenantID string) (*AuthToken, error) {
+	return gs.verifyMFA(ctx, mfaToken, code, remoteIP, tenantID)
+}
+
+// EnrollMFA generates a TOTP secret for the user of the access or enrollment token.
+// The returned provisioning URI can be shown as a QR code to authenticator apps.
+func (gs granicaService) EnrollMFA(ctx context.Context, token, tenantID string) (*MFAEnrollment, error) {
+	user, err := gs.mfaUser(ctx, token, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gs.enrollMFA(ctx, user)
+}
+
+// ConfirmMFA enables the second factor enrolled with a first TOTP code
+// and returns the one time recovery codes.
+func (gs granicaService) ConfirmMFA(ctx context.Context, token, code, tenantID string) ([]string, error) {
+	user, err := gs.mfaUser(ctx, token, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gs.confirmMFA(ctx, user, code)
+}
+
+// DisableMFA removes the second factor of the user.
+// A valid code is required.
+func (gs granicaService) DisableMFA(ctx context.Context, accessToken, code, tenantID string) error {
+	user, err := gs.authenticatedUser(ctx, accessToken, tenantID)
+	if err != nil {
+		return err
+	}
+
+	return gs.disableMFA(ctx, user, code)
+}
+
+// BeginWebAuthnRegistration starts the registration of a passkey for the signed in user.
+func (gs granicaService) BeginWebAuthnRegistration(ctx context.Context, accessToken, tenantID string) (*WebAuthnRegistration, error) {
+	user, err := gs.authenticatedUser(ctx, accessToken, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gs.beginWebAuthnRegistration(ctx, user)
+}
+
+// FinishWebAuthnRegistration verifies the new credential created by the
+// authenticator and registers it for the signed in user.
+func (gs granicaService) FinishWebAuthnRegistration(ctx context.Context, accessToken, token, name string, resp webauthn.RegistrationResponse, tenantID string) (*m.WebAuthnCredential, error) {
+	user, err := gs.authenticatedUser(ctx, accessToken, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gs.finishWebAuthnRegistration(ctx, user, token, name, resp)
+}
+
+// BeginWebAuthnLogin starts a passwordless sign in.
+// The username is optional, without it any discoverable credential is accepted.
+func (gs granicaService) BeginWebAuthnLogin(ctx context.Context, username, tenantID string) (*WebAuthnLogin, error) {
+	err := gs.checkSignInMethod(ctx, m.SignInWebAuthn, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gs.beginWebAuthnLogin(ctx, username, tenantID)
+}
+
+// FinishWebAuthnLogin signs in the owner of the credential that signed the challenge.
+// It opens a session as SignIn does.
+func (gs granicaService) FinishWebAuthnLogin(ctx context.Context, token string, resp webauthn.AssertionResponse, tenantID string) (*AuthToken, error) {
+	err := gs.checkSignInMethod(ctx, m.SignInWebAuthn, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gs.finishWebAuthnLogin(ctx, token, resp, tenantID)
+}
+
+// SignOut lets a user sign out revoking the session the access token belongs to,
+// or all of the user sessions if all is set.
+func (gs granicaService) SignOut(ctx context.Context, accessToken string, all bool, tenantID string) error {
+	claims, err := gs.authenticate(ctx, accessToken, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if all {
+		userID, err := uuid.Parse(claims.UserID)
+		if err != nil {
+			return ErrUnauthorized
+		}
+		return gs.sessions.RevokeAll(ctx, userID)
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return ErrUnauthorized
+	}
+
+	err = gs.sessions.Revoke(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return gs.refreshRepo.RevokeFamily(ctx, sessionID)
+}
+
+// Refresh exchanges a refresh token for a new access token.
+// The presented refresh token is rotated out on every use; presenting
+// it again revokes every token of its family.
+func (gs granicaService) Refresh(ctx context.Context, refreshToken, tenantID string) (*AuthToken, error) {
+	return gs.refresh(ctx, refreshToken, "", tenantID)
+}
+
+// JWKS returns the public keys access tokens are signed with.
+func (gs granicaService) JWKS(ctx context.Context) (*JWKSet, error) {
+	return gs.tokens.JWKS()
+}
+
+// Introspect tells whether a token is active and who it belongs to.
+// Only confidential clients of the tenant can introspect tokens (RFC 7662).
+// The type hint only sets which kind of token is looked up first.
+// Request errors are returned as *OAuthError.
+func (gs granicaService) Introspect(ctx context.Context, token, tokenTypeHint, clientID, clientSecret, tenantID string) (*Introspection, error) {
+	client, err := gs.verifyClient(ctx, clientID, clientSecret, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.IsConfidential() {
+		return nil, oauthError(errUnauthorizedClient, "introspection requires a confidential client")
+	}
+
+	first, second := gs.introspectAccessToken, gs.introspectRefreshToken
+	if tokenTypeHint == refreshTokenHint {
+		first, second = second, first
+	}
+
+	if i := first(ctx, token, tenantID); i != nil {
+		return i, nil
+	}
+
+	if i := second(ctx, token, tenantID); i != nil {
+		return i, nil
+	}
+
+	return &Introspection{Active: false}, nil
+}
+
+// RevokeToken revokes an access or refresh token issued to the client (RFC 7009).
+// Invalid or unknown tokens are silently ignored.
+// Request errors are returned as *OAuthError.
+func (gs granicaService) RevokeToken(ctx context.Context, token, tokenTypeHint, clientID, clientSecret, tenantID string) error {
+	client, err := gs.verifyClient(ctx, clientID, clientSecret, tenantID)
+	if err != nil {
+		return err
+	}
+
+	first, second := gs.revokeAccessToken, gs.revokeRefreshToken
+	if tokenTypeHint == refreshTokenHint {
+		first, second = second, first
+	}
+
+	ok, err := first(ctx, token, client.ClientID, tenantID)
+	if ok || err != nil {
+		return err
+	}
+
+	_, err = second(ctx, token, client.ClientID, tenantID)
+	return err
+}
+
+// RegisterClient registers an OAuth client in the tenant.
+// Clients can only be redirected to the URIs registered here.
+// Confidential clients are issued a secret that is returned only once.
+func (gs granicaService) RegisterClient(ctx context.Context, name string, redirectURIs, scopes, grantTypes []string, confidential bool, tenantID string) (*m.Client, string, error) {
+	if name == "" {
+		return nil, "", oauthError(errInvalidRequest, "name required")
+	}
+
+	if len(grantTypes) == 0 {
+		grantTypes = defaultGrantTypes
+	}
+
+	oerr := checkGrantTypes(grantTypes, redirectURIs, confidential)
+	if oerr != nil {
+		return nil, "", oerr
+	}
+
+	for _, uri := range redirectURIs {
+		if !validRedirectURI(uri, gs.cfg.Token.RedirectSchemes) {
+			return nil, "", oauthError(errInvalidRequest, fmt.Sprintf("invalid redirect URI '%s'", uri))
+		}
+	}
+
+	now := time.Now()
+	client := &m.Client{
+		ID:           uuid.New(),
+		ClientID:     uuid.New().String(),
+		Name:         name,
+		TenantID:     tenantID,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+		GrantTypes:   grantTypes,
+		IsActive:     true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	var secret string
+	if confidential {
+		var err error
+		secret, client.SecretDigest, err = genClientSecret()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	err := gs.clientRepo.Insert(ctx, client)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return client, secret, nil
+}
+
+// RotateClientSecret replaces the secret of a confidential client.
+// The previous secret stops being accepted right away.
+func (gs granicaService) RotateClientSecret(ctx context.Context, clientID, tenantID string) (string, error) {
+	client, err := gs.clientRepo.GetByClientIDAndTenant(ctx, clientID, tenantID)
+	if err != nil {
+		return "", err
+	}
+
+	if !client.IsConfidential() {
+		return "", errors.New("client is not confidential")
+	}
+
+	secret, digest, err := genClientSecret()
+	if err != nil {
+		return "", err
+	}
+
+	client.SecretDigest = digest
+	client.UpdatedAt = time.Now()
+
+	err = gs.clientRepo.Update(ctx, client)
+	if err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// ValidateAuthorization checks an authorization request before the user is
+// asked to sign in and consent.
+// A redirect URL is returned if the request has to be rejected back to the client;
+// an error is returned if the client or the redirect URI cannot be trusted.
+func (gs granicaService) ValidateAuthorization(ctx context.Context, ar AuthorizationRequest) (*m.Client, string, error) {
+	client, redirectURI, err := gs.authorizationRedirect(ctx, ar)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, oerr := checkAuthorization(client, ar)
+	if oerr != nil {
+		return client, errorRedirect(redirectURI, ar.State, oerr), nil
+	}
+
+	return client, "", nil
+}
+
+// Authorize signs the user in and, if consent is given, issues an authorization
+// code to the client. It returns the URL the user agent is redirected to, carrying
+// either the code or the reason the request was rejected.
+// Users with a second factor must also provide a code.
+// ErrUnauthorized is returned if the user credentials are not valid.
+func (gs granicaService) Authorize(ctx context.Context, ar AuthorizationRequest, username, password, otp string, consent bool) (string, error) {
+	client, redirectURI, err := gs.authorizationRedirect(ctx, ar)
+	if err != nil {
+		return "", err
+	}
+
+	scope, oerr := checkAuthorization(client, ar)
+	if oerr != nil {
+		return errorRedirect(redirectURI, ar.State, oerr), nil
+	}
+
+	if !consent {
+		return errorRedirect(redirectURI, ar.State, oauthError(errAccessDenied, "")), nil
+	}
+
+	err = gs.checkSignInMethod(ctx, m.SignInPassword, ar.TenantID)
+	if err != nil {
+		return errorRedirect(redirectURI, ar.State, oauthError(errAccessDenied, err.Error())), nil
+	}
+
+	user, err := gs.verifyCredentials(ctx, username, password, ar.RemoteIP, ar.TenantID)
+	if err == ErrLocked {
+		return "", err
+	}
+
+	if err != nil {
+		return "", ErrUnauthorized
+	}
+
+	if !user.IsMFAEnabled && gs.requiresMFA(ctx, user) {
+		return "", ErrMFARequired
+	}
+
+	if user.IsMFAEnabled {
+		ok, err := gs.checkSecondFactor(ctx, user, otp)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			gs.recordFailure(ctx, user.Username, ar.RemoteIP, ar.TenantID)
+			return "", ErrUnauthorized
+		}
+	}
+
+	gs.recordSuccess(ctx, user.Username, ar.TenantID)
+
+	raw, code, err := gs.newAuthorizationCode(user, client, ar, scope)
+	if err != nil {
+		return "", err
+	}
+
+	err = gs.sessions.CreateCode(ctx, code)
+	if err != nil {
+		return "", err
+	}
+
+	return codeRedirect(redirectURI, raw, ar.State), nil
+}
+
+// Token is the OAuth token endpoint.
+// It redeems authorization codes and refresh tokens issued to clients
+// and issues tokens to clients acting on their own behalf.
+// Request errors are returned as *OAuthError.
+func (gs granicaService) Token(ctx context.Context, tr TokenRequest) (*AuthToken, error) {
+	switch tr.GrantType {
+	case authorizationCodeGrant, refreshTokenGrant, clientCredentialsGrant:
+	default:
+		return nil, oauthError(errUnsupportedGrantType, "")
+	}
+
+	client, err := gs.authenticateClient(ctx, tr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tr.GrantType {
+	case authorizationCodeGrant:
+		return gs.exchangeCode(ctx, client, tr)
+
+	case refreshTokenGrant:
+		token, err := gs.refresh(ctx, tr.RefreshToken, client.ClientID, tr.TenantID)
+		if err == ErrInvalidRefreshToken || err == ErrRefreshTokenReused {
+			return nil, oauthError(errInvalidGrant, err.Error())
+		}
+		return token, err
+	}
+
+	return gs.issueClientToken(client, tr)
+}
+
+// OpenIDConfiguration returns the OpenID provider configuration.
+func (gs granicaService) OpenIDConfiguration(ctx context.Context) (*ProviderMetadata, error) {
+	return gs.providerMetadata(), nil
+}
+
+// UserInfo returns the claims about the user the access token was issued for.
+// Only the claims of the scopes granted to the token are returned.
+func (gs granicaService) UserInfo(ctx context.Context, accessToken, tenantID string) (*UserInfo, error) {
+	claims, err := gs.authenticate(ctx, accessToken, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.isClientToken() {
+		return nil, ErrUnauthorized
+	}
+
+	if !hasScope(claims.Scope, openIDScope) {
+		return nil, ErrInsufficientScope
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	user, err := gs.users(tenantID).Get(ctx, userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	return &UserInfo{
+		Subject:    claims.Subject,
+		UserClaims: userClaimsFor(user, claims.Scope),
+	}, nil
 }
 
 // Create lets the system administrator create a user.
-func (gs granicaService) Create(username, password, email, tenantID string) (*m.User, error) {
+func (gs granicaService) Create(ctx context.Context, username, password, email, tenantID string) (*m.User, error) {
 	user := m.User{
 		Username: username,
 		Password: password,
@@ -117,7 +704,19 @@ func (gs granicaService) Create(username, password, email, tenantID string) (*m.
 		TenantID: tenantID,
 	}
 
-	_, err := gs.repo.Insert(&user)
+	err := gs.passwordPolicy(ctx, tenantID).Validate(password, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	user.SetCreateValues(tenantID)
+
+	err = gs.hashPassword(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gs.users(tenantID).Insert(ctx, &user)
 	if err != nil {
 		return nil, err
 	}
@@ -126,40 +725,44 @@ func (gs granicaService) Create(username, password, email, tenantID string) (*m.
 }
 
 // Update lets the system administrator user a user.
-func (gs granicaService) Update(username, password, passwordConfirmation,
+func (gs granicaService) Update(ctx context.Context, username, password, passwordConfirmation,
 	email, emailConfirmation, description,
-	givenName, middleNames, familyName, tenantID string) error {
-	user, err := gs.repo.GetByUsernameAndTenant(username, tenantID)
+	givenName, middleNames, familyName, remoteIP, tenantID string) error {
+	user, err := gs.checkPassword(ctx, username, password, remoteIP, tenantID)
 	if err != nil {
 		return err
 	}
 
-	if !passwordMatches(user.PasswordDigest, password) {
-		return errors.New("password doesn't match")
-	}
-
 	// Copy values from current but only update allowed fields.
 	// TODO: Validations.
 	// TODO: Audit fields auto update.
 	u := user
+	emailChanged := u.Email != email
 	u.Password = password
 	u.Email = email
+	if emailChanged {
+		u.IsEmailVerified = false
+	}
 	u.Description = description
 	u.GivenName = givenName
 	u.MiddleNames = middleNames
 	u.FamilyName = familyName
 	u.SetUpdateValues(u.ID)
 
-	err = gs.repo.Update(u)
+	err = gs.users(tenantID).Update(ctx, u)
 	if err != nil {
 		return err
 	}
 
+	if emailChanged {
+		return gs.sendEmailVerification(u)
+	}
+
 	return nil
 }
 
 // Create lets system administrator remove a user providing username/email and tenant.
-func (gs granicaService) Remove(username, email, tenantID string) error {
+func (gs granicaService) Remove(ctx context.Context, username, email, tenantID string) error {
 	// tx := gs.newTx()
 	// rs := makeRelayService(tx, gs.logger)
 	// user, err := rs.Remove(username, email, tenantID)
@@ -173,14 +776,277 @@ func (gs granicaService) Remove(username, email, tenantID string) error {
 	return nil
 }
 
+// Unlock lets the system administrator lift the lockout of an account,
+// a remote IP or both, clearing their failed attempts.
+func (gs granicaService) Unlock(ctx context.Context, username, remoteIP, tenantID string) error {
+	if username == "" && remoteIP == "" {
+		return errors.New("username or remote IP required")
+	}
+
+	return gs.unlock(ctx, username, remoteIP, tenantID)
+}
+
+// CreateRole lets the system administrator define a role of the tenant.
+// Permissions must be built-in or defined by the tenant.
+func (gs granicaService) CreateRole(ctx context.Context, name, description string, permissions []string, tenantID string) (*m.Role, error) {
+	if name == "" {
+		return nil, errors.New("name required")
+	}
+
+	if _, err := gs.roleRepo.GetByNameAndTenant(ctx, name, tenantID); err == nil {
+		return nil, fmt.Errorf("role '%s' already exists", name)
+	}
+
+	err := gs.checkRolePermissions(ctx, permissions, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	role := &m.Role{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Name:        name,
+		Description: description,
+		Permissions: permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err = gs.roleRepo.Insert(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// GetRoles lists the roles of the tenant.
+func (gs granicaService) GetRoles(ctx context.Context, tenantID string) ([]m.Role, error) {
+	return gs.roleRepo.GetByTenant(ctx, tenantID)
+}
+
+// UpdateRole lets the system administrator change a role of the tenant.
+// Users holding it get the new permissions in the tokens issued afterwards.
+func (gs granicaService) UpdateRole(ctx context.Context, roleID, name, description string, permissions []string, tenantID string) (*m.Role, error) {
+	role, err := gs.tenantRole(ctx, roleID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return nil, errors.New("name required")
+	}
+
+	if other, err := gs.roleRepo.GetByNameAndTenant(ctx, name, tenantID); err == nil && other.ID != role.ID {
+		return nil, fmt.Errorf("role '%s' already exists", name)
+	}
+
+	err = gs.checkRolePermissions(ctx, permissions, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Name = name
+	role.Description = description
+	role.Permissions = permissions
+	role.UpdatedAt = time.Now()
+
+	err = gs.roleRepo.Update(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// DeleteRole lets the system administrator delete a role of the tenant
+// along with its assignments.
+func (gs granicaService) DeleteRole(ctx context.Context, roleID, tenantID string) error {
+	role, err := gs.tenantRole(ctx, roleID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	err = gs.assignmentRepo.DeleteByRole(ctx, role.ID)
+	if err != nil {
+		return err
+	}
+
+	return gs.roleRepo.Delete(ctx, role.ID)
+}
+
+// CreatePermission lets the system administrator define a permission of the tenant
+// for the applications relying on it. Names have the 'resource:action' form.
+func (gs granicaService) CreatePermission(ctx context.Context, name, description, tenantID string) (*m.Permission, error) {
+	if !validPermissionName(name) || strings.Contains(name, "*") {
+		return nil, fmt.Errorf("invalid permission '%s'", name)
+	}
+
+	if isBuiltInPermission(name) {
+		return nil, fmt.Errorf("permission '%s' is built-in", name)
+	}
+
+	if _, err := gs.permissionRepo.GetByNameAndTenant(ctx, name, tenantID); err == nil {
+		return nil, fmt.Errorf("permission '%s' already exists", name)
+	}
+
+	permission := &m.Permission{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Name:        name,
+		Description: description,
+		CreatedAt:   time.Now(),
+	}
+
+	err := gs.permissionRepo.Insert(ctx, permission)
+	if err != nil {
+		return nil, err
+	}
+
+	return permission, nil
+}
+
+// GetPermissions lists the built-in permissions and those defined by the tenant.
+func (gs granicaService) GetPermissions(ctx context.Context, tenantID string) ([]m.Permission, error) {
+	permissions, err := gs.permissionRepo.GetByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]m.Permission{}, builtInPermissions...), permissions...), nil
+}
+
+// AssignRole lets the system administrator assign a role of the tenant to a user.
+func (gs granicaService) AssignRole(ctx context.Context, username, roleID, tenantID string) error {
+	user, err := gs.users(tenantID).GetByUsernameAndTenant(ctx, username, tenantID)
+	if err != nil {
+		return err
+	}
+
+	role, err := gs.tenantRole(ctx, roleID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	assignments, err := gs.assignmentRepo.GetByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range assignments {
+		if a.RoleID == role.ID {
+			return nil
+		}
+	}
+
+	return gs.assignmentRepo.Insert(ctx, &m.RoleAssignment{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		UserID:    user.ID,
+		RoleID:    role.ID,
+		CreatedAt: time.Now(),
+	})
+}
+
+// UnassignRole lets the system administrator take a role away from a user.
+func (gs granicaService) UnassignRole(ctx context.Context, username, roleID, tenantID string) error {
+	user, err := gs.users(tenantID).GetByUsernameAndTenant(ctx, username, tenantID)
+	if err != nil {
+		return err
+	}
+
+	role, err := gs.tenantRole(ctx, roleID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	return gs.assignmentRepo.Delete(ctx, user.ID, role.ID)
+}
+
+// GetUserRoles lists the roles assigned to a user.
+func (gs granicaService) GetUserRoles(ctx context.Context, username, tenantID string) ([]m.Role, error) {
+	user, err := gs.users(tenantID).GetByUsernameAndTenant(ctx, username, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := gs.assignmentRepo.GetByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []m.Role
+	for _, a := range assignments {
+		role, err := gs.roleRepo.Get(ctx, a.RoleID)
+		if err != nil || role.TenantID != tenantID {
+			continue
+		}
+		roles = append(roles, *role)
+	}
+
+	return roles, nil
+}
+
+// CheckPermission returns ErrUnauthorized if the access token is not valid
+// for the tenant and ErrForbidden if it does not grant the permission.
+func (gs granicaService) CheckPermission(ctx context.Context, accessToken, permission, tenantID string) error {
+	claims, err := gs.authenticate(ctx, accessToken, tenantID)
+	if err != nil {
+		return ErrUnauthorized
+	}
+
+	if !m.PermissionGranted(claims.Permissions, permission) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
 func (gs granicaService) Logger() log.Logger {
 	return gs.logger
 }
 
-func passwordMatches(digest, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(digest), []byte(password))
+// verifyCredentials returns the user of the tenant if the password matches
+// and the user is allowed to sign in.
+func (gs granicaService) verifyCredentials(ctx context.Context, username, password, remoteIP, tenantID string) (*m.User, error) {
+	user, err := gs.checkPassword(ctx, username, password, remoteIP, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if gs.cfg.Auth.RequireVerifiedEmail && !user.IsEmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	return user, nil
+}
+
+// checkPassword returns the user of the tenant if the password matches.
+// Failed attempts are counted per account and remote IP, and no password
+// is checked while either of them is locked. Failures are only cleared
+// once the whole sign in succeeded, second factor included.
+func (gs granicaService) checkPassword(ctx context.Context, username, password, remoteIP, tenantID string) (*m.User, error) {
+	err := gs.checkLockout(ctx, username, remoteIP, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := gs.users(tenantID).GetByUsernameAndTenant(ctx, username, tenantID)
 	if err != nil {
-		return false
+		gs.recordFailure(ctx, username, remoteIP, tenantID)
+		return nil, err
+	}
+
+	if !passwordMatches(user.PasswordDigest, password) {
+		gs.recordFailure(ctx, username, remoteIP, tenantID)
+		return nil, errors.New("password doesn't match")
 	}
-	return true
+
+	gs.rehashPassword(ctx, user, password)
+	return user, nil
+}
+
+func passwordMatches(digest, password string) bool {
+	return hasher.Verify(digest, password)
 }
@@ -0,0 +1,127 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// newSession opens and stores a new session for the user.
+// It lasts as long as the refresh tokens issued for it.
+func (gs granicaService) newSession(ctx context.Context, user *m.User) (*m.Session, error) {
+	now := time.Now()
+	s := &m.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(gs.cfg.Token.RefreshTTL),
+	}
+
+	err := gs.sessions.Create(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// startSession opens a session for the user and returns an access token and
+// a refresh token that starts a new token family bound to that session.
+// Tokens issued to an OAuth client carry its ID and the granted scope.
+func (gs granicaService) startSession(ctx context.Context, user *m.User, clientID, scope string) (*AuthToken, error) {
+	s, err := gs.newSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := userClaims(user, s.ID.String())
+	claims.ClientID = clientID
+	claims.Scope = scope
+
+	err = gs.grantRoles(ctx, &claims, user)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := gs.tokens.Issue(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, rt, err := gs.newRefreshToken(user, s.ID, clientID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gs.refreshRepo.Insert(ctx, rt)
+	if err != nil {
+		return nil, err
+	}
+
+	token.RefreshToken = raw
+	return token, nil
+}
+
+// authenticate verifies an access token issued for the tenant
+// and checks that neither the token nor its session have been revoked.
+func (gs granicaService) authenticate(ctx context.Context, accessToken, tenantID string) (*AppClaims, error) {
+	claims, err := gs.tokens.Parse(accessToken)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	if claims.TenantID != tenantID {
+		return nil, ErrUnauthorized
+	}
+
+	// Client credentials tokens are not bound to a session.
+	if !claims.isClientToken() {
+		sessionID, err := uuid.Parse(claims.SessionID)
+		if err != nil {
+			return nil, ErrUnauthorized
+		}
+
+		_, err = gs.sessions.Get(ctx, sessionID)
+		if err != nil {
+			return nil, ErrUnauthorized
+		}
+	}
+
+	revoked, err := gs.sessions.IsTokenRevoked(ctx, claims.Id)
+	if err != nil || revoked {
+		return nil, ErrUnauthorized
+	}
+
+	return claims, nil
+}
+
+// authenticatedUser returns the user an access token was issued to.
+// Client credentials tokens are not accepted.
+func (gs granicaService) authenticatedUser(ctx context.Context, accessToken, tenantID string) (*m.User, error) {
+	claims, err := gs.authenticate(ctx, accessToken, tenantID)
+	if err != nil || claims.isClientToken() {
+		return nil, ErrUnauthorized
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	user, err := gs.users(tenantID).Get(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrUnauthorized
+	}
+
+	return user, nil
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// ErrNotFound is returned when a session does not exist or has expired.
+var ErrNotFound = errors.New("session not found")
+
+// Store is an in-memory implementation of SessionStore interface.
+// Sessions are lost on restart and not shared between replicas.
+type Store struct {
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]m.Session
+	revoked  map[string]time.Time
+	codes    map[string]m.AuthorizationCode
+}
+
+// NewStore makes a new in-memory session store.
+func NewStore(ctx context.Context, cfg *config.Config, logger log.Logger) (*Store, error) {
+	return &Store{
+		sessions: make(map[uuid.UUID]m.Session),
+		revoked:  make(map[string]time.Time),
+		codes:    make(map[string]m.AuthorizationCode),
+	}, nil
+}
+
+// Create a session in Store.
+func (s *Store) Create(ctx context.Context, session *m.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge()
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+// Get a session from Store by its ID.
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (*m.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.IsExpired() {
+		return nil, ErrNotFound
+	}
+
+	return &session, nil
+}
+
+// Revoke a session.
+func (s *Store) Revoke(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+// RevokeAll sessions of a user.
+func (s *Store) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+// RevokeToken keeps a token ID as revoked until the token expires.
+func (s *Store) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge()
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+// IsTokenRevoked - True if the token ID has been revoked.
+func (s *Store) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.revoked[jti]
+	return ok, nil
+}
+
+// CreateCode stores an authorization code until it is consumed or expires.
+func (s *Store) CreateCode(ctx context.Context, code *m.AuthorizationCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge()
+	s.codes[code.Digest] = *code
+	return nil
+}
+
+// ConsumeCode gets and removes an authorization code, it can only be consumed once.
+func (s *Store) ConsumeCode(ctx context.Context, digest string) (*m.AuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, ok := s.codes[digest]
+	delete(s.codes, digest)
+	if !ok || code.IsExpired() {
+		return nil, ErrNotFound
+	}
+
+	return &code, nil
+}
+
+// purge removes expired entries, callers must hold the lock.
+func (s *Store) purge() {
+	now := time.Now()
+	for id, session := range s.sessions {
+		if session.IsExpired() {
+			delete(s.sessions, id)
+		}
+	}
+	for jti, exp := range s.revoked {
+		if now.After(exp) {
+			delete(s.revoked, jti)
+		}
+	}
+	for digest, code := range s.codes {
+		if code.IsExpired() {
+			delete(s.codes, digest)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-redis/redis"
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const (
+	keyPrefix = "granica:session"
+)
+
+// ErrNotFound is returned when a session does not exist or has expired.
+var ErrNotFound = errors.New("session not found")
+
+// Store is a Redis implementation of SessionStore interface.
+// Each session is kept under its own key expiring with the session,
+// a set per user indexes the sessions to revoke them all at once.
+type Store struct {
+	client *redis.Client
+}
+
+// NewStore makes a new Redis session store.
+func NewStore(ctx context.Context, cfg *config.Config, logger log.Logger) (*Store, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", cfg.Cache.Redis.Host, cfg.Cache.Redis.Port),
+		Password: cfg.Cache.Redis.Password,
+	})
+
+	err := client.Ping().Err()
+	if err != nil {
+		logger.Log("level", config.LogLevel.Error, "msg", "Redis connection error", "err", err.Error())
+		return nil, err
+	}
+
+	logger.Log("level", config.LogLevel.Info, "msg", "Redis connection established")
+
+	return &Store{
+		client: client,
+	}, nil
+}
+
+// Create a session in Store.
+func (s *Store) Create(ctx context.Context, session *m.Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("session already expired")
+	}
+
+	val, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	userKey := userSessionsKey(session.UserID)
+	_, err = s.client.WithContext(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(sessionKey(session.ID), val, ttl)
+		pipe.SAdd(userKey, session.ID.String())
+		// Index lives as long as the newest session of the user.
+		pipe.Expire(userKey, ttl)
+		return nil
+	})
+
+	return err
+}
+
+// Get a session from Store by its ID.
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (*m.Session, error) {
+	val, err := s.client.WithContext(ctx).Get(sessionKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session m.Session
+	err = json.Unmarshal(val, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// Revoke a session.
+func (s *Store) Revoke(ctx context.Context, id uuid.UUID) error {
+	session, err := s.Get(ctx, id)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.WithContext(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(sessionKey(id))
+		pipe.SRem(userSessionsKey(session.UserID), id.String())
+		return nil
+	})
+
+	return err
+}
+
+// RevokeAll sessions of a user.
+func (s *Store) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	userKey := userSessionsKey(userID)
+	ids, err := s.client.WithContext(ctx).SMembers(userKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{userKey}
+	for _, id := range ids {
+		keys = append(keys, fmt.Sprintf("%s:%s", keyPrefix, id))
+	}
+
+	return s.client.WithContext(ctx).Del(keys...).Err()
+}
+
+// RevokeToken keeps a token ID as revoked until the token expires.
+func (s *Store) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return s.client.WithContext(ctx).Set(revokedTokenKey(jti), 1, ttl).Err()
+}
+
+// IsTokenRevoked - True if the token ID has been revoked.
+func (s *Store) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.client.WithContext(ctx).Exists(revokedTokenKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// CreateCode stores an authorization code until it is consumed or expires.
+func (s *Store) CreateCode(ctx context.Context, code *m.AuthorizationCode) error {
+	ttl := time.Until(code.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("code already expired")
+	}
+
+	val, err := json.Marshal(code)
+	if err != nil {
+		return err
+	}
+
+	return s.client.WithContext(ctx).Set(codeKey(code.Digest), val, ttl).Err()
+}
+
+// ConsumeCode gets and removes an authorization code, it can only be consumed once.
+func (s *Store) ConsumeCode(ctx context.Context, digest string) (*m.AuthorizationCode, error) {
+	key := codeKey(digest)
+
+	var get *redis.StringCmd
+	_, err := s.client.WithContext(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		get = pipe.Get(key)
+		pipe.Del(key)
+		return nil
+	})
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := get.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var code m.AuthorizationCode
+	err = json.Unmarshal(val, &code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &code, nil
+}
+
+func sessionKey(id uuid.UUID) string {
+	return fmt.Sprintf("%s:%s", keyPrefix, id.String())
+}
+
+func userSessionsKey(userID uuid.UUID) string {
+	return fmt.Sprintf("%s:user:%s", keyPrefix, userID.String())
+}
+
+func revokedTokenKey(jti string) string {
+	return fmt.Sprintf("%s:revoked:%s", keyPrefix, jti)
+}
+
+func codeKey(digest string) string {
+	return fmt.Sprintf("%s:code:%s", keyPrefix, digest)
+}
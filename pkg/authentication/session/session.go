@@ -0,0 +1,42 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/session/memory"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/session/redis"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// SessionStore interface
+// Besides sessions it keeps the IDs of revoked tokens until they expire
+// and the pending OAuth authorization codes.
+type SessionStore interface {
+	Create(ctx context.Context, session *m.Session) error
+	Get(ctx context.Context, id uuid.UUID) (*m.Session, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	RevokeAll(ctx context.Context, userID uuid.UUID) error
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	CreateCode(ctx context.Context, code *m.AuthorizationCode) error
+	ConsumeCode(ctx context.Context, digest string) (*m.AuthorizationCode, error)
+}
+
+// NewStore makes a new session store.
+func NewStore(ctx context.Context, cfg *config.Config, logger log.Logger) (SessionStore, error) {
+
+	if cfg.Cache.Type == "redis" {
+		return redis.NewStore(ctx, cfg, logger)
+
+	} else if cfg.Cache.Type == "memory" {
+		return memory.NewStore(ctx, cfg, logger)
+	}
+
+	return nil, errors.New("not a valid session store type")
+}
@@ -0,0 +1,85 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSignOutRevokesSession(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = svc.SignOut(ctx, first.AccessToken, false, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.authenticate(context.Background(), first.AccessToken, "localhost"); err != ErrUnauthorized {
+		t.Errorf("Error: %v | Expected: %v", err, ErrUnauthorized)
+	}
+
+	if _, err := svc.Refresh(ctx, first.RefreshToken, "localhost"); err != ErrInvalidRefreshToken {
+		t.Errorf("Error: %v | Expected: %v", err, ErrInvalidRefreshToken)
+	}
+
+	if _, err := svc.authenticate(context.Background(), second.AccessToken, "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestSignOutAllRevokesEverySession(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = svc.SignOut(ctx, first.AccessToken, true, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Refresh(ctx, second.RefreshToken, "localhost"); err != ErrInvalidRefreshToken {
+		t.Errorf("Error: %v | Expected: %v", err, ErrInvalidRefreshToken)
+	}
+
+	if err := svc.SignOut(ctx, second.AccessToken, false, "localhost"); err != ErrUnauthorized {
+		t.Errorf("Error: %v | Expected: %v", err, ErrUnauthorized)
+	}
+}
@@ -17,7 +17,13 @@ import (
 
 	"github.com/go-kit/kit/log"
 	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/hasher"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/lockout"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/mailer"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/policy"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/rebac"
 	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/session"
 )
 
 // checkSigTerm listens to sigterm events.
@@ -42,24 +48,91 @@ func makeService(ctx context.Context, cfg *config.Config, log log.Logger) *grani
 func (svc *granicaService) Init() (GranicaService, error) {
 	var gs GranicaService
 
-	// Repo
-	rerr := make(chan error)
-	rrepo := make(chan repo.UserRepo)
-	go func() {
-		c, err := repo.NewRepo(svc.ctx, svc.cfg, svc.Logger())
+	// Repos
+	repos, err := repo.NewRepos(svc.ctx, svc.cfg, svc.Logger())
+	if err != nil {
+		return gs, fmt.Errorf("cannot initialize '%s' service repos: %s", svc.name, err.Error())
+	}
+
+	svc.repo = repos.Users
+	svc.refreshRepo = repos.RefreshTokens
+	svc.clientRepo = repos.Clients
+	svc.resetRepo = repos.PasswordResets
+	svc.credentialRepo = repos.WebAuthnCredentials
+	svc.roleRepo = repos.Roles
+	svc.permissionRepo = repos.Permissions
+	svc.assignmentRepo = repos.RoleAssignments
+	svc.tupleRepo = repos.RelationTuples
+	svc.tenantRepo = repos.Tenants
+
+	err = svc.initSystemTenant(svc.ctx)
+	if err != nil {
+		return gs, fmt.Errorf("cannot initialize '%s' service system tenant: %s", svc.name, err.Error())
+	}
+
+	// Sessions
+	sessions, err := session.NewStore(svc.ctx, svc.cfg, svc.Logger())
+	if err != nil {
+		return gs, fmt.Errorf("cannot initialize '%s' service session store", svc.name)
+	}
+
+	svc.sessions = sessions
+
+	// Tokens
+	tokens, err := newTokenIssuer(svc.cfg.Token)
+	if err != nil {
+		return gs, fmt.Errorf("cannot initialize '%s' service token issuer: %s", svc.name, err.Error())
+	}
+	svc.tokens = tokens
+	go tokens.keys.Watch(svc.ctx, svc.Logger())
+
+	// Mailer
+	mail, err := mailer.NewMailer(svc.ctx, svc.cfg, svc.Logger())
+	if err != nil {
+		return gs, fmt.Errorf("cannot initialize '%s' service mailer", svc.name)
+	}
+	svc.mailer = mail
+
+	// Lockout
+	lockouts, err := lockout.NewStore(svc.ctx, svc.cfg, svc.Logger())
+	if err != nil {
+		return gs, fmt.Errorf("cannot initialize '%s' service lockout store", svc.name)
+	}
+	svc.lockouts = lockouts
+
+	// Password hashing
+	h, err := hasher.New(svc.cfg)
+	if err != nil {
+		return gs, fmt.Errorf("cannot initialize '%s' service password hasher", svc.name)
+	}
+	svc.hasher = h
+
+	// Breached passwords
+	if svc.cfg.Auth.BreachedPasswords != "" {
+		breached, err := loadBreachedList(svc.cfg.Auth.BreachedPasswords)
 		if err != nil {
-			rerr <- err
-			return
+			return gs, fmt.Errorf("cannot initialize '%s' service breached passwords list: %s", svc.name, err.Error())
 		}
-		rerr <- nil
-		rrepo <- c
-	}()
+		svc.breached = breached
+	}
 
-	if <-rerr != nil {
-		return gs, fmt.Errorf("cannot initialize '%s' service", svc.name)
+	// Access policies
+	if svc.cfg.Auth.Policies != "" {
+		policies, err := policy.Load(svc.cfg.Auth.Policies)
+		if err != nil {
+			return gs, fmt.Errorf("cannot initialize '%s' service access policies: %s", svc.name, err.Error())
+		}
+		svc.policies = policies
 	}
 
-	svc.repo = <-rrepo
+	// Relation namespaces
+	if svc.cfg.Auth.Namespaces != "" {
+		namespaces, err := rebac.Load(svc.cfg.Auth.Namespaces)
+		if err != nil {
+			return gs, fmt.Errorf("cannot initialize '%s' service relation namespaces: %s", svc.name, err.Error())
+		}
+		svc.namespaces = namespaces
+	}
 
 	// Middleware
 	gs = addLogging(svc, svc.logger)
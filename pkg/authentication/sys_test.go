@@ -0,0 +1,52 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/hasher"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestInitMemoryRepos(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := newTestConfig()
+	cfg.Repo.Type = "memory"
+	cfg.Cache.Type = "memory"
+	cfg.Mailer.Type = "file"
+	cfg.Auth.Hasher.Algorithm = hasher.BcryptAlg
+	cfg.Auth.Hasher.BcryptCost = bcrypt.MinCost
+
+	svc := makeService(ctx, cfg, log.NewNopLogger())
+	gs, err := svc.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tenant, err := svc.tenantRepo.GetBySlug(ctx, "localhost")
+	if err != nil {
+		t.Fatalf("system tenant not registered: %v", err)
+	}
+
+	if len(tenant.Domains) != 1 || tenant.Domains[0] != "localhost" {
+		t.Errorf("Domains: %v | Expected: [localhost]", tenant.Domains)
+	}
+
+	if _, err := gs.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gs.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,294 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// ErrUnknownTenant is returned when a request cannot be bound to an active tenant.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// ErrSignInMethodNotAllowed is returned when the tenant does not allow signing in with a method.
+var ErrSignInMethodNotAllowed = errors.New("sign in method not allowed")
+
+// Kinds of keys tenants are resolved by.
+const (
+	TenantByDomain = "domain"
+	TenantBySlug   = "slug"
+	TenantByToken  = "token"
+)
+
+// ResolveTenant returns the active tenant the key identifies: a domain of the tenant,
+// its slug or an access token issued for it.
+func (gs granicaService) ResolveTenant(ctx context.Context, kind, key string) (*m.Tenant, error) {
+	var tenant *m.Tenant
+	var err error
+
+	switch kind {
+	case TenantByDomain:
+		tenant, err = gs.tenantRepo.GetByDomain(ctx, m.NormalizeDomain(key))
+
+	case TenantBySlug:
+		tenant, err = gs.tenantRepo.GetBySlug(ctx, key)
+
+	case TenantByToken:
+		claims, perr := gs.tokens.Parse(key)
+		if perr != nil {
+			return nil, ErrUnknownTenant
+		}
+		tenant, err = gs.tenantRepo.GetBySlug(ctx, claims.TenantID)
+
+	default:
+		return nil, fmt.Errorf("unknown tenant key kind '%s'", kind)
+	}
+
+	if err != nil || !tenant.IsActive() {
+		return nil, ErrUnknownTenant
+	}
+
+	return tenant, nil
+}
+
+// CreateTenant registers a new tenant.
+// Only the system tenant can manage tenants.
+func (gs granicaService) CreateTenant(ctx context.Context, slug, name string, domains []string, settings m.TenantSettings, tenantID string) (*m.Tenant, error) {
+	if tenantID != gs.cfg.Auth.SystemTenant {
+		return nil, ErrForbidden
+	}
+
+	if !m.ValidSlug(slug) {
+		return nil, errors.New("invalid tenant slug")
+	}
+
+	_, err := gs.tenantRepo.GetBySlug(ctx, slug)
+	if err == nil {
+		return nil, errors.New("tenant already exists")
+	}
+
+	tenant := &m.Tenant{
+		ID:        uuid.New(),
+		Slug:      slug,
+		Name:      name,
+		Status:    m.TenantActive,
+		Settings:  settings,
+		CreatedAt: time.Now(),
+	}
+
+	tenant.Domains, err = gs.checkDomains(ctx, tenant, domains)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkTenantSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gs.tenantRepo.Insert(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// GetTenants lists the registered tenants.
+func (gs granicaService) GetTenants(ctx context.Context, tenantID string) ([]m.Tenant, error) {
+	if tenantID != gs.cfg.Auth.SystemTenant {
+		return nil, ErrForbidden
+	}
+
+	return gs.tenantRepo.GetAll(ctx)
+}
+
+// GetTenant returns the tenant with the slug.
+func (gs granicaService) GetTenant(ctx context.Context, slug, tenantID string) (*m.Tenant, error) {
+	if tenantID != gs.cfg.Auth.SystemTenant {
+		return nil, ErrForbidden
+	}
+
+	tenant, err := gs.tenantRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, errors.New("tenant not found")
+	}
+
+	return tenant, nil
+}
+
+// UpdateTenant changes the name, domains, status and settings of a tenant.
+// The system tenant cannot be suspended.
+func (gs granicaService) UpdateTenant(ctx context.Context, slug, name string, domains []string, status string, settings m.TenantSettings, tenantID string) (*m.Tenant, error) {
+	tenant, err := gs.GetTenant(ctx, slug, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != m.TenantActive && status != m.TenantSuspended {
+		return nil, fmt.Errorf("invalid tenant status '%s'", status)
+	}
+
+	if slug == gs.cfg.Auth.SystemTenant && status != m.TenantActive {
+		return nil, errors.New("system tenant cannot be suspended")
+	}
+
+	tenant.Domains, err = gs.checkDomains(ctx, tenant, domains)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkTenantSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.Name = name
+	tenant.Status = status
+	tenant.Settings = settings
+	tenant.UpdatedAt = time.Now()
+
+	err = gs.tenantRepo.Update(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// DeleteTenant deletes a tenant so that requests are no longer bound to it.
+// The data of the tenant is kept. The system tenant cannot be deleted.
+func (gs granicaService) DeleteTenant(ctx context.Context, slug, tenantID string) error {
+	tenant, err := gs.GetTenant(ctx, slug, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if slug == gs.cfg.Auth.SystemTenant {
+		return errors.New("system tenant cannot be deleted")
+	}
+
+	return gs.tenantRepo.Delete(ctx, tenant.ID)
+}
+
+// initSystemTenant registers the system tenant, the one tenants
+// are managed from, if it is not registered yet.
+func (gs granicaService) initSystemTenant(ctx context.Context) error {
+	_, err := gs.tenantRepo.GetBySlug(ctx, gs.cfg.Auth.SystemTenant)
+	if err == nil {
+		return nil
+	}
+
+	tenant := &m.Tenant{
+		ID:        uuid.New(),
+		Slug:      gs.cfg.Auth.SystemTenant,
+		Name:      gs.cfg.Auth.SystemTenant,
+		Status:    m.TenantActive,
+		CreatedAt: time.Now(),
+	}
+
+	if !m.ValidSlug(tenant.Slug) {
+		return errors.New("invalid system tenant slug")
+	}
+
+	tenant.Domains, err = gs.checkDomains(ctx, tenant, gs.cfg.Auth.SystemTenantDomains)
+	if err != nil {
+		return err
+	}
+
+	return gs.tenantRepo.Insert(ctx, tenant)
+}
+
+// checkDomains returns the normalized domains of the tenant or an error
+// if any of them belongs to another tenant.
+func (gs granicaService) checkDomains(ctx context.Context, tenant *m.Tenant, domains []string) ([]string, error) {
+	var normalized []string
+	seen := map[string]bool{}
+	for _, d := range domains {
+		d = m.NormalizeDomain(d)
+		if d == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+
+		owner, err := gs.tenantRepo.GetByDomain(ctx, d)
+		if err == nil && owner.ID != tenant.ID {
+			return nil, fmt.Errorf("domain '%s' belongs to another tenant", d)
+		}
+
+		normalized = append(normalized, d)
+	}
+
+	if len(normalized) == 0 {
+		return nil, errors.New("at least one domain required")
+	}
+
+	return normalized, nil
+}
+
+// checkTenantSettings returns an error if the settings name unknown sign in methods.
+func checkTenantSettings(settings m.TenantSettings) error {
+	for _, method := range settings.SignInMethods {
+		if method != m.SignInPassword && method != m.SignInWebAuthn {
+			return fmt.Errorf("unknown sign in method '%s'", method)
+		}
+	}
+	return nil
+}
+
+// users returns the user repo scoped to the tenant.
+// Users of other tenants can be neither read nor written through it.
+func (gs granicaService) users(tenantID string) repo.UserRepo {
+	return repo.ForTenant(gs.repo, tenantID)
+}
+
+// tenantSettings returns the settings of the tenant.
+// Tenants not registered have the default ones.
+func (gs granicaService) tenantSettings(ctx context.Context, tenantID string) m.TenantSettings {
+	tenant, err := gs.tenantRepo.GetBySlug(ctx, tenantID)
+	if err != nil {
+		return m.TenantSettings{}
+	}
+	return tenant.Settings
+}
+
+// checkSignInMethod returns ErrSignInMethodNotAllowed if users of the tenant
+// cannot sign in with the method.
+func (gs granicaService) checkSignInMethod(ctx context.Context, method, tenantID string) error {
+	if !gs.tenantSettings(ctx, tenantID).AllowsSignIn(method) {
+		return ErrSignInMethodNotAllowed
+	}
+	return nil
+}
+
+// requiresMFA - True if the user, or every user of its tenant, is required to have a second factor.
+func (gs granicaService) requiresMFA(ctx context.Context, user *m.User) bool {
+	return user.RequireMFA || gs.tenantSettings(ctx, user.TenantID).RequireMFA
+}
+
+// passwordPolicyConfig returns the policy config of the tenant settings.
+func passwordPolicyConfig(p *m.TenantPasswordPolicy) config.PasswordPolicyConfig {
+	return config.PasswordPolicyConfig{
+		MinLength:        p.MinLength,
+		MaxLength:        p.MaxLength,
+		RequireUpper:     p.RequireUpper,
+		RequireLower:     p.RequireLower,
+		RequireDigit:     p.RequireDigit,
+		RequireSymbol:    p.RequireSymbol,
+		DisallowUserInfo: p.DisallowUserInfo,
+		DisallowBreached: p.DisallowBreached,
+		HistorySize:      p.HistorySize,
+	}
+}
@@ -0,0 +1,229 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// Tenant resolution strategies
+const (
+	hostStrategy      = "host"
+	headerStrategy    = "header"
+	pathStrategy      = "path"
+	subdomainStrategy = "subdomain"
+	tokenStrategy     = "token"
+)
+
+// Tenant resolution error codes
+const (
+	errTenantRequired = "tenant_required"
+	errTenantNotFound = "tenant_not_found"
+)
+
+const (
+	tenantPlaceholder = "{tenant}"
+)
+
+// TenantResolutionError is the body of the responses to requests
+// no tenant can be bound to.
+type TenantResolutionError struct {
+	Code        string   `json:"error"`
+	Description string   `json:"error_description"`
+	Strategy    string   `json:"strategy,omitempty"`
+	Strategies  []string `json:"strategies"`
+}
+
+func (e *TenantResolutionError) Error() string {
+	return e.Description
+}
+
+// globalPaths are served without a tenant: signing keys and provider
+// metadata are shared by all the tenants and verification links carry
+// the tenant in their token.
+var globalPaths = map[string]bool{
+	"/.well-known/jwks.json":            true,
+	"/.well-known/openid-configuration": true,
+	"/verify-email":                     true,
+}
+
+// tenantResolver binds requests to a tenant trying the configured strategies in order.
+// The first strategy finding an active tenant decides; keys that do not identify
+// one, such as the name of a shared host, leave the request to the next strategies.
+type tenantResolver struct {
+	svc        GranicaService
+	strategies []string
+	header     string
+	pathPrefix string
+	subdomain  *regexp.Regexp
+}
+
+// newTenantResolver makes a new tenant resolver.
+func newTenantResolver(svc GranicaService, cfg config.TenantResolutionConfig) (*tenantResolver, error) {
+	tr := &tenantResolver{
+		svc:        svc,
+		strategies: cfg.Strategies,
+		header:     cfg.Header,
+		pathPrefix: "/" + strings.Trim(cfg.PathPrefix, "/") + "/",
+	}
+
+	if len(tr.strategies) == 0 {
+		tr.strategies = []string{hostStrategy}
+	}
+
+	for _, s := range tr.strategies {
+		switch s {
+		case hostStrategy, tokenStrategy:
+		case headerStrategy:
+			if tr.header == "" {
+				return nil, fmt.Errorf("tenant header required")
+			}
+		case pathStrategy:
+			if tr.pathPrefix == "//" {
+				return nil, fmt.Errorf("tenant path prefix required")
+			}
+		case subdomainStrategy:
+			re, err := subdomainRegexp(cfg.SubdomainPattern)
+			if err != nil {
+				return nil, err
+			}
+			tr.subdomain = re
+		default:
+			return nil, fmt.Errorf("unknown tenant resolution strategy '%s'", s)
+		}
+	}
+
+	return tr, nil
+}
+
+// Handler binds the requests to their tenant before passing them to next.
+// Requests no tenant can be bound to are answered with a 400.
+func (tr *tenantResolver) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if globalPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenant, r, err := tr.resolve(r)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err)
+			return
+		}
+
+		ctx := repo.WithTenant(r.Context(), tenant.Slug)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolve returns the tenant of the request. Requests resolved by their path
+// are returned without the tenant prefix, so that they can be routed as usual.
+// Requests whose keys identify no active tenant are rejected naming the
+// first strategy that found one.
+func (tr *tenantResolver) resolve(r *http.Request) (*m.Tenant, *http.Request, *TenantResolutionError) {
+	var notFound *TenantResolutionError
+	for _, s := range tr.strategies {
+		kind, key, rest := tr.tenantKey(s, r)
+		if key == "" {
+			continue
+		}
+
+		tenant, err := tr.svc.ResolveTenant(r.Context(), kind, key)
+		if err != nil {
+			if notFound == nil {
+				notFound = &TenantResolutionError{
+					Code:        errTenantNotFound,
+					Description: fmt.Sprintf("no active tenant found by %s", s),
+					Strategy:    s,
+					Strategies:  tr.strategies,
+				}
+			}
+			continue
+		}
+
+		if rest != nil {
+			r = rest
+		}
+
+		return tenant, r, nil
+	}
+
+	if notFound != nil {
+		return nil, r, notFound
+	}
+
+	return nil, r, &TenantResolutionError{
+		Code:        errTenantRequired,
+		Description: "request does not identify a tenant",
+		Strategies:  tr.strategies,
+	}
+}
+
+// tenantKey returns the kind and the key of the tenant the strategy finds in the request.
+// The path strategy also returns the request without the tenant prefix.
+func (tr *tenantResolver) tenantKey(strategy string, r *http.Request) (kind, key string, rest *http.Request) {
+	switch strategy {
+	case hostStrategy:
+		return TenantByDomain, getHostname(r), nil
+
+	case headerStrategy:
+		return TenantBySlug, strings.TrimSpace(r.Header.Get(tr.header)), nil
+
+	case pathStrategy:
+		if !strings.HasPrefix(r.URL.Path, tr.pathPrefix) {
+			return "", "", nil
+		}
+
+		slug := strings.TrimPrefix(r.URL.Path, tr.pathPrefix)
+		path := "/"
+		if i := strings.Index(slug, "/"); i >= 0 {
+			slug, path = slug[:i], slug[i:]
+		}
+
+		rest = r.WithContext(r.Context())
+		u := *r.URL
+		u.Path = path
+		u.RawPath = ""
+		rest.URL = &u
+		return TenantBySlug, slug, rest
+
+	case subdomainStrategy:
+		match := tr.subdomain.FindStringSubmatch(m.NormalizeDomain(getHostname(r)))
+		if match == nil {
+			return "", "", nil
+		}
+		return TenantBySlug, match[1], nil
+
+	case tokenStrategy:
+		return TenantByToken, getBearerToken(r), nil
+	}
+
+	return "", "", nil
+}
+
+// subdomainRegexp compiles a host pattern with a tenant placeholder.
+func subdomainRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.ToLower(pattern)
+	if strings.Count(pattern, tenantPlaceholder) != 1 {
+		return nil, fmt.Errorf("tenant subdomain pattern must hold one '%s'", tenantPlaceholder)
+	}
+
+	parts := strings.Split(pattern, tenantPlaceholder)
+	expr := "^" + regexp.QuoteMeta(parts[0]) + "([a-z0-9-]+)" + regexp.QuoteMeta(parts[1]) + "$"
+	return regexp.Compile(expr)
+}
@@ -0,0 +1,170 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+func TestTenantResolutionStrategies(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	if err := svc.initSystemTenant(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := svc.CreateTenant(ctx, "acme", "Acme", []string{"auth.acme.com"}, m.TenantSettings{}, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.SignUp(ctx, "username", "password", "username@acme.com", "username@acme.com", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tenants, err := newTenantResolver(svc, config.TenantResolutionConfig{
+		Strategies:       []string{"header", "path", "token", "subdomain", "host"},
+		Header:           "X-Tenant-ID",
+		PathPrefix:       "/t/",
+		SubdomainPattern: "{tenant}.granica.dev",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tenantID, path string
+	handler := tenants.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID = getTenant(r)
+		path = r.URL.Path
+	}))
+
+	tests := []struct {
+		name     string
+		url      string
+		header   string
+		token    string
+		status   int
+		tenantID string
+		path     string
+		code     string
+	}{
+		{"header", "http://shared.granica.dev/sign-in", "acme", "", http.StatusOK, "acme", "/sign-in", ""},
+		{"header over host", "http://localhost/sign-in", "acme", "", http.StatusOK, "acme", "/sign-in", ""},
+		{"unknown header", "http://localhost/sign-in", "nobody", "", http.StatusOK, "localhost", "/sign-in", ""},
+		{"unknown header nor host", "http://shared.example.com/sign-in", "nobody", "", http.StatusBadRequest, "", "", errTenantNotFound},
+		{"path", "http://shared.granica.dev/t/acme/oauth/token", "", "", http.StatusOK, "acme", "/oauth/token", ""},
+		{"path root", "http://shared.granica.dev/t/acme", "", "", http.StatusOK, "acme", "/", ""},
+		{"token", "http://shared.granica.dev/userinfo", "", token.AccessToken, http.StatusOK, "acme", "/userinfo", ""},
+		{"invalid token", "http://shared.granica.dev/userinfo", "", "invalid", http.StatusBadRequest, "", "", errTenantNotFound},
+		{"subdomain", "http://acme.granica.dev/sign-in", "", "", http.StatusOK, "acme", "/sign-in", ""},
+		{"host", "http://auth.acme.com/sign-in", "", "", http.StatusOK, "acme", "/sign-in", ""},
+		{"unresolved", "http://shared.example.com/sign-in", "", "", http.StatusBadRequest, "", "", errTenantNotFound},
+	}
+
+	for _, tt := range tests {
+		tenantID, path = "", ""
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		if tt.header != "" {
+			req.Header.Set("X-Tenant-ID", tt.header)
+		}
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status || tenantID != tt.tenantID || path != tt.path {
+			t.Errorf("%s: %d, '%s', '%s' | Expected: %d, '%s', '%s'", tt.name, rec.Code, tenantID, path, tt.status, tt.tenantID, tt.path)
+		}
+
+		if tt.code != "" {
+			var res TenantResolutionError
+			json.NewDecoder(rec.Body).Decode(&res)
+			if res.Code != tt.code || len(res.Strategies) != 5 {
+				t.Errorf("%s error: %+v | Expected: '%s'", tt.name, res, tt.code)
+			}
+		}
+	}
+
+	// Shared hosts are not tenant domains, the next strategies resolve them.
+	tenants, _ = newTenantResolver(svc, config.TenantResolutionConfig{Strategies: []string{"host", "header"}, Header: "X-Tenant-ID"})
+	req := httptest.NewRequest(http.MethodGet, "http://staging.granica.dev/sign-in", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	tenantID = ""
+	rec := httptest.NewRecorder()
+	tenants.Handler(handler).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || tenantID != "acme" {
+		t.Errorf("Shared host: %d, '%s' | Expected: %d, 'acme'", rec.Code, tenantID, http.StatusOK)
+	}
+
+	// Without host fallback requests must identify the tenant.
+	tenants, _ = newTenantResolver(svc, config.TenantResolutionConfig{Strategies: []string{"header"}, Header: "X-Tenant-ID"})
+	rec = httptest.NewRecorder()
+	tenants.Handler(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/sign-in", nil))
+
+	var res TenantResolutionError
+	json.NewDecoder(rec.Body).Decode(&res)
+	if rec.Code != http.StatusBadRequest || res.Code != errTenantRequired {
+		t.Errorf("Response: %d, %+v | Expected: %d, '%s'", rec.Code, res, http.StatusBadRequest, errTenantRequired)
+	}
+}
+
+func TestTenantResolverConfig(t *testing.T) {
+	invalid := []config.TenantResolutionConfig{
+		{Strategies: []string{"cookie"}},
+		{Strategies: []string{"header"}},
+		{Strategies: []string{"subdomain"}, SubdomainPattern: "granica.dev"},
+		{Strategies: []string{"subdomain"}, SubdomainPattern: "{tenant}.{tenant}.granica.dev"},
+	}
+
+	for _, cfg := range invalid {
+		if _, err := newTenantResolver(nil, cfg); err == nil {
+			t.Errorf("Config %+v accepted | Expected: error", cfg)
+		}
+	}
+}
+
+func TestTenantResolutionGlobalPaths(t *testing.T) {
+	svc := newTestService(t)
+
+	tenants, err := newTenantResolver(svc, config.TenantResolutionConfig{Strategies: []string{"header"}, Header: "X-Tenant-ID"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := tenants.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, path := range []string{"/.well-known/jwks.json", "/.well-known/openid-configuration", "/verify-email?token=abc"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://shared.granica.dev"+path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: %d | Expected: %d", path, rec.Code, http.StatusOK)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://shared.granica.dev/sign-in", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("/sign-in: %d | Expected: %d", rec.Code, http.StatusBadRequest)
+	}
+}
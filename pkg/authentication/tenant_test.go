@@ -0,0 +1,185 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+func TestTenantAdministration(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	if err := svc.initSystemTenant(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.CreateTenant(ctx, "acme", "Acme", []string{"acme.granica.dev"}, m.TenantSettings{}, "acme.granica.dev"); err != ErrForbidden {
+		t.Errorf("Error: %v | Expected: %v", err, ErrForbidden)
+	}
+
+	tenant, err := svc.CreateTenant(ctx, "acme", "Acme", []string{"Acme.Granica.dev", "auth.acme.com"}, m.TenantSettings{}, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tenant.Domains[0] != "acme.granica.dev" || !tenant.IsActive() {
+		t.Errorf("Tenant: %+v | Expected: active with normalized domains", tenant)
+	}
+
+	invalid := []struct {
+		slug     string
+		domains  []string
+		settings m.TenantSettings
+	}{
+		{"acme", []string{"other.granica.dev"}, m.TenantSettings{}},
+		{"Not a slug", []string{"other.granica.dev"}, m.TenantSettings{}},
+		{"other", []string{"auth.acme.com"}, m.TenantSettings{}},
+		{"other", nil, m.TenantSettings{}},
+		{"other", []string{"other.granica.dev"}, m.TenantSettings{SignInMethods: []string{"carrier-pigeon"}}},
+	}
+
+	for _, tt := range invalid {
+		if _, err := svc.CreateTenant(ctx, tt.slug, "", tt.domains, tt.settings, "localhost"); err == nil {
+			t.Errorf("Tenant '%s' %v created | Expected: error", tt.slug, tt.domains)
+		}
+	}
+
+	resolved, err := svc.ResolveTenant(ctx, TenantByDomain, "AUTH.acme.com:8443")
+	if err != nil || resolved.Slug != "acme" {
+		t.Errorf("Resolved: %v, %v | Expected: acme", resolved, err)
+	}
+
+	_, err = svc.UpdateTenant(ctx, "acme", "Acme", []string{"auth.acme.com"}, m.TenantSuspended, m.TenantSettings{}, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.ResolveTenant(ctx, TenantByDomain, "auth.acme.com"); err != ErrUnknownTenant {
+		t.Errorf("Suspended tenant error: %v | Expected: %v", err, ErrUnknownTenant)
+	}
+
+	// Released domains can be taken by other tenants.
+	if _, err := svc.CreateTenant(ctx, "acme-eu", "Acme EU", []string{"acme.granica.dev"}, m.TenantSettings{}, "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+
+	if _, err := svc.UpdateTenant(ctx, "localhost", "", []string{"localhost"}, m.TenantSuspended, m.TenantSettings{}, "localhost"); err == nil {
+		t.Error("expected system tenant suspension to fail")
+	}
+
+	if err := svc.DeleteTenant(ctx, "localhost", "localhost"); err == nil {
+		t.Error("expected system tenant deletion to fail")
+	}
+
+	if err := svc.DeleteTenant(ctx, "acme", "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	tenants, _ := svc.GetTenants(ctx, "localhost")
+	if len(tenants) != 2 {
+		t.Errorf("Tenants: %d | Expected: 2", len(tenants))
+	}
+}
+
+func TestResolveTenantMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	if err := svc.initSystemTenant(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := svc.CreateTenant(ctx, "acme", "Acme", []string{"auth.acme.com"}, m.TenantSettings{}, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tenants, err := newTenantResolver(svc, config.TenantResolutionConfig{Strategies: []string{"host"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tenantID string
+	handler := tenants.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID = getTenant(r)
+	}))
+
+	tests := []struct {
+		host     string
+		status   int
+		tenantID string
+	}{
+		{"auth.acme.com", http.StatusOK, "acme"},
+		{"localhost:8080", http.StatusOK, "localhost"},
+		{"evil.example.com", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		tenantID = ""
+		req := httptest.NewRequest(http.MethodGet, "http://"+tt.host+"/userinfo", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status || tenantID != tt.tenantID {
+			t.Errorf("Host '%s': %d, '%s' | Expected: %d, '%s'", tt.host, rec.Code, tenantID, tt.status, tt.tenantID)
+		}
+	}
+}
+
+func TestTenantSettings(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	if err := svc.initSystemTenant(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := m.TenantSettings{
+		PasswordPolicy: &m.TenantPasswordPolicy{MinLength: 12},
+		RequireMFA:     true,
+	}
+	_, err = svc.UpdateTenant(ctx, "localhost", "", []string{"localhost"}, m.TenantActive, settings, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.SignUp(ctx, "other", "password", "other@granica.dev", "other@granica.dev", "localhost"); err == nil {
+		t.Error("expected password too short for tenant settings to be rejected")
+	}
+
+	challenge, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if challenge.MFAToken == "" || challenge.AccessToken != "" {
+		t.Errorf("Token: %+v | Expected: MFA challenge", challenge)
+	}
+
+	settings = m.TenantSettings{SignInMethods: []string{m.SignInWebAuthn}}
+	_, err = svc.UpdateTenant(ctx, "localhost", "", []string{"localhost"}, m.TenantActive, settings, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != ErrSignInMethodNotAllowed {
+		t.Errorf("Error: %v | Expected: %v", err, ErrSignInMethodNotAllowed)
+	}
+}
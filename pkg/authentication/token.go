@@ -0,0 +1,239 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const (
+	bearerTokenType = "Bearer"
+)
+
+// AppClaims provides custom claims for access tokens.
+type AppClaims struct {
+	UserID      string   `json:"userID"`
+	Username    string   `json:"username"`
+	TenantID    string   `json:"tenant"`
+	SessionID   string   `json:"sid,omitempty"`
+	ClientID    string   `json:"client_id,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	jwt.StandardClaims
+}
+
+// ActionClaims provides the claims of single purpose tokens
+// mailed to users, such as email verification links.
+type ActionClaims struct {
+	Action    string `json:"action"`
+	TenantID  string `json:"tenant"`
+	Email     string `json:"email,omitempty"`
+	Challenge string `json:"challenge,omitempty"`
+	jwt.StandardClaims
+}
+
+// AuthToken is the set of credentials handed to a client
+// after a successful authentication.
+type AuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	// MFA token is returned instead of the tokens above when
+	// a second factor has to be passed or enrolled.
+	MFAToken      string `json:"mfa_token,omitempty"`
+	MFAEnrollment bool   `json:"mfa_enrollment,omitempty"`
+}
+
+// tokenIssuer signs and verifies access tokens.
+type tokenIssuer struct {
+	cfg  config.TokenConfig
+	keys *keyring
+}
+
+// newTokenIssuer makes a token issuer using the configured signing keys.
+func newTokenIssuer(cfg config.TokenConfig) (*tokenIssuer, error) {
+	keys, err := newKeyring(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenIssuer{
+		cfg:  cfg,
+		keys: keys,
+	}, nil
+}
+
+// userClaims returns the claims of an access token issued to a user
+// bound to a session.
+func userClaims(user *m.User, sessionID string) AppClaims {
+	return AppClaims{
+		UserID:    user.ID.String(),
+		Username:  user.Username,
+		TenantID:  user.TenantID,
+		SessionID: sessionID,
+		StandardClaims: jwt.StandardClaims{
+			Subject: user.ID.String(),
+		},
+	}
+}
+
+// clientClaims returns the claims of an access token issued to a client
+// acting on its own behalf. They are not bound to any user or session.
+func clientClaims(client *m.Client, scope string) AppClaims {
+	return AppClaims{
+		TenantID: client.TenantID,
+		ClientID: client.ClientID,
+		Scope:    scope,
+		StandardClaims: jwt.StandardClaims{
+			Subject: client.ClientID,
+		},
+	}
+}
+
+// isClientToken - True if the token was issued to a client on its own behalf.
+func (c *AppClaims) isClientToken() bool {
+	return c.UserID == "" && c.ClientID != ""
+}
+
+// Issue mints a signed access token with the given claims.
+// Registered claims (jti, iss, aud, iat, exp) are set by the issuer.
+func (ti *tokenIssuer) Issue(claims AppClaims) (*AuthToken, error) {
+	now := time.Now()
+	claims.Id = uuid.New().String()
+	claims.Issuer = ti.cfg.Issuer
+	claims.Audience = ti.cfg.Audience
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ti.cfg.AccessTTL).Unix()
+
+	ss, err := ti.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthToken{
+		AccessToken: ss,
+		TokenType:   bearerTokenType,
+		ExpiresIn:   int64(ti.cfg.AccessTTL.Seconds()),
+		Scope:       claims.Scope,
+	}, nil
+}
+
+// IssueIDToken mints a signed ID token with the given claims.
+// Issuer and validity (iss, iat, exp) are set by the issuer.
+func (ti *tokenIssuer) IssueIDToken(claims IDTokenClaims) (string, error) {
+	now := time.Now()
+	claims.Issuer = ti.cfg.Issuer
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ti.cfg.AccessTTL).Unix()
+
+	return ti.sign(claims)
+}
+
+// sign signs the claims with the current signing key.
+func (ti *tokenIssuer) sign(claims jwt.Claims) (string, error) {
+	key, err := ti.keys.Signing()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Parse verifies an access token and returns its claims.
+func (ti *tokenIssuer) Parse(tokenString string) (*AppClaims, error) {
+	claims := &AppClaims{}
+	err := ti.verify(tokenString, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claims.VerifyIssuer(ti.cfg.Issuer, true) || !claims.VerifyAudience(ti.cfg.Audience, true) {
+		return nil, errors.New("invalid token issuer or audience")
+	}
+
+	return claims, nil
+}
+
+// IssueAction mints a signed action token valid for the given time.
+// Action tokens are addressed to the action, so they are never
+// accepted as access tokens.
+func (ti *tokenIssuer) IssueAction(claims ActionClaims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.Id = uuid.New().String()
+	claims.Issuer = ti.cfg.Issuer
+	claims.Audience = claims.Action
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+
+	return ti.sign(claims)
+}
+
+// ParseAction verifies an action token for the given action and returns its claims.
+func (ti *tokenIssuer) ParseAction(tokenString, action string) (*ActionClaims, error) {
+	claims := &ActionClaims{}
+	err := ti.verify(tokenString, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claims.VerifyIssuer(ti.cfg.Issuer, true) || !claims.VerifyAudience(action, true) || claims.Action != action {
+		return nil, errors.New("invalid token issuer or action")
+	}
+
+	return claims, nil
+}
+
+// verify checks the signature and validity of a token decoding its claims.
+func (ti *tokenIssuer) verify(tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := ti.keys.Get(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key '%s'", kid)
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method '%s'", t.Method.Alg())
+		}
+		return key.Private.Public(), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+
+	return nil
+}
+
+// JWKS returns the public keys tokens can be verified with.
+func (ti *tokenIssuer) JWKS() (*JWKSet, error) {
+	set := &JWKSet{Keys: []JWK{}}
+	for _, k := range ti.keys.All() {
+		jwk, err := makeJWK(k.ID, k.Method, k.Private.Public())
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
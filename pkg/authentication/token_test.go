@@ -0,0 +1,91 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/mikrowezel/backend/granica/internal/config"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+func TestTokenIssueAndParse(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256"} {
+		ti, err := newTokenIssuer(config.TokenConfig{
+			Issuer:       "granica",
+			Audience:     "granica",
+			AccessTTL:    time.Minute,
+			Algorithm:    alg,
+			EphemeralKey: true,
+		})
+		if err != nil {
+			t.Fatalf("%s: %s", alg, err.Error())
+		}
+
+		user := &m.User{Username: "username", TenantID: "localhost"}
+		user.SetID(uuid.New())
+
+		claims := userClaims(user, "")
+		claims.Roles = []string{"admin"}
+
+		token, err := ti.Issue(claims)
+		if err != nil {
+			t.Fatalf("%s: %s", alg, err.Error())
+		}
+
+		parsed, err := ti.Parse(token.AccessToken)
+		if err != nil {
+			t.Fatalf("%s: %s", alg, err.Error())
+		}
+
+		if parsed.UserID != user.ID.String() || parsed.TenantID != "localhost" || parsed.Id == "" {
+			t.Errorf("%s: unexpected claims %+v", alg, parsed)
+		}
+	}
+}
+
+func TestTokenParseRejectsForeignAudience(t *testing.T) {
+	cfg := config.TokenConfig{
+		Issuer:       "granica",
+		Audience:     "granica",
+		AccessTTL:    time.Minute,
+		Algorithm:    "ES256",
+		EphemeralKey: true,
+	}
+	ti, err := newTokenIssuer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := *ti
+	other.cfg.Audience = "other"
+
+	token, err := other.Issue(userClaims(&m.User{Username: "username"}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ti.Parse(token.AccessToken); err == nil {
+		t.Error("expected token with foreign audience to be rejected")
+	}
+}
+
+func TestTokenIssuerRequiresKey(t *testing.T) {
+	cfg := config.TokenConfig{
+		Issuer:    "granica",
+		Audience:  "granica",
+		AccessTTL: time.Minute,
+		Algorithm: "ES256",
+	}
+
+	if _, err := newTokenIssuer(cfg); err == nil {
+		t.Error("expected issuer without a configured key to fail")
+	}
+}
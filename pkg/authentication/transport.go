@@ -10,6 +10,10 @@ package authentication
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
 
 	"net/http"
 	"net/url"
@@ -17,6 +21,7 @@ import (
 	httptransport "github.com/go-kit/kit/transport/http"
 	"gitlab.com/mikrowezel/backend/granica/internal/config"
 	c "gitlab.com/mikrowezel/backend/granica/internal/config"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/repo"
 )
 
 const (
@@ -47,13 +52,62 @@ func Run() {
 
 	http.Handle("/sign-up", SignUpHandler(svc))
 	http.Handle("/cancel", CancelHandler(svc))
+	http.Handle("/verify-email", VerifyEmailHandler(svc))
+	http.Handle("/verify-email/resend", ResendEmailVerificationHandler(svc))
+	http.Handle("/password/forgot", ForgotPasswordHandler(svc))
+	http.Handle("/password/reset", ResetPasswordHandler(svc))
 	http.Handle("/sign-in", SignInHandler(svc))
+	http.Handle("/sign-in/mfa", VerifyMFAHandler(svc))
+	http.Handle("/mfa/enroll", EnrollMFAHandler(svc))
+	http.Handle("/mfa/confirm", ConfirmMFAHandler(svc))
+	http.Handle("/mfa/disable", DisableMFAHandler(svc))
+	http.Handle("/sign-in/webauthn/begin", BeginWebAuthnLoginHandler(svc))
+	http.Handle("/sign-in/webauthn/finish", FinishWebAuthnLoginHandler(svc))
+	http.Handle("/webauthn/register/begin", BeginWebAuthnRegistrationHandler(svc))
+	http.Handle("/webauthn/register/finish", FinishWebAuthnRegistrationHandler(svc))
 	http.Handle("/sign-out", SignOutHandler(svc))
+	http.Handle("/token/refresh", RefreshHandler(svc))
+	http.Handle("/.well-known/jwks.json", JWKSHandler(svc))
+	http.Handle("/oauth/introspect", IntrospectHandler(svc))
+	http.Handle("/oauth/revoke", RevokeTokenHandler(svc))
+	http.Handle("/oauth/clients", RegisterClientHandler(svc))
+	http.Handle("/oauth/clients/secret", RotateClientSecretHandler(svc))
+	http.Handle("/oauth/authorize", AuthorizeHandler(svc))
+	http.Handle("/oauth/token", TokenHandler(svc))
+	http.Handle("/.well-known/openid-configuration", OpenIDConfigurationHandler(svc))
+	http.Handle("/userinfo", UserInfoHandler(svc))
 	http.Handle("/create", CreateHandler(svc))
 	http.Handle("/update", UpdateHandler(svc))
 	http.Handle("/remove", RemoveHandler(svc))
+	http.Handle("/unlock", UnlockHandler(svc))
+	http.Handle("/roles", GetRolesHandler(svc))
+	http.Handle("/roles/create", CreateRoleHandler(svc))
+	http.Handle("/roles/update", UpdateRoleHandler(svc))
+	http.Handle("/roles/delete", DeleteRoleHandler(svc))
+	http.Handle("/roles/assign", AssignRoleHandler(svc))
+	http.Handle("/roles/unassign", UnassignRoleHandler(svc))
+	http.Handle("/roles/user", GetUserRolesHandler(svc))
+	http.Handle("/permissions", GetPermissionsHandler(svc))
+	http.Handle("/permissions/create", CreatePermissionHandler(svc))
+	http.Handle("/authorize", DecideHandler(svc))
+	http.Handle("/relations/write", WriteRelationHandler(svc))
+	http.Handle("/relations/delete", DeleteRelationHandler(svc))
+	http.Handle("/relations/check", CheckRelationHandler(svc))
+	http.Handle("/relations/expand", ExpandRelationHandler(svc))
+	http.Handle("/relations/objects", ListObjectsHandler(svc))
+	http.Handle("/tenants", GetTenantsHandler(svc))
+	http.Handle("/tenants/get", GetTenantHandler(svc))
+	http.Handle("/tenants/create", CreateTenantHandler(svc))
+	http.Handle("/tenants/update", UpdateTenantHandler(svc))
+	http.Handle("/tenants/delete", DeleteTenantHandler(svc))
 
-	err = http.ListenAndServe(":8080", nil)
+	tenants, err := newTenantResolver(svc, cfg.Auth.TenantResolution)
+	checkError(err)
+
+	clientIPs, err := newClientIPResolver(cfg.Auth.TrustedProxies)
+	checkError(err)
+
+	err = http.ListenAndServe(":8080", clientIPs.Handler(tenants.Handler(http.DefaultServeMux)))
 
 	logger.Log("level", c.LogLevel.Error, "msg", err.Error())
 }
@@ -76,6 +130,42 @@ func CancelHandler(svc GranicaService) *httptransport.Server {
 	)
 }
 
+// VerifyEmailHandler manages email verification process.
+func VerifyEmailHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeVerifyEmailEndpoint(svc),
+		decodeVerifyEmailRequest,
+		encodeResponse,
+	)
+}
+
+// ResendEmailVerificationHandler manages email verification resending process.
+func ResendEmailVerificationHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeResendEmailVerificationEndpoint(svc),
+		decodeResendEmailVerificationRequest,
+		encodeResponse,
+	)
+}
+
+// ForgotPasswordHandler manages password reset request process.
+func ForgotPasswordHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeForgotPasswordEndpoint(svc),
+		decodeForgotPasswordRequest,
+		encodeResponse,
+	)
+}
+
+// ResetPasswordHandler manages password reset process.
+func ResetPasswordHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeResetPasswordEndpoint(svc),
+		decodeResetPasswordRequest,
+		encodeResponse,
+	)
+}
+
 // SignInHandler manages signing in process.
 func SignInHandler(svc GranicaService) *httptransport.Server {
 	return httptransport.NewServer(
@@ -85,6 +175,78 @@ func SignInHandler(svc GranicaService) *httptransport.Server {
 	)
 }
 
+// VerifyMFAHandler manages second factor verification at sign in.
+func VerifyMFAHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeVerifyMFAEndpoint(svc),
+		decodeVerifyMFARequest,
+		encodeResponse,
+	)
+}
+
+// EnrollMFAHandler manages second factor enrollment.
+func EnrollMFAHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeEnrollMFAEndpoint(svc),
+		decodeEnrollMFARequest,
+		encodeResponse,
+	)
+}
+
+// ConfirmMFAHandler manages second factor enrollment confirmation.
+func ConfirmMFAHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeConfirmMFAEndpoint(svc),
+		decodeConfirmMFARequest,
+		encodeResponse,
+	)
+}
+
+// DisableMFAHandler manages second factor removal.
+func DisableMFAHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeDisableMFAEndpoint(svc),
+		decodeDisableMFARequest,
+		encodeResponse,
+	)
+}
+
+// BeginWebAuthnRegistrationHandler manages the start of passkey registration.
+func BeginWebAuthnRegistrationHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeBeginWebAuthnRegistrationEndpoint(svc),
+		decodeBeginWebAuthnRegistrationRequest,
+		encodeResponse,
+	)
+}
+
+// FinishWebAuthnRegistrationHandler manages the end of passkey registration.
+func FinishWebAuthnRegistrationHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeFinishWebAuthnRegistrationEndpoint(svc),
+		decodeFinishWebAuthnRegistrationRequest,
+		encodeResponse,
+	)
+}
+
+// BeginWebAuthnLoginHandler manages the start of passwordless sign in.
+func BeginWebAuthnLoginHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeBeginWebAuthnLoginEndpoint(svc),
+		decodeBeginWebAuthnLoginRequest,
+		encodeResponse,
+	)
+}
+
+// FinishWebAuthnLoginHandler manages the end of passwordless sign in.
+func FinishWebAuthnLoginHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeFinishWebAuthnLoginEndpoint(svc),
+		decodeFinishWebAuthnLoginRequest,
+		encodeResponse,
+	)
+}
+
 // SignOutHandler manages signing out process.
 func SignOutHandler(svc GranicaService) *httptransport.Server {
 	return httptransport.NewServer(
@@ -94,30 +256,337 @@ func SignOutHandler(svc GranicaService) *httptransport.Server {
 	)
 }
 
+// RefreshHandler manages access token refreshing process.
+func RefreshHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeRefreshEndpoint(svc),
+		decodeRefreshRequest,
+		encodeResponse,
+	)
+}
+
+// JWKSHandler publishes the token signing public keys.
+func JWKSHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeJWKSEndpoint(svc),
+		decodeJWKSRequest,
+		encodeResponse,
+	)
+}
+
+// IntrospectHandler manages token introspection process.
+func IntrospectHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeIntrospectEndpoint(svc),
+		decodeIntrospectRequest,
+		encodeIntrospectResponse,
+	)
+}
+
+// RevokeTokenHandler manages token revocation process.
+func RevokeTokenHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeRevokeTokenEndpoint(svc),
+		decodeRevokeTokenRequest,
+		encodeRevokeTokenResponse,
+	)
+}
+
+// RegisterClientHandler manages OAuth client registration process.
+func RegisterClientHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, clientsWritePermission)(makeRegisterClientEndpoint(svc)),
+		decodeRegisterClientRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// RotateClientSecretHandler manages OAuth client secret rotation process.
+func RotateClientSecretHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, clientsWritePermission)(makeRotateClientSecretEndpoint(svc)),
+		decodeRotateClientSecretRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// AuthorizeHandler manages OAuth authorization process.
+// GET renders the sign in and consent form, POST submits it.
+func AuthorizeHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeAuthorizeEndpoint(svc),
+		decodeAuthorizeRequest,
+		encodeAuthorizeResponse,
+	)
+}
+
+// TokenHandler manages OAuth token issuance process.
+func TokenHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeTokenEndpoint(svc),
+		decodeTokenRequest,
+		encodeTokenResponse,
+	)
+}
+
+// OpenIDConfigurationHandler publishes the OpenID provider configuration.
+func OpenIDConfigurationHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeOpenIDConfigurationEndpoint(svc),
+		decodeOpenIDConfigurationRequest,
+		encodeResponse,
+	)
+}
+
+// UserInfoHandler manages OpenID user info process.
+func UserInfoHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeUserInfoEndpoint(svc),
+		decodeUserInfoRequest,
+		encodeUserInfoResponse,
+	)
+}
+
 // CreateHandler manages create user process
 func CreateHandler(svc GranicaService) *httptransport.Server {
 	return httptransport.NewServer(
-		makeCreateEndpoint(svc),
+		requirePermission(svc, usersCreatePermission)(makeCreateEndpoint(svc)),
 		decodeCreateRequest,
 		encodeResponse,
+		protectedOptions...,
 	)
 }
 
 // UpdateHandler manages user update process
 func UpdateHandler(svc GranicaService) *httptransport.Server {
 	return httptransport.NewServer(
-		makeUpdateEndpoint(svc),
+		requirePermission(svc, usersUpdatePermission)(makeUpdateEndpoint(svc)),
 		decodeUpdateRequest,
 		encodeResponse,
+		protectedOptions...,
 	)
 }
 
 // RemoveHandler manages user removing process
 func RemoveHandler(svc GranicaService) *httptransport.Server {
 	return httptransport.NewServer(
-		makeRemoveEndpoint(svc),
+		requirePermission(svc, usersRemovePermission)(makeRemoveEndpoint(svc)),
 		decodeRemoveRequest,
 		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// UnlockHandler manages account and IP unlocking process
+func UnlockHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, usersUnlockPermission)(makeUnlockEndpoint(svc)),
+		decodeUnlockRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// GetRolesHandler lists the roles of the tenant.
+func GetRolesHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, rolesReadPermission)(makeGetRolesEndpoint(svc)),
+		decodeGetRolesRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// CreateRoleHandler manages role creation process.
+func CreateRoleHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, rolesWritePermission)(makeCreateRoleEndpoint(svc)),
+		decodeCreateRoleRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// UpdateRoleHandler manages role update process.
+func UpdateRoleHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, rolesWritePermission)(makeUpdateRoleEndpoint(svc)),
+		decodeUpdateRoleRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// DeleteRoleHandler manages role deletion process.
+func DeleteRoleHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, rolesWritePermission)(makeDeleteRoleEndpoint(svc)),
+		decodeDeleteRoleRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// AssignRoleHandler manages role assignment process.
+func AssignRoleHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, rolesAssignPermission)(makeAssignRoleEndpoint(svc)),
+		decodeAssignRoleRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// UnassignRoleHandler manages role unassignment process.
+func UnassignRoleHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, rolesAssignPermission)(makeUnassignRoleEndpoint(svc)),
+		decodeUnassignRoleRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// GetUserRolesHandler lists the roles assigned to a user.
+func GetUserRolesHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, rolesReadPermission)(makeGetUserRolesEndpoint(svc)),
+		decodeGetUserRolesRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// GetPermissionsHandler lists the permissions roles can grant.
+func GetPermissionsHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, rolesReadPermission)(makeGetPermissionsEndpoint(svc)),
+		decodeGetPermissionsRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// CreatePermissionHandler manages permission creation process.
+func CreatePermissionHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, rolesWritePermission)(makeCreatePermissionEndpoint(svc)),
+		decodeCreatePermissionRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// DecideHandler answers access requests of services on behalf of the
+// user whose bearer token they forward.
+func DecideHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		makeDecideEndpoint(svc),
+		decodeDecideRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// WriteRelationHandler writes relation tuples.
+func WriteRelationHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, relationsWritePermission)(makeWriteRelationEndpoint(svc)),
+		decodeWriteRelationRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// DeleteRelationHandler deletes relation tuples.
+func DeleteRelationHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, relationsWritePermission)(makeDeleteRelationEndpoint(svc)),
+		decodeDeleteRelationRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// CheckRelationHandler checks if a subject has a relation with an object.
+func CheckRelationHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, relationsReadPermission)(makeCheckRelationEndpoint(svc)),
+		decodeCheckRelationRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// ExpandRelationHandler expands the subjects having a relation with an object.
+func ExpandRelationHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, relationsReadPermission)(makeExpandRelationEndpoint(svc)),
+		decodeExpandRelationRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// ListObjectsHandler lists the objects a subject has a relation with.
+func ListObjectsHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, relationsReadPermission)(makeListObjectsEndpoint(svc)),
+		decodeListObjectsRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// GetTenantsHandler lists the registered tenants.
+func GetTenantsHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, tenantsReadPermission)(makeGetTenantsEndpoint(svc)),
+		decodeGetTenantsRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// GetTenantHandler returns a registered tenant.
+func GetTenantHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, tenantsReadPermission)(makeGetTenantEndpoint(svc)),
+		decodeGetTenantRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// CreateTenantHandler registers tenants.
+func CreateTenantHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, tenantsWritePermission)(makeCreateTenantEndpoint(svc)),
+		decodeCreateTenantRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// UpdateTenantHandler updates tenants.
+func UpdateTenantHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, tenantsWritePermission)(makeUpdateTenantEndpoint(svc)),
+		decodeUpdateTenantRequest,
+		encodeResponse,
+		protectedOptions...,
+	)
+}
+
+// DeleteTenantHandler deletes tenants.
+func DeleteTenantHandler(svc GranicaService) *httptransport.Server {
+	return httptransport.NewServer(
+		requirePermission(svc, tenantsWritePermission)(makeDeleteTenantEndpoint(svc)),
+		decodeDeleteTenantRequest,
+		encodeResponse,
+		protectedOptions...,
 	)
 }
 
@@ -138,13 +607,20 @@ func decodeCancelRequest(_ context.Context, r *http.Request) (interface{}, error
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
+	request.RemoteIP = getRemoteIP(r)
 	request.TenantID = getTenant(r)
 	return request, nil
 }
 
-func decodeSignInRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	// var request authenticateRequest = authenticateRequest{}
-	var request signInRequest
+// Verification links carry the token in the query.
+func decodeVerifyEmailRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request verifyEmailRequest
+	request.Token = r.URL.Query().Get("token")
+	return request, nil
+}
+
+func decodeResendEmailVerificationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request resendEmailVerificationRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
@@ -152,9 +628,8 @@ func decodeSignInRequest(_ context.Context, r *http.Request) (interface{}, error
 	return request, nil
 }
 
-func decodeSignOutRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	// var request authenticateRequest = authenticateRequest{}
-	var request signOutRequest
+func decodeForgotPasswordRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request forgotPasswordRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
@@ -162,44 +637,656 @@ func decodeSignOutRequest(_ context.Context, r *http.Request) (interface{}, erro
 	return request, nil
 }
 
-func decodeCreateRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	// var request authenticateRequest = authenticateRequest{}
-	var request createRequest
+func decodeResetPasswordRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request resetPasswordRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
-	request.TenantID = getTenant(r)
 	return request, nil
 }
 
-func decodeUpdateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+func decodeSignInRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	// var request authenticateRequest = authenticateRequest{}
-	var request updateRequest
+	var request signInRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
+	request.RemoteIP = getRemoteIP(r)
 	request.TenantID = getTenant(r)
 	return request, nil
 }
 
-func decodeRemoveRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	// var request authenticateRequest = authenticateRequest{}
-	var request removeRequest
+func decodeVerifyMFARequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request verifyMFARequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
+	request.RemoteIP = getRemoteIP(r)
 	request.TenantID = getTenant(r)
 	return request, nil
 }
 
-func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
-	return json.NewEncoder(w).Encode(response)
+// Enrollment is authorized by an access token or the
+// enrollment token returned at sign in.
+func decodeEnrollMFARequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request enrollMFARequest
+	request.Token = getBearerToken(r)
+	request.TenantID = getTenant(r)
+	return request, nil
 }
 
-func getTenant(r *http.Request) string {
-	h, err := url.ParseRequestURI("https://" + r.Host)
-	if err != nil {
-		return ""
+func decodeConfirmMFARequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request confirmMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
 	}
-	return h.Hostname()
+	request.Token = getBearerToken(r)
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeDisableMFARequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request disableMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.AccessToken = getBearerToken(r)
+	request.TenantID = getTenant(r)
+	return request, nil
 }
+
+func decodeBeginWebAuthnRegistrationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request beginWebAuthnRegistrationRequest
+	request.AccessToken = getBearerToken(r)
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeFinishWebAuthnRegistrationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request finishWebAuthnRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.AccessToken = getBearerToken(r)
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeBeginWebAuthnLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request beginWebAuthnLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeFinishWebAuthnLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request finishWebAuthnLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeSignOutRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	// var request authenticateRequest = authenticateRequest{}
+	var request signOutRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		return nil, err
+	}
+	request.AccessToken = getBearerToken(r)
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeRefreshRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeJWKSRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return jwksRequest{}, nil
+}
+
+// Introspection and revocation requests are form encoded (RFC 7662, RFC 7009).
+// Client credentials are read as in token requests.
+func decodeIntrospectRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	var request introspectRequest
+	request.Token = r.PostForm.Get("token")
+	request.TokenTypeHint = r.PostForm.Get("token_type_hint")
+	request.ClientID, request.ClientSecret = getClientCredentials(r)
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeRevokeTokenRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	var request revokeTokenRequest
+	request.Token = r.PostForm.Get("token")
+	request.TokenTypeHint = r.PostForm.Get("token_type_hint")
+	request.ClientID, request.ClientSecret = getClientCredentials(r)
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeRegisterClientRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request registerClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeRotateClientSecretRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request rotateClientSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+// Authorization parameters come in the query on GET and in the form on POST.
+func decodeAuthorizeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	var request authorizeRequest
+	request.ResponseType = r.Form.Get("response_type")
+	request.ClientID = r.Form.Get("client_id")
+	request.RedirectURI = r.Form.Get("redirect_uri")
+	request.Scope = r.Form.Get("scope")
+	request.State = r.Form.Get("state")
+	request.CodeChallenge = r.Form.Get("code_challenge")
+	request.CodeChallengeMethod = r.Form.Get("code_challenge_method")
+	request.Nonce = r.Form.Get("nonce")
+	request.RemoteIP = getRemoteIP(r)
+	request.TenantID = getTenant(r)
+	if r.Method == http.MethodPost {
+		request.Submitted = true
+		request.Username = r.PostForm.Get("username")
+		request.Password = r.PostForm.Get("password")
+		request.OTP = r.PostForm.Get("otp")
+		request.Consent = r.PostForm.Get("consent") == "allow"
+	}
+	return request, nil
+}
+
+// Token requests are form encoded (RFC 6749).
+// Client credentials are read from the basic authorization header
+// or else from the form.
+func decodeTokenRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	var request tokenRequest
+	request.GrantType = r.PostForm.Get("grant_type")
+	request.Code = r.PostForm.Get("code")
+	request.RedirectURI = r.PostForm.Get("redirect_uri")
+	request.ClientID, request.ClientSecret = getClientCredentials(r)
+	request.CodeVerifier = r.PostForm.Get("code_verifier")
+	request.RefreshToken = r.PostForm.Get("refresh_token")
+	request.Scope = r.PostForm.Get("scope")
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeOpenIDConfigurationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return openIDConfigurationRequest{}, nil
+}
+
+func decodeUserInfoRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request userInfoRequest
+	request.AccessToken = getBearerToken(r)
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeCreateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	// var request authenticateRequest = authenticateRequest{}
+	var request createRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeUpdateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	// var request authenticateRequest = authenticateRequest{}
+	var request updateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.RemoteIP = getRemoteIP(r)
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeRemoveRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	// var request authenticateRequest = authenticateRequest{}
+	var request removeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeUnlockRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request unlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeGetRolesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request getRolesRequest
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeCreateRoleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request createRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeUpdateRoleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request updateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeDeleteRoleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request deleteRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeAssignRoleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request assignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeUnassignRoleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request unassignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+// The user is given in the query.
+func decodeGetUserRolesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request getUserRolesRequest
+	request.Username = r.URL.Query().Get("username")
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeGetPermissionsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request getPermissionsRequest
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeCreatePermissionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request createPermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeDecideRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request decideRequest
+	if err := json.NewDecoder(r.Body).Decode(&request.AccessRequest); err != nil {
+		return nil, err
+	}
+	request.AccessToken = getBearerToken(r)
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeWriteRelationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request writeRelationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeDeleteRelationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request deleteRelationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeCheckRelationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request checkRelationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeExpandRelationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request expandRelationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeListObjectsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request listObjectsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeGetTenantsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request getTenantsRequest
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeGetTenantRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request getTenantRequest
+	request.Slug = r.URL.Query().Get("slug")
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeCreateTenantRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request createTenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeUpdateTenantRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request updateTenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func decodeDeleteTenantRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request deleteTenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.TenantID = getTenant(r)
+	return request, nil
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	return json.NewEncoder(w).Encode(response)
+}
+
+// encodeError reports the errors of protected endpoints.
+// Those not caused by the caller credentials are server errors.
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	switch err {
+	case ErrUnauthorized:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="granica"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	case ErrForbidden:
+		w.WriteHeader(http.StatusForbidden)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// encodeAuthorizeResponse redirects the user agent back to the client
+// or renders the sign in and consent form.
+// Requests that cannot be redirected show the error to the user.
+func encodeAuthorizeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(authorizeResponse)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+
+	if res.RedirectURL != "" {
+		w.Header().Set("Location", res.RedirectURL)
+		w.WriteHeader(http.StatusFound)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if res.Client == nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	return authorizePage.Execute(w, res)
+}
+
+// encodeTokenResponse encodes token endpoint responses as defined in RFC 6749.
+func encodeTokenResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(tokenResponse)
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if res.OAuthError != nil {
+		return encodeOAuthError(w, res.OAuthError)
+	}
+
+	return json.NewEncoder(w).Encode(res.AuthToken)
+}
+
+// encodeIntrospectResponse encodes introspection responses as defined in RFC 7662.
+func encodeIntrospectResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(introspectResponse)
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if res.OAuthError != nil {
+		return encodeOAuthError(w, res.OAuthError)
+	}
+
+	return json.NewEncoder(w).Encode(res.Introspection)
+}
+
+// encodeRevokeTokenResponse encodes revocation responses as defined in RFC 7009.
+func encodeRevokeTokenResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(revokeTokenResponse)
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+
+	if res.OAuthError != nil {
+		return encodeOAuthError(w, res.OAuthError)
+	}
+
+	return json.NewEncoder(w).Encode(struct{}{})
+}
+
+// encodeOAuthError writes an error of an OAuth endpoint as defined in RFC 6749.
+// Clients that fail to authenticate are asked for basic credentials.
+func encodeOAuthError(w http.ResponseWriter, oerr *OAuthError) error {
+	switch oerr.Code {
+	case errInvalidClient:
+		w.Header().Set("WWW-Authenticate", `Basic realm="granica"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	case errServerError:
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	return json.NewEncoder(w).Encode(oerr)
+}
+
+// encodeUserInfoResponse reports errors as bearer token errors (RFC 6750).
+func encodeUserInfoResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(userInfoResponse)
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if res.Err != "" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="%s"`, res.Err))
+		if res.Err == "insufficient_scope" {
+			w.WriteHeader(http.StatusForbidden)
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}
+
+	return json.NewEncoder(w).Encode(res)
+}
+
+type contextKey string
+
+const (
+	accessTokenContextKey contextKey = "accessToken"
+)
+
+// protectedOptions are the server options of the endpoints that require a permission.
+var protectedOptions = []httptransport.ServerOption{
+	httptransport.ServerBefore(credentialsToContext),
+	httptransport.ServerErrorEncoder(encodeError),
+}
+
+// credentialsToContext puts the bearer token and the tenant of the request
+// in the context for endpoint middlewares to check them.
+func credentialsToContext(ctx context.Context, r *http.Request) context.Context {
+	ctx = context.WithValue(ctx, accessTokenContextKey, getBearerToken(r))
+	return repo.WithTenant(ctx, getTenant(r))
+}
+
+func getBearerToken(r *http.Request) string {
+	ah := r.Header.Get("Authorization")
+	if len(ah) > 7 && strings.EqualFold(ah[0:7], "Bearer ") {
+		return ah[7:]
+	}
+	return ""
+}
+
+// getClientCredentials returns the client credentials of the basic authorization
+// header or else those of the parsed form.
+func getClientCredentials(r *http.Request) (string, string) {
+	if id, secret, ok := getBasicCredentials(r); ok {
+		return id, secret
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+// getBasicCredentials returns the client credentials of the basic authorization
+// header. Both are form encoded before being put in the header (RFC 6749).
+func getBasicCredentials(r *http.Request) (string, string, bool) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return "", "", false
+	}
+
+	id, err := url.QueryUnescape(id)
+	if err != nil {
+		return "", "", false
+	}
+
+	secret, err = url.QueryUnescape(secret)
+	if err != nil {
+		return "", "", false
+	}
+
+	return id, secret, true
+}
+
+// getTenant returns the tenant the request was bound to.
+// Requests not bound by a tenant resolver fall back to their hostname.
+func getTenant(r *http.Request) string {
+	if tenantID, ok := repo.TenantFromContext(r.Context()); ok {
+		return tenantID
+	}
+	return getHostname(r)
+}
+
+func getHostname(r *http.Request) string {
+	h, err := url.ParseRequestURI("https://" + r.Host)
+	if err != nil {
+		return ""
+	}
+	return h.Hostname()
+}
+
+// getRemoteIP returns the IP of the client.
+// Requests not passed through a client IP resolver fall back to their peer,
+// X-Forwarded-For is only trusted when set by a configured proxy.
+func getRemoteIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey).(string); ok {
+		return ip
+	}
+	return peerIP(r)
+}
+
+var authorizePage = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Granica - Authorize</title></head>
+<body>
+{{if .Client}}
+<h1>{{.Client.Name}} wants to access your account</h1>
+{{if .Request.Scope}}<p>Requested scope: {{.Request.Scope}}</p>{{end}}
+{{if .Err}}<p>{{.Err}}</p>{{end}}
+<form method="post" action="/oauth/authorize">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+<label>Username <input type="text" name="username" autocomplete="username"></label>
+<label>Password <input type="password" name="password" autocomplete="current-password"></label>
+<label>Code <input type="text" name="otp" autocomplete="one-time-code" inputmode="numeric"> (if enabled)</label>
+<button type="submit" name="consent" value="allow">Allow</button>
+<button type="submit" name="consent" value="deny">Deny</button>
+</form>
+{{else}}
+<h1>Invalid authorization request</h1>
+<p>{{.Err}}</p>
+{{end}}
+</body>
+</html>
+`))
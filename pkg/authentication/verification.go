@@ -0,0 +1,73 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const (
+	verifyEmailAction = "verify_email"
+)
+
+// sendEmailVerification mails the user a link to verify its email address.
+// The link carries a signed token bound to the address it was sent to.
+func (gs granicaService) sendEmailVerification(user *m.User) error {
+	ttl := gs.cfg.Auth.EmailVerificationTTL
+	token, err := gs.tokens.IssueAction(ActionClaims{
+		Action:   verifyEmailAction,
+		TenantID: user.TenantID,
+		Email:    user.Email,
+		StandardClaims: jwt.StandardClaims{
+			Subject: user.ID.String(),
+		},
+	}, ttl)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", strings.TrimSuffix(gs.cfg.Token.Issuer, "/"), url.QueryEscape(token))
+	body := fmt.Sprintf("Hi %s,\n\nPlease verify your email address following this link:\n\n%s\n\nThe link expires in %s.\n",
+		user.Username, link, ttl)
+
+	return gs.mailer.Send(user.Email, "Verify your email address", body)
+}
+
+// verifyEmail marks the email address of the user the token was issued for as verified.
+// Tokens sent to an address the user no longer has are rejected.
+func (gs granicaService) verifyEmail(ctx context.Context, token string) error {
+	claims, err := gs.tokens.ParseAction(token, verifyEmailAction)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := gs.users(claims.TenantID).Get(ctx, userID)
+	if err != nil || user.TenantID != claims.TenantID || user.Email != claims.Email {
+		return ErrInvalidVerificationToken
+	}
+
+	if user.IsEmailVerified {
+		return nil
+	}
+
+	user.IsEmailVerified = true
+	user.SetUpdateValues(user.ID)
+	return gs.users(user.TenantID).Update(ctx, user)
+}
@@ -0,0 +1,140 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+type fakeMessage struct {
+	To, Subject, Body string
+}
+
+type fakeMailer struct {
+	sync.Mutex
+	sent []fakeMessage
+}
+
+func (fm *fakeMailer) Send(to, subject, body string) error {
+	fm.Lock()
+	defer fm.Unlock()
+	fm.sent = append(fm.sent, fakeMessage{to, subject, body})
+	return nil
+}
+
+var linkTokenRe = regexp.MustCompile(`token=([^\s]+)`)
+
+// lastMailedToken returns the token of the link in the last message sent to the address.
+func lastMailedToken(t *testing.T, svc *granicaService, to string) string {
+	fm := svc.mailer.(*fakeMailer)
+	fm.Lock()
+	defer fm.Unlock()
+
+	for i := len(fm.sent) - 1; i >= 0; i-- {
+		if fm.sent[i].To != to {
+			continue
+		}
+		match := linkTokenRe.FindStringSubmatch(fm.sent[i].Body)
+		if match == nil {
+			t.Fatalf("no link in message: %s", fm.sent[i].Body)
+		}
+		token, err := url.QueryUnescape(match[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	t.Fatalf("no message sent to '%s'", to)
+	return ""
+}
+
+func TestEmailVerification(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	svc.cfg.Auth.RequireVerifiedEmail = true
+
+	user, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.IsEmailVerified {
+		t.Fatal("new users must not have a verified email")
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != ErrEmailNotVerified {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrEmailNotVerified)
+	}
+
+	token := lastMailedToken(t, svc, "username@granica.dev")
+
+	// Verification tokens are not access tokens.
+	if _, err := svc.tokens.Parse(token); err == nil {
+		t.Error("verification token must not be accepted as an access token")
+	}
+
+	if err := svc.VerifyEmail(ctx, "invalid"); err != ErrInvalidVerificationToken {
+		t.Errorf("Error: %v | Expected: %v", err, ErrInvalidVerificationToken)
+	}
+
+	if err := svc.VerifyEmail(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost"); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestSignUpEmailConfirmation(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "other@granica.dev", "localhost")
+	if err == nil {
+		t.Error("expected sign up to fail when email confirmation doesn't match")
+	}
+}
+
+func TestEmailVerificationBoundToAddress(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+
+	user, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := lastMailedToken(t, svc, "username@granica.dev")
+
+	// Email changed after the link was sent.
+	user.Email = "changed@granica.dev"
+	if err := svc.repo.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.VerifyEmail(ctx, token); err != ErrInvalidVerificationToken {
+		t.Errorf("Error: %v | Expected: %v", err, ErrInvalidVerificationToken)
+	}
+
+	if err := svc.ResendEmailVerification(ctx, "username", "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.VerifyEmail(ctx, lastMailedToken(t, svc, "changed@granica.dev")); err != nil {
+		t.Errorf("Error: %v | Expected: nil", err)
+	}
+}
@@ -0,0 +1,325 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/webauthn"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+const (
+	webAuthnRegisterAction = "webauthn_register"
+	webAuthnLoginAction    = "webauthn_login"
+	webAuthnChallengeBytes = 32
+	publicKeyCredential    = "public-key"
+)
+
+// WebAuthnRegistration holds the options of a registration ceremony
+// along with the token that must be returned to finish it.
+type WebAuthnRegistration struct {
+	Token   string                   `json:"token"`
+	Options webauthn.CreationOptions `json:"publicKey"`
+}
+
+// WebAuthnLogin holds the options of an authentication ceremony
+// along with the token that must be returned to finish it.
+type WebAuthnLogin struct {
+	Token   string                  `json:"token"`
+	Options webauthn.RequestOptions `json:"publicKey"`
+}
+
+// relyingParty returns the relying party ceremonies are verified for.
+func (gs granicaService) relyingParty() webauthn.RelyingParty {
+	return webauthn.RelyingParty{
+		ID:      gs.cfg.Auth.WebAuthn.RPID,
+		Origins: gs.cfg.Auth.WebAuthn.Origins,
+	}
+}
+
+// beginCeremony returns a new challenge and the signed token that binds it
+// to the ceremony, the tenant and, if known, the user.
+// Challenges are not stored, the token is handed back to finish the ceremony.
+func (gs granicaService) beginCeremony(action, userID, tenantID string) (string, string, error) {
+	b := make([]byte, webAuthnChallengeBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := webauthn.Encode(b)
+	token, err := gs.tokens.IssueAction(ActionClaims{
+		Action:    action,
+		TenantID:  tenantID,
+		Challenge: challenge,
+		StandardClaims: jwt.StandardClaims{
+			Subject: userID,
+		},
+	}, gs.cfg.Auth.WebAuthn.Timeout)
+	if err != nil {
+		return "", "", err
+	}
+
+	return challenge, token, nil
+}
+
+// parseCeremony verifies a ceremony token for the action and tenant.
+func (gs granicaService) parseCeremony(ctx context.Context, token, action, tenantID string) (*ActionClaims, error) {
+	claims, err := gs.tokens.ParseAction(token, action)
+	if err != nil || claims.TenantID != tenantID || claims.Challenge == "" {
+		return nil, ErrUnauthorized
+	}
+
+	revoked, err := gs.sessions.IsTokenRevoked(ctx, claims.Id)
+	if err != nil || revoked {
+		return nil, ErrUnauthorized
+	}
+
+	return claims, nil
+}
+
+// endCeremony revokes a ceremony token so that its challenge is used once.
+func (gs granicaService) endCeremony(ctx context.Context, claims *ActionClaims) error {
+	return gs.sessions.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+}
+
+// beginWebAuthnRegistration returns the options to create a new credential for the user.
+// Credentials already registered are excluded so that an authenticator is not registered twice.
+func (gs granicaService) beginWebAuthnRegistration(ctx context.Context, user *m.User) (*WebAuthnRegistration, error) {
+	credentials, err := gs.credentialRepo.GetByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, token, err := gs.beginCeremony(webAuthnRegisterAction, user.ID.String(), user.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := user.GivenName + " " + user.FamilyName
+	if user.GivenName == "" && user.FamilyName == "" {
+		displayName = user.Username
+	}
+
+	return &WebAuthnRegistration{
+		Token: token,
+		Options: webauthn.CreationOptions{
+			RP: webauthn.RPEntity{
+				ID:   gs.cfg.Auth.WebAuthn.RPID,
+				Name: gs.cfg.Auth.WebAuthn.RPName,
+			},
+			User: webauthn.UserEntity{
+				ID:          webauthn.Encode(user.ID[:]),
+				Name:        user.Username,
+				DisplayName: displayName,
+			},
+			Challenge:          challenge,
+			PubKeyCredParams:   webauthn.Algorithms,
+			Timeout:            int64(gs.cfg.Auth.WebAuthn.Timeout / time.Millisecond),
+			ExcludeCredentials: descriptors(credentials),
+			AuthenticatorSelection: webauthn.AuthenticatorSelection{
+				ResidentKey:      "preferred",
+				UserVerification: "preferred",
+			},
+			Attestation: "direct",
+		},
+	}, nil
+}
+
+// finishWebAuthnRegistration verifies the attestation returned by the authenticator
+// and stores the new credential of the user.
+func (gs granicaService) finishWebAuthnRegistration(ctx context.Context, user *m.User, token, name string, resp webauthn.RegistrationResponse) (*m.WebAuthnCredential, error) {
+	claims, err := gs.parseCeremony(ctx, token, webAuthnRegisterAction, user.TenantID)
+	if err != nil || claims.Subject != user.ID.String() {
+		return nil, ErrUnauthorized
+	}
+
+	if resp.Type != publicKeyCredential {
+		return nil, errors.New("not a public key credential")
+	}
+
+	clientDataJSON, err := webauthn.Decode(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	attestationObject, err := webauthn.Decode(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := gs.relyingParty().VerifyRegistration(claims.Challenge, clientDataJSON, attestationObject, false)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialID := webauthn.Encode(cred.ID)
+	if rawID, err := webauthn.Decode(resp.RawID); err != nil || webauthn.Encode(rawID) != credentialID {
+		return nil, errors.New("credential ID mismatch")
+	}
+
+	if _, err := gs.credentialRepo.GetByCredentialIDAndTenant(ctx, credentialID, user.TenantID); err == nil {
+		return nil, errors.New("credential already registered")
+	}
+
+	err = gs.endCeremony(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	credential := &m.WebAuthnCredential{
+		ID:                uuid.New(),
+		CredentialID:      credentialID,
+		UserID:            user.ID,
+		TenantID:          user.TenantID,
+		Name:              name,
+		PublicKey:         cred.PublicKey,
+		Algorithm:         cred.Algorithm,
+		SignCount:         cred.SignCount,
+		AAGUID:            cred.AAGUID,
+		AttestationFormat: cred.Format,
+		CreatedAt:         now,
+	}
+
+	err = gs.credentialRepo.Insert(ctx, credential)
+	if err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// beginWebAuthnLogin returns the options to authenticate with a credential.
+// If the user is known only its credentials are allowed, otherwise the
+// authenticator is asked for a discoverable credential. Unknown users get
+// the latter so that accounts cannot be probed.
+func (gs granicaService) beginWebAuthnLogin(ctx context.Context, username, tenantID string) (*WebAuthnLogin, error) {
+	var userID string
+	var allowed []webauthn.CredentialDescriptor
+
+	if username != "" {
+		user, err := gs.users(tenantID).GetByUsernameAndTenant(ctx, username, tenantID)
+		if err == nil {
+			credentials, err := gs.credentialRepo.GetByUser(ctx, user.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(credentials) > 0 {
+				userID = user.ID.String()
+				allowed = descriptors(credentials)
+			}
+		}
+	}
+
+	challenge, token, err := gs.beginCeremony(webAuthnLoginAction, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnLogin{
+		Token: token,
+		Options: webauthn.RequestOptions{
+			Challenge:        challenge,
+			Timeout:          int64(gs.cfg.Auth.WebAuthn.Timeout / time.Millisecond),
+			RPID:             gs.cfg.Auth.WebAuthn.RPID,
+			AllowCredentials: allowed,
+			UserVerification: "required",
+		},
+	}, nil
+}
+
+// finishWebAuthnLogin verifies the assertion returned by the authenticator
+// and opens a session for the credential owner.
+// User verification is required as the credential replaces both the password
+// and the second factor.
+func (gs granicaService) finishWebAuthnLogin(ctx context.Context, token string, resp webauthn.AssertionResponse, tenantID string) (*AuthToken, error) {
+	claims, err := gs.parseCeremony(ctx, token, webAuthnLoginAction, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rawID, err := webauthn.Decode(resp.RawID)
+	if err != nil || resp.Type != publicKeyCredential {
+		return nil, ErrUnauthorized
+	}
+
+	credential, err := gs.credentialRepo.GetByCredentialIDAndTenant(ctx, webauthn.Encode(rawID), tenantID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	if claims.Subject != "" && claims.Subject != credential.UserID.String() {
+		return nil, ErrUnauthorized
+	}
+
+	if resp.Response.UserHandle != "" {
+		handle, err := webauthn.Decode(resp.Response.UserHandle)
+		if err != nil || string(handle) != string(credential.UserID[:]) {
+			return nil, ErrUnauthorized
+		}
+	}
+
+	clientDataJSON, err := webauthn.Decode(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	authenticatorData, err := webauthn.Decode(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	signature, err := webauthn.Decode(resp.Response.Signature)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	signCount, err := gs.relyingParty().VerifyAssertion(claims.Challenge, clientDataJSON, authenticatorData, signature,
+		credential.PublicKey, credential.SignCount, true)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	user, err := gs.users(tenantID).Get(ctx, credential.UserID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrUnauthorized
+	}
+
+	if gs.cfg.Auth.RequireVerifiedEmail && !user.IsEmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	err = gs.endCeremony(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gs.credentialRepo.UpdateSignCount(ctx, credential.ID, signCount, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return gs.startSession(ctx, user, "", "")
+}
+
+// descriptors returns the descriptors of the credentials.
+func descriptors(credentials []m.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	var ds []webauthn.CredentialDescriptor
+	for _, c := range credentials {
+		ds = append(ds, webauthn.CredentialDescriptor{Type: publicKeyCredential, ID: c.CredentialID})
+	}
+	return ds
+}
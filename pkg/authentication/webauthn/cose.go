@@ -0,0 +1,163 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithms (RFC 8152) supported for credentials and attestations.
+const (
+	AlgES256 int64 = -7
+	AlgRS256 int64 = -257
+)
+
+// COSE key parameters.
+const (
+	coseKty    = 1
+	coseAlg    = 3
+	coseEC2    = 2
+	coseRSA    = 3
+	coseCrv    = -1
+	coseX      = -2
+	coseY      = -3
+	coseN      = -1
+	coseE      = -2
+	cosePoint  = 1
+	minRSABits = 2048
+)
+
+// PublicKey is a credential public key decoded from its COSE representation.
+type PublicKey struct {
+	Algorithm int64
+	Key       crypto.PublicKey
+}
+
+// ParsePublicKey decodes a COSE key.
+// Only ES256 keys on P-256 and RS256 keys are supported.
+func ParsePublicKey(cose []byte) (*PublicKey, error) {
+	v, rest, err := decodeCBOR(cose)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after public key")
+	}
+
+	return publicKeyFrom(v)
+}
+
+func publicKeyFrom(v interface{}) (*PublicKey, error) {
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("malformed public key")
+	}
+
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+
+	switch {
+	case kty == coseEC2 && alg == AlgES256:
+		crv, _ := m[int64(coseCrv)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		y, _ := m[int64(coseY)].([]byte)
+		if crv != cosePoint || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("malformed EC2 public key")
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("EC2 public key not on curve")
+		}
+
+		return &PublicKey{Algorithm: alg, Key: key}, nil
+
+	case kty == coseRSA && alg == AlgRS256:
+		n, _ := m[int64(coseN)].([]byte)
+		e, _ := m[int64(coseE)].([]byte)
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("malformed RSA public key")
+		}
+
+		key := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		if key.N.BitLen() < minRSABits {
+			return nil, errors.New("RSA public key too short")
+		}
+
+		return &PublicKey{Algorithm: alg, Key: key}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported public key (kty %d, alg %d)", kty, alg)
+}
+
+// Verify checks the signature of data with the key.
+func (pk *PublicKey) Verify(data, sig []byte) error {
+	return verifySignature(pk.Algorithm, pk.Key, data, sig)
+}
+
+// verifySignature checks a signature made with the COSE algorithm.
+// ES256 signatures are ASN.1 DER encoded as WebAuthn requires.
+func verifySignature(alg int64, key crypto.PublicKey, data, sig []byte) error {
+	sum := sha256.Sum256(data)
+
+	switch alg {
+	case AlgES256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key does not match algorithm")
+		}
+
+		var es struct{ R, S *big.Int }
+		rest, err := asn1.Unmarshal(sig, &es)
+		if err != nil || len(rest) != 0 {
+			return errors.New("malformed signature")
+		}
+
+		if !ecdsa.Verify(k, sum[:], es.R, es.S) {
+			return errors.New("invalid signature")
+		}
+		return nil
+
+	case AlgRS256:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key does not match algorithm")
+		}
+
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], sig) != nil {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported algorithm %d", alg)
+}
+
+// certAlgorithm tells whether the certificate key can verify signatures
+// made with the COSE algorithm.
+func certAlgorithm(cert *x509.Certificate, alg int64) bool {
+	switch alg {
+	case AlgES256:
+		k, ok := cert.PublicKey.(*ecdsa.PublicKey)
+		return ok && k.Curve == elliptic.P256()
+	case AlgRS256:
+		_, ok := cert.PublicKey.(*rsa.PublicKey)
+		return ok
+	}
+	return false
+}
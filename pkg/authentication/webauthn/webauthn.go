@@ -0,0 +1,388 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Ceremony types of the client data.
+const (
+	createType = "webauthn.create"
+	getType    = "webauthn.get"
+)
+
+// Attestation statement formats supported.
+const (
+	FormatNone   = "none"
+	FormatPacked = "packed"
+)
+
+// Authenticator data flags.
+const (
+	flagUP = 0x01
+	flagUV = 0x04
+	flagAT = 0x40
+)
+
+const (
+	rpIDHashLen = 32
+	aaguidLen   = 16
+)
+
+// id-fido-gen-ce-aaguid certificate extension.
+var oidAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// RelyingParty identifies the relying party ceremonies are verified for.
+type RelyingParty struct {
+	ID      string
+	Origins []string
+}
+
+// Credential is a public key credential verified at registration.
+type Credential struct {
+	ID        []byte
+	PublicKey []byte
+	Algorithm int64
+	SignCount uint32
+	AAGUID    []byte
+	Format    string
+}
+
+// ClientData is the client data collected by the browser for a ceremony.
+type ClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// AuthenticatorData is the data the authenticator signs.
+// Attested credential data is only present at registration.
+type AuthenticatorData struct {
+	Raw          []byte
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// UserPresent - True if the user was present.
+func (ad *AuthenticatorData) UserPresent() bool {
+	return ad.Flags&flagUP != 0
+}
+
+// UserVerified - True if the user was verified by the authenticator.
+func (ad *AuthenticatorData) UserVerified() bool {
+	return ad.Flags&flagUV != 0
+}
+
+// VerifyRegistration verifies the response of a registration ceremony
+// for the challenge and returns the new credential.
+func (rp RelyingParty) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte, requireUV bool) (*Credential, error) {
+	err := rp.verifyClientData(clientDataJSON, createType, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	v, rest, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+
+	ao, ok := v.(map[interface{}]interface{})
+	if !ok || len(rest) != 0 {
+		return nil, errors.New("malformed attestation object")
+	}
+
+	format, _ := ao["fmt"].(string)
+	attStmt, _ := ao["attStmt"].(map[interface{}]interface{})
+	rawAuthData, _ := ao["authData"].([]byte)
+	if attStmt == nil {
+		return nil, errors.New("malformed attestation object")
+	}
+
+	ad, err := ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rp.verifyAuthenticatorData(ad, requireUV)
+	if err != nil {
+		return nil, err
+	}
+
+	if ad.Flags&flagAT == 0 {
+		return nil, errors.New("attested credential data missing")
+	}
+
+	pk, err := ParsePublicKey(ad.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+
+	switch format {
+	case FormatNone:
+		if len(attStmt) != 0 {
+			return nil, errors.New("none attestation must have an empty statement")
+		}
+
+	case FormatPacked:
+		err = verifyPacked(attStmt, signed, ad, pk)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("attestation format '%s' not supported", format)
+	}
+
+	return &Credential{
+		ID:        ad.CredentialID,
+		PublicKey: ad.PublicKey,
+		Algorithm: pk.Algorithm,
+		SignCount: ad.SignCount,
+		AAGUID:    ad.AAGUID,
+		Format:    format,
+	}, nil
+}
+
+// VerifyAssertion verifies the response of an authentication ceremony
+// for the challenge with the credential public key and returns the new
+// signature counter.
+// A counter not greater than the stored one signals a cloned authenticator,
+// unless the authenticator does not implement counters at all.
+func (rp RelyingParty) VerifyAssertion(challenge string, clientDataJSON, authenticatorData, signature, publicKey []byte, signCount uint32, requireUV bool) (uint32, error) {
+	err := rp.verifyClientData(clientDataJSON, getType, challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	ad, err := ParseAuthenticatorData(authenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	err = rp.verifyAuthenticatorData(ad, requireUV)
+	if err != nil {
+		return 0, err
+	}
+
+	pk, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, authenticatorData...), clientDataHash[:]...)
+
+	err = pk.Verify(signed, signature)
+	if err != nil {
+		return 0, err
+	}
+
+	if (ad.SignCount != 0 || signCount != 0) && ad.SignCount <= signCount {
+		return 0, errors.New("signature counter did not increase")
+	}
+
+	return ad.SignCount, nil
+}
+
+// verifyClientData checks the type, challenge and origin of the client data.
+func (rp RelyingParty) verifyClientData(clientDataJSON []byte, ceremony, challenge string) error {
+	var cd ClientData
+	err := json.Unmarshal(clientDataJSON, &cd)
+	if err != nil {
+		return errors.New("malformed client data")
+	}
+
+	if cd.Type != ceremony {
+		return fmt.Errorf("unexpected ceremony type '%s'", cd.Type)
+	}
+
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge)) != 1 {
+		return errors.New("challenge mismatch")
+	}
+
+	for _, o := range rp.Origins {
+		if cd.Origin == o {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("origin '%s' not allowed", cd.Origin)
+}
+
+// verifyAuthenticatorData checks the data was produced for this relying party
+// with the user present and, if required, verified.
+func (rp RelyingParty) verifyAuthenticatorData(ad *AuthenticatorData, requireUV bool) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(ad.RPIDHash, rpIDHash[:]) {
+		return errors.New("relying party ID mismatch")
+	}
+
+	if !ad.UserPresent() {
+		return errors.New("user not present")
+	}
+
+	if requireUV && !ad.UserVerified() {
+		return errors.New("user not verified")
+	}
+
+	return nil
+}
+
+// ParseAuthenticatorData decodes authenticator data.
+// Extensions, if any, are ignored.
+func ParseAuthenticatorData(b []byte) (*AuthenticatorData, error) {
+	if len(b) < rpIDHashLen+5 {
+		return nil, errors.New("authenticator data too short")
+	}
+
+	ad := &AuthenticatorData{
+		Raw:       b,
+		RPIDHash:  b[:rpIDHashLen],
+		Flags:     b[rpIDHashLen],
+		SignCount: binary.BigEndian.Uint32(b[rpIDHashLen+1 : rpIDHashLen+5]),
+	}
+
+	if ad.Flags&flagAT == 0 {
+		return ad, nil
+	}
+
+	rest := b[rpIDHashLen+5:]
+	if len(rest) < aaguidLen+2 {
+		return nil, errors.New("attested credential data too short")
+	}
+
+	ad.AAGUID = rest[:aaguidLen]
+	idLen := int(binary.BigEndian.Uint16(rest[aaguidLen : aaguidLen+2]))
+	rest = rest[aaguidLen+2:]
+	if idLen == 0 || len(rest) < idLen {
+		return nil, errors.New("malformed credential ID")
+	}
+
+	ad.CredentialID = rest[:idLen]
+	rest = rest[idLen:]
+
+	_, after, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	ad.PublicKey = rest[:len(rest)-len(after)]
+	return ad, nil
+}
+
+// verifyPacked verifies a packed attestation statement (WebAuthn 8.2).
+// Statements with a certificate chain are checked against the attestation
+// certificate requirements only, no trust anchors are evaluated.
+// Statements without one are self attestations signed with the credential key.
+func verifyPacked(attStmt map[interface{}]interface{}, signed []byte, ad *AuthenticatorData, pk *PublicKey) error {
+	alg, _ := attStmt["alg"].(int64)
+	sig, _ := attStmt["sig"].([]byte)
+	if len(sig) == 0 {
+		return errors.New("packed attestation signature missing")
+	}
+
+	x5c, ok := attStmt["x5c"].([]interface{})
+	if !ok {
+		if alg != pk.Algorithm {
+			return errors.New("self attestation algorithm mismatch")
+		}
+		return pk.Verify(signed, sig)
+	}
+
+	if len(x5c) == 0 {
+		return errors.New("empty attestation certificate chain")
+	}
+
+	der, _ := x5c[0].([]byte)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+
+	if !certAlgorithm(cert, alg) {
+		return errors.New("attestation certificate does not match algorithm")
+	}
+
+	err = verifySignature(alg, cert.PublicKey, signed, sig)
+	if err != nil {
+		return err
+	}
+
+	return verifyAttestationCert(cert, ad.AAGUID)
+}
+
+// verifyAttestationCert checks the packed attestation certificate requirements (WebAuthn 8.2.1).
+func verifyAttestationCert(cert *x509.Certificate, aaguid []byte) error {
+	if cert.Version != 3 {
+		return errors.New("attestation certificate must be version 3")
+	}
+
+	if cert.IsCA {
+		return errors.New("attestation certificate must not be a CA")
+	}
+
+	s := cert.Subject
+	if len(s.Country) == 0 || len(s.Organization) == 0 || s.CommonName == "" {
+		return errors.New("attestation certificate subject incomplete")
+	}
+
+	ou := false
+	for _, u := range s.OrganizationalUnit {
+		if u == "Authenticator Attestation" {
+			ou = true
+		}
+	}
+	if !ou {
+		return errors.New("attestation certificate subject unit mismatch")
+	}
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidAAGUID) {
+			continue
+		}
+
+		if ext.Critical {
+			return errors.New("AAGUID extension must not be critical")
+		}
+
+		var value []byte
+		_, err := asn1.Unmarshal(ext.Value, &value)
+		if err != nil || !bytes.Equal(value, aaguid) {
+			return errors.New("attestation certificate AAGUID mismatch")
+		}
+	}
+
+	return nil
+}
+
+// Encode returns the base64url encoding WebAuthn uses for binary values.
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode decodes base64url values, padded or not.
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(trimPadding(s))
+}
+
+func trimPadding(s string) string {
+	for len(s) > 0 && s[len(s)-1] == '=' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
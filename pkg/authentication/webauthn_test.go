@@ -0,0 +1,426 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package authentication
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"gitlab.com/mikrowezel/backend/granica/pkg/authentication/webauthn"
+	m "gitlab.com/mikrowezel/backend/granica/pkg/models"
+)
+
+// cborPair is a map entry, maps are encoded in the given order.
+type cborPair struct {
+	k, v interface{}
+}
+
+// encodeCBOR encodes the subset of CBOR software authenticators need.
+func encodeCBOR(v interface{}) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 1<<8:
+			return []byte{major<<5 | 24, byte(n)}
+		case n < 1<<16:
+			b := []byte{major<<5 | 25, 0, 0}
+			binary.BigEndian.PutUint16(b[1:], uint16(n))
+			return b
+		default:
+			b := []byte{major<<5 | 26, 0, 0, 0, 0}
+			binary.BigEndian.PutUint32(b[1:], uint32(n))
+			return b
+		}
+	}
+
+	switch t := v.(type) {
+	case int:
+		if t < 0 {
+			return head(1, uint64(-1-t))
+		}
+		return head(0, uint64(t))
+	case int64:
+		return encodeCBOR(int(t))
+	case []byte:
+		return append(head(2, uint64(len(t))), t...)
+	case string:
+		return append(head(3, uint64(len(t))), t...)
+	case []interface{}:
+		b := head(4, uint64(len(t)))
+		for _, e := range t {
+			b = append(b, encodeCBOR(e)...)
+		}
+		return b
+	case []cborPair:
+		b := head(5, uint64(len(t)))
+		for _, p := range t {
+			b = append(b, encodeCBOR(p.k)...)
+			b = append(b, encodeCBOR(p.v)...)
+		}
+		return b
+	}
+	panic("unsupported type")
+}
+
+// softAuthenticator is a software WebAuthn authenticator.
+type softAuthenticator struct {
+	key       crypto.Signer
+	alg       int64
+	id        []byte
+	aaguid    []byte
+	signCount uint32
+	noUV      bool
+}
+
+func newSoftAuthenticator(t *testing.T, alg int64) *softAuthenticator {
+	a := &softAuthenticator{alg: alg, id: make([]byte, 32), aaguid: make([]byte, 16)}
+	rand.Read(a.id)
+	rand.Read(a.aaguid)
+
+	var err error
+	if alg == webauthn.AlgRS256 {
+		a.key, err = rsa.GenerateKey(rand.Reader, 2048)
+	} else {
+		a.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	switch k := a.key.Public().(type) {
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		xb, yb := k.X.Bytes(), k.Y.Bytes()
+		copy(x[32-len(xb):], xb)
+		copy(y[32-len(yb):], yb)
+		return encodeCBOR([]cborPair{{1, 2}, {3, -7}, {-1, 1}, {-2, x}, {-3, y}})
+	case *rsa.PublicKey:
+		return encodeCBOR([]cborPair{{1, 3}, {3, -257}, {-1, k.N.Bytes()}, {-2, big.NewInt(int64(k.E)).Bytes()}})
+	}
+	return nil
+}
+
+func (a *softAuthenticator) flags(attested bool) byte {
+	f := byte(0x01)
+	if !a.noUV {
+		f |= 0x04
+	}
+	if attested {
+		f |= 0x40
+	}
+	return f
+}
+
+func (a *softAuthenticator) authData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	b := append([]byte{}, rpIDHash[:]...)
+	b = append(b, a.flags(attested))
+	count := make([]byte, 4)
+	binary.BigEndian.PutUint32(count, a.signCount)
+	b = append(b, count...)
+	if attested {
+		b = append(b, a.aaguid...)
+		b = append(b, byte(len(a.id)>>8), byte(len(a.id)))
+		b = append(b, a.id...)
+		b = append(b, a.coseKey()...)
+	}
+	return b
+}
+
+func clientDataJSON(ceremony, challenge, origin string) []byte {
+	b, _ := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": origin})
+	return b
+}
+
+func sign(t *testing.T, key crypto.Signer, data []byte) []byte {
+	sum := sha256.Sum256(data)
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+		return sig
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	return nil
+}
+
+// attestationCert returns a packed attestation certificate for the AAGUID.
+func attestationCert(t *testing.T, aaguid []byte) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ext, _ := asn1.Marshal(aaguid)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"PL"},
+			Organization:       []string{"Granica"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "Granica Soft Authenticator",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}, Value: ext},
+		},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, der
+}
+
+// create answers a registration ceremony with the attestation format,
+// "packed" statements carry a certificate chain and "self" is packed
+// self attestation.
+func (a *softAuthenticator) create(t *testing.T, opts webauthn.CreationOptions, origin, format string) webauthn.RegistrationResponse {
+	cd := clientDataJSON("webauthn.create", opts.Challenge, origin)
+	ad := a.authData(opts.RP.ID, true)
+	cdHash := sha256.Sum256(cd)
+	signed := append(append([]byte{}, ad...), cdHash[:]...)
+
+	var attFmt string
+	var stmt []cborPair
+	switch format {
+	case "none":
+		attFmt, stmt = "none", []cborPair{}
+	case "packed":
+		key, der := attestationCert(t, a.aaguid)
+		attFmt, stmt = "packed", []cborPair{{"alg", -7}, {"sig", sign(t, key, signed)}, {"x5c", []interface{}{der}}}
+	case "self":
+		attFmt, stmt = "packed", []cborPair{{"alg", a.alg}, {"sig", sign(t, a.key, signed)}}
+	}
+
+	var resp webauthn.RegistrationResponse
+	resp.ID = webauthn.Encode(a.id)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = webauthn.Encode(cd)
+	resp.Response.AttestationObject = webauthn.Encode(encodeCBOR([]cborPair{{"fmt", attFmt}, {"attStmt", stmt}, {"authData", ad}}))
+	return resp
+}
+
+// get answers an authentication ceremony.
+func (a *softAuthenticator) get(t *testing.T, opts webauthn.RequestOptions, origin string, userHandle []byte) webauthn.AssertionResponse {
+	a.signCount++
+	cd := clientDataJSON("webauthn.get", opts.Challenge, origin)
+	ad := a.authData(opts.RPID, false)
+	cdHash := sha256.Sum256(cd)
+
+	var resp webauthn.AssertionResponse
+	resp.ID = webauthn.Encode(a.id)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = webauthn.Encode(cd)
+	resp.Response.AuthenticatorData = webauthn.Encode(ad)
+	resp.Response.Signature = webauthn.Encode(sign(t, a.key, append(append([]byte{}, ad...), cdHash[:]...)))
+	resp.Response.UserHandle = webauthn.Encode(userHandle)
+	return resp
+}
+
+// registerTestPasskey signs up a user and registers a passkey with the authenticator.
+func registerTestPasskey(ctx context.Context, t *testing.T, svc *granicaService, a *softAuthenticator, format string) *m.User {
+	user, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := svc.BeginWebAuthnRegistration(ctx, token.AccessToken, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.FinishWebAuthnRegistration(ctx, token.AccessToken, reg.Token, "key", a.create(t, reg.Options, "https://localhost", format), "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+func TestWebAuthnRegistrationAndLogin(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name   string
+		alg    int64
+		format string
+	}{
+		{"ES256 packed", webauthn.AlgES256, "packed"},
+		{"ES256 self", webauthn.AlgES256, "self"},
+		{"RS256 none", webauthn.AlgRS256, "none"},
+		{"RS256 self", webauthn.AlgRS256, "self"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			a := newSoftAuthenticator(t, tt.alg)
+			user := registerTestPasskey(ctx, t, svc, a, tt.format)
+
+			login, err := svc.BeginWebAuthnLogin(ctx, "username", "localhost")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(login.Options.AllowCredentials) != 1 || login.Options.AllowCredentials[0].ID != webauthn.Encode(a.id) {
+				t.Fatalf("Allowed credentials: %+v | Expected: the registered one", login.Options.AllowCredentials)
+			}
+
+			resp := a.get(t, login.Options, "https://localhost", user.ID[:])
+			token, err := svc.FinishWebAuthnLogin(ctx, login.Token, resp, "localhost")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := svc.authenticate(context.Background(), token.AccessToken, "localhost"); err != nil {
+				t.Errorf("Error: %v | Expected: nil", err)
+			}
+
+			// Ceremonies are single use.
+			if _, err := svc.FinishWebAuthnLogin(ctx, login.Token, resp, "localhost"); err != ErrUnauthorized {
+				t.Errorf("Error: %v | Expected: %v", err, ErrUnauthorized)
+			}
+		})
+	}
+}
+
+func TestWebAuthnDiscoverableLogin(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	a := newSoftAuthenticator(t, webauthn.AlgES256)
+	user := registerTestPasskey(ctx, t, svc, a, "none")
+
+	login, err := svc.BeginWebAuthnLogin(ctx, "", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(login.Options.AllowCredentials) != 0 {
+		t.Fatalf("Allowed credentials: %d | Expected: 0", len(login.Options.AllowCredentials))
+	}
+
+	// User handle must be the one of the credential owner.
+	if _, err := svc.FinishWebAuthnLogin(ctx, login.Token, a.get(t, login.Options, "https://localhost", []byte("other")), "localhost"); err != ErrUnauthorized {
+		t.Fatalf("Error: %v | Expected: %v", err, ErrUnauthorized)
+	}
+
+	if _, err := svc.FinishWebAuthnLogin(ctx, login.Token, a.get(t, login.Options, "https://localhost", user.ID[:]), "localhost"); err != nil {
+		t.Fatalf("Error: %v | Expected: nil", err)
+	}
+}
+
+func TestWebAuthnRejectsInvalidCeremonies(t *testing.T) {
+	ctx := context.Background()
+
+	svc := newTestService(t)
+	a := newSoftAuthenticator(t, webauthn.AlgES256)
+
+	_, err := svc.SignUp(ctx, "username", "password", "username@granica.dev", "username@granica.dev", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := svc.SignIn(ctx, "username", "password", "127.0.0.1", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := svc.BeginWebAuthnRegistration(ctx, token.AccessToken, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.FinishWebAuthnRegistration(ctx, token.AccessToken, reg.Token, "key", a.create(t, reg.Options, "https://evil.dev", "none"), "localhost"); err == nil {
+		t.Error("expected registration from other origin to be rejected")
+	}
+
+	tampered := a.create(t, reg.Options, "https://localhost", "packed")
+	ao, _ := webauthn.Decode(tampered.Response.AttestationObject)
+	ao[len(ao)-1] ^= 0xff
+	tampered.Response.AttestationObject = webauthn.Encode(ao)
+	if _, err := svc.FinishWebAuthnRegistration(ctx, token.AccessToken, reg.Token, "key", tampered, "localhost"); err == nil {
+		t.Error("expected tampered attestation to be rejected")
+	}
+
+	_, err = svc.FinishWebAuthnRegistration(ctx, token.AccessToken, reg.Token, "key", a.create(t, reg.Options, "https://localhost", "packed"), "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, _ := svc.repo.GetByUsernameAndTenant(ctx, "username", "localhost")
+
+	// Assertions without user verification do not replace the password.
+	login, _ := svc.BeginWebAuthnLogin(ctx, "username", "localhost")
+	a.noUV = true
+	if _, err := svc.FinishWebAuthnLogin(ctx, login.Token, a.get(t, login.Options, "https://localhost", user.ID[:]), "localhost"); err != ErrUnauthorized {
+		t.Errorf("Error: %v | Expected: %v", err, ErrUnauthorized)
+	}
+	a.noUV = false
+
+	// A counter that does not increase signals a cloned authenticator.
+	a.signCount = 0
+	if _, err := svc.FinishWebAuthnLogin(ctx, login.Token, a.get(t, login.Options, "https://localhost", user.ID[:]), "localhost"); err != nil {
+		t.Fatalf("Error: %v | Expected: nil", err)
+	}
+
+	clone := *a
+	login, _ = svc.BeginWebAuthnLogin(ctx, "username", "localhost")
+	if _, err := svc.FinishWebAuthnLogin(ctx, login.Token, a.get(t, login.Options, "https://localhost", user.ID[:]), "localhost"); err != nil {
+		t.Fatalf("Error: %v | Expected: nil", err)
+	}
+
+	login, _ = svc.BeginWebAuthnLogin(ctx, "username", "localhost")
+	if _, err := svc.FinishWebAuthnLogin(ctx, login.Token, clone.get(t, login.Options, "https://localhost", user.ID[:]), "localhost"); err != ErrUnauthorized {
+		t.Errorf("Error: %v | Expected: %v", err, ErrUnauthorized)
+	}
+
+	// The same authenticator cannot be registered twice.
+	reg, _ = svc.BeginWebAuthnRegistration(ctx, token.AccessToken, "localhost")
+	if len(reg.Options.ExcludeCredentials) != 1 {
+		t.Errorf("Excluded credentials: %d | Expected: 1", len(reg.Options.ExcludeCredentials))
+	}
+
+	if _, err := svc.FinishWebAuthnRegistration(ctx, token.AccessToken, reg.Token, "key", a.create(t, reg.Options, "https://localhost", "none"), "localhost"); err == nil {
+		t.Error("expected duplicated credential to be rejected")
+	}
+}
@@ -0,0 +1,36 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthorizationCode model struct.
+// Issued by the authorization endpoint and exchanged once for tokens.
+// Only a digest of the code handed to the client is stored.
+type AuthorizationCode struct {
+	Digest              string    `json:"digest"`
+	ClientID            string    `json:"clientID"`
+	UserID              uuid.UUID `json:"userID"`
+	TenantID            string    `json:"tenantID"`
+	RedirectURI         string    `json:"redirectURI"`
+	Scope               string    `json:"scope"`
+	CodeChallenge       string    `json:"codeChallenge"`
+	CodeChallengeMethod string    `json:"codeChallengeMethod"`
+	Nonce               string    `json:"nonce"`
+	AuthTime            time.Time `json:"authTime"`
+	ExpiresAt           time.Time `json:"expiresAt"`
+}
+
+// IsExpired - True if the code is past its expiration date.
+func (ac *AuthorizationCode) IsExpired() bool {
+	return time.Now().After(ac.ExpiresAt)
+}
@@ -0,0 +1,67 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client model struct.
+// An OAuth client registered in a tenant.
+// Confidential clients authenticate with a secret, only its digest is stored.
+type Client struct {
+	ID           uuid.UUID `bson:"_id" json:"id"`
+	ClientID     string    `bson:"client_id" json:"clientID"`
+	SecretDigest string    `bson:"secret_digest" json:"-"`
+	Name         string    `bson:"name" json:"name"`
+	TenantID     string    `bson:"tenant_id" json:"tenantID"`
+	RedirectURIs []string  `bson:"redirect_uris" json:"redirectURIs"`
+	Scopes       []string  `bson:"scopes" json:"scopes"`
+	GrantTypes   []string  `bson:"grant_types" json:"grantTypes"`
+	IsActive     bool      `bson:"is_active" json:"isActive"`
+	CreatedAt    time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// IsConfidential - True if the client authenticates with a secret.
+func (c *Client) IsConfidential() bool {
+	return c.SecretDigest != ""
+}
+
+// AllowsGrant - True if the client is allowed to use the grant type.
+func (c *Client) AllowsGrant(grantType string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRedirectURI - True if the URI is one of the client registered redirect URIs.
+// URIs are compared using simple string comparison.
+func (c *Client) HasRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsScope - True if the client is allowed to request the scope.
+func (c *Client) AllowsScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordReset model struct.
+// A pending password reset requested by a user.
+// Only a digest of the token mailed to the user is stored.
+type PasswordReset struct {
+	ID        uuid.UUID `bson:"_id" json:"id"`
+	Digest    string    `bson:"digest" json:"-"`
+	UserID    uuid.UUID `bson:"user_id" json:"userID"`
+	TenantID  string    `bson:"tenant_id" json:"tenantID"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expiresAt"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// IsExpired - True if the reset is past its expiration date.
+func (pr *PasswordReset) IsExpired() bool {
+	return time.Now().After(pr.ExpiresAt)
+}
@@ -7,6 +7,12 @@
 
 package models
 
+import "errors"
+
+// ErrNotFound is returned by the repos of the models other than users
+// when no model matches the query.
+var ErrNotFound = errors.New("not found")
+
 // Persist is a Generic interface for persistible models
 type Persist struct{}
 
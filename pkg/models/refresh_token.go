@@ -0,0 +1,41 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken model struct.
+// Only a digest of the opaque token handed to the client is stored.
+// All tokens obtained by rotation from the same sign in share a FamilyID.
+type RefreshToken struct {
+	ID         uuid.UUID `bson:"_id" json:"id"`
+	Digest     string    `bson:"digest" json:"-"`
+	FamilyID   uuid.UUID `bson:"family_id" json:"familyID"`
+	UserID     uuid.UUID `bson:"user_id" json:"userID"`
+	TenantID   string    `bson:"tenant_id" json:"tenantID"`
+	ClientID   string    `bson:"client_id" json:"clientID"`
+	Scope      string    `bson:"scope" json:"scope"`
+	ReplacedBy uuid.UUID `bson:"replaced_by" json:"replacedBy"`
+	IsRevoked  bool      `bson:"is_revoked" json:"isRevoked"`
+	ExpiresAt  time.Time `bson:"expires_at" json:"expiresAt"`
+	CreatedAt  time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// IsRotated - True if the token has already been exchanged for a new one.
+func (rt *RefreshToken) IsRotated() bool {
+	return rt.ReplacedBy != uuid.Nil
+}
+
+// IsExpired - True if the token is past its expiration date.
+func (rt *RefreshToken) IsExpired() bool {
+	return time.Now().After(rt.ExpiresAt)
+}
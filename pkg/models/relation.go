@@ -0,0 +1,91 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidRelationTuple is returned when parsing malformed relation tuples.
+var ErrInvalidRelationTuple = errors.New("invalid relation tuple")
+
+// RelationTuple model struct.
+// States that the subject has the relation with the object, as in
+// 'document:readme#viewer@user:42'. Objects are named 'namespace:id'
+// and subjects can also be the set of subjects having a relation
+// with another object, as in 'group:eng#member'.
+type RelationTuple struct {
+	ID        uuid.UUID `bson:"_id" json:"id"`
+	TenantID  string    `bson:"tenant_id" json:"tenantID"`
+	Object    string    `bson:"object" json:"object"`
+	Relation  string    `bson:"relation" json:"relation"`
+	Subject   string    `bson:"subject" json:"subject"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// String returns the tuple as 'object#relation@subject'.
+func (t RelationTuple) String() string {
+	return t.Object + "#" + t.Relation + "@" + t.Subject
+}
+
+// ParseRelationTuple parses a 'object#relation@subject' tuple.
+func ParseRelationTuple(s string) (RelationTuple, error) {
+	i := strings.Index(s, "#")
+	j := strings.Index(s, "@")
+	if i < 0 || j < i {
+		return RelationTuple{}, ErrInvalidRelationTuple
+	}
+
+	t := RelationTuple{Object: s[:i], Relation: s[i+1 : j], Subject: s[j+1:]}
+	if !ValidObject(t.Object) || !validName(t.Relation) || !ValidSubject(t.Subject) {
+		return RelationTuple{}, ErrInvalidRelationTuple
+	}
+
+	return t, nil
+}
+
+// ValidObject - True if the object is named 'namespace:id'.
+func ValidObject(object string) bool {
+	i := strings.Index(object, ":")
+	return i > 0 && i < len(object)-1 && validName(object[:i]) && !strings.ContainsAny(object[i+1:], "#@ ")
+}
+
+// ValidSubject - True if the subject is an object or an 'object#relation' set.
+func ValidSubject(subject string) bool {
+	object, relation := SplitSubject(subject)
+	if relation == "" && strings.Contains(subject, "#") {
+		return false
+	}
+	return ValidObject(object) && (relation == "" || validName(relation))
+}
+
+// SplitSubject returns the object and, if the subject is a set, the relation of a subject.
+func SplitSubject(subject string) (object, relation string) {
+	if i := strings.Index(subject, "#"); i >= 0 {
+		return subject[:i], subject[i+1:]
+	}
+	return subject, ""
+}
+
+// Namespace returns the namespace of an object.
+func Namespace(object string) string {
+	if i := strings.Index(object, ":"); i >= 0 {
+		return object[:i]
+	}
+	return ""
+}
+
+// validName - True if the namespace or relation name is not empty
+// and holds no separators.
+func validName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, ":#@ ")
+}
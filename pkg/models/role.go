@@ -0,0 +1,67 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AllPermissions grants every permission.
+const AllPermissions = "*"
+
+// Role model struct.
+// A named set of permissions of a tenant assigned to users.
+type Role struct {
+	ID          uuid.UUID `bson:"_id" json:"id"`
+	TenantID    string    `bson:"tenant_id" json:"tenantID"`
+	Name        string    `bson:"name" json:"name"`
+	Description string    `bson:"description" json:"description"`
+	Permissions []string  `bson:"permissions" json:"permissions"`
+	CreatedAt   time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// Permission model struct.
+// Permissions are named after the resource and the action they allow,
+// as in 'users:create'. Besides the built-in ones, tenants can define
+// their own for the applications relying on them.
+type Permission struct {
+	ID          uuid.UUID `bson:"_id" json:"id"`
+	TenantID    string    `bson:"tenant_id" json:"tenantID"`
+	Name        string    `bson:"name" json:"name"`
+	Description string    `bson:"description" json:"description"`
+	CreatedAt   time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// RoleAssignment model struct.
+// A role of a tenant assigned to one of its users.
+type RoleAssignment struct {
+	ID        uuid.UUID `bson:"_id" json:"id"`
+	TenantID  string    `bson:"tenant_id" json:"tenantID"`
+	UserID    uuid.UUID `bson:"user_id" json:"userID"`
+	RoleID    uuid.UUID `bson:"role_id" json:"roleID"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// PermissionGranted - True if the permission is one of the granted ones.
+// A granted '*' matches any permission and 'resource:*' any action on the resource.
+func PermissionGranted(granted []string, permission string) bool {
+	for _, g := range granted {
+		if g == permission || g == AllPermissions {
+			return true
+		}
+
+		if strings.HasSuffix(g, ":*") && strings.HasPrefix(permission, strings.TrimSuffix(g, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session model struct.
+// A session starts at sign in and lasts until it is revoked or expires.
+// Its ID is shared with the refresh token family issued at sign in.
+type Session struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"userID"`
+	TenantID  string    `json:"tenantID"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// IsExpired - True if the session is past its expiration date.
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
@@ -0,0 +1,107 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant statuses
+const (
+	TenantActive    = "active"
+	TenantSuspended = "suspended"
+)
+
+// Sign in methods
+const (
+	SignInPassword = "password"
+	SignInWebAuthn = "webauthn"
+)
+
+// ErrCrossTenant is returned by tenant scoped repos when asked to read
+// or write data of another tenant.
+var ErrCrossTenant = errors.New("cross tenant access")
+
+// slugRe matches tenant slugs. Dots are allowed so that hostnames,
+// the tenant IDs of data stored before tenants were registered, are valid slugs.
+var slugRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]{0,61}[a-z0-9])?$`)
+
+// Tenant model struct.
+// Its slug is the tenant ID the rest of the models are scoped by
+// and cannot be changed. Requests are bound to the tenant one of whose
+// domains matches their host.
+type Tenant struct {
+	ID        uuid.UUID      `bson:"_id" json:"id"`
+	Slug      string         `bson:"slug" json:"slug"`
+	Name      string         `bson:"name" json:"name"`
+	Domains   []string       `bson:"domains" json:"domains"`
+	Status    string         `bson:"status" json:"status"`
+	Settings  TenantSettings `bson:"settings" json:"settings"`
+	CreatedAt time.Time      `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time      `bson:"updated_at" json:"updatedAt"`
+}
+
+// TenantSettings are the authentication settings of a tenant.
+// A nil password policy leaves the configured one in place
+// and no sign in methods allows all of them.
+type TenantSettings struct {
+	PasswordPolicy *TenantPasswordPolicy `bson:"password_policy" json:"passwordPolicy,omitempty"`
+	RequireMFA     bool                  `bson:"require_mfa" json:"requireMFA"`
+	SignInMethods  []string              `bson:"sign_in_methods" json:"signInMethods,omitempty"`
+}
+
+// TenantPasswordPolicy are the password rules of a tenant.
+type TenantPasswordPolicy struct {
+	MinLength        int  `bson:"min_length" json:"minLength"`
+	MaxLength        int  `bson:"max_length" json:"maxLength"`
+	RequireUpper     bool `bson:"require_upper" json:"requireUpper"`
+	RequireLower     bool `bson:"require_lower" json:"requireLower"`
+	RequireDigit     bool `bson:"require_digit" json:"requireDigit"`
+	RequireSymbol    bool `bson:"require_symbol" json:"requireSymbol"`
+	DisallowUserInfo bool `bson:"disallow_user_info" json:"disallowUserInfo"`
+	DisallowBreached bool `bson:"disallow_breached" json:"disallowBreached"`
+	HistorySize      int  `bson:"history_size" json:"historySize"`
+}
+
+// IsActive - True if the tenant is not suspended.
+func (t *Tenant) IsActive() bool {
+	return t.Status == TenantActive
+}
+
+// AllowsSignIn - True if users of the tenant can sign in with the method.
+func (s TenantSettings) AllowsSignIn(method string) bool {
+	if len(s.SignInMethods) == 0 {
+		return true
+	}
+
+	for _, m := range s.SignInMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidSlug - True if the slug can identify a tenant.
+func ValidSlug(slug string) bool {
+	return slugRe.MatchString(slug)
+}
+
+// NormalizeDomain returns the domain lowercased and without port or trailing dot.
+func NormalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if i := strings.LastIndex(domain, ":"); i >= 0 && !strings.Contains(domain[i:], "]") {
+		domain = domain[:i]
+	}
+	return strings.TrimSuffix(domain, ".")
+}
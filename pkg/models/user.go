@@ -14,13 +14,21 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	userModelName = "user"
 )
 
+// User repo errors, returned alike by every repo implementation.
+var (
+	// ErrUserNotFound is returned when no user matches the query.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrDuplicateUser is returned when the ID of the user, or its username or email
+	// within its tenant, are already taken.
+	ErrDuplicateUser = errors.New("user already exists")
+)
+
 // User model struct
 type User struct {
 	MongoID `bson:",inline"`
@@ -30,8 +38,15 @@ type User struct {
 	Password             string    `bson:"-" json:"password"`
 	PasswordConfirmation string    `bson:"-" json:"passwordConfirmation"`
 	PasswordDigest       string    `bson:"password_digest" json:"-"`
+	PasswordHistory      []string  `bson:"password_history" json:"-"`
 	Email                string    `bson:"email" json:"email"`
 	EmailConfirmation    string    `bson:"-" json:"emailConfirmation"`
+	IsEmailVerified      bool      `bson:"is_email_verified" json:"isEmailVerified"`
+	MFASecret            string    `bson:"mfa_secret" json:"-"`
+	MFALastStep          int64     `bson:"mfa_last_step" json:"-"`
+	IsMFAEnabled         bool      `bson:"is_mfa_enabled" json:"isMFAEnabled"`
+	RequireMFA           bool      `bson:"require_mfa" json:"requireMFA"`
+	RecoveryCodes        []string  `bson:"recovery_codes" json:"-"`
 	Description          string    `bson:"description" json:"description"`
 	GivenName            string    `bson:"given_name" json:"givenName"`
 	MiddleNames          string    `bson:"middle_names" json:"middleNames"`
@@ -59,7 +74,6 @@ func (user *User) SetCreateValues(tenantID string, createdBy ...uuid.UUID) {
 	user.GenAndSetID()
 	user.GenAndSetNameKey()
 	user.UpdateSlug()
-	user.UpdatePasswordDigest()
 	user.SetTenant(tenantID)
 	user.SetCreatedBy(user.idOrSelf(createdBy...))
 	user.SetUpdatedBy(user.idOrSelf(createdBy...))
@@ -72,7 +86,6 @@ func (user *User) SetCreateValues(tenantID string, createdBy ...uuid.UUID) {
 // to a generic interface embedded in all models.
 func (user *User) SetUpdateValues(updatedBy ...uuid.UUID) {
 	user.UpdateSlug()
-	user.UpdatePasswordDigest()
 	user.SetUpdatedBy(user.idOrSelf(updatedBy...))
 	user.SetUpdatedAt()
 }
@@ -92,19 +105,6 @@ func (user *User) GenAndSetNameKey() string {
 	return user.NameKey
 }
 
-// UpdatePasswordDigest creates a password digest from current password.
-func (user *User) UpdatePasswordDigest() error {
-	if strings.Trim(user.Password, " ") == "" {
-		return nil
-	}
-	hpass, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-	user.PasswordDigest = string(hpass)
-	return nil
-}
-
 // CalcGeoHash calculates geohash using recever's lat and lng.
 func (user *User) CalcGeoHash() (string, error) {
 	return "#notimplemented", errors.New("CalcGeoHash not implemented")
@@ -0,0 +1,33 @@
+/**
+ * Copyright (c) 2019 Adrian P.K. <apk@kuguar.io>
+ *
+ * This software is released under the MIT License.
+ * https://opensource.org/licenses/MIT
+ */
+
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential model struct.
+// A public key credential (passkey) registered by a user.
+// CredentialID is the base64url encoded ID assigned by the authenticator
+// and PublicKey its COSE encoded public key.
+type WebAuthnCredential struct {
+	ID                uuid.UUID `bson:"_id" json:"id"`
+	CredentialID      string    `bson:"credential_id" json:"credentialID"`
+	UserID            uuid.UUID `bson:"user_id" json:"userID"`
+	TenantID          string    `bson:"tenant_id" json:"tenantID"`
+	Name              string    `bson:"name" json:"name"`
+	PublicKey         []byte    `bson:"public_key" json:"-"`
+	Algorithm         int64     `bson:"algorithm" json:"algorithm"`
+	SignCount         uint32    `bson:"sign_count" json:"signCount"`
+	AAGUID            []byte    `bson:"aaguid" json:"aaguid"`
+	AttestationFormat string    `bson:"attestation_format" json:"attestationFormat"`
+	CreatedAt         time.Time `bson:"created_at" json:"createdAt"`
+	LastUsedAt        time.Time `bson:"last_used_at" json:"lastUsedAt"`
+}
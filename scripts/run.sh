@@ -19,6 +19,17 @@ export REPO_PORT=27017
 export REPO_DB="granica"
 export REPO_USER="granica"
 export REPO_PASSWORD="granica"
+export REPO_MIGRATION="m"
+export REPO_READ_TIMEOUT="5s"
+export REPO_WRITE_TIMEOUT="5s"
+export POSTGRES_HOST="localhost"
+export POSTGRES_PORT=5432
+export POSTGRES_DB="granica"
+export POSTGRES_USER="granica"
+export POSTGRES_PASSWORD="granica"
+export POSTGRES_SSL_MODE="disable"
+export BOLT_PATH="granica.db"
+export BOLT_BACKUPS=3
 
 # Broker
 export BROKER_TYPE="rabbitmq"
@@ -37,6 +48,80 @@ export REDIS_DB=0
 export REDIS_USER="granica"
 export REDIS_PASSWORD="granica"
 
+# Token
+export TOKEN_ISSUER="http://localhost:8080"
+export TOKEN_AUDIENCE="granica"
+export TOKEN_ACCESS_TTL="15m"
+export TOKEN_REFRESH_TTL="720h"
+export TOKEN_CODE_TTL="1m"
+export TOKEN_REDIRECT_SCHEMES=""
+export TOKEN_ALGORITHM="RS256"
+export TOKEN_PRIVATE_KEY_PATH=""
+export TOKEN_KEYS_DIR=""
+export TOKEN_EPHEMERAL_KEY="true"
+export TOKEN_KEY_RELOAD_INTERVAL="1m"
+export TOKEN_KEY_ACTIVATION_DELAY="1h"
+
+# Mailer
+export MAILER_TYPE="file"
+export MAILER_FROM="no-reply@granica.dev"
+export MAILER_FILE_PATH=""
+export SMTP_HOST="localhost"
+export SMTP_PORT=587
+export SMTP_USER=""
+export SMTP_PASSWORD=""
+
+# Auth
+export AUTH_REQUIRE_VERIFIED_EMAIL="false"
+export AUTH_EMAIL_VERIFICATION_TTL="24h"
+export AUTH_PASSWORD_RESET_TTL="1h"
+export AUTH_MFA_KEY=""
+export AUTH_MFA_ISSUER="Granica"
+export AUTH_MFA_CHALLENGE_TTL="5m"
+export AUTH_ADMINS=""
+export AUTH_POLICIES_FILE=""
+export AUTH_NAMESPACES_FILE=""
+export AUTH_SYSTEM_TENANT="localhost"
+export AUTH_SYSTEM_TENANT_DOMAINS="localhost"
+# Tenant resolution
+export TENANT_RESOLUTION_STRATEGIES="host"
+export TENANT_HEADER="X-Tenant-ID"
+export TENANT_PATH_PREFIX="/t/"
+export TENANT_SUBDOMAIN_PATTERN=""
+export AUTH_TRUSTED_PROXIES=""
+# WebAuthn
+export WEBAUTHN_RP_ID="localhost"
+export WEBAUTHN_RP_NAME="Granica"
+export WEBAUTHN_ORIGINS="http://localhost:8080"
+export WEBAUTHN_TIMEOUT="5m"
+# Lockout
+export LOCKOUT_ACCOUNT_THRESHOLD="5"
+export LOCKOUT_IP_THRESHOLD="20"
+export LOCKOUT_DURATION="1m"
+export LOCKOUT_MAX_DURATION="1h"
+export LOCKOUT_WINDOW="24h"
+# Password policy
+export PASSWORD_MIN_LENGTH="8"
+export PASSWORD_MAX_LENGTH="64"
+export PASSWORD_REQUIRE_UPPER="false"
+export PASSWORD_REQUIRE_LOWER="false"
+export PASSWORD_REQUIRE_DIGIT="false"
+export PASSWORD_REQUIRE_SYMBOL="false"
+export PASSWORD_DISALLOW_USER_INFO="true"
+export PASSWORD_DISALLOW_BREACHED="true"
+export PASSWORD_HISTORY_SIZE="5"
+export PASSWORD_BREACHED_FILE=""
+export PASSWORD_TENANT_POLICIES_FILE=""
+# Password hashing
+export HASHER_ALGORITHM="argon2id"
+export HASHER_BCRYPT_COST="10"
+export HASHER_SCRYPT_LOG_N="15"
+export HASHER_SCRYPT_R="8"
+export HASHER_SCRYPT_P="1"
+export HASHER_ARGON2_TIME="3"
+export HASHER_ARGON2_MEMORY="65536"
+export HASHER_ARGON2_THREADS="2"
+
 # Start
 go run main.go
 